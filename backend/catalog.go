@@ -0,0 +1,95 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// maxCatalogVersions bounds how many past catalogs can serve as a delta
+// base; older clients get the full catalog.
+const maxCatalogVersions = 32
+
+// skillDelta answers /sync-skills?since=<version> with the changes only.
+type skillDelta struct {
+	Version string            `json:"version"`
+	Base    string            `json:"base"`
+	Upserts []SkillDefinition `json:"upserts,omitempty"`
+	Deletes []string          `json:"deletes,omitempty"`
+}
+
+// catalogHistory remembers recently served catalogs by version.
+type catalogHistory struct {
+	mu       sync.Mutex
+	versions map[string]map[string]SkillDefinition
+	order    []string
+}
+
+var history = &catalogHistory{versions: map[string]map[string]SkillDefinition{}}
+
+// catalogVersion hashes the catalog independently of skill order.
+func catalogVersion(skills []SkillDefinition) string {
+	sorted := append([]SkillDefinition(nil), skills...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+	raw, _ := json.Marshal(sorted)
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:16])
+}
+
+func (h *catalogHistory) remember(version string, skills []SkillDefinition) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.versions[version]; ok {
+		return
+	}
+	byName := make(map[string]SkillDefinition, len(skills))
+	for _, s := range skills {
+		byName[s.Name] = s
+	}
+	h.versions[version] = byName
+	h.order = append(h.order, version)
+	if len(h.order) > maxCatalogVersions {
+		delete(h.versions, h.order[0])
+		h.order = h.order[1:]
+	}
+}
+
+// delta diffs the current catalog against base; false when base is unknown.
+func (h *catalogHistory) delta(base, version string, skills []SkillDefinition) (*skillDelta, bool) {
+	h.mu.Lock()
+	old, ok := h.versions[base]
+	h.mu.Unlock()
+	if !ok {
+		return nil, false
+	}
+	d := &skillDelta{Version: version, Base: base}
+	current := map[string]bool{}
+	for _, s := range skills {
+		current[s.Name] = true
+		if prev, ok := old[s.Name]; !ok || !reflect.DeepEqual(prev, s) {
+			d.Upserts = append(d.Upserts, s)
+		}
+	}
+	for name := range old {
+		if !current[name] {
+			d.Deletes = append(d.Deletes, name)
+		}
+	}
+	sort.Strings(d.Deletes)
+	return d, true
+}
+
+// etagMatches reports whether an If-None-Match header names etag.
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			return true
+		}
+	}
+	return false
+}
@@ -15,6 +15,11 @@ type SkillDefinition struct {
 	Name        string `json:"name"`
 	Description string `json:"description"`
 	JSONSchema  string `json:"json_schema"`
+
+	// Optional WASM bundle the agent installs at runtime.
+	WasmManifest json.RawMessage `json:"wasm_manifest,omitempty"`
+	WasmURL      string          `json:"wasm_url,omitempty"`
+	WasmSHA256   string          `json:"wasm_sha256,omitempty"`
 }
 
 var qdrant *QdrantClient
@@ -51,15 +56,33 @@ func handleHealth(w http.ResponseWriter, _ *http.Request) {
 	_, _ = w.Write([]byte("OK"))
 }
 
-func handleSyncSkills(w http.ResponseWriter, _ *http.Request) {
+// handleSyncSkills serves the skill catalog with its version as ETag. A
+// matching If-None-Match gets 304, and ?since=<version> gets a skillDelta
+// when that version is still remembered; otherwise the full array.
+func handleSyncSkills(w http.ResponseWriter, r *http.Request) {
 	skills, err := qdrant.ScrollSkills("skills")
 	if err != nil {
 		http.Error(w, "DB Error: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	version := catalogVersion(skills)
+	history.remember(version, skills)
+	etag := `"` + version + `"`
+	w.Header().Set("ETag", etag)
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	var body interface{} = skills
+	if since := r.URL.Query().Get("since"); since != "" {
+		if d, ok := history.delta(since, version, skills); ok {
+			body = d
+		}
+	}
 	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(skills); err != nil {
+	if err := json.NewEncoder(w).Encode(body); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 	}
 }
@@ -1,8 +1,139 @@
 package main
 
-import "github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/engine"
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/engine"
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/mcp"
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/plugins/desktop"
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/plugins/wasmhost"
+)
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "mcp":
+			os.Exit(runMCP(os.Args[2:]))
+		case "spotify":
+			os.Exit(runSpotify(os.Args[2:]))
+		case "help", "-h", "--help":
+			printUsage()
+			return
+		default:
+			fmt.Fprintf(os.Stderr, "unknown command: %s\n", os.Args[1])
+			printUsage()
+			os.Exit(2)
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	agent := engine.NewAgent()
-	agent.Start()
+	registerDesktopPlugins(ctx, agent.Plugins)
+	agent.Start(ctx)
+	if err := agent.Stop(context.Background()); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: plugin shutdown: %v\n", err)
+	}
+}
+
+func printUsage() {
+	fmt.Println("Usage:")
+	fmt.Println("  upcraft-cli            start the agent")
+	fmt.Println("  upcraft-cli mcp serve  expose registered skills as an MCP server over stdio")
+	fmt.Println("  upcraft-cli spotify login|status|logout")
+	fmt.Println("                         manage the Spotify login used by the MusicPlayer skill")
+}
+
+func runMCP(args []string) int {
+	if len(args) == 0 || args[0] != "serve" {
+		fmt.Fprintln(os.Stderr, "usage: upcraft-cli mcp serve")
+		return 2
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// stdout carries the MCP protocol, so every diagnostic goes to stderr.
+	registry := engine.NewRegistry()
+	plugins := engine.NewPluginManager()
+	registerDesktopPlugins(ctx, plugins)
+	if err := plugins.StartAll(ctx, registry); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	defer func() {
+		if err := plugins.StopAll(context.Background()); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: plugin shutdown: %v\n", err)
+		}
+	}()
+
+	server := mcp.NewServer(registry)
+	if err := server.Serve(ctx, os.Stdin, os.Stdout); err != nil && ctx.Err() == nil {
+		fmt.Fprintf(os.Stderr, "mcp server stopped: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// registerDesktopPlugins registers every desktop plugin that can be
+// configured from the environment; once started, each one binds its own
+// actions.
+func registerDesktopPlugins(ctx context.Context, plugins *engine.PluginManager) {
+	if err := plugins.Register("browser", desktop.NewBrowserPlugin()); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+
+	if music, err := newMusicPlugin(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: MusicPlayer unavailable (%v)\n", err)
+	} else if err := plugins.Register("music", music); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+
+	if calendar, err := desktop.NewCalendarPluginFromEnv(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Calendar unavailable (%v)\n", err)
+	} else if err := plugins.Register("calendar", calendar); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+
+	if dir := strings.TrimSpace(os.Getenv(wasmhost.SkillsDirEnv)); dir != "" {
+		host := wasmhost.NewHost(wasmhost.NewFileKVStore(filepath.Join(dir, ".kv")))
+		loaded, err := host.LoadDir(ctx, dir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+		for _, skill := range loaded {
+			if err := plugins.Register("wasm:"+skill.Manifest.Name, skill); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+			}
+		}
+	}
+
+}
+
+// newMusicPlugin picks the backend named by UPCRAFT_MUSIC_BACKEND, or Spotify
+// when it is configured and the local library otherwise.
+func newMusicPlugin() (interface{}, error) {
+	switch backend := strings.ToLower(strings.TrimSpace(os.Getenv("UPCRAFT_MUSIC_BACKEND"))); backend {
+	case "spotify":
+		return desktop.NewMusicPluginFromEnv()
+	case "local":
+		return desktop.NewLocalMusicPluginFromEnv()
+	case "":
+		spotifyPlugin, spotifyErr := desktop.NewMusicPluginFromEnv()
+		if spotifyErr == nil {
+			return spotifyPlugin, nil
+		}
+		if strings.TrimSpace(os.Getenv(desktop.MusicDirEnv)) == "" {
+			return nil, spotifyErr
+		}
+		return desktop.NewLocalMusicPluginFromEnv()
+	default:
+		return nil, fmt.Errorf("unknown UPCRAFT_MUSIC_BACKEND %q (want spotify or local)", backend)
+	}
 }
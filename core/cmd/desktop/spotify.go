@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/plugins/desktop"
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/plugins/spotify"
+)
+
+const spotifyUsage = "usage: upcraft-cli spotify login|status|logout"
+
+func runSpotify(args []string) int {
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, spotifyUsage)
+		return 2
+	}
+	auth, err := spotify.NewAuthenticatorFromEnv()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "spotify: %v\n", err)
+		return 1
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	switch args[0] {
+	case "login":
+		return spotifyLogin(ctx, auth)
+	case "status":
+		return spotifyStatus(ctx, auth)
+	case "logout":
+		if err := auth.Store.Delete(); err != nil {
+			fmt.Fprintf(os.Stderr, "spotify: %v\n", err)
+			return 1
+		}
+		fmt.Println("Logged out of Spotify.")
+		return 0
+	default:
+		fmt.Fprintln(os.Stderr, spotifyUsage)
+		return 2
+	}
+}
+
+func spotifyLogin(ctx context.Context, auth *spotify.Authenticator) int {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+	defer cancel()
+
+	browser := desktop.NewBrowserPlugin()
+	tok, err := auth.Login(ctx, func(authURL string) error {
+		fmt.Printf("Opening Spotify login. If no browser appears, visit:\n\n  %s\n\n", authURL)
+		if err := browser.Visit(ctx, authURL); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+		return nil
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "spotify login failed: %v\n", err)
+		return 1
+	}
+	fmt.Printf("Logged in to Spotify (scopes: %s).\n", tok.Scope)
+	return 0
+}
+
+// spotifyStatus reports the stored login and proves the refresh token still
+// works by fetching a valid access token.
+func spotifyStatus(ctx context.Context, auth *spotify.Authenticator) int {
+	tok, err := auth.Token(ctx)
+	if errors.Is(err, spotify.ErrNotLoggedIn) {
+		fmt.Println("Spotify: not logged in. Run `upcraft-cli spotify login`.")
+		return 1
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "spotify: %v\n", err)
+		return 1
+	}
+	fmt.Println("Spotify: logged in")
+	fmt.Printf("  scopes:  %s\n", tok.Scope)
+	fmt.Printf("  expires: %s\n", tok.Expiry.Local().Format(time.RFC1123))
+	return 0
+}
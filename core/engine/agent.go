@@ -1,67 +1,306 @@
 package engine
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/memory"
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/protocol"
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/redact"
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/screen"
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/skills"
 )
 
-// Agent is the lightweight runtime shell for syncing cloud skill definitions
-// before entering the deterministic ReAct loop.
+// screenPlanTimeout bounds one HandleScreenInput call, model included.
+const screenPlanTimeout = 20 * time.Second
+
+// Agent owns the runtime: the LLM provider, the action registry and the
+// plugins that fill it, and the skill descriptions synced from the cloud
+// RAG backend. The planner only sees skills that are both synced and
+// implemented locally (see Skills).
 type Agent struct {
-	RAG            *memory.RAGClient
-	mu             sync.Mutex
-	lastScreenJSON string
+	RAG *memory.RAGClient
+	// Provider drives the planners; nil leaves screen planning to rules
+	// and makes Run fail.
+	Provider LLMProvider
+	Registry *Registry
+	Plugins  *PluginManager
+	// State is snapshotted into Run's system prompt.
+	State *StateCache
+	// Redactor keeps personal data out of every provider call.
+	Redactor *redact.Redactor
+	// SyncInterval, when positive, resyncs skills in the background after
+	// Start until Stop.
+	SyncInterval time.Duration
+	// Screen picks actions for typed accessibility trees.
+	Screen *ScreenPlanner
+	// Sessions tracks multi-step screen goals.
+	Sessions *ScreenSessions
+	// Stability debounces HandleScreenInput; nil plans on every report.
+	Stability *ScreenStabilizer
+
+	mu       sync.Mutex
+	stopSync context.CancelFunc
+	syncDone chan struct{}
+	// hostSkills describes skills added with AddSkill, keyed by skillKey.
+	hostSkills map[string]memory.RemoteSkill
 }
 
+// NewAgent assembles the runtime from the environment. Plugins are
+// registered on Plugins by the host before Start.
 func NewAgent() *Agent {
 	baseURL := os.Getenv("UPCRAFT_RAG_URL")
 	if baseURL == "" {
 		baseURL = "http://localhost:8080"
 	}
-	return &Agent{RAG: memory.NewRAGClient(baseURL)}
+	// Without OpenRouter credentials the screen planner runs rules only.
+	var provider LLMProvider
+	if p, err := NewOpenRouterProviderFromEnv(); err == nil {
+		provider = p
+	}
+	redactor := newRedactor()
+	planner := NewScreenPlanner(provider)
+	planner.Redactor = redactor
+	sessions := NewScreenSessions(planner)
+	if path, err := DefaultMacroPath(); err == nil {
+		sessions.Macros = &FileMacroStore{Path: path}
+	}
+	rag := memory.NewRAGClient(baseURL)
+	if path, err := memory.DefaultSkillCachePath(); err == nil {
+		rag.CachePath = path
+	}
+	plugins := NewPluginManager()
+	return &Agent{
+		RAG:          rag,
+		Provider:     provider,
+		Registry:     NewRegistry(),
+		Plugins:      plugins,
+		State:        NewPluginStateCache(plugins),
+		Redactor:     redactor,
+		SyncInterval: syncIntervalFromEnv(),
+		Screen:       planner,
+		Sessions:     sessions,
+		Stability:    &ScreenStabilizer{RequireChange: true},
+	}
 }
 
-func (a *Agent) Start() {
+// newRedactor returns the default redaction pipeline, auditing to the
+// user config directory when there is one.
+func newRedactor() *redact.Redactor {
+	r := redact.New()
+	if path, err := redact.DefaultAuditPath(); err == nil {
+		r.Audit = &redact.FileAuditLog{Path: path}
+	}
+	return r
+}
+
+// Start starts the plugins, which bind their actions into Registry, syncs
+// skills and, with a positive SyncInterval, keeps resyncing until Stop or
+// until ctx ends. Failures are reported but not fatal: the agent serves
+// whatever did start.
+func (a *Agent) Start(ctx context.Context) {
 	fmt.Println("UpCraft Agent Starting...")
 
-	skills, err := a.RAG.FetchSkills()
-	if err != nil {
-		fmt.Printf("Warning: Could not sync skills (%v). Using cached defaults.\n", err)
-		return
+	if err := a.Plugins.StartAll(ctx, a.Registry); err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	}
+	if err := a.SyncSkills(ctx); err != nil {
+		if catalog := a.RAG.Catalog(); catalog != nil {
+			fmt.Printf("Warning: Could not sync skills (%v). Using the catalog synced %s.\n", err, catalog.SyncedAt.Format(time.RFC3339))
+		} else {
+			fmt.Printf("Warning: Could not sync skills (%v). Using local skills only.\n", err)
+		}
+	} else {
+		set := a.Skills()
+		fmt.Printf("Synced skills from Cloud RAG: %d available, %d without a local implementation.\n", len(set.Exposed), len(set.Missing))
 	}
+	if a.SyncInterval > 0 {
+		a.startResync(ctx)
+	}
+}
 
-	fmt.Printf("Synced %d skills from Cloud RAG.\n", len(skills))
+// Stop ends background resync and stops the plugins.
+func (a *Agent) Stop(ctx context.Context) error {
+	a.stopResync()
+	return a.Plugins.StopAll(ctx)
 }
 
-// HandleScreenInput accepts simplified screen-state JSON from mobile/desktop UI layers
-// and returns a deterministic JSON command envelope that the caller can execute.
-func (a *Agent) HandleScreenInput(inputJSON string) string {
+// Run plans and executes prompt with the skills currently exposed. hooks
+// follow the run and approve actions marked Confirm.
+func (a *Agent) Run(ctx context.Context, prompt string, hooks LoopHooks) (string, error) {
+	if a.Provider == nil {
+		return "", ErrNoProvider
+	}
+	set := a.Skills()
+	cfg := LoopConfig{Provider: a.Provider, Registry: set.registry, State: a.State, Redactor: a.Redactor, LoopHooks: hooks}
+	return RunDeterministicLoop(ctx, cfg, prompt, set.Exposed)
+}
+
+// AddSkill registers a skill implemented outside the core, such as by a
+// mobile host, under def.Name. Its description counts as synced, so the
+// skill is exposed whatever the catalog says. Either every action is
+// registered or none is.
+func (a *Agent) AddSkill(def skills.SkillDefinition, actions []RegisteredAction) error {
+	key := skillKey(def.Name)
+	if key == "" {
+		return errors.New("skill name is required")
+	}
+	if len(actions) == 0 {
+		return fmt.Errorf("skill %s has no actions", def.Name)
+	}
+	for i, action := range actions {
+		action.Skill = def.Name
+		if err := a.Registry.Register(action); err != nil {
+			for _, done := range actions[:i] {
+				a.Registry.Unregister(def.Name, done.Action)
+			}
+			return err
+		}
+	}
 	a.mu.Lock()
-	a.lastScreenJSON = inputJSON
-	a.mu.Unlock()
+	defer a.mu.Unlock()
+	if a.hostSkills == nil {
+		a.hostSkills = map[string]memory.RemoteSkill{}
+	}
+	a.hostSkills[key] = memory.RemoteSkill{Name: def.Name, Description: def.Description}
+	return nil
+}
 
+// HandleScreenInput accepts screen-state JSON from mobile/desktop UI layers
+// and returns a protocol.ScreenAction envelope that the caller can execute.
+//
+// Input with a "root" node is a protocol.ScreenReport: a typed accessibility
+// tree with an optional "goal". While the screen is still changing the reply
+// is WAIT, and a screen whose content matches the last one planned on gets
+// NOOP "unchanged". Older untyped payloads keep the original "Play" text
+// match.
+func (a *Agent) HandleScreenInput(inputJSON string) string {
 	trimmed := strings.TrimSpace(inputJSON)
 	if trimmed == "" {
-		return `{"action":"NOOP","reason":"empty_input"}`
+		return screenReply(screen.Noop("empty_input"))
 	}
 
 	var payload map[string]interface{}
 	if err := json.Unmarshal([]byte(trimmed), &payload); err != nil {
-		return `{"action":"NOOP","reason":"invalid_json"}`
+		return screenReply(screen.Noop("invalid_json"))
+	}
+	if _, typed := payload["root"]; typed {
+		return screenReply(a.planScreen([]byte(trimmed)))
 	}
 
 	// Minimal deterministic behavior: if a visible label "Play" exists,
 	// return a click command targeting that text.
 	if containsText(payload, "Play") {
-		return `{"action":"CLICK","text":"Play"}`
+		return screenReply(screen.Action{Action: screen.ActionClick, Text: "Play"})
+	}
+
+	return screenReply(screen.Noop("no_target"))
+}
+
+func (a *Agent) planScreen(raw []byte) screen.Action {
+	report, reason := parseScreenReport(raw)
+	if report == nil {
+		return screen.Noop(reason)
+	}
+	s := &report.Screen
+
+	req := ScreenRequest{Goal: report.Goal, Screen: s}
+	if a.Stability != nil {
+		obs := a.Stability.Observe(s)
+		switch {
+		case obs.Reason == ReasonSettling:
+			return screen.Action{Action: screen.ActionWait, DurationMs: waitMillis(obs.Wait), Reason: ReasonSettling}
+		case !obs.Ready:
+			return screen.Noop(obs.Reason)
+		}
+		req.Diff = &obs.Diff
 	}
 
-	return `{"action":"NOOP","reason":"no_target"}`
+	planner := a.Screen
+	if planner == nil {
+		planner = NewScreenPlanner(nil)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), screenPlanTimeout)
+	defer cancel()
+	action, err := planner.Next(ctx, req)
+	if err != nil {
+		return screen.Noop("planner_error")
+	}
+	return action
+}
+
+// parseScreenReport decodes a host report, or returns a NOOP reason.
+func parseScreenReport(raw []byte) (*protocol.ScreenReport, string) {
+	var report protocol.ScreenReport
+	if err := json.Unmarshal(raw, &report); err != nil {
+		return nil, ReasonInvalidScreen
+	}
+	if err := report.Validate(); errors.Is(err, protocol.ErrVersion) {
+		return nil, ReasonUnsupportedVersion
+	} else if err != nil {
+		return nil, ReasonInvalidScreen
+	}
+	return &report, ""
+}
+
+// screenReply encodes action as a protocol.ScreenAction; an action that
+// fails validation is replaced by NOOP rather than sent to the host.
+func screenReply(action screen.Action) string {
+	raw, err := protocol.Encode(&protocol.ScreenAction{Action: action})
+	if err != nil {
+		raw, _ = protocol.Encode(&protocol.ScreenAction{Action: screen.Noop("invalid_action")})
+	}
+	return string(raw)
+}
+
+// StartScreenGoal opens a goal session; the host then reports each screen
+// through StepScreenGoal. Zero limits take the defaults.
+func (a *Agent) StartScreenGoal(goal string, limits ScreenLimits) (string, error) {
+	return a.sessions().Start(goal, limits)
+}
+
+// StepScreenGoal plans the next action of a goal session for the screen
+// the host now shows and returns a protocol.GoalStep envelope.
+func (a *Agent) StepScreenGoal(sessionID, screenJSON string) string {
+	var result protocol.GoalStep
+	report, reason := parseScreenReport([]byte(screenJSON))
+	switch {
+	case report == nil && !a.sessions().active(sessionID):
+		result = protocol.GoalStep{SessionID: sessionID, Status: protocol.GoalFailed, Reason: ReasonUnknownSession}
+	case report == nil:
+		// A malformed report ends nothing; the host may send a better one.
+		noop := screen.Noop(reason)
+		result = protocol.GoalStep{SessionID: sessionID, Status: protocol.GoalRunning, Action: &noop, Reason: reason}
+	default:
+		ctx, cancel := context.WithTimeout(context.Background(), screenPlanTimeout)
+		defer cancel()
+		result = a.sessions().Step(ctx, sessionID, &report.Screen)
+	}
+	raw, err := protocol.Encode(&result)
+	if err != nil {
+		raw, _ = protocol.Encode(&protocol.GoalStep{SessionID: sessionID, Status: protocol.GoalFailed, Reason: ReasonPlannerError, Message: err.Error()})
+	}
+	return string(raw)
+}
+
+// CancelScreenGoal ends a goal session; it reports whether it was running.
+func (a *Agent) CancelScreenGoal(sessionID string) bool {
+	return a.sessions().Cancel(sessionID)
+}
+
+func (a *Agent) sessions() *ScreenSessions {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.Sessions == nil {
+		a.Sessions = NewScreenSessions(a.Screen)
+	}
+	return a.Sessions
 }
 
 func containsText(v interface{}, target string) bool {
@@ -0,0 +1,155 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// The helpers below decode loosely typed planner input into handler arguments.
+// They return a ready-to-send error result so generated handlers stay flat.
+
+func stringArg(input map[string]interface{}, name string, required bool) (string, *ActionResult) {
+	raw, ok := input[name]
+	if !ok || raw == nil {
+		if required {
+			return "", missingField(name)
+		}
+		return "", nil
+	}
+	s, ok := raw.(string)
+	if !ok {
+		return "", invalidField(name, "string", raw)
+	}
+	if required && strings.TrimSpace(s) == "" {
+		return "", missingField(name)
+	}
+	return s, nil
+}
+
+func intArg(input map[string]interface{}, name string, required bool) (int64, *ActionResult) {
+	raw, ok := input[name]
+	if !ok || raw == nil {
+		if required {
+			return 0, missingField(name)
+		}
+		return 0, nil
+	}
+	switch v := raw.(type) {
+	case float64:
+		if v != math.Trunc(v) {
+			return 0, invalidField(name, "integer", raw)
+		}
+		return int64(v), nil
+	case int:
+		return int64(v), nil
+	case int64:
+		return v, nil
+	case json.Number:
+		n, err := v.Int64()
+		if err != nil {
+			return 0, invalidField(name, "integer", raw)
+		}
+		return n, nil
+	case string:
+		n, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+		if err != nil {
+			return 0, invalidField(name, "integer", raw)
+		}
+		return n, nil
+	}
+	return 0, invalidField(name, "integer", raw)
+}
+
+func floatArg(input map[string]interface{}, name string, required bool) (float64, *ActionResult) {
+	raw, ok := input[name]
+	if !ok || raw == nil {
+		if required {
+			return 0, missingField(name)
+		}
+		return 0, nil
+	}
+	switch v := raw.(type) {
+	case float64:
+		return v, nil
+	case int:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case json.Number:
+		f, err := v.Float64()
+		if err != nil {
+			return 0, invalidField(name, "number", raw)
+		}
+		return f, nil
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return 0, invalidField(name, "number", raw)
+		}
+		return f, nil
+	}
+	return 0, invalidField(name, "number", raw)
+}
+
+func boolArg(input map[string]interface{}, name string, required bool) (bool, *ActionResult) {
+	raw, ok := input[name]
+	if !ok || raw == nil {
+		if required {
+			return false, missingField(name)
+		}
+		return false, nil
+	}
+	switch v := raw.(type) {
+	case bool:
+		return v, nil
+	case string:
+		b, err := strconv.ParseBool(strings.TrimSpace(v))
+		if err != nil {
+			return false, invalidField(name, "boolean", raw)
+		}
+		return b, nil
+	}
+	return false, invalidField(name, "boolean", raw)
+}
+
+// decodeArg round-trips one input field through JSON into out, for
+// structured, slice and named parameter types.
+func decodeArg(input map[string]interface{}, name string, required bool, out interface{}) *ActionResult {
+	raw, ok := input[name]
+	if !ok || raw == nil {
+		if required {
+			return missingField(name)
+		}
+		return nil
+	}
+	encoded, err := json.Marshal(raw)
+	if err != nil {
+		return ErrorResult("invalid field: "+name, err)
+	}
+	if err := json.Unmarshal(encoded, out); err != nil {
+		return ErrorResult("invalid field: "+name, err)
+	}
+	return nil
+}
+
+// modelJSON encodes a structured action output as Data. ForModel is left
+// empty: the model reads the same JSON through ModelText, so it is stored
+// once.
+func modelJSON(label string, v interface{}) *ActionResult {
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		return ErrorResult(label+" returned unencodable output", err)
+	}
+	return &ActionResult{Data: encoded}
+}
+
+func missingField(name string) *ActionResult {
+	return ErrorResult("missing required field: "+name, fmt.Errorf("%s is required", name))
+}
+
+func invalidField(name, want string, got interface{}) *ActionResult {
+	return ErrorResult("invalid field: "+name, fmt.Errorf("%s must be a %s, got %T", name, want, got))
+}
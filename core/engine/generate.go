@@ -0,0 +1,5 @@
+package engine
+
+// Registry bindings for annotated interfaces in core/skills are generated into
+// register_*_gen.go; rerun after changing a skill contract.
+//go:generate go run ../../scripts/skillgen -skills ../skills -out .
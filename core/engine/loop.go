@@ -3,9 +3,12 @@ package engine
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/protocol"
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/redact"
 	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/skills"
 )
 
@@ -27,6 +30,46 @@ type LoopConfig struct {
 	Registry      *Registry
 	MaxIterations int
 	LLMOptions    map[string]interface{}
+	// State, when set, is snapshotted into the system prompt before planning.
+	State *StateCache
+	// Redactor, when set, keeps personal data out of every provider call;
+	// see RedactProvider.
+	Redactor *redact.Redactor
+	LoopHooks
+}
+
+// LoopHooks let a caller follow a run as it happens. Both are optional.
+type LoopHooks struct {
+	// Events receives partial text, action start and finish, and the final
+	// answer. Header, SessionID and Seq are left to the receiver.
+	Events func(protocol.Event)
+	// Confirm asks the user to approve an action marked Confirm and blocks
+	// until they answer or ctx ends. Without it such actions are declined.
+	Confirm func(ctx context.Context, tool, action string, input map[string]interface{}) bool
+}
+
+func (h LoopHooks) emit(ev protocol.Event) {
+	if h.Events != nil {
+		h.Events(ev)
+	}
+}
+
+// finishedEvent reports result to the host with its rich parts, which the
+// model only sees summarized.
+func finishedEvent(tool, action string, result *ActionResult) protocol.Event {
+	ev := protocol.Event{Type: protocol.EventActionFinished, Tool: tool, Action: action, Text: result.UserText(), Data: result.Data}
+	if result.IsError {
+		ev.Error = result.ModelText()
+	}
+	// A card or artifact that does not encode is left out; Text still
+	// reports the result.
+	if result.Card != nil {
+		ev.Card, _ = json.Marshal(result.Card)
+	}
+	if len(result.Artifacts) > 0 {
+		ev.Artifacts, _ = json.Marshal(result.Artifacts)
+	}
+	return ev
 }
 
 func RunDeterministicLoop(ctx context.Context, cfg LoopConfig, userPrompt string, defs []skills.SkillDefinition) (string, error) {
@@ -36,6 +79,9 @@ func RunDeterministicLoop(ctx context.Context, cfg LoopConfig, userPrompt string
 	if cfg.Registry == nil {
 		return "", fmt.Errorf("registry is required")
 	}
+	if cfg.Redactor != nil {
+		cfg.Provider = RedactProvider(cfg.Provider, cfg.Redactor)
+	}
 	if strings.TrimSpace(cfg.Model) == "" {
 		cfg.Model = cfg.Provider.GetDefaultModel()
 	}
@@ -55,6 +101,13 @@ func RunDeterministicLoop(ctx context.Context, cfg LoopConfig, userPrompt string
 		"When tool execution is needed, return: {\"tool\":\"<SkillName>\",\"action\":\"<ActionName>\",\"input\":{...}}. " +
 		"When task is complete, return: {\"response\":\"<final user response>\",\"done\":true}. " +
 		"Available skills: " + string(skillDefsJSON)
+	if cfg.State != nil {
+		if snapshot := cfg.State.Snapshot(ctx); len(snapshot) > 0 {
+			if stateJSON, err := json.Marshal(snapshot); err == nil {
+				systemPrompt += " Current state (use it to resolve references like \"this song\"): " + string(stateJSON)
+			}
+		}
+	}
 
 	messages := []Message{
 		{Role: "system", Content: systemPrompt},
@@ -62,6 +115,9 @@ func RunDeterministicLoop(ctx context.Context, cfg LoopConfig, userPrompt string
 	}
 
 	for i := 0; i < cfg.MaxIterations; i++ {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
 		resp, err := cfg.Provider.Chat(ctx, messages, nil, cfg.Model, cfg.LLMOptions)
 		if err != nil {
 			return "", fmt.Errorf("provider chat failed at iteration %d: %w", i+1, err)
@@ -78,6 +134,7 @@ func RunDeterministicLoop(ctx context.Context, cfg LoopConfig, userPrompt string
 			if strings.TrimSpace(instr.Response) == "" {
 				return "", fmt.Errorf("done=true but response is empty")
 			}
+			cfg.emit(protocol.Event{Type: protocol.EventFinalAnswer, Text: instr.Response})
 			return instr.Response, nil
 		}
 
@@ -96,17 +153,32 @@ func RunDeterministicLoop(ctx context.Context, cfg LoopConfig, userPrompt string
 			input["url"] = instr.URL
 		}
 
-		result := cfg.Registry.Execute(ctx, instr.Tool, instr.Action, input)
-		toolPayload := result.ForModel
-		if result.IsError && result.Err != nil {
-			toolPayload = toolPayload + " | error=" + result.Err.Error()
+		if strings.TrimSpace(instr.Response) != "" {
+			cfg.emit(protocol.Event{Type: protocol.EventPartialText, Text: instr.Response})
 		}
-		messages = append(messages, Message{Role: "tool", Content: toolPayload, ToolCallID: fmt.Sprintf("iter-%d", i+1)})
+		cfg.emit(protocol.Event{Type: protocol.EventActionStarted, Tool: instr.Tool, Action: instr.Action, Input: input})
+		result := execute(ctx, cfg, instr.Tool, instr.Action, input)
+		cfg.emit(finishedEvent(instr.Tool, instr.Action, result))
+		if cfg.State != nil && !result.IsError {
+			// The action may have changed what the next request should see.
+			cfg.State.Invalidate()
+		}
+		messages = append(messages, Message{Role: "tool", Content: result.ModelText(), ToolCallID: fmt.Sprintf("iter-%d", i+1)})
 	}
 
 	return "", fmt.Errorf("max iterations reached (%d)", cfg.MaxIterations)
 }
 
+// execute runs one action, asking for approval first when it needs it.
+func execute(ctx context.Context, cfg LoopConfig, tool, action string, input map[string]interface{}) *ActionResult {
+	if a, ok := cfg.Registry.Action(tool, action); ok && a.Confirm {
+		if cfg.Confirm == nil || !cfg.Confirm(ctx, tool, action, input) {
+			return ErrorResult(fmt.Sprintf("%s.%s was declined by the user", tool, action), errors.New("declined by user"))
+		}
+	}
+	return cfg.Registry.Execute(ctx, tool, action, input)
+}
+
 func parseInstruction(raw string) (*Instruction, error) {
 	trimmed := strings.TrimSpace(raw)
 	trimmed = strings.TrimPrefix(trimmed, "```json")
@@ -34,7 +34,17 @@ func NewOpenRouterProviderFromEnv() (*OpenRouterProvider, error) {
 	if apiKey == "" {
 		return nil, fmt.Errorf("%s is required", OpenRouterAPIKeyEnv)
 	}
-	model := strings.TrimSpace(os.Getenv(OpenRouterModelEnv))
+	return NewOpenRouterProvider(apiKey, os.Getenv(OpenRouterModelEnv))
+}
+
+// NewOpenRouterProvider uses apiKey and model; an empty model means
+// DefaultFreeModel.
+func NewOpenRouterProvider(apiKey, model string) (*OpenRouterProvider, error) {
+	apiKey = strings.TrimSpace(apiKey)
+	if apiKey == "" {
+		return nil, fmt.Errorf("openrouter api key is required")
+	}
+	model = strings.TrimSpace(model)
 	if model == "" {
 		model = DefaultFreeModel
 	}
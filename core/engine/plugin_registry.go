@@ -1,34 +1,477 @@
 package engine
 
-import "sync"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
 
-var (
-	pluginRegistryMu sync.RWMutex
-	pluginRegistry   = map[string]interface{}{}
+// Optional plugin lifecycle hooks. A plugin implements only the ones it needs.
+type (
+	// PluginInitializer prepares a plugin before any dependent starts.
+	PluginInitializer interface {
+		Init(ctx context.Context) error
+	}
+	// PluginStarter brings a plugin into service once its dependencies are running.
+	PluginStarter interface {
+		Start(ctx context.Context) error
+	}
+	// PluginStopper releases plugin resources; called in reverse start order.
+	PluginStopper interface {
+		Stop(ctx context.Context) error
+	}
+	// PluginHealthChecker reports whether a running plugin can serve requests.
+	PluginHealthChecker interface {
+		Health(ctx context.Context) error
+	}
+	// ActionBinder lets a plugin register its own actions once it has started.
+	// Plugins that do not implement it are bound through the skill interfaces
+	// they satisfy (see register_*_gen.go).
+	ActionBinder interface {
+		BindActions(registry ActionRegistrar) error
+	}
+	// PluginConfigurer accepts settings from the host, such as credentials,
+	// at any point in the lifecycle. It validates them all before using any.
+	PluginConfigurer interface {
+		Configure(settings map[string]string) error
+	}
+)
+
+// PluginState is the lifecycle position of one managed plugin.
+type PluginState string
+
+const (
+	PluginRegistered  PluginState = "registered"
+	PluginInitialized PluginState = "initialized"
+	PluginStarted     PluginState = "started"
+	PluginStopped     PluginState = "stopped"
+	PluginFailed      PluginState = "failed"
 )
 
+// PluginHealth is the outcome of one health probe.
+type PluginHealth struct {
+	Name      string      `json:"name"`
+	State     PluginState `json:"state"`
+	Healthy   bool        `json:"healthy"`
+	Error     string      `json:"error,omitempty"`
+	CheckedAt time.Time   `json:"checked_at"`
+}
+
+type managedPlugin struct {
+	name      string
+	impl      interface{}
+	dependsOn []string
+	state     PluginState
+	err       error
+	bound     []string
+}
+
+// PluginManager owns plugin instances, starts them in dependency order and
+// binds their actions into a Registry while they are running.
+type PluginManager struct {
+	// lifecycle serializes StartAll and StopAll. mu guards plugin state
+	// and is not held while plugin hooks run, so a hook may look up other
+	// plugins.
+	lifecycle sync.Mutex
+	mu        sync.RWMutex
+	plugins   map[string]*managedPlugin
+	order     []string
+	started   []string
+	// registry and each plugin's bound keys are used under lifecycle only.
+	registry *Registry
+}
+
+func NewPluginManager() *PluginManager {
+	return &PluginManager{plugins: map[string]*managedPlugin{}}
+}
+
+// DefaultPlugins backs the package-level RegisterPlugin helpers.
+var DefaultPlugins = NewPluginManager()
+
 // RegisterPlugin allows plugins to self-register during init().
-func RegisterPlugin(name string, implementation interface{}) {
-	pluginRegistryMu.Lock()
-	defer pluginRegistryMu.Unlock()
-	pluginRegistry[name] = implementation
+func RegisterPlugin(name string, implementation interface{}, dependsOn ...string) error {
+	return DefaultPlugins.Register(name, implementation, dependsOn...)
 }
 
 // GetPlugin returns one registered plugin implementation by name.
 func GetPlugin(name string) (interface{}, bool) {
-	pluginRegistryMu.RLock()
-	defer pluginRegistryMu.RUnlock()
-	v, ok := pluginRegistry[name]
-	return v, ok
+	return DefaultPlugins.Get(name)
 }
 
 // ListPlugins returns a copy of the current plugin registry.
 func ListPlugins() map[string]interface{} {
-	pluginRegistryMu.RLock()
-	defer pluginRegistryMu.RUnlock()
-	out := make(map[string]interface{}, len(pluginRegistry))
-	for k, v := range pluginRegistry {
-		out[k] = v
+	return DefaultPlugins.List()
+}
+
+// Register adds a plugin under name. dependsOn names plugins that must be
+// started first.
+func (m *PluginManager) Register(name string, implementation interface{}, dependsOn ...string) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return fmt.Errorf("plugin name is required")
+	}
+	if implementation == nil {
+		return fmt.Errorf("plugin %s implementation is required", name)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, exists := m.plugins[name]; exists {
+		return fmt.Errorf("plugin already registered: %s", name)
+	}
+	m.plugins[name] = &managedPlugin{
+		name:      name,
+		impl:      implementation,
+		dependsOn: append([]string(nil), dependsOn...),
+		state:     PluginRegistered,
+	}
+	m.order = append(m.order, name)
+	return nil
+}
+
+// Get returns a plugin by name regardless of its lifecycle state.
+func (m *PluginManager) Get(name string) (interface{}, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	p, ok := m.plugins[name]
+	if !ok {
+		return nil, false
+	}
+	return p.impl, true
+}
+
+// List returns every registered plugin keyed by name.
+func (m *PluginManager) List() map[string]interface{} {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	out := make(map[string]interface{}, len(m.plugins))
+	for name, p := range m.plugins {
+		out[name] = p.impl
+	}
+	return out
+}
+
+// State reports the lifecycle state of a plugin and the error that failed it.
+func (m *PluginManager) State(name string) (PluginState, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	p, ok := m.plugins[name]
+	if !ok {
+		return "", fmt.Errorf("plugin not registered: %s", name)
+	}
+	return p.state, p.err
+}
+
+// Configure hands settings to the plugin registered as name.
+func (m *PluginManager) Configure(name string, settings map[string]string) error {
+	impl, ok := m.Get(name)
+	if !ok {
+		return fmt.Errorf("plugin not registered: %s", name)
+	}
+	c, ok := impl.(PluginConfigurer)
+	if !ok {
+		return fmt.Errorf("plugin %s takes no settings", name)
+	}
+	return c.Configure(settings)
+}
+
+// StartAll initializes and starts every plugin that is not yet running, in
+// dependency order, then binds its actions into registry (nil skips binding).
+// A failed plugin does not stop unrelated plugins, but its dependents are not
+// started. The returned error joins every failure.
+func (m *PluginManager) StartAll(ctx context.Context, registry *Registry) error {
+	m.lifecycle.Lock()
+	defer m.lifecycle.Unlock()
+
+	m.mu.Lock()
+	m.registry = registry
+	order, err := m.startOrder()
+	plugins := make([]*managedPlugin, 0, len(order))
+	for _, name := range order {
+		plugins = append(plugins, m.plugins[name])
+	}
+	m.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, p := range plugins {
+		m.mu.RLock()
+		state, depErr := p.state, m.dependencyError(p)
+		m.mu.RUnlock()
+		if state == PluginStarted {
+			continue
+		}
+		err := depErr
+		if err == nil {
+			err = m.startOne(ctx, p, state)
+		}
+
+		m.mu.Lock()
+		if err != nil {
+			p.state, p.err = PluginFailed, err
+			errs = append(errs, fmt.Errorf("plugin %s: %w", p.name, err))
+		} else {
+			p.state, p.err = PluginStarted, nil
+			m.started = append(m.started, p.name)
+		}
+		m.mu.Unlock()
+	}
+	return errors.Join(errs...)
+}
+
+// StopAll stops running plugins in reverse start order and removes the actions
+// they bound.
+func (m *PluginManager) StopAll(ctx context.Context) error {
+	m.lifecycle.Lock()
+	defer m.lifecycle.Unlock()
+
+	m.mu.Lock()
+	started := make([]*managedPlugin, 0, len(m.started))
+	for _, name := range m.started {
+		started = append(started, m.plugins[name])
+	}
+	m.started = nil
+	m.mu.Unlock()
+
+	var errs []error
+	for i := len(started) - 1; i >= 0; i-- {
+		p := started[i]
+		m.unbind(p)
+		if s, ok := p.impl.(PluginStopper); ok {
+			if err := s.Stop(ctx); err != nil {
+				errs = append(errs, fmt.Errorf("plugin %s: stop: %w", p.name, err))
+			}
+		}
+		m.mu.Lock()
+		p.state = PluginStopped
+		m.mu.Unlock()
+	}
+	return errors.Join(errs...)
+}
+
+// Health probes every plugin. Running plugins without a Health hook are healthy.
+func (m *PluginManager) Health(ctx context.Context) []PluginHealth {
+	m.mu.RLock()
+	plugins := make([]managedPlugin, 0, len(m.order))
+	for _, name := range m.order {
+		plugins = append(plugins, *m.plugins[name])
+	}
+	m.mu.RUnlock()
+
+	out := make([]PluginHealth, 0, len(plugins))
+	for _, p := range plugins {
+		h := PluginHealth{Name: p.name, State: p.state, CheckedAt: time.Now()}
+		switch {
+		case p.state != PluginStarted:
+			if p.err != nil {
+				h.Error = p.err.Error()
+			}
+		default:
+			h.Healthy = true
+			if hc, ok := p.impl.(PluginHealthChecker); ok {
+				if err := hc.Health(ctx); err != nil {
+					h.Healthy, h.Error = false, err.Error()
+				}
+			}
+		}
+		out = append(out, h)
+	}
+	return out
+}
+
+// LookupPlugin returns the first started plugin that implements T, in
+// registration order.
+func LookupPlugin[T any](m *PluginManager) (T, bool) {
+	all := LookupPlugins[T](m)
+	if len(all) == 0 {
+		var zero T
+		return zero, false
+	}
+	return all[0], true
+}
+
+// LookupPlugins returns every started plugin that implements T.
+func LookupPlugins[T any](m *PluginManager) []T {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var out []T
+	for _, name := range m.order {
+		p := m.plugins[name]
+		if p.state != PluginStarted {
+			continue
+		}
+		if impl, ok := p.impl.(T); ok {
+			out = append(out, impl)
+		}
 	}
 	return out
 }
+
+// startOne runs the hooks of p, whose state was state, without holding
+// m.mu. The caller holds m.lifecycle.
+func (m *PluginManager) startOne(ctx context.Context, p *managedPlugin, state PluginState) error {
+	if state != PluginInitialized {
+		if i, ok := p.impl.(PluginInitializer); ok {
+			if err := i.Init(ctx); err != nil {
+				return fmt.Errorf("init: %w", err)
+			}
+		}
+		m.mu.Lock()
+		p.state = PluginInitialized
+		m.mu.Unlock()
+	}
+	if s, ok := p.impl.(PluginStarter); ok {
+		if err := s.Start(ctx); err != nil {
+			return fmt.Errorf("start: %w", err)
+		}
+	}
+	if err := m.bind(p); err != nil {
+		if s, ok := p.impl.(PluginStopper); ok {
+			_ = s.Stop(ctx)
+		}
+		return fmt.Errorf("bind actions: %w", err)
+	}
+	return nil
+}
+
+func (m *PluginManager) bind(p *managedPlugin) error {
+	if m.registry == nil {
+		return nil
+	}
+	rec := &recordingRegistrar{registry: m.registry}
+
+	var err error
+	if b, ok := p.impl.(ActionBinder); ok {
+		err = b.BindActions(rec)
+	} else {
+		err = bindSkillInterfaces(rec, p.impl)
+	}
+
+	p.bound = append(p.bound, rec.keys...)
+	if err != nil {
+		m.unbind(p)
+	}
+	return err
+}
+
+// recordingRegistrar remembers the actions one plugin adds, so actions
+// registered concurrently by others are never mistaken for its own.
+type recordingRegistrar struct {
+	registry *Registry
+	keys     []string
+}
+
+func (r *recordingRegistrar) Register(a RegisteredAction) error {
+	if err := r.registry.Register(a); err != nil {
+		return err
+	}
+	r.keys = append(r.keys, actionKey(a.Skill, a.Action))
+	return nil
+}
+
+func (m *PluginManager) unbind(p *managedPlugin) {
+	if m.registry != nil {
+		for _, key := range p.bound {
+			m.registry.unregisterKey(key)
+		}
+	}
+	p.bound = nil
+}
+
+func (m *PluginManager) dependencyError(p *managedPlugin) error {
+	for _, dep := range p.dependsOn {
+		d, ok := m.plugins[dep]
+		if !ok {
+			return fmt.Errorf("missing dependency %s", dep)
+		}
+		if d.state != PluginStarted {
+			return fmt.Errorf("dependency %s not started", dep)
+		}
+	}
+	return nil
+}
+
+// startOrder topologically sorts plugins, keeping registration order among
+// independent plugins.
+func (m *PluginManager) startOrder() ([]string, error) {
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	marks := map[string]int{}
+	order := make([]string, 0, len(m.order))
+
+	var visit func(name string, path []string) error
+	visit = func(name string, path []string) error {
+		switch marks[name] {
+		case done:
+			return nil
+		case visiting:
+			return fmt.Errorf("plugin dependency cycle: %s", strings.Join(append(path, name), " -> "))
+		}
+		marks[name] = visiting
+		p := m.plugins[name]
+		for _, dep := range p.dependsOn {
+			if _, ok := m.plugins[dep]; !ok {
+				// Reported per plugin by dependencyError.
+				continue
+			}
+			if err := visit(dep, append(path, name)); err != nil {
+				return err
+			}
+		}
+		marks[name] = done
+		order = append(order, name)
+		return nil
+	}
+
+	for _, name := range m.order {
+		if err := visit(name, nil); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
+
+// skillBinder registers a plugin's actions when it implements one skill interface.
+type skillBinder func(registry ActionRegistrar, plugin interface{}) (bool, error)
+
+var (
+	skillBindersMu sync.RWMutex
+	skillBinders   = map[string]skillBinder{}
+)
+
+// registerSkillBinder is called from generated bindings during init(). It is
+// keyed by interface name because capability interfaces share a skill.
+func registerSkillBinder(iface string, binder skillBinder) {
+	skillBindersMu.Lock()
+	defer skillBindersMu.Unlock()
+	skillBinders[iface] = binder
+}
+
+func bindSkillInterfaces(registry ActionRegistrar, plugin interface{}) error {
+	skillBindersMu.RLock()
+	names := make([]string, 0, len(skillBinders))
+	for name := range skillBinders {
+		names = append(names, name)
+	}
+	skillBindersMu.RUnlock()
+	sort.Strings(names)
+
+	for _, name := range names {
+		skillBindersMu.RLock()
+		binder := skillBinders[name]
+		skillBindersMu.RUnlock()
+		if _, err := binder(registry, plugin); err != nil {
+			return err
+		}
+	}
+	return nil
+}
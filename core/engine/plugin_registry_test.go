@@ -0,0 +1,260 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+type lifecyclePlugin struct {
+	name      string
+	events    *[]string
+	failStart bool
+	unhealthy bool
+}
+
+func (p *lifecyclePlugin) Init(context.Context) error {
+	*p.events = append(*p.events, "init:"+p.name)
+	return nil
+}
+
+func (p *lifecyclePlugin) Start(context.Context) error {
+	if p.failStart {
+		return errors.New("boom")
+	}
+	*p.events = append(*p.events, "start:"+p.name)
+	return nil
+}
+
+func (p *lifecyclePlugin) Stop(context.Context) error {
+	*p.events = append(*p.events, "stop:"+p.name)
+	return nil
+}
+
+func (p *lifecyclePlugin) Health(context.Context) error {
+	if p.unhealthy {
+		return errors.New("degraded")
+	}
+	return nil
+}
+
+// fakeMusic satisfies skills.MusicPlayer and is bound through the generated binder.
+type fakeMusic struct{ lifecyclePlugin }
+
+func (f *fakeMusic) Play(context.Context, string) error { return nil }
+func (f *fakeMusic) Pause(context.Context) error        { return nil }
+func (f *fakeMusic) Resume(context.Context) error       { return nil }
+func (f *fakeMusic) Next(context.Context) error         { return nil }
+
+// loudMusic adds only the volume capability on top of the core controls.
+type loudMusic struct{ fakeMusic }
+
+func (l *loudMusic) SetVolume(context.Context, int) error { return nil }
+
+type selfBinding struct{}
+
+func (selfBinding) BindActions(registry ActionRegistrar) error {
+	return registry.Register(RegisteredAction{
+		Skill:   "Custom",
+		Action:  "Ping",
+		Handler: func(context.Context, map[string]interface{}) *ActionResult { return SuccessResult("pong", "") },
+	})
+}
+
+func TestPluginManager_StartsInDependencyOrderAndStopsInReverse(t *testing.T) {
+	var events []string
+	m := NewPluginManager()
+	mustRegister(t, m, "c", &lifecyclePlugin{name: "c", events: &events}, "b")
+	mustRegister(t, m, "a", &lifecyclePlugin{name: "a", events: &events})
+	mustRegister(t, m, "b", &lifecyclePlugin{name: "b", events: &events}, "a")
+
+	if err := m.StartAll(context.Background(), nil); err != nil {
+		t.Fatalf("StartAll: %v", err)
+	}
+	if err := m.StopAll(context.Background()); err != nil {
+		t.Fatalf("StopAll: %v", err)
+	}
+
+	want := "init:a,start:a,init:b,start:b,init:c,start:c,stop:c,stop:b,stop:a"
+	if got := strings.Join(events, ","); got != want {
+		t.Errorf("events = %s\nwant     %s", got, want)
+	}
+}
+
+func TestPluginManager_FailedDependencyBlocksDependents(t *testing.T) {
+	var events []string
+	m := NewPluginManager()
+	mustRegister(t, m, "db", &lifecyclePlugin{name: "db", events: &events, failStart: true})
+	mustRegister(t, m, "api", &lifecyclePlugin{name: "api", events: &events}, "db")
+	mustRegister(t, m, "solo", &lifecyclePlugin{name: "solo", events: &events})
+	mustRegister(t, m, "orphan", &lifecyclePlugin{name: "orphan", events: &events}, "ghost")
+
+	err := m.StartAll(context.Background(), nil)
+	if err == nil {
+		t.Fatal("expected StartAll to report failures")
+	}
+	for _, want := range []string{"plugin db: start: boom", "dependency db not started", "missing dependency ghost"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q missing %q", err, want)
+		}
+	}
+
+	for name, want := range map[string]PluginState{"db": PluginFailed, "api": PluginFailed, "solo": PluginStarted, "orphan": PluginFailed} {
+		if state, _ := m.State(name); state != want {
+			t.Errorf("State(%s) = %s, want %s", name, state, want)
+		}
+	}
+}
+
+func TestPluginManager_DetectsCycles(t *testing.T) {
+	m := NewPluginManager()
+	var events []string
+	mustRegister(t, m, "a", &lifecyclePlugin{name: "a", events: &events}, "b")
+	mustRegister(t, m, "b", &lifecyclePlugin{name: "b", events: &events}, "a")
+
+	if err := m.StartAll(context.Background(), nil); err == nil || !strings.Contains(err.Error(), "cycle") {
+		t.Fatalf("expected cycle error, got %v", err)
+	}
+	if len(events) != 0 {
+		t.Errorf("nothing should start on a cycle, got %v", events)
+	}
+}
+
+func TestPluginManager_BindsActionsWhileRunning(t *testing.T) {
+	var events []string
+	registry := NewRegistry()
+	m := NewPluginManager()
+	mustRegister(t, m, "music", &fakeMusic{lifecyclePlugin{name: "music", events: &events, unhealthy: true}})
+	mustRegister(t, m, "custom", selfBinding{})
+
+	if err := m.StartAll(context.Background(), registry); err != nil {
+		t.Fatalf("StartAll: %v", err)
+	}
+	if n := len(registry.Actions()); n != 5 {
+		t.Fatalf("expected 4 music actions + 1 custom action, got %d", n)
+	}
+	if res := registry.Execute(context.Background(), "Custom", "Ping", nil); res.IsError {
+		t.Errorf("custom action failed: %+v", res)
+	}
+
+	player, ok := LookupPlugin[interface{ Pause(context.Context) error }](m)
+	if !ok || player == nil {
+		t.Error("expected typed lookup to find the music plugin")
+	}
+
+	health := m.Health(context.Background())
+	if len(health) != 2 || health[0].Healthy || health[0].Error != "degraded" || !health[1].Healthy {
+		t.Errorf("unexpected health: %+v", health)
+	}
+
+	if err := m.StopAll(context.Background()); err != nil {
+		t.Fatalf("StopAll: %v", err)
+	}
+	if n := len(registry.Actions()); n != 0 {
+		t.Errorf("actions should be unbound after stop, %d remain", n)
+	}
+	if _, ok := LookupPlugin[interface{ Pause(context.Context) error }](m); ok {
+		t.Error("stopped plugins must not be returned by typed lookup")
+	}
+}
+
+// racingBinder fails after another caller registered an action while it
+// was binding, as RegisterSkill may during an async start.
+type racingBinder struct{ shared *Registry }
+
+func (r racingBinder) BindActions(registry ActionRegistrar) error {
+	ping := func(context.Context, map[string]interface{}) *ActionResult { return SuccessResult("pong", "") }
+	if err := registry.Register(RegisteredAction{Skill: "Custom", Action: "Ping", Handler: ping}); err != nil {
+		return err
+	}
+	if err := r.shared.Register(RegisteredAction{Skill: "Host", Action: "Ping", Handler: ping}); err != nil {
+		return err
+	}
+	return errors.New("boom")
+}
+
+func TestPluginManager_UnbindsOnlyItsOwnActions(t *testing.T) {
+	registry := NewRegistry()
+	m := NewPluginManager()
+	mustRegister(t, m, "racing", racingBinder{shared: registry})
+
+	if err := m.StartAll(context.Background(), registry); err == nil {
+		t.Fatal("expected the bind failure")
+	}
+	if _, ok := registry.Action("Custom", "Ping"); ok {
+		t.Error("failed plugin's action is still registered")
+	}
+	if _, ok := registry.Action("Host", "Ping"); !ok {
+		t.Error("concurrently registered action was removed with the plugin")
+	}
+}
+
+func TestPluginManager_ExposesOnlySupportedCapabilities(t *testing.T) {
+	var events []string
+	registry := NewRegistry()
+	m := NewPluginManager()
+	mustRegister(t, m, "music", &loudMusic{fakeMusic{lifecyclePlugin{name: "music", events: &events}}})
+	if err := m.StartAll(context.Background(), registry); err != nil {
+		t.Fatalf("StartAll: %v", err)
+	}
+
+	var names []string
+	for _, a := range registry.Actions() {
+		if a.Skill != "MusicPlayer" {
+			t.Errorf("capability bound under skill %q", a.Skill)
+		}
+		names = append(names, a.Action)
+	}
+	sort.Strings(names)
+	if got := strings.Join(names, ","); got != "Next,Pause,Play,Resume,SetVolume" {
+		t.Fatalf("actions = %s", got)
+	}
+}
+
+func mustRegister(t *testing.T, m *PluginManager, name string, impl interface{}, deps ...string) {
+	t.Helper()
+	if err := m.Register(name, impl, deps...); err != nil {
+		t.Fatalf("Register(%s): %v", name, err)
+	}
+}
+
+// lookupPlugin reaches the dependency it declared from its own Start.
+type lookupPlugin struct {
+	m     *PluginManager
+	found *fakeMusic
+}
+
+func (p *lookupPlugin) Start(context.Context) error {
+	music, ok := LookupPlugin[*fakeMusic](p.m)
+	if !ok {
+		return errors.New("music dependency not found")
+	}
+	p.found = music
+	return nil
+}
+
+func TestPluginManager_HooksCanLookUpPlugins(t *testing.T) {
+	var events []string
+	m := NewPluginManager()
+	music := &fakeMusic{lifecyclePlugin{name: "music", events: &events}}
+	mustRegister(t, m, "music", music)
+	dependent := &lookupPlugin{m: m}
+	mustRegister(t, m, "dependent", dependent, "music")
+
+	done := make(chan error, 1)
+	go func() { done <- m.StartAll(context.Background(), NewRegistry()) }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("StartAll deadlocked on a plugin looking up its dependency")
+	}
+	if dependent.found != music {
+		t.Fatalf("Start found %v", dependent.found)
+	}
+}
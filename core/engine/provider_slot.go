@@ -0,0 +1,47 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrNoProvider is returned by a ProviderSlot that holds no provider.
+var ErrNoProvider = errors.New("no LLM provider configured")
+
+// ProviderSlot is an LLMProvider whose backing provider can be replaced, for
+// example when the host sends new credentials. Each call uses the provider
+// held when it starts, so runs in flight are not disturbed.
+type ProviderSlot struct {
+	mu sync.RWMutex
+	p  LLMProvider
+}
+
+// Set replaces the provider; nil empties the slot.
+func (s *ProviderSlot) Set(p LLMProvider) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.p = p
+}
+
+// Get returns the current provider, or nil.
+func (s *ProviderSlot) Get() LLMProvider {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.p
+}
+
+func (s *ProviderSlot) Chat(ctx context.Context, messages []Message, tools []ToolDefinition, model string, options map[string]interface{}) (*LLMResponse, error) {
+	p := s.Get()
+	if p == nil {
+		return nil, ErrNoProvider
+	}
+	return p.Chat(ctx, messages, tools, model, options)
+}
+
+func (s *ProviderSlot) GetDefaultModel() string {
+	if p := s.Get(); p != nil {
+		return p.GetDefaultModel()
+	}
+	return ""
+}
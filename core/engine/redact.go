@@ -0,0 +1,103 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/redact"
+)
+
+// RedactProvider wraps p so every outgoing message is passed through r and
+// placeholders in the reply are restored before the caller sees it. System
+// messages also get redact.Notice so the model keeps placeholders intact.
+func RedactProvider(p LLMProvider, r *redact.Redactor) LLMProvider {
+	return &redactingProvider{LLMProvider: p, redactor: r}
+}
+
+type redactingProvider struct {
+	LLMProvider
+	redactor *redact.Redactor
+}
+
+func (p *redactingProvider) Chat(ctx context.Context, messages []Message, tools []ToolDefinition, model string, options map[string]interface{}) (*LLMResponse, error) {
+	r := p.redactor
+	out := make([]Message, len(messages))
+	for i, m := range messages {
+		source := "prompt:" + m.Role
+		m.Content = r.Text(m.Content, source)
+		if m.Role == "system" {
+			m.Content += " " + redact.Notice
+		}
+		m.ToolCalls = mapToolCalls(m.ToolCalls, func(s string) string { return r.Text(s, source) })
+		out[i] = m
+	}
+
+	resp, err := p.LLMProvider.Chat(ctx, out, tools, model, options)
+	if err != nil || resp == nil {
+		return resp, err
+	}
+	restored := *resp
+	restored.Content = r.Restore(resp.Content)
+	restored.ToolCalls = mapToolCalls(resp.ToolCalls, r.Restore)
+	return &restored, nil
+}
+
+// mapToolCalls copies calls with fn applied to every argument string.
+func mapToolCalls(calls []ToolCall, fn func(string) string) []ToolCall {
+	if len(calls) == 0 {
+		return calls
+	}
+	out := make([]ToolCall, len(calls))
+	for i, c := range calls {
+		if c.Function != nil {
+			f := *c.Function
+			f.Arguments = mapJSONStrings(f.Arguments, fn)
+			c.Function = &f
+		}
+		if c.Arguments != nil {
+			c.Arguments = mapStrings(c.Arguments, fn).(map[string]interface{})
+		}
+		out[i] = c
+	}
+	return out
+}
+
+// mapJSONStrings applies fn to the strings inside the JSON document raw and
+// re-encodes it, so a value containing quotes or backslashes stays valid
+// JSON. Text that is not JSON is mapped as a whole.
+func mapJSONStrings(raw string, fn func(string) string) string {
+	dec := json.NewDecoder(strings.NewReader(raw))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil || dec.More() {
+		return fn(raw)
+	}
+	var b strings.Builder
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(mapStrings(v, fn)); err != nil {
+		return fn(raw)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+func mapStrings(v interface{}, fn func(string) string) interface{} {
+	switch t := v.(type) {
+	case string:
+		return fn(t)
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(t))
+		for k, value := range t {
+			out[k] = mapStrings(value, fn)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(t))
+		for i, value := range t {
+			out[i] = mapStrings(value, fn)
+		}
+		return out
+	}
+	return v
+}
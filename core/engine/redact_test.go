@@ -0,0 +1,88 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/redact"
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/screen"
+)
+
+func TestRunDeterministicLoop_RedactsProviderCalls(t *testing.T) {
+	var sentTo string
+	registry := NewRegistry()
+	if err := registry.Register(RegisteredAction{
+		Skill:  "Messages",
+		Action: "Send",
+		Handler: func(_ context.Context, input map[string]interface{}) *ActionResult {
+			sentTo, _ = input["to"].(string)
+			return SuccessResult("sent to "+sentTo, "Sent")
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	provider := &scriptedProvider{replies: []string{
+		`{"tool":"Messages","action":"Send","input":{"to":"[PHONE_1]"}}`,
+		`{"response":"Texted [PHONE_1]","done":true}`,
+	}}
+
+	cfg := LoopConfig{Provider: provider, Registry: registry, Redactor: redact.New()}
+	reply, err := RunDeterministicLoop(context.Background(), cfg, "text +1 415 555 0132 that I'm late", registry.SkillDefinitions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sentTo != "+1 415 555 0132" || reply != "Texted +1 415 555 0132" {
+		t.Fatalf("sent to %q, reply %q", sentTo, reply)
+	}
+	for _, call := range provider.seen {
+		for _, m := range call {
+			if strings.Contains(m.Content, "555 0132") {
+				t.Fatalf("%s message leaks the number: %s", m.Role, m.Content)
+			}
+		}
+	}
+	if system := provider.seen[0][0].Content; !strings.Contains(system, redact.Notice) {
+		t.Fatalf("system prompt lacks the placeholder notice: %s", system)
+	}
+}
+
+// toolCallProvider replies with a single native tool call.
+type toolCallProvider struct{ call ToolCall }
+
+func (p *toolCallProvider) Chat(context.Context, []Message, []ToolDefinition, string, map[string]interface{}) (*LLMResponse, error) {
+	return &LLMResponse{ToolCalls: []ToolCall{p.call}}, nil
+}
+
+func (p *toolCallProvider) GetDefaultModel() string { return "test" }
+
+func TestRedactProvider_RestoresToolCallArgumentsAsJSON(t *testing.T) {
+	const password = `p"a\ss`
+	r := redact.New()
+	s := &screen.Screen{App: "com.bank", Root: &screen.Node{ID: "pw", Role: screen.RoleEdit, ResourceID: "com.bank:id/password", Text: password}}
+	ph := r.Screen(s).Root.Text
+	if ph == password {
+		t.Fatal("password field was not redacted")
+	}
+
+	provider := RedactProvider(&toolCallProvider{call: ToolCall{
+		Function:  &FunctionCall{Name: "type", Arguments: `{"text":"` + ph + `","count":12345678901234567890}`},
+		Arguments: map[string]interface{}{"text": ph},
+	}}, r)
+	resp, err := provider.Chat(context.Background(), nil, nil, "test", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	call := resp.ToolCalls[0]
+	var args struct {
+		Text  string      `json:"text"`
+		Count json.Number `json:"count"`
+	}
+	if err := json.Unmarshal([]byte(call.Function.Arguments), &args); err != nil {
+		t.Fatalf("arguments are not JSON: %v: %s", err, call.Function.Arguments)
+	}
+	if args.Text != password || args.Count != "12345678901234567890" || call.Arguments["text"] != password {
+		t.Fatalf("arguments = %s, %v", call.Function.Arguments, call.Arguments)
+	}
+}
@@ -0,0 +1,110 @@
+// Code generated by skillgen. DO NOT EDIT.
+
+package engine
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/skills"
+)
+
+func RegisterBrowser(registry ActionRegistrar, impl skills.Browser) error {
+	if registry == nil {
+		return fmt.Errorf("registry is required")
+	}
+	if impl == nil {
+		return fmt.Errorf("browser implementation is required")
+	}
+
+	entries := []RegisteredAction{
+		{
+			Skill:       "Browser",
+			Action:      "Visit",
+			Description: "Open a URL in the user's browser",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"url": map[string]interface{}{"type": "string", "description": "Absolute http(s) URL"},
+				},
+				"required": []string{"url"},
+			},
+			Handler: func(ctx context.Context, input map[string]interface{}) *ActionResult {
+				url, bad := stringArg(input, "url", true)
+				if bad != nil {
+					return bad
+				}
+				if err := impl.Visit(ctx, url); err != nil {
+					return ErrorResult("Browser.Visit failed", err)
+				}
+				return SuccessResult("Browser.Visit executed", "Opened: "+url)
+			},
+		},
+		{
+			Skill:       "Browser",
+			Action:      "Search",
+			Description: "Open a web search for the query in the user's browser",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"query": map[string]interface{}{"type": "string"},
+				},
+				"required": []string{"query"},
+			},
+			Handler: func(ctx context.Context, input map[string]interface{}) *ActionResult {
+				query, bad := stringArg(input, "query", true)
+				if bad != nil {
+					return bad
+				}
+				if err := impl.Search(ctx, query); err != nil {
+					return ErrorResult("Browser.Search failed", err)
+				}
+				return SuccessResult("Browser.Search executed", "Searching for: "+query)
+			},
+		},
+		{
+			Skill:       "Browser",
+			Action:      "Fetch",
+			Description: "Fetch a page without opening it and read its text and links",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"url": map[string]interface{}{"type": "string", "description": "Absolute http(s) URL"},
+				},
+				"required": []string{"url"},
+			},
+			Handler: func(ctx context.Context, input map[string]interface{}) *ActionResult {
+				url, bad := stringArg(input, "url", true)
+				if bad != nil {
+					return bad
+				}
+				out, err := impl.Fetch(ctx, url)
+				if err != nil {
+					return ErrorResult("Browser.Fetch failed", err)
+				}
+				result := modelJSON("Browser.Fetch", out)
+				if !result.IsError {
+					result.ForUser = "Read: " + url
+				}
+				return result
+			},
+		},
+	}
+
+	for _, e := range entries {
+		if err := registry.Register(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func init() {
+	registerSkillBinder("Browser", func(registry ActionRegistrar, plugin interface{}) (bool, error) {
+		impl, ok := plugin.(skills.Browser)
+		if !ok {
+			return false, nil
+		}
+		return true, RegisterBrowser(registry, impl)
+	})
+}
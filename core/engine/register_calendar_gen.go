@@ -0,0 +1,212 @@
+// Code generated by skillgen. DO NOT EDIT.
+
+package engine
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/skills"
+)
+
+func RegisterCalendar(registry ActionRegistrar, impl skills.Calendar) error {
+	if registry == nil {
+		return fmt.Errorf("registry is required")
+	}
+	if impl == nil {
+		return fmt.Errorf("calendar implementation is required")
+	}
+
+	entries := []RegisteredAction{
+		{
+			Skill:       "Calendar",
+			Action:      "CreateEvent",
+			Description: "Create a calendar event and return it with its assigned id",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"event": map[string]interface{}{
+						"type":        "object",
+						"description": "Event to create; leave id empty",
+						"properties": map[string]interface{}{
+							"id":        map[string]interface{}{"type": "string"},
+							"title":     map[string]interface{}{"type": "string"},
+							"start":     map[string]interface{}{"type": "string", "format": "date-time"},
+							"end":       map[string]interface{}{"type": "string", "format": "date-time", "description": "End defaults to one hour after Start, or one day for all-day events"},
+							"all_day":   map[string]interface{}{"type": "boolean"},
+							"time_zone": map[string]interface{}{"type": "string", "description": "IANA zone such as Asia/Kolkata; empty means UTC"},
+							"location":  map[string]interface{}{"type": "string"},
+							"notes":     map[string]interface{}{"type": "string"},
+							"recurrence": map[string]interface{}{
+								"type": "object",
+								"properties": map[string]interface{}{
+									"frequency": map[string]interface{}{"type": "string", "description": "One of daily, weekly, monthly, yearly"},
+									"interval":  map[string]interface{}{"type": "integer", "description": "Repeat every Interval periods; defaults to 1"},
+									"count":     map[string]interface{}{"type": "integer", "description": "Stop after Count occurrences; zero means no limit"},
+									"until":     map[string]interface{}{"type": "string", "format": "date-time", "description": "Stop after this time"},
+									"by_day": map[string]interface{}{
+										"type":        "array",
+										"description": "Weekdays for weekly rules as two-letter codes: MO, TU, WE, TH, FR, SA, SU",
+										"items":       map[string]interface{}{"type": "string"},
+									},
+								},
+								"required": []string{"frequency"},
+							},
+							"alert_minutes": map[string]interface{}{
+								"type":        "array",
+								"description": "Minutes before Start at which to alert the user",
+								"items":       map[string]interface{}{"type": "integer"},
+							},
+						},
+						"required": []string{"title", "start"},
+					},
+				},
+				"required": []string{"event"},
+			},
+			Handler: func(ctx context.Context, input map[string]interface{}) *ActionResult {
+				var event skills.Event
+				if bad := decodeArg(input, "event", true, &event); bad != nil {
+					return bad
+				}
+				out, err := impl.CreateEvent(ctx, event)
+				if err != nil {
+					return ErrorResult("Calendar.CreateEvent failed", err)
+				}
+				result := modelJSON("Calendar.CreateEvent", out)
+				if !result.IsError {
+					result.ForUser = "Added to your calendar"
+				}
+				return result
+			},
+		},
+		{
+			Skill:       "Calendar",
+			Action:      "ListEvents",
+			Description: "List event occurrences overlapping a time range, expanding recurring events",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"from": map[string]interface{}{"type": "string", "format": "date-time", "description": "Range start (RFC 3339)"},
+					"to":   map[string]interface{}{"type": "string", "format": "date-time", "description": "Range end (RFC 3339)"},
+				},
+				"required": []string{"from", "to"},
+			},
+			Handler: func(ctx context.Context, input map[string]interface{}) *ActionResult {
+				var from time.Time
+				if bad := decodeArg(input, "from", true, &from); bad != nil {
+					return bad
+				}
+				var to time.Time
+				if bad := decodeArg(input, "to", true, &to); bad != nil {
+					return bad
+				}
+				out, err := impl.ListEvents(ctx, from, to)
+				if err != nil {
+					return ErrorResult("Calendar.ListEvents failed", err)
+				}
+				return modelJSON("Calendar.ListEvents", out)
+			},
+		},
+		{
+			Skill:       "Calendar",
+			Action:      "UpdateEvent",
+			Description: "Replace an existing event, matched by id",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"event": map[string]interface{}{
+						"type":        "object",
+						"description": "Full event including its id",
+						"properties": map[string]interface{}{
+							"id":        map[string]interface{}{"type": "string"},
+							"title":     map[string]interface{}{"type": "string"},
+							"start":     map[string]interface{}{"type": "string", "format": "date-time"},
+							"end":       map[string]interface{}{"type": "string", "format": "date-time", "description": "End defaults to one hour after Start, or one day for all-day events"},
+							"all_day":   map[string]interface{}{"type": "boolean"},
+							"time_zone": map[string]interface{}{"type": "string", "description": "IANA zone such as Asia/Kolkata; empty means UTC"},
+							"location":  map[string]interface{}{"type": "string"},
+							"notes":     map[string]interface{}{"type": "string"},
+							"recurrence": map[string]interface{}{
+								"type": "object",
+								"properties": map[string]interface{}{
+									"frequency": map[string]interface{}{"type": "string", "description": "One of daily, weekly, monthly, yearly"},
+									"interval":  map[string]interface{}{"type": "integer", "description": "Repeat every Interval periods; defaults to 1"},
+									"count":     map[string]interface{}{"type": "integer", "description": "Stop after Count occurrences; zero means no limit"},
+									"until":     map[string]interface{}{"type": "string", "format": "date-time", "description": "Stop after this time"},
+									"by_day": map[string]interface{}{
+										"type":        "array",
+										"description": "Weekdays for weekly rules as two-letter codes: MO, TU, WE, TH, FR, SA, SU",
+										"items":       map[string]interface{}{"type": "string"},
+									},
+								},
+								"required": []string{"frequency"},
+							},
+							"alert_minutes": map[string]interface{}{
+								"type":        "array",
+								"description": "Minutes before Start at which to alert the user",
+								"items":       map[string]interface{}{"type": "integer"},
+							},
+						},
+						"required": []string{"title", "start"},
+					},
+				},
+				"required": []string{"event"},
+			},
+			Handler: func(ctx context.Context, input map[string]interface{}) *ActionResult {
+				var event skills.Event
+				if bad := decodeArg(input, "event", true, &event); bad != nil {
+					return bad
+				}
+				out, err := impl.UpdateEvent(ctx, event)
+				if err != nil {
+					return ErrorResult("Calendar.UpdateEvent failed", err)
+				}
+				result := modelJSON("Calendar.UpdateEvent", out)
+				if !result.IsError {
+					result.ForUser = "Calendar event updated"
+				}
+				return result
+			},
+		},
+		{
+			Skill:       "Calendar",
+			Action:      "DeleteEvent",
+			Description: "Delete an event and all of its occurrences",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"id": map[string]interface{}{"type": "string", "description": "Event id"},
+				},
+				"required": []string{"id"},
+			},
+			Handler: func(ctx context.Context, input map[string]interface{}) *ActionResult {
+				id, bad := stringArg(input, "id", true)
+				if bad != nil {
+					return bad
+				}
+				if err := impl.DeleteEvent(ctx, id); err != nil {
+					return ErrorResult("Calendar.DeleteEvent failed", err)
+				}
+				return SuccessResult("Calendar.DeleteEvent executed", "Calendar event deleted")
+			},
+		},
+	}
+
+	for _, e := range entries {
+		if err := registry.Register(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func init() {
+	registerSkillBinder("Calendar", func(registry ActionRegistrar, plugin interface{}) (bool, error) {
+		impl, ok := plugin.(skills.Calendar)
+		if !ok {
+			return false, nil
+		}
+		return true, RegisterCalendar(registry, impl)
+	})
+}
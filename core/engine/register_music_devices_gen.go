@@ -0,0 +1,77 @@
+// Code generated by skillgen. DO NOT EDIT.
+
+package engine
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/skills"
+)
+
+func RegisterMusicDevices(registry ActionRegistrar, impl skills.MusicDevices) error {
+	if registry == nil {
+		return fmt.Errorf("registry is required")
+	}
+	if impl == nil {
+		return fmt.Errorf("music devices implementation is required")
+	}
+
+	entries := []RegisteredAction{
+		{
+			Skill:       "MusicPlayer",
+			Action:      "ListDevices",
+			Description: "List the devices playback can run on",
+			InputSchema: map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{},
+			},
+			Handler: func(ctx context.Context, input map[string]interface{}) *ActionResult {
+				out, err := impl.ListDevices(ctx)
+				if err != nil {
+					return ErrorResult("MusicPlayer.ListDevices failed", err)
+				}
+				return modelJSON("MusicPlayer.ListDevices", out)
+			},
+		},
+		{
+			Skill:       "MusicPlayer",
+			Action:      "TransferPlayback",
+			Description: "Move playback to another device, matched by id or name",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"device": map[string]interface{}{"type": "string", "description": "Device id or name"},
+				},
+				"required": []string{"device"},
+			},
+			Handler: func(ctx context.Context, input map[string]interface{}) *ActionResult {
+				device, bad := stringArg(input, "device", true)
+				if bad != nil {
+					return bad
+				}
+				if err := impl.TransferPlayback(ctx, device); err != nil {
+					return ErrorResult("MusicPlayer.TransferPlayback failed", err)
+				}
+				return SuccessResult("MusicPlayer.TransferPlayback executed", "Playing on "+device)
+			},
+		},
+	}
+
+	for _, e := range entries {
+		if err := registry.Register(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func init() {
+	registerSkillBinder("MusicDevices", func(registry ActionRegistrar, plugin interface{}) (bool, error) {
+		impl, ok := plugin.(skills.MusicDevices)
+		if !ok {
+			return false, nil
+		}
+		return true, RegisterMusicDevices(registry, impl)
+	})
+}
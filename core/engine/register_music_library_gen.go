@@ -0,0 +1,105 @@
+// Code generated by skillgen. DO NOT EDIT.
+
+package engine
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/skills"
+)
+
+func RegisterMusicLibrary(registry ActionRegistrar, impl skills.MusicLibrary) error {
+	if registry == nil {
+		return fmt.Errorf("registry is required")
+	}
+	if impl == nil {
+		return fmt.Errorf("music library implementation is required")
+	}
+
+	entries := []RegisteredAction{
+		{
+			Skill:       "MusicPlayer",
+			Action:      "PlayAlbum",
+			Description: "Play the best matching album",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"name": map[string]interface{}{"type": "string", "description": "Album name, optionally with the artist"},
+				},
+				"required": []string{"name"},
+			},
+			Handler: func(ctx context.Context, input map[string]interface{}) *ActionResult {
+				name, bad := stringArg(input, "name", true)
+				if bad != nil {
+					return bad
+				}
+				if err := impl.PlayAlbum(ctx, name); err != nil {
+					return ErrorResult("MusicPlayer.PlayAlbum failed", err)
+				}
+				return SuccessResult("MusicPlayer.PlayAlbum executed", "Playing album: "+name)
+			},
+		},
+		{
+			Skill:       "MusicPlayer",
+			Action:      "PlayArtist",
+			Description: "Play an artist's top tracks",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"name": map[string]interface{}{"type": "string", "description": "Artist name"},
+				},
+				"required": []string{"name"},
+			},
+			Handler: func(ctx context.Context, input map[string]interface{}) *ActionResult {
+				name, bad := stringArg(input, "name", true)
+				if bad != nil {
+					return bad
+				}
+				if err := impl.PlayArtist(ctx, name); err != nil {
+					return ErrorResult("MusicPlayer.PlayArtist failed", err)
+				}
+				return SuccessResult("MusicPlayer.PlayArtist executed", "Playing "+name)
+			},
+		},
+		{
+			Skill:       "MusicPlayer",
+			Action:      "PlayPlaylist",
+			Description: "Play the best matching playlist",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"name": map[string]interface{}{"type": "string", "description": "Playlist name"},
+				},
+				"required": []string{"name"},
+			},
+			Handler: func(ctx context.Context, input map[string]interface{}) *ActionResult {
+				name, bad := stringArg(input, "name", true)
+				if bad != nil {
+					return bad
+				}
+				if err := impl.PlayPlaylist(ctx, name); err != nil {
+					return ErrorResult("MusicPlayer.PlayPlaylist failed", err)
+				}
+				return SuccessResult("MusicPlayer.PlayPlaylist executed", "Playing playlist: "+name)
+			},
+		},
+	}
+
+	for _, e := range entries {
+		if err := registry.Register(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func init() {
+	registerSkillBinder("MusicLibrary", func(registry ActionRegistrar, plugin interface{}) (bool, error) {
+		impl, ok := plugin.(skills.MusicLibrary)
+		if !ok {
+			return false, nil
+		}
+		return true, RegisterMusicLibrary(registry, impl)
+	})
+}
@@ -0,0 +1,83 @@
+// Code generated by skillgen. DO NOT EDIT.
+
+package engine
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/skills"
+)
+
+func RegisterMusicModes(registry ActionRegistrar, impl skills.MusicModes) error {
+	if registry == nil {
+		return fmt.Errorf("registry is required")
+	}
+	if impl == nil {
+		return fmt.Errorf("music modes implementation is required")
+	}
+
+	entries := []RegisteredAction{
+		{
+			Skill:       "MusicPlayer",
+			Action:      "SetShuffle",
+			Description: "Turn shuffle on or off",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"enabled": map[string]interface{}{"type": "boolean", "description": "True to shuffle"},
+				},
+				"required": []string{"enabled"},
+			},
+			Handler: func(ctx context.Context, input map[string]interface{}) *ActionResult {
+				enabled, bad := boolArg(input, "enabled", true)
+				if bad != nil {
+					return bad
+				}
+				if err := impl.SetShuffle(ctx, enabled); err != nil {
+					return ErrorResult("MusicPlayer.SetShuffle failed", err)
+				}
+				return SuccessResult("MusicPlayer.SetShuffle executed", "")
+			},
+		},
+		{
+			Skill:       "MusicPlayer",
+			Action:      "SetRepeat",
+			Description: "Set the repeat mode: off, the current track, or the whole album or playlist",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"mode": map[string]interface{}{"type": "string", "description": "Repeat mode", "enum": []string{"off", "track", "context"}},
+				},
+				"required": []string{"mode"},
+			},
+			Handler: func(ctx context.Context, input map[string]interface{}) *ActionResult {
+				mode, bad := stringArg(input, "mode", true)
+				if bad != nil {
+					return bad
+				}
+				if err := impl.SetRepeat(ctx, mode); err != nil {
+					return ErrorResult("MusicPlayer.SetRepeat failed", err)
+				}
+				return SuccessResult("MusicPlayer.SetRepeat executed", "Repeat set to "+mode)
+			},
+		},
+	}
+
+	for _, e := range entries {
+		if err := registry.Register(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func init() {
+	registerSkillBinder("MusicModes", func(registry ActionRegistrar, plugin interface{}) (bool, error) {
+		impl, ok := plugin.(skills.MusicModes)
+		if !ok {
+			return false, nil
+		}
+		return true, RegisterMusicModes(registry, impl)
+	})
+}
@@ -0,0 +1,76 @@
+// Code generated by skillgen. DO NOT EDIT.
+
+package engine
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/skills"
+)
+
+func RegisterMusicNavigator(registry ActionRegistrar, impl skills.MusicNavigator) error {
+	if registry == nil {
+		return fmt.Errorf("registry is required")
+	}
+	if impl == nil {
+		return fmt.Errorf("music navigator implementation is required")
+	}
+
+	entries := []RegisteredAction{
+		{
+			Skill:       "MusicPlayer",
+			Action:      "Previous",
+			Description: "Go back to the previous track",
+			InputSchema: map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{},
+			},
+			Handler: func(ctx context.Context, input map[string]interface{}) *ActionResult {
+				if err := impl.Previous(ctx); err != nil {
+					return ErrorResult("MusicPlayer.Previous failed", err)
+				}
+				return SuccessResult("MusicPlayer.Previous executed", "Back to the previous track")
+			},
+		},
+		{
+			Skill:       "MusicPlayer",
+			Action:      "Seek",
+			Description: "Jump to a position in the current track",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"position_ms": map[string]interface{}{"type": "integer", "description": "Position from the start of the track in milliseconds"},
+				},
+				"required": []string{"position_ms"},
+			},
+			Handler: func(ctx context.Context, input map[string]interface{}) *ActionResult {
+				positionMs, bad := intArg(input, "position_ms", true)
+				if bad != nil {
+					return bad
+				}
+				if err := impl.Seek(ctx, int(positionMs)); err != nil {
+					return ErrorResult("MusicPlayer.Seek failed", err)
+				}
+				return SuccessResult("MusicPlayer.Seek executed", "Jumped to "+fmt.Sprint(positionMs)+" ms")
+			},
+		},
+	}
+
+	for _, e := range entries {
+		if err := registry.Register(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func init() {
+	registerSkillBinder("MusicNavigator", func(registry ActionRegistrar, plugin interface{}) (bool, error) {
+		impl, ok := plugin.(skills.MusicNavigator)
+		if !ok {
+			return false, nil
+		}
+		return true, RegisterMusicNavigator(registry, impl)
+	})
+}
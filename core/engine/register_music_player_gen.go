@@ -1,3 +1,5 @@
+// Code generated by skillgen. DO NOT EDIT.
+
 package engine
 
 import (
@@ -7,11 +9,11 @@ import (
 	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/skills"
 )
 
-func RegisterMusicPlayer(registry *Registry, player skills.MusicPlayer) error {
+func RegisterMusicPlayer(registry ActionRegistrar, impl skills.MusicPlayer) error {
 	if registry == nil {
 		return fmt.Errorf("registry is required")
 	}
-	if player == nil {
+	if impl == nil {
 		return fmt.Errorf("music player implementation is required")
 	}
 
@@ -23,16 +25,16 @@ func RegisterMusicPlayer(registry *Registry, player skills.MusicPlayer) error {
 			InputSchema: map[string]interface{}{
 				"type": "object",
 				"properties": map[string]interface{}{
-					"query": map[string]interface{}{"type": "string"},
+					"query": map[string]interface{}{"type": "string", "description": "Track, artist or free-form search text"},
 				},
 				"required": []string{"query"},
 			},
 			Handler: func(ctx context.Context, input map[string]interface{}) *ActionResult {
-				query, _ := input["query"].(string)
-				if query == "" {
-					return ErrorResult("missing required field: query", fmt.Errorf("query is required"))
+				query, bad := stringArg(input, "query", true)
+				if bad != nil {
+					return bad
 				}
-				if err := player.Play(ctx, query); err != nil {
+				if err := impl.Play(ctx, query); err != nil {
 					return ErrorResult("MusicPlayer.Play failed", err)
 				}
 				return SuccessResult("MusicPlayer.Play executed", "Playing: "+query)
@@ -42,9 +44,12 @@ func RegisterMusicPlayer(registry *Registry, player skills.MusicPlayer) error {
 			Skill:       "MusicPlayer",
 			Action:      "Pause",
 			Description: "Pause current playback",
-			InputSchema: map[string]interface{}{"type": "object", "properties": map[string]interface{}{}},
+			InputSchema: map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{},
+			},
 			Handler: func(ctx context.Context, input map[string]interface{}) *ActionResult {
-				if err := player.Pause(ctx); err != nil {
+				if err := impl.Pause(ctx); err != nil {
 					return ErrorResult("MusicPlayer.Pause failed", err)
 				}
 				return SuccessResult("MusicPlayer.Pause executed", "Playback paused")
@@ -54,9 +59,12 @@ func RegisterMusicPlayer(registry *Registry, player skills.MusicPlayer) error {
 			Skill:       "MusicPlayer",
 			Action:      "Resume",
 			Description: "Resume current playback",
-			InputSchema: map[string]interface{}{"type": "object", "properties": map[string]interface{}{}},
+			InputSchema: map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{},
+			},
 			Handler: func(ctx context.Context, input map[string]interface{}) *ActionResult {
-				if err := player.Resume(ctx); err != nil {
+				if err := impl.Resume(ctx); err != nil {
 					return ErrorResult("MusicPlayer.Resume failed", err)
 				}
 				return SuccessResult("MusicPlayer.Resume executed", "Playback resumed")
@@ -66,9 +74,12 @@ func RegisterMusicPlayer(registry *Registry, player skills.MusicPlayer) error {
 			Skill:       "MusicPlayer",
 			Action:      "Next",
 			Description: "Skip to next track",
-			InputSchema: map[string]interface{}{"type": "object", "properties": map[string]interface{}{}},
+			InputSchema: map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{},
+			},
 			Handler: func(ctx context.Context, input map[string]interface{}) *ActionResult {
-				if err := player.Next(ctx); err != nil {
+				if err := impl.Next(ctx); err != nil {
 					return ErrorResult("MusicPlayer.Next failed", err)
 				}
 				return SuccessResult("MusicPlayer.Next executed", "Skipped to next track")
@@ -83,3 +94,13 @@ func RegisterMusicPlayer(registry *Registry, player skills.MusicPlayer) error {
 	}
 	return nil
 }
+
+func init() {
+	registerSkillBinder("MusicPlayer", func(registry ActionRegistrar, plugin interface{}) (bool, error) {
+		impl, ok := plugin.(skills.MusicPlayer)
+		if !ok {
+			return false, nil
+		}
+		return true, RegisterMusicPlayer(registry, impl)
+	})
+}
@@ -0,0 +1,61 @@
+// Code generated by skillgen. DO NOT EDIT.
+
+package engine
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/skills"
+)
+
+func RegisterMusicQueue(registry ActionRegistrar, impl skills.MusicQueue) error {
+	if registry == nil {
+		return fmt.Errorf("registry is required")
+	}
+	if impl == nil {
+		return fmt.Errorf("music queue implementation is required")
+	}
+
+	entries := []RegisteredAction{
+		{
+			Skill:       "MusicPlayer",
+			Action:      "AddToQueue",
+			Description: "Queue the best matching track to play next",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"query": map[string]interface{}{"type": "string", "description": "Track or free-form search text"},
+				},
+				"required": []string{"query"},
+			},
+			Handler: func(ctx context.Context, input map[string]interface{}) *ActionResult {
+				query, bad := stringArg(input, "query", true)
+				if bad != nil {
+					return bad
+				}
+				if err := impl.AddToQueue(ctx, query); err != nil {
+					return ErrorResult("MusicPlayer.AddToQueue failed", err)
+				}
+				return SuccessResult("MusicPlayer.AddToQueue executed", "Queued: "+query)
+			},
+		},
+	}
+
+	for _, e := range entries {
+		if err := registry.Register(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func init() {
+	registerSkillBinder("MusicQueue", func(registry ActionRegistrar, plugin interface{}) (bool, error) {
+		impl, ok := plugin.(skills.MusicQueue)
+		if !ok {
+			return false, nil
+		}
+		return true, RegisterMusicQueue(registry, impl)
+	})
+}
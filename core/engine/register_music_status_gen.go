@@ -0,0 +1,55 @@
+// Code generated by skillgen. DO NOT EDIT.
+
+package engine
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/skills"
+)
+
+func RegisterMusicStatus(registry ActionRegistrar, impl skills.MusicStatus) error {
+	if registry == nil {
+		return fmt.Errorf("registry is required")
+	}
+	if impl == nil {
+		return fmt.Errorf("music status implementation is required")
+	}
+
+	entries := []RegisteredAction{
+		{
+			Skill:       "MusicPlayer",
+			Action:      "NowPlaying",
+			Description: "Describe the track that is playing now, if any",
+			InputSchema: map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{},
+			},
+			Handler: func(ctx context.Context, input map[string]interface{}) *ActionResult {
+				out, err := impl.NowPlaying(ctx)
+				if err != nil {
+					return ErrorResult("MusicPlayer.NowPlaying failed", err)
+				}
+				return modelJSON("MusicPlayer.NowPlaying", out)
+			},
+		},
+	}
+
+	for _, e := range entries {
+		if err := registry.Register(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func init() {
+	registerSkillBinder("MusicStatus", func(registry ActionRegistrar, plugin interface{}) (bool, error) {
+		impl, ok := plugin.(skills.MusicStatus)
+		if !ok {
+			return false, nil
+		}
+		return true, RegisterMusicStatus(registry, impl)
+	})
+}
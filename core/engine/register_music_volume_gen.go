@@ -0,0 +1,61 @@
+// Code generated by skillgen. DO NOT EDIT.
+
+package engine
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/skills"
+)
+
+func RegisterMusicVolume(registry ActionRegistrar, impl skills.MusicVolume) error {
+	if registry == nil {
+		return fmt.Errorf("registry is required")
+	}
+	if impl == nil {
+		return fmt.Errorf("music volume implementation is required")
+	}
+
+	entries := []RegisteredAction{
+		{
+			Skill:       "MusicPlayer",
+			Action:      "SetVolume",
+			Description: "Set the playback volume",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"percent": map[string]interface{}{"type": "integer", "description": "Volume from 0 to 100"},
+				},
+				"required": []string{"percent"},
+			},
+			Handler: func(ctx context.Context, input map[string]interface{}) *ActionResult {
+				percent, bad := intArg(input, "percent", true)
+				if bad != nil {
+					return bad
+				}
+				if err := impl.SetVolume(ctx, int(percent)); err != nil {
+					return ErrorResult("MusicPlayer.SetVolume failed", err)
+				}
+				return SuccessResult("MusicPlayer.SetVolume executed", "Volume set to "+fmt.Sprint(percent)+"%")
+			},
+		},
+	}
+
+	for _, e := range entries {
+		if err := registry.Register(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func init() {
+	registerSkillBinder("MusicVolume", func(registry ActionRegistrar, plugin interface{}) (bool, error) {
+		impl, ok := plugin.(skills.MusicVolume)
+		if !ok {
+			return false, nil
+		}
+		return true, RegisterMusicVolume(registry, impl)
+	})
+}
@@ -0,0 +1,191 @@
+// Code generated by skillgen. DO NOT EDIT.
+
+package engine
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/skills"
+)
+
+func RegisterReminders(registry ActionRegistrar, impl skills.Reminders) error {
+	if registry == nil {
+		return fmt.Errorf("registry is required")
+	}
+	if impl == nil {
+		return fmt.Errorf("reminders implementation is required")
+	}
+
+	entries := []RegisteredAction{
+		{
+			Skill:       "Reminders",
+			Action:      "CreateReminder",
+			Description: "Create a reminder and return it with its assigned id",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"reminder": map[string]interface{}{
+						"type":        "object",
+						"description": "Reminder to create; leave id empty",
+						"properties": map[string]interface{}{
+							"id":        map[string]interface{}{"type": "string"},
+							"title":     map[string]interface{}{"type": "string"},
+							"due":       map[string]interface{}{"type": "string", "format": "date-time"},
+							"time_zone": map[string]interface{}{"type": "string", "description": "IANA zone such as Asia/Kolkata; empty means UTC"},
+							"notes":     map[string]interface{}{"type": "string"},
+							"recurrence": map[string]interface{}{
+								"type": "object",
+								"properties": map[string]interface{}{
+									"frequency": map[string]interface{}{"type": "string", "description": "One of daily, weekly, monthly, yearly"},
+									"interval":  map[string]interface{}{"type": "integer", "description": "Repeat every Interval periods; defaults to 1"},
+									"count":     map[string]interface{}{"type": "integer", "description": "Stop after Count occurrences; zero means no limit"},
+									"until":     map[string]interface{}{"type": "string", "format": "date-time", "description": "Stop after this time"},
+									"by_day": map[string]interface{}{
+										"type":        "array",
+										"description": "Weekdays for weekly rules as two-letter codes: MO, TU, WE, TH, FR, SA, SU",
+										"items":       map[string]interface{}{"type": "string"},
+									},
+								},
+								"required": []string{"frequency"},
+							},
+							"done": map[string]interface{}{"type": "boolean"},
+						},
+						"required": []string{"title", "due"},
+					},
+				},
+				"required": []string{"reminder"},
+			},
+			Handler: func(ctx context.Context, input map[string]interface{}) *ActionResult {
+				var reminder skills.Reminder
+				if bad := decodeArg(input, "reminder", true, &reminder); bad != nil {
+					return bad
+				}
+				out, err := impl.CreateReminder(ctx, reminder)
+				if err != nil {
+					return ErrorResult("Reminders.CreateReminder failed", err)
+				}
+				result := modelJSON("Reminders.CreateReminder", out)
+				if !result.IsError {
+					result.ForUser = "Reminder set"
+				}
+				return result
+			},
+		},
+		{
+			Skill:       "Reminders",
+			Action:      "ListReminders",
+			Description: "List reminders ordered by due time",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"include_done": map[string]interface{}{"type": "boolean", "description": "Also return completed reminders"},
+				},
+			},
+			Handler: func(ctx context.Context, input map[string]interface{}) *ActionResult {
+				includeDone, bad := boolArg(input, "include_done", false)
+				if bad != nil {
+					return bad
+				}
+				out, err := impl.ListReminders(ctx, includeDone)
+				if err != nil {
+					return ErrorResult("Reminders.ListReminders failed", err)
+				}
+				return modelJSON("Reminders.ListReminders", out)
+			},
+		},
+		{
+			Skill:       "Reminders",
+			Action:      "UpdateReminder",
+			Description: "Replace an existing reminder, matched by id. Set done to complete it",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"reminder": map[string]interface{}{
+						"type":        "object",
+						"description": "Full reminder including its id",
+						"properties": map[string]interface{}{
+							"id":        map[string]interface{}{"type": "string"},
+							"title":     map[string]interface{}{"type": "string"},
+							"due":       map[string]interface{}{"type": "string", "format": "date-time"},
+							"time_zone": map[string]interface{}{"type": "string", "description": "IANA zone such as Asia/Kolkata; empty means UTC"},
+							"notes":     map[string]interface{}{"type": "string"},
+							"recurrence": map[string]interface{}{
+								"type": "object",
+								"properties": map[string]interface{}{
+									"frequency": map[string]interface{}{"type": "string", "description": "One of daily, weekly, monthly, yearly"},
+									"interval":  map[string]interface{}{"type": "integer", "description": "Repeat every Interval periods; defaults to 1"},
+									"count":     map[string]interface{}{"type": "integer", "description": "Stop after Count occurrences; zero means no limit"},
+									"until":     map[string]interface{}{"type": "string", "format": "date-time", "description": "Stop after this time"},
+									"by_day": map[string]interface{}{
+										"type":        "array",
+										"description": "Weekdays for weekly rules as two-letter codes: MO, TU, WE, TH, FR, SA, SU",
+										"items":       map[string]interface{}{"type": "string"},
+									},
+								},
+								"required": []string{"frequency"},
+							},
+							"done": map[string]interface{}{"type": "boolean"},
+						},
+						"required": []string{"title", "due"},
+					},
+				},
+				"required": []string{"reminder"},
+			},
+			Handler: func(ctx context.Context, input map[string]interface{}) *ActionResult {
+				var reminder skills.Reminder
+				if bad := decodeArg(input, "reminder", true, &reminder); bad != nil {
+					return bad
+				}
+				out, err := impl.UpdateReminder(ctx, reminder)
+				if err != nil {
+					return ErrorResult("Reminders.UpdateReminder failed", err)
+				}
+				result := modelJSON("Reminders.UpdateReminder", out)
+				if !result.IsError {
+					result.ForUser = "Reminder updated"
+				}
+				return result
+			},
+		},
+		{
+			Skill:       "Reminders",
+			Action:      "DeleteReminder",
+			Description: "Delete a reminder",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"id": map[string]interface{}{"type": "string", "description": "Reminder id"},
+				},
+				"required": []string{"id"},
+			},
+			Handler: func(ctx context.Context, input map[string]interface{}) *ActionResult {
+				id, bad := stringArg(input, "id", true)
+				if bad != nil {
+					return bad
+				}
+				if err := impl.DeleteReminder(ctx, id); err != nil {
+					return ErrorResult("Reminders.DeleteReminder failed", err)
+				}
+				return SuccessResult("Reminders.DeleteReminder executed", "Reminder deleted")
+			},
+		},
+	}
+
+	for _, e := range entries {
+		if err := registry.Register(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func init() {
+	registerSkillBinder("Reminders", func(registry ActionRegistrar, plugin interface{}) (bool, error) {
+		impl, ok := plugin.(skills.Reminders)
+		if !ok {
+			return false, nil
+		}
+		return true, RegisterReminders(registry, impl)
+	})
+}
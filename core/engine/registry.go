@@ -20,6 +20,15 @@ type RegisteredAction struct {
 	Description string
 	InputSchema map[string]interface{}
 	Handler     ActionHandler
+	// Confirm marks actions with side effects the user must approve before
+	// the loop runs them.
+	Confirm bool
+}
+
+// ActionRegistrar adds actions to a Registry. Plugins bind their actions
+// through one so the PluginManager knows which actions are theirs.
+type ActionRegistrar interface {
+	Register(a RegisteredAction) error
 }
 
 type Registry struct {
@@ -53,6 +62,27 @@ func (r *Registry) Register(a RegisteredAction) error {
 	return nil
 }
 
+// Unregister removes one action; it reports whether the action existed.
+func (r *Registry) Unregister(skillName, actionName string) bool {
+	return r.unregisterKey(actionKey(skillName, actionName))
+}
+
+func (r *Registry) unregisterKey(key string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, ok := r.actions[key]
+	delete(r.actions, key)
+	return ok
+}
+
+// Action looks up one registered action.
+func (r *Registry) Action(skillName, actionName string) (RegisteredAction, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	a, ok := r.actions[actionKey(skillName, actionName)]
+	return a, ok
+}
+
 func (r *Registry) Execute(ctx context.Context, skillName, actionName string, input map[string]interface{}) *ActionResult {
 	if input == nil {
 		input = map[string]interface{}{}
@@ -72,6 +102,21 @@ func (r *Registry) Execute(ctx context.Context, skillName, actionName string, in
 	return result
 }
 
+// Actions returns a snapshot of every registered action ordered by skill and action name.
+func (r *Registry) Actions() []RegisteredAction {
+	r.mu.RLock()
+	out := make([]RegisteredAction, 0, len(r.actions))
+	for _, a := range r.actions {
+		out = append(out, a)
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(out, func(i, j int) bool {
+		return actionKey(out[i].Skill, out[i].Action) < actionKey(out[j].Skill, out[j].Action)
+	})
+	return out
+}
+
 func (r *Registry) ToProviderDefs() []ToolDefinition {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
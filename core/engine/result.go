@@ -1,11 +1,69 @@
 package engine
 
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
 // ActionResult is the normalized output of one skill action execution.
+//
+// ForModel is the compact text the planner sees. Data, Artifacts and Card are
+// optional rich payloads for the app and chat channels; the model only sees
+// them summarized through ModelText.
 type ActionResult struct {
-	ForModel string `json:"for_model"`
-	ForUser  string `json:"for_user,omitempty"`
-	IsError  bool   `json:"is_error"`
-	Err      error  `json:"-"`
+	ForModel  string          `json:"for_model"`
+	ForUser   string          `json:"for_user,omitempty"`
+	Data      json.RawMessage `json:"data,omitempty"`
+	Artifacts []Artifact      `json:"artifacts,omitempty"`
+	Card      *Card           `json:"card,omitempty"`
+	IsError   bool            `json:"is_error"`
+	Err       error           `json:"-"`
+}
+
+// Artifact kinds.
+const (
+	ArtifactFile  = "file"
+	ArtifactImage = "image"
+	ArtifactURL   = "url"
+)
+
+// Artifact is a file, image or link produced by an action. URI is a file://,
+// http(s):// or data: URI.
+type Artifact struct {
+	Kind     string `json:"kind"`
+	Name     string `json:"name,omitempty"`
+	MIMEType string `json:"mime_type,omitempty"`
+	URI      string `json:"uri"`
+	Size     int64  `json:"size,omitempty"`
+}
+
+// Card is a lightweight UI card the app renders natively and text-only
+// channels render through PlainText.
+type Card struct {
+	Title    string       `json:"title"`
+	Subtitle string       `json:"subtitle,omitempty"`
+	ImageURL string       `json:"image_url,omitempty"`
+	Items    []CardItem   `json:"items,omitempty"`
+	Actions  []CardAction `json:"actions,omitempty"`
+}
+
+// CardItem is one row of a card, such as a track in a search result.
+type CardItem struct {
+	Title    string `json:"title"`
+	Subtitle string `json:"subtitle,omitempty"`
+	ImageURL string `json:"image_url,omitempty"`
+	URL      string `json:"url,omitempty"`
+}
+
+// CardAction is a button on a card. It either opens URL or runs Skill.Action
+// with Input when tapped.
+type CardAction struct {
+	Label  string                 `json:"label"`
+	URL    string                 `json:"url,omitempty"`
+	Skill  string                 `json:"skill,omitempty"`
+	Action string                 `json:"action,omitempty"`
+	Input  map[string]interface{} `json:"input,omitempty"`
 }
 
 func SuccessResult(forModel, forUser string) *ActionResult {
@@ -15,3 +73,84 @@ func SuccessResult(forModel, forUser string) *ActionResult {
 func ErrorResult(message string, err error) *ActionResult {
 	return &ActionResult{ForModel: message, IsError: true, Err: err}
 }
+
+// WithData attaches v encoded as JSON. An unencodable value turns the result
+// into an error so the failure is not silently dropped.
+func (r *ActionResult) WithData(v interface{}) *ActionResult {
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		return ErrorResult("result data is not encodable", err)
+	}
+	r.Data = encoded
+	return r
+}
+
+// WithArtifacts appends artifacts to the result.
+func (r *ActionResult) WithArtifacts(artifacts ...Artifact) *ActionResult {
+	r.Artifacts = append(r.Artifacts, artifacts...)
+	return r
+}
+
+// WithCard sets the UI card for the result.
+func (r *ActionResult) WithCard(card *Card) *ActionResult {
+	r.Card = card
+	return r
+}
+
+// ModelText is the compact view sent back to the planner: ForModel, the
+// error if any, and one line naming attached artifacts.
+func (r *ActionResult) ModelText() string {
+	text := r.ForModel
+	if text == "" && len(r.Data) > 0 {
+		text = string(r.Data)
+	}
+	if r.IsError && r.Err != nil {
+		text = text + " | error=" + r.Err.Error()
+	}
+	if len(r.Artifacts) > 0 {
+		names := make([]string, 0, len(r.Artifacts))
+		for _, a := range r.Artifacts {
+			names = append(names, a.label())
+		}
+		text = text + "\n[artifacts: " + strings.Join(names, ", ") + "]"
+	}
+	return text
+}
+
+// UserText is what a text-only channel shows: ForUser, else the card.
+func (r *ActionResult) UserText() string {
+	if strings.TrimSpace(r.ForUser) != "" || r.Card == nil {
+		return r.ForUser
+	}
+	return r.Card.PlainText()
+}
+
+func (a Artifact) label() string {
+	name := a.Name
+	if name == "" {
+		name = a.URI
+	}
+	if a.MIMEType != "" {
+		return fmt.Sprintf("%s (%s)", name, a.MIMEType)
+	}
+	return name
+}
+
+// PlainText renders the card as a short bulleted message.
+func (c *Card) PlainText() string {
+	var b strings.Builder
+	b.WriteString(c.Title)
+	if c.Subtitle != "" {
+		b.WriteString("\n" + c.Subtitle)
+	}
+	for _, item := range c.Items {
+		b.WriteString("\n• " + item.Title)
+		if item.Subtitle != "" {
+			b.WriteString(" — " + item.Subtitle)
+		}
+		if item.URL != "" {
+			b.WriteString(" " + item.URL)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,146 @@
+package engine
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/protocol"
+)
+
+func TestActionResult_ModelTextIsCompact(t *testing.T) {
+	result := SuccessResult("found 2 tracks", "").
+		WithData([]map[string]string{{"title": "Yellow"}, {"title": "Clocks"}}).
+		WithArtifacts(Artifact{Kind: ArtifactFile, Name: "playlist.m3u", MIMEType: "audio/x-mpegurl", URI: "file:///tmp/playlist.m3u"})
+
+	got := result.ModelText()
+	want := "found 2 tracks\n[artifacts: playlist.m3u (audio/x-mpegurl)]"
+	if got != want {
+		t.Fatalf("ModelText = %q, want %q", got, want)
+	}
+	if string(result.Data) != `[{"title":"Yellow"},{"title":"Clocks"}]` {
+		t.Fatalf("Data = %s", result.Data)
+	}
+}
+
+func TestActionResult_ModelTextFallsBackToDataAndError(t *testing.T) {
+	if got := (&ActionResult{Data: json.RawMessage(`{"ok":true}`)}).ModelText(); got != `{"ok":true}` {
+		t.Fatalf("ModelText = %q", got)
+	}
+	if got := ErrorResult("Music.Play failed", errors.New("no device")).ModelText(); got != "Music.Play failed | error=no device" {
+		t.Fatalf("ModelText = %q", got)
+	}
+}
+
+func TestActionResult_WithDataRejectsUnencodable(t *testing.T) {
+	result := SuccessResult("ok", "").WithData(make(chan int))
+	if !result.IsError || result.Err == nil {
+		t.Fatalf("expected error result, got %+v", result)
+	}
+}
+
+func TestActionResult_UserTextRendersCard(t *testing.T) {
+	result := SuccessResult("2 results", "").WithCard(&Card{
+		Title: "Search results",
+		Items: []CardItem{
+			{Title: "Yellow", Subtitle: "Coldplay"},
+			{Title: "Clocks", URL: "https://open.spotify.com/track/1"},
+		},
+	})
+	text := result.UserText()
+	for _, want := range []string{"Search results", "• Yellow — Coldplay", "• Clocks https://open.spotify.com/track/1"} {
+		if !strings.Contains(text, want) {
+			t.Fatalf("UserText missing %q:\n%s", want, text)
+		}
+	}
+
+	result.ForUser = "Here you go"
+	if got := result.UserText(); got != "Here you go" {
+		t.Fatalf("ForUser should win over card, got %q", got)
+	}
+}
+
+func TestActionResult_JSONShape(t *testing.T) {
+	encoded, err := json.Marshal(SuccessResult("ok", "").WithData(map[string]int{"n": 1}).WithCard(&Card{Title: "T"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"for_model":"ok","data":{"n":1},"card":{"title":"T"},"is_error":false}`
+	if string(encoded) != want {
+		t.Fatalf("json = %s, want %s", encoded, want)
+	}
+}
+
+func TestFinishedEvent_CarriesRichParts(t *testing.T) {
+	result := SuccessResult("2 tracks", "Found 2 tracks").
+		WithData([]string{"a", "b"}).
+		WithCard(&Card{Title: "Tracks", Items: []CardItem{{Title: "a"}}}).
+		WithArtifacts(Artifact{Kind: ArtifactURL, URI: "https://example.com/a"})
+	ev := finishedEvent("MusicPlayer", "Search", result)
+	ev.SessionID, ev.Seq = "s", 1
+	raw, err := protocol.Encode(&ev)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`"text":"Found 2 tracks"`,
+		`"data":["a","b"]`,
+		`"card":{"title":"Tracks","items":[{"title":"a"}]}`,
+		`"artifacts":[{"kind":"url","uri":"https://example.com/a"}]`,
+	} {
+		if !strings.Contains(string(raw), want) {
+			t.Errorf("event %s lacks %s", raw, want)
+		}
+	}
+}
+
+// TestSchema_DescribesCardsAndArtifacts keeps the protocol schema, which
+// hosts generate their types from, in step with the engine types events
+// carry.
+func TestSchema_DescribesCardsAndArtifacts(t *testing.T) {
+	type def struct {
+		Properties map[string]json.RawMessage `json:"properties"`
+		Items      *def                       `json:"items"`
+	}
+	var doc struct {
+		Defs map[string]def `json:"$defs"`
+	}
+	if err := json.Unmarshal(protocol.Schema(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	field := func(d def, name string) def {
+		var out def
+		if err := json.Unmarshal(d.Properties[name], &out); err != nil || out.Items == nil {
+			t.Fatalf("schema property %s: %v", name, err)
+		}
+		return *out.Items
+	}
+	card := doc.Defs["Card"]
+	for d, v := range map[string]struct {
+		schema def
+		value  interface{}
+	}{
+		"Artifact":   {doc.Defs["Artifact"], Artifact{}},
+		"Card":       {card, Card{}},
+		"CardItem":   {field(card, "items"), CardItem{}},
+		"CardAction": {field(card, "actions"), CardAction{}},
+	} {
+		var props, fields []string
+		for name := range v.schema.Properties {
+			props = append(props, name)
+		}
+		typ := reflect.TypeOf(v.value)
+		for i := 0; i < typ.NumField(); i++ {
+			name, _, _ := strings.Cut(typ.Field(i).Tag.Get("json"), ",")
+			fields = append(fields, name)
+		}
+		sort.Strings(props)
+		sort.Strings(fields)
+		if !reflect.DeepEqual(props, fields) {
+			t.Errorf("%s: schema properties %v, Go fields %v", d, props, fields)
+		}
+	}
+}
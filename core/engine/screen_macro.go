@@ -0,0 +1,190 @@
+package engine
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/screen"
+)
+
+// MacroPathEnv overrides where FileMacroStore keeps recorded macros.
+const MacroPathEnv = "UPCRAFT_MACRO_PATH"
+
+// MacroStep is one recorded action and the screen it was taken on. Target
+// locates the acted-on node again, since host node IDs are not stable
+// between runs.
+type MacroStep struct {
+	App         string           `json:"app,omitempty"`
+	Fingerprint string           `json:"fingerprint"`
+	Action      screen.Action    `json:"action"`
+	Target      *screen.Selector `json:"target,omitempty"`
+}
+
+// Macro is a goal session that succeeded, kept for deterministic replay.
+// It is keyed by the goal, the app and the fingerprint of the first screen.
+type Macro struct {
+	Goal  string      `json:"goal"`
+	App   string      `json:"app,omitempty"`
+	Start string      `json:"start"`
+	Steps []MacroStep `json:"steps"`
+	// Final is the fingerprint of the screen on which the goal was reached.
+	Final      string    `json:"final"`
+	RecordedAt time.Time `json:"recorded_at"`
+	Replays    int       `json:"replays,omitempty"`
+}
+
+// Key returns the lookup key for the macro.
+func (m *Macro) Key() string {
+	return MacroKey(m.Goal, m.App, m.Start)
+}
+
+// MacroKey builds the key a macro is stored under. Goals differing only in
+// case or spacing share macros.
+func MacroKey(goal, app, fingerprint string) string {
+	goal = strings.Join(strings.Fields(strings.ToLower(goal)), " ")
+	return goal + "|" + app + "|" + fingerprint
+}
+
+// MacroStore persists recorded macros. Find returns nil, nil when there is
+// no macro for key.
+type MacroStore interface {
+	Find(key string) (*Macro, error)
+	Save(m *Macro) error
+	Delete(key string) error
+}
+
+// FileMacroStore keeps every macro in one JSON file.
+type FileMacroStore struct {
+	Path string
+
+	mu sync.Mutex
+}
+
+// DefaultMacroPath honors UPCRAFT_MACRO_PATH and otherwise uses the user
+// config directory.
+func DefaultMacroPath() (string, error) {
+	if path := strings.TrimSpace(os.Getenv(MacroPathEnv)); path != "" {
+		return path, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("locate config dir: %w", err)
+	}
+	return filepath.Join(dir, "upcraft", "screen_macros.json"), nil
+}
+
+func (s *FileMacroStore) Find(key string) (*Macro, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	macros, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	return macros[key], nil
+}
+
+func (s *FileMacroStore) Save(m *Macro) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	macros, err := s.load()
+	if err != nil {
+		return err
+	}
+	macros[m.Key()] = m
+	return s.store(macros)
+}
+
+func (s *FileMacroStore) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	macros, err := s.load()
+	if err != nil {
+		return err
+	}
+	if _, ok := macros[key]; !ok {
+		return nil
+	}
+	delete(macros, key)
+	return s.store(macros)
+}
+
+func (s *FileMacroStore) load() (map[string]*Macro, error) {
+	macros := map[string]*Macro{}
+	raw, err := os.ReadFile(s.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return macros, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read screen macros: %w", err)
+	}
+	if err := json.Unmarshal(raw, &macros); err != nil {
+		return nil, fmt.Errorf("decode screen macros: %w", err)
+	}
+	return macros, nil
+}
+
+func (s *FileMacroStore) store(macros map[string]*Macro) error {
+	raw, err := json.MarshalIndent(macros, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.Path), 0o700); err != nil {
+		return fmt.Errorf("create macro dir: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.Path), ".screen-macros-*")
+	if err != nil {
+		return fmt.Errorf("save screen macros: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(raw); err != nil {
+		tmp.Close()
+		return fmt.Errorf("save screen macros: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("save screen macros: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.Path); err != nil {
+		return fmt.Errorf("save screen macros: %w", err)
+	}
+	return nil
+}
+
+// recordStep captures action as taken on s.
+func recordStep(s *screen.Screen, fingerprint string, action screen.Action) MacroStep {
+	step := MacroStep{App: s.App, Fingerprint: fingerprint, Action: action}
+	if action.NodeID != "" {
+		step.Target = s.SelectorFor(action.NodeID)
+		step.Action.NodeID = ""
+	}
+	return step
+}
+
+// replay returns the recorded action bound to the current screen, or false
+// when the screen has diverged from the recording: another app, a target
+// that no longer resolves to exactly one node, or, for untargeted steps, a
+// different screen.
+func (step MacroStep) replay(s *screen.Screen, fingerprint string) (screen.Action, bool) {
+	if s.App != step.App {
+		return screen.Action{}, false
+	}
+	action := step.Action
+	if step.Target != nil {
+		n := s.Resolve(*step.Target)
+		if n == nil {
+			return screen.Action{}, false
+		}
+		action.NodeID = n.ID
+	} else if fingerprint != step.Fingerprint {
+		return screen.Action{}, false
+	}
+	if err := action.Normalize(s); err != nil {
+		return screen.Action{}, false
+	}
+	return action, true
+}
@@ -0,0 +1,203 @@
+package engine
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/protocol"
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/redact"
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/screen"
+)
+
+const darkModeGoal = "turn on dark mode in Settings"
+
+// renumbered gives every node a fresh ID, as a host does between runs.
+func renumbered(t *testing.T, s *screen.Screen, prefix string) *screen.Screen {
+	t.Helper()
+	s.Walk(func(n *screen.Node, _ int) bool {
+		n.ID = prefix + n.ID
+		return true
+	})
+	if err := s.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// runGoal drives one session over screens and returns every step result.
+func runGoal(t *testing.T, sessions *ScreenSessions, now *time.Time, screens ...*screen.Screen) []protocol.GoalStep {
+	t.Helper()
+	id, err := sessions.Start(darkModeGoal, ScreenLimits{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var results []protocol.GoalStep
+	for _, s := range screens {
+		r := step(t, sessions, now, id, s)
+		results = append(results, r)
+		if r.Status != protocol.GoalRunning {
+			break
+		}
+	}
+	return results
+}
+
+func darkModeScreens(t *testing.T, prefix string) []*screen.Screen {
+	t.Helper()
+	return []*screen.Screen{
+		renumbered(t, settingsScreen(t, "Settings", "Network", "Display"), prefix),
+		renumbered(t, settingsScreen(t, "Display", "Dark theme: off"), prefix),
+		renumbered(t, settingsScreen(t, "Display", "Dark theme: on"), prefix),
+	}
+}
+
+func recordDarkMode(t *testing.T, store MacroStore) {
+	t.Helper()
+	provider := &scriptedProvider{replies: []string{
+		`{"action":"CLICK","node_id":"rowb"}`,
+		`{"action":"CLICK","node_id":"rowa"}`,
+		`{"action":"DONE","reason":"dark theme is on"}`,
+	}}
+	sessions, now := newTestSessions(NewScreenPlanner(provider))
+	sessions.Macros = store
+	results := runGoal(t, sessions, now, darkModeScreens(t, "")...)
+	if last := results[len(results)-1]; last.Status != protocol.GoalCompleted {
+		t.Fatalf("recording run = %+v", last)
+	}
+}
+
+func TestScreenSessions_ReplaysRecordedMacro(t *testing.T) {
+	store := &FileMacroStore{Path: filepath.Join(t.TempDir(), "macros.json")}
+	recordDarkMode(t, store)
+
+	provider := &scriptedProvider{}
+	sessions, now := newTestSessions(NewScreenPlanner(provider))
+	sessions.Macros = store
+	screens := darkModeScreens(t, "run2-")
+	results := runGoal(t, sessions, now, screens...)
+
+	if len(provider.seen) != 0 {
+		t.Fatalf("replay consulted the model %d times", len(provider.seen))
+	}
+	if len(results) != 3 {
+		t.Fatalf("results = %+v", results)
+	}
+	if a := results[0].Action; a == nil || a.NodeID != "run2-rowb" {
+		t.Fatalf("step 1 = %+v", a)
+	}
+	if a := results[1].Action; a == nil || a.NodeID != "run2-rowa" || a.Reason != "macro step 2/2" {
+		t.Fatalf("step 2 = %+v", a)
+	}
+	if results[2].Status != protocol.GoalCompleted {
+		t.Fatalf("final = %+v", results[2])
+	}
+
+	macro, err := store.Find(MacroKey("Turn on dark mode  in settings", "com.android.settings", screens[0].Fingerprint()))
+	if err != nil || macro == nil {
+		t.Fatalf("macro = %v, %v", macro, err)
+	}
+	if macro.Replays != 1 || len(macro.Steps) != 2 || macro.Steps[0].Target.Label != "Display" {
+		t.Fatalf("macro = %+v", macro)
+	}
+}
+
+func TestScreenSessions_FallsBackToPlannerWhenScreenDiverges(t *testing.T) {
+	store := &FileMacroStore{Path: filepath.Join(t.TempDir(), "macros.json")}
+	recordDarkMode(t, store)
+
+	// A system update renamed the toggle, so step 2 cannot be replayed.
+	provider := &scriptedProvider{replies: []string{
+		`{"action":"CLICK","node_id":"rowa","reason":"renamed toggle"}`,
+		`{"action":"DONE"}`,
+	}}
+	sessions, now := newTestSessions(NewScreenPlanner(provider))
+	sessions.Macros = store
+	start := settingsScreen(t, "Settings", "Network", "Display")
+	results := runGoal(t, sessions, now,
+		start,
+		settingsScreen(t, "Display", "Dark mode: off"),
+		settingsScreen(t, "Display", "Dark mode: on"),
+	)
+
+	if results[0].Action.Reason != "macro step 1/2" || results[1].Action.Reason != "renamed toggle" {
+		t.Fatalf("results = %+v", results)
+	}
+	if results[2].Status != protocol.GoalCompleted || len(provider.seen) != 2 {
+		t.Fatalf("final = %+v after %d model calls", results[2], len(provider.seen))
+	}
+	macro, _ := store.Find(MacroKey(darkModeGoal, start.App, start.Fingerprint()))
+	if macro == nil || macro.Replays != 0 || macro.Steps[1].Target.Label != "Dark mode: off" {
+		t.Fatalf("macro was not re-recorded: %+v", macro)
+	}
+}
+
+func TestScreenSessions_DropsMacroWhoseReplayFails(t *testing.T) {
+	store := &FileMacroStore{Path: filepath.Join(t.TempDir(), "macros.json")}
+	recordDarkMode(t, store)
+
+	sessions, now := newTestSessions(NewScreenPlanner(nil))
+	sessions.Macros = store
+	start := settingsScreen(t, "Settings", "Network", "Display")
+	id, _ := sessions.Start(darkModeGoal, ScreenLimits{MaxRepeats: 1})
+	step(t, sessions, now, id, start)
+	// The click did nothing; replay is still in control when the session
+	// gives up.
+	if r := step(t, sessions, now, id, start); r.Reason != ReasonNoProgress {
+		t.Fatalf("result = %+v", r)
+	}
+	if macro, _ := store.Find(MacroKey(darkModeGoal, start.App, start.Fingerprint())); macro != nil {
+		t.Fatalf("failed macro kept: %+v", macro)
+	}
+}
+
+// loginScreen shows an SMS with a one-time code and a field to enter it.
+func loginScreen(t *testing.T, fieldID, entered string) *screen.Screen {
+	t.Helper()
+	s := &screen.Screen{App: "com.bank", Activity: "Login", Root: &screen.Node{ID: "root", Role: screen.RoleGroup, Children: []*screen.Node{
+		{ID: "sms", Role: screen.RoleText, Text: "Your code is 482913"},
+		{ID: "field", Role: screen.RoleEdit, ResourceID: "com.bank:id/" + fieldID, Text: entered, Editable: true},
+		{ID: "go", Role: screen.RoleButton, Text: "Continue", Clickable: true},
+	}}}
+	if err := s.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestScreenSessions_DoesNotRecordTypedPersonalData(t *testing.T) {
+	for _, tc := range []struct {
+		name, field, typed string
+	}{
+		{"denied field", "otp_input", "482913"},
+		{"restored placeholder", "entry", "[OTP_1]"},
+		{"ordinary text", "entry", "hello"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			store := &FileMacroStore{Path: filepath.Join(t.TempDir(), "macros.json")}
+			planner := NewScreenPlanner(&scriptedProvider{replies: []string{
+				`{"action":"TYPE","node_id":"field","text":"` + tc.typed + `"}`,
+				`{"action":"DONE","reason":"signed in"}`,
+			}})
+			planner.Redactor = redact.New()
+			sessions, now := newTestSessions(planner)
+			sessions.Macros = store
+			start := loginScreen(t, tc.field, "")
+			id, _ := sessions.Start(darkModeGoal, ScreenLimits{})
+			if r := step(t, sessions, now, id, start); r.Action == nil || r.Action.Text == "[OTP_1]" {
+				t.Fatalf("type step = %+v", r.Action)
+			}
+			if r := step(t, sessions, now, id, loginScreen(t, tc.field, "typed")); r.Status != protocol.GoalCompleted {
+				t.Fatalf("result = %+v", r)
+			}
+
+			macro, err := store.Find(MacroKey(darkModeGoal, start.App, start.Fingerprint()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := tc.name == "ordinary text"; (macro != nil) != want {
+				t.Fatalf("macro = %+v, want recorded %v", macro, want)
+			}
+		})
+	}
+}
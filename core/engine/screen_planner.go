@@ -0,0 +1,239 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/redact"
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/screen"
+)
+
+const defaultScreenAttempts = 2
+
+// ScreenRequest is one planning step: the goal, the current screen and the
+// actions already taken toward the goal in this session, oldest first.
+// Diff, when set, tells the model what changed since it last planned.
+type ScreenRequest struct {
+	Goal    string
+	Screen  *screen.Screen
+	History []screen.Action
+	Diff    *screen.Diff
+}
+
+// ScreenRule is a deterministic fast path: it returns an action and true
+// when it can decide without the model.
+type ScreenRule func(req ScreenRequest) (screen.Action, bool)
+
+// DefaultScreenRules are tried in order before the model is consulted.
+var DefaultScreenRules = []ScreenRule{TapLabelRule, PlayButtonRule}
+
+var tapGoal = regexp.MustCompile(`(?i)^\s*(?:tap|click|press|open|select)\s+(?:on\s+)?(?:the\s+)?["']?(.+?)["']?(?:\s+button)?\s*$`)
+
+// TapLabelRule handles goals like "tap Settings" when exactly one enabled
+// node on screen carries that label. Once it has tapped, the goal is done.
+func TapLabelRule(req ScreenRequest) (screen.Action, bool) {
+	m := tapGoal.FindStringSubmatch(req.Goal)
+	if m == nil {
+		return screen.Action{}, false
+	}
+	switch {
+	case len(req.History) == 1 && req.History[0].Reason == tapLabelReason:
+		return screen.Action{Action: screen.ActionDone, Reason: tapLabelReason}, true
+	case len(req.History) > 0:
+		return screen.Action{}, false
+	}
+	s := req.Screen
+	var target *screen.Node
+	matches := 0
+	s.Walk(func(n *screen.Node, _ int) bool {
+		if strings.EqualFold(n.Label(), m[1]) {
+			if t := s.ClickTarget(n.ID); t != nil && t != target {
+				target = t
+				matches++
+			}
+		}
+		return matches < 2
+	})
+	if matches != 1 {
+		return screen.Action{}, false
+	}
+	return screen.Action{Action: screen.ActionClick, NodeID: target.ID, Reason: tapLabelReason}, true
+}
+
+const tapLabelReason = "rule:tap_label"
+
+// PlayButtonRule keeps the original behavior for goal-less input: press a
+// visible "Play" control.
+func PlayButtonRule(req ScreenRequest) (screen.Action, bool) {
+	if strings.TrimSpace(req.Goal) != "" {
+		return screen.Action{}, false
+	}
+	s := req.Screen
+	var target *screen.Node
+	s.Walk(func(n *screen.Node, _ int) bool {
+		if strings.EqualFold(n.Label(), "Play") {
+			target = s.ClickTarget(n.ID)
+		}
+		return target == nil
+	})
+	if target == nil {
+		return screen.Action{}, false
+	}
+	return screen.Action{Action: screen.ActionClick, NodeID: target.ID, Reason: "rule:play"}, true
+}
+
+// ScreenPlanner picks the next UI action for a goal on the current screen.
+// Rules run first; otherwise the model is asked, and a reply that fails
+// validation is sent back with the error for another attempt.
+type ScreenPlanner struct {
+	Provider LLMProvider
+	Model    string
+	Rules    []ScreenRule
+	// MaxAttempts bounds model calls per step; zero means two.
+	MaxAttempts int
+	LLMOptions  map[string]interface{}
+	// Redactor, when set, replaces personal data in the goal, history and
+	// screen before they reach the model, and restores it in the reply.
+	Redactor *redact.Redactor
+}
+
+// NewScreenPlanner uses the default rules with provider as the fallback;
+// provider may be nil for rules-only planning.
+func NewScreenPlanner(provider LLMProvider) *ScreenPlanner {
+	return &ScreenPlanner{Provider: provider, Rules: DefaultScreenRules}
+}
+
+// Next returns a validated action. Without a provider, a screen no rule
+// matches yields NOOP with reason "no_target".
+func (p *ScreenPlanner) Next(ctx context.Context, req ScreenRequest) (screen.Action, error) {
+	s := req.Screen
+	for _, rule := range p.Rules {
+		if action, ok := rule(req); ok {
+			if err := action.Normalize(s); err == nil {
+				return action, nil
+			}
+		}
+	}
+	if p.Provider == nil || strings.TrimSpace(req.Goal) == "" {
+		return screen.Noop("no_target"), nil
+	}
+
+	model := p.Model
+	if strings.TrimSpace(model) == "" {
+		model = p.Provider.GetDefaultModel()
+	}
+	options := p.LLMOptions
+	if options == nil {
+		options = map[string]interface{}{"temperature": 0.0, "max_tokens": 200}
+	}
+	attempts := p.MaxAttempts
+	if attempts <= 0 {
+		attempts = defaultScreenAttempts
+	}
+
+	system, prompt := screenSystemPrompt, req
+	if p.Redactor != nil {
+		system += " " + redact.Notice
+		prompt = redactScreenRequest(p.Redactor, req)
+	}
+	messages := []Message{
+		{Role: "system", Content: system},
+		{Role: "user", Content: screenUserPrompt(prompt)},
+	}
+	var lastErr error
+	for i := 0; i < attempts; i++ {
+		resp, err := p.Provider.Chat(ctx, messages, nil, model, options)
+		if errors.Is(err, ErrNoProvider) {
+			// An empty ProviderSlot plans with rules only.
+			return screen.Noop("no_target"), nil
+		}
+		if err != nil {
+			return screen.Action{}, fmt.Errorf("screen planner chat failed: %w", err)
+		}
+		action, err := parseScreenAction(resp.Content)
+		if err == nil && p.Redactor != nil {
+			action.Text = p.Redactor.Restore(action.Text)
+			action.Reason = p.Redactor.Restore(action.Reason)
+		}
+		if err == nil {
+			err = action.Normalize(s)
+		}
+		if err == nil {
+			return action, nil
+		}
+		lastErr = err
+		messages = append(messages,
+			Message{Role: "assistant", Content: resp.Content},
+			Message{Role: "user", Content: "That action is invalid: " + err.Error() + ". Reply with a corrected action."},
+		)
+	}
+	return screen.Action{}, fmt.Errorf("screen planner gave no valid action after %d attempts: %w", attempts, lastErr)
+}
+
+// redactScreenRequest copies req with personal data replaced. Node IDs are
+// kept, so the reply still resolves against the real screen.
+func redactScreenRequest(r *redact.Redactor, req ScreenRequest) ScreenRequest {
+	out := ScreenRequest{
+		Goal:   r.Text(req.Goal, "goal"),
+		Screen: r.Screen(req.Screen),
+	}
+	for _, a := range req.History {
+		a.Text = r.Text(a.Text, "history")
+		a.Reason = r.Text(a.Reason, "history")
+		out.History = append(out.History, a)
+	}
+	if req.Diff != nil {
+		d := r.Diff(*req.Diff, req.Screen.App)
+		out.Diff = &d
+	}
+	return out
+}
+
+const screenSystemPrompt = "You operate a phone or desktop UI for the user. Output JSON only with no markdown. " +
+	"Each line of the screen is [node_id] role \"label\" {flags}. Choose ONE next step toward the goal: " +
+	"{\"action\":\"CLICK\",\"node_id\":\"<id>\"}, " +
+	"{\"action\":\"TYPE\",\"node_id\":\"<editable id>\",\"text\":\"...\"}, " +
+	"{\"action\":\"SCROLL\",\"direction\":\"up|down|left|right\",\"node_id\":\"<optional scrollable id>\"}, " +
+	"{\"action\":\"BACK\"}, {\"action\":\"WAIT\",\"duration_ms\":1000}, " +
+	"{\"action\":\"DONE\",\"reason\":\"...\"} once the screen shows the goal is achieved, " +
+	"{\"action\":\"FAIL\",\"reason\":\"...\"} when the goal cannot be achieved from here, " +
+	"or {\"action\":\"NOOP\",\"reason\":\"...\"} when nothing sensible can be done. " +
+	"Only use node ids that appear on the screen. Add a short \"reason\"."
+
+func screenUserPrompt(req ScreenRequest) string {
+	var b strings.Builder
+	b.WriteString("Goal: " + req.Goal + "\n")
+	if len(req.History) > 0 {
+		b.WriteString("\nSteps taken so far:\n")
+		for i, a := range req.History {
+			fmt.Fprintf(&b, "%d. %s\n", i+1, a.JSON())
+		}
+	}
+	if req.Diff != nil {
+		if summary := req.Diff.Summary(); summary != "" {
+			b.WriteString("\nChanges since the last step:\n" + summary + "\n")
+		}
+	}
+	b.WriteString("\nScreen:\n" + req.Screen.Outline())
+	return b.String()
+}
+
+func parseScreenAction(raw string) (screen.Action, error) {
+	trimmed := strings.TrimSpace(raw)
+	trimmed = strings.TrimPrefix(trimmed, "```json")
+	trimmed = strings.TrimPrefix(trimmed, "```")
+	trimmed = strings.TrimSuffix(trimmed, "```")
+	trimmed = strings.TrimSpace(trimmed)
+
+	dec := json.NewDecoder(strings.NewReader(trimmed))
+	dec.DisallowUnknownFields()
+	var action screen.Action
+	if err := dec.Decode(&action); err != nil {
+		return screen.Action{}, fmt.Errorf("decode action: %w", err)
+	}
+	return action, nil
+}
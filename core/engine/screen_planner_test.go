@@ -0,0 +1,149 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/redact"
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/screen"
+)
+
+const playerScreenJSON = `{
+  "app": "com.example.music",
+  "root": {"id": "root", "children": [
+    {"id": "play", "role": "button", "description": "Play", "clickable": true},
+    {"id": "settings-row", "clickable": true, "children": [
+      {"id": "settings-label", "text": "Settings"}
+    ]},
+    {"id": "query", "role": "edit", "editable": true}
+  ]}
+}`
+
+func parseTestScreen(t *testing.T) *screen.Screen {
+	t.Helper()
+	s, err := screen.Parse([]byte(playerScreenJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestScreenPlanner_RulesSkipTheModel(t *testing.T) {
+	provider := &scriptedProvider{}
+	planner := NewScreenPlanner(provider)
+	s := parseTestScreen(t)
+
+	action, err := planner.Next(context.Background(), ScreenRequest{Goal: "tap settings", Screen: s})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if action.Action != screen.ActionClick || action.NodeID != "settings-row" {
+		t.Fatalf("tap rule = %+v", action)
+	}
+
+	action, err = planner.Next(context.Background(), ScreenRequest{Screen: s})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if action.Action != screen.ActionClick || action.NodeID != "play" {
+		t.Fatalf("play rule = %+v", action)
+	}
+	if len(provider.seen) != 0 {
+		t.Fatalf("model consulted %d times for rule-covered goals", len(provider.seen))
+	}
+}
+
+func TestScreenPlanner_RetriesInvalidModelActions(t *testing.T) {
+	provider := &scriptedProvider{replies: []string{
+		`{"action":"TYPE","node_id":"settings-label","text":"coldplay"}`,
+		"```json\n{\"action\":\"TYPE\",\"node_id\":\"query\",\"text\":\"coldplay\",\"reason\":\"search box\"}\n```",
+	}}
+	planner := NewScreenPlanner(provider)
+
+	action, err := planner.Next(context.Background(), ScreenRequest{Goal: "search for coldplay", Screen: parseTestScreen(t)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if action.Action != screen.ActionTypeText || action.NodeID != "query" || action.Text != "coldplay" {
+		t.Fatalf("action = %+v", action)
+	}
+	if len(provider.seen) != 2 {
+		t.Fatalf("model called %d times, want 2", len(provider.seen))
+	}
+	first := provider.seen[0][1].Content
+	if !strings.Contains(first, "Goal: search for coldplay") || !strings.Contains(first, "[query] edit {editable}") {
+		t.Fatalf("prompt lacks goal or outline:\n%s", first)
+	}
+	retry := provider.seen[1][len(provider.seen[1])-1].Content
+	if !strings.Contains(retry, "not editable") {
+		t.Fatalf("retry did not explain the error: %q", retry)
+	}
+}
+
+func TestScreenPlanner_GivesUpAfterMaxAttempts(t *testing.T) {
+	provider := &scriptedProvider{replies: []string{`{"action":"CLICK","node_id":"ghost"}`, `not json`}}
+	planner := NewScreenPlanner(provider)
+	if _, err := planner.Next(context.Background(), ScreenRequest{Goal: "open the ghost menu", Screen: parseTestScreen(t)}); err == nil {
+		t.Fatal("Next accepted invalid actions")
+	}
+}
+
+func TestScreenPlanner_RedactsPromptAndRestoresTypedText(t *testing.T) {
+	provider := &scriptedProvider{replies: []string{`{"action":"TYPE","node_id":"query","text":"[EMAIL_1]","reason":"share with [EMAIL_1]"}`}}
+	planner := NewScreenPlanner(provider)
+	planner.Redactor = redact.New()
+	s := parseTestScreen(t)
+	s.Find("settings-label").Text = "Signed in as anu@example.com"
+
+	action, err := planner.Next(context.Background(), ScreenRequest{
+		Goal:    "share the playlist with anu@example.com",
+		Screen:  s,
+		History: []screen.Action{{Action: screen.ActionTypeText, NodeID: "query", Text: "anu@example.com"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if action.Text != "anu@example.com" || action.Reason != "share with anu@example.com" {
+		t.Fatalf("action = %+v", action)
+	}
+	for _, m := range provider.seen[0] {
+		if strings.Contains(m.Content, "anu@example.com") {
+			t.Fatalf("%s prompt leaks the email:\n%s", m.Role, m.Content)
+		}
+	}
+	if prompt := provider.seen[0][1].Content; !strings.Contains(prompt, `"Signed in as [EMAIL_1]"`) {
+		t.Fatalf("prompt lacks the placeholder:\n%s", prompt)
+	}
+}
+
+func TestAgent_HandleScreenInput(t *testing.T) {
+	agent := &Agent{Screen: NewScreenPlanner(nil)}
+	cases := []struct {
+		name, input, want string
+	}{
+		{"empty", "  ", `{"action":"NOOP","reason":"empty_input"}`},
+		{"invalid json", "{", `{"action":"NOOP","reason":"invalid_json"}`},
+		{"legacy play", `{"nodes":[{"text":"Play"}]}`, `{"action":"CLICK","text":"Play"}`},
+		{"legacy miss", `{"nodes":[{"text":"Pause"}]}`, `{"action":"NOOP","reason":"no_target"}`},
+		{"invalid tree", `{"root":{"children":[{"id":"a"}]}}`, `{"action":"NOOP","reason":"invalid_screen"}`},
+		{"typed play", playerScreenJSON, `{"action":"CLICK","node_id":"play","reason":"rule:play"}`},
+		{"typed no model", `{"goal":"turn on dark mode",` + strings.TrimPrefix(playerScreenJSON, "{"), `{"action":"NOOP","reason":"no_target"}`},
+		{"versioned", `{"v":1,"kind":"screen_report",` + strings.TrimPrefix(playerScreenJSON, "{"), `{"action":"CLICK","node_id":"play","reason":"rule:play"}`},
+		{"future version", `{"v":2,"kind":"screen_report",` + strings.TrimPrefix(playerScreenJSON, "{"), `{"action":"NOOP","reason":"unsupported_version"}`},
+	}
+	for _, tc := range cases {
+		got := agent.HandleScreenInput(tc.input)
+		var gotV, wantV interface{}
+		if err := json.Unmarshal([]byte(got), &gotV); err != nil {
+			t.Fatalf("%s: reply %q is not JSON", tc.name, got)
+		}
+		_ = json.Unmarshal([]byte(`{"v":1,"kind":"screen_action",`+strings.TrimPrefix(tc.want, "{")), &wantV)
+		gotJSON, _ := json.Marshal(gotV)
+		wantJSON, _ := json.Marshal(wantV)
+		if string(gotJSON) != string(wantJSON) {
+			t.Errorf("%s: got %s, want %s", tc.name, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,366 @@
+package engine
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/protocol"
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/redact"
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/screen"
+)
+
+const (
+	defaultSessionSteps   = 25
+	defaultSessionTimeout = 2 * time.Minute
+	defaultSessionRepeats = 3
+)
+
+// ScreenLimits bounds one goal session. Zero fields take the defaults:
+// 25 steps, two minutes, and three visits to the same screen.
+type ScreenLimits struct {
+	MaxSteps int
+	Timeout  time.Duration
+	// MaxRepeats is how often one screen may be seen again before the
+	// session is judged stuck.
+	MaxRepeats int
+}
+
+func (l ScreenLimits) withDefaults() ScreenLimits {
+	if l.MaxSteps <= 0 {
+		l.MaxSteps = defaultSessionSteps
+	}
+	if l.Timeout <= 0 {
+		l.Timeout = defaultSessionTimeout
+	}
+	if l.MaxRepeats <= 0 {
+		l.MaxRepeats = defaultSessionRepeats
+	}
+	return l
+}
+
+// Reasons a session ends.
+const (
+	ReasonGoalReached    = "goal_reached"
+	ReasonGoalFailed     = "goal_failed"
+	ReasonMaxSteps       = "max_steps"
+	ReasonTimeout        = "timeout"
+	ReasonLoopDetected   = "loop_detected"
+	ReasonNoProgress     = "no_progress"
+	ReasonPlannerError   = "planner_error"
+	ReasonUnknownSession = "unknown_session"
+	ReasonInvalidScreen  = "invalid_screen"
+	// ReasonUnsupportedVersion answers reports of another protocol version.
+	ReasonUnsupportedVersion = "unsupported_version"
+)
+
+type screenSession struct {
+	mu        sync.Mutex
+	id        string
+	goal      string
+	limits    ScreenLimits
+	deadline  time.Time
+	history   []screen.Action
+	visits    map[string]int
+	lastFP    string
+	stability *ScreenStabilizer
+
+	// start and startApp key the macro this run is recorded as; recorded
+	// holds its steps. While replaying, macro drives the session instead of
+	// the planner.
+	start     string
+	startApp  string
+	recorded  []MacroStep
+	macro     *Macro
+	replaying bool
+	// private is set once the run types personal data, which is not
+	// recorded: a macro would store it on disk and type it again on replay.
+	private bool
+}
+
+// ScreenSessions tracks goal-driven screen automation: the host starts a
+// goal, then reports each screen and executes the returned action until the
+// session completes or fails. Finished sessions are forgotten.
+type ScreenSessions struct {
+	Planner *ScreenPlanner
+	// Limits apply to sessions started without their own.
+	Limits ScreenLimits
+	// StableFor and MaxSettling configure each session's ScreenStabilizer.
+	StableFor   time.Duration
+	MaxSettling time.Duration
+	// Macros, when set, records completed sessions and replays them when
+	// the same goal starts on the same screen, consulting the planner only
+	// once the screen diverges from the recording. Store errors are
+	// ignored; macros are an optimization.
+	Macros MacroStore
+
+	mu       sync.Mutex
+	sessions map[string]*screenSession
+	now      func() time.Time
+}
+
+// NewScreenSessions plans every session with planner.
+func NewScreenSessions(planner *ScreenPlanner) *ScreenSessions {
+	return &ScreenSessions{Planner: planner, sessions: map[string]*screenSession{}}
+}
+
+// Start opens a session for goal and returns its ID. Zero fields in limits
+// fall back to m.Limits, then to the defaults.
+func (m *ScreenSessions) Start(goal string, limits ScreenLimits) (string, error) {
+	goal = strings.TrimSpace(goal)
+	if goal == "" {
+		return "", errors.New("goal is required")
+	}
+	if limits.MaxSteps <= 0 {
+		limits.MaxSteps = m.Limits.MaxSteps
+	}
+	if limits.Timeout <= 0 {
+		limits.Timeout = m.Limits.Timeout
+	}
+	if limits.MaxRepeats <= 0 {
+		limits.MaxRepeats = m.Limits.MaxRepeats
+	}
+	limits = limits.withDefaults()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.sessions == nil {
+		m.sessions = map[string]*screenSession{}
+	}
+	now := m.clock()
+	// Hosts that stop reporting screens leave sessions behind.
+	for id, s := range m.sessions {
+		if now.After(s.deadline) {
+			delete(m.sessions, id)
+		}
+	}
+	s := &screenSession{
+		id:       newSessionID(),
+		goal:     goal,
+		limits:   limits,
+		deadline: now.Add(limits.Timeout),
+		visits:   map[string]int{},
+		stability: &ScreenStabilizer{
+			StableFor:   m.StableFor,
+			MaxSettling: m.MaxSettling,
+			now:         m.now,
+		},
+	}
+	m.sessions[s.id] = s
+	return s.id, nil
+}
+
+// Step plans the next action for the screen the host now shows.
+func (m *ScreenSessions) Step(ctx context.Context, id string, current *screen.Screen) protocol.GoalStep {
+	m.mu.Lock()
+	s := m.sessions[id]
+	m.mu.Unlock()
+	if s == nil {
+		return protocol.GoalStep{SessionID: id, Status: protocol.GoalFailed, Reason: ReasonUnknownSession}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result := s.step(ctx, m.clock(), m.planner(), m.Macros, current)
+	switch result.Status {
+	case protocol.GoalCompleted:
+		s.saveMacro(m.Macros, m.clock())
+	case protocol.GoalFailed:
+		s.dropMacro(m.Macros)
+	}
+	if result.Status != protocol.GoalRunning {
+		m.forget(id)
+	}
+	return result
+}
+
+// Cancel ends a session early; it reports whether the session existed.
+func (m *ScreenSessions) Cancel(id string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, ok := m.sessions[id]
+	delete(m.sessions, id)
+	return ok
+}
+
+func (s *screenSession) step(ctx context.Context, now time.Time, planner *ScreenPlanner, macros MacroStore, current *screen.Screen) protocol.GoalStep {
+	result := protocol.GoalStep{SessionID: s.id, Step: len(s.history)}
+	fail := func(reason, message string) protocol.GoalStep {
+		result.Status, result.Reason, result.Message = protocol.GoalFailed, reason, message
+		return result
+	}
+
+	if !now.Before(s.deadline) {
+		return fail(ReasonTimeout, fmt.Sprintf("goal not reached within %s", s.limits.Timeout))
+	}
+	if len(s.history) >= s.limits.MaxSteps {
+		return fail(ReasonMaxSteps, fmt.Sprintf("goal not reached in %d steps", s.limits.MaxSteps))
+	}
+
+	// Reports of a screen that is still animating are answered with WAIT
+	// and count for nothing; the host reports again once it has waited.
+	obs := s.stability.Observe(current)
+	if !obs.Ready {
+		wait := screen.Action{Action: screen.ActionWait, DurationMs: waitMillis(obs.Wait), Reason: obs.Reason}
+		result.Status, result.Action = protocol.GoalRunning, &wait
+		return result
+	}
+
+	// A screen seen again after anything but WAIT means the last actions
+	// led nowhere: unchanged is a dead end, revisited is a loop.
+	fp := obs.Diff.Current
+	waited := len(s.history) > 0 && s.history[len(s.history)-1].Action == screen.ActionWait
+	if !waited {
+		s.visits[fp]++
+	}
+	if s.visits[fp] > s.limits.MaxRepeats {
+		if fp == s.lastFP {
+			return fail(ReasonNoProgress, "the screen stopped changing")
+		}
+		return fail(ReasonLoopDetected, "the same screen keeps coming back")
+	}
+	s.lastFP = fp
+
+	if len(s.history) == 0 {
+		s.start, s.startApp = fp, current.App
+		if macros != nil {
+			if macro, err := macros.Find(MacroKey(s.goal, current.App, fp)); err == nil && macro != nil {
+				s.macro, s.replaying = macro, true
+			}
+		}
+	}
+
+	var (
+		action screen.Action
+		err    error
+	)
+	replayed := false
+	if s.replaying {
+		if action, replayed = s.replayNext(current, fp); !replayed {
+			s.replaying = false
+		}
+	}
+	if !replayed {
+		planCtx, cancel := context.WithDeadline(ctx, s.deadline)
+		defer cancel()
+		action, err = planner.Next(planCtx, ScreenRequest{Goal: s.goal, Screen: current, History: s.history, Diff: &obs.Diff})
+		if err != nil && planCtx.Err() == context.DeadlineExceeded {
+			return fail(ReasonTimeout, fmt.Sprintf("goal not reached within %s", s.limits.Timeout))
+		}
+	}
+	switch {
+	case err != nil:
+		return fail(ReasonPlannerError, err.Error())
+	case action.Action == screen.ActionDone:
+		result.Status, result.Reason, result.Message = protocol.GoalCompleted, ReasonGoalReached, action.Reason
+		return result
+	case action.Action == screen.ActionFail:
+		return fail(ReasonGoalFailed, action.Reason)
+	}
+
+	s.history = append(s.history, action)
+	s.recorded = append(s.recorded, recordStep(current, fp, action))
+	if typesPersonalData(planner.Redactor, current, action) {
+		s.private = true
+	}
+	result.Status, result.Step, result.Action = protocol.GoalRunning, len(s.history), &action
+	return result
+}
+
+// replayNext binds the macro's next step to the current screen. Past the
+// last step, reaching the recorded final screen completes the goal.
+func (s *screenSession) replayNext(current *screen.Screen, fp string) (screen.Action, bool) {
+	next := len(s.history)
+	if next < len(s.macro.Steps) {
+		action, ok := s.macro.Steps[next].replay(current, fp)
+		if ok {
+			action.Reason = fmt.Sprintf("macro step %d/%d", next+1, len(s.macro.Steps))
+		}
+		return action, ok
+	}
+	if fp == s.macro.Final {
+		return screen.Action{Action: screen.ActionDone, Reason: "macro replayed"}, true
+	}
+	return screen.Action{}, false
+}
+
+// saveMacro records a completed run, or counts one more replay when the
+// macro ran start to finish.
+func (s *screenSession) saveMacro(macros MacroStore, now time.Time) {
+	if macros == nil || len(s.recorded) == 0 || s.private {
+		return
+	}
+	if s.replaying {
+		s.macro.Replays++
+		_ = macros.Save(s.macro)
+		return
+	}
+	_ = macros.Save(&Macro{
+		Goal:       s.goal,
+		App:        s.startApp,
+		Start:      s.start,
+		Steps:      s.recorded,
+		Final:      s.lastFP,
+		RecordedAt: now,
+	})
+}
+
+// typesPersonalData reports whether action types text r would redact.
+// Without a Redactor the default detectors and field denylist decide.
+func typesPersonalData(r *redact.Redactor, s *screen.Screen, action screen.Action) bool {
+	if action.Action != screen.ActionTypeText {
+		return false
+	}
+	if r == nil {
+		r = redact.New()
+	}
+	var resourceID string
+	if n := s.Find(action.NodeID); n != nil {
+		resourceID = n.ResourceID
+	}
+	return r.Sensitive(s.App, resourceID, action.Text)
+}
+
+// dropMacro forgets a macro whose replay led the session to fail.
+func (s *screenSession) dropMacro(macros MacroStore) {
+	if macros != nil && s.replaying {
+		_ = macros.Delete(s.macro.Key())
+	}
+}
+
+func (m *ScreenSessions) active(id string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.sessions[id] != nil
+}
+
+func (m *ScreenSessions) forget(id string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.sessions, id)
+}
+
+func (m *ScreenSessions) planner() *ScreenPlanner {
+	if m.Planner != nil {
+		return m.Planner
+	}
+	return NewScreenPlanner(nil)
+}
+
+func (m *ScreenSessions) clock() time.Time {
+	if m.now != nil {
+		return m.now()
+	}
+	return time.Now()
+}
+
+func newSessionID() string {
+	var b [8]byte
+	_, _ = rand.Read(b[:])
+	return "goal-" + hex.EncodeToString(b[:])
+}
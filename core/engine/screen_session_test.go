@@ -0,0 +1,208 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/protocol"
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/screen"
+)
+
+// settingsScreen builds a screen of clickable rows with the given labels.
+func settingsScreen(t *testing.T, activity string, labels ...string) *screen.Screen {
+	t.Helper()
+	root := &screen.Node{ID: "root", Role: screen.RoleList, Scrollable: true}
+	for i, label := range labels {
+		root.Children = append(root.Children, &screen.Node{
+			ID: "row" + string(rune('a'+i)), Role: screen.RoleButton, Text: label, Clickable: true,
+		})
+	}
+	s := &screen.Screen{App: "com.android.settings", Activity: activity, Root: root}
+	if err := s.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// newTestSessions runs sessions on a fake clock that step advances past
+// the settling period, so each screen is planned on its first report.
+func newTestSessions(planner *ScreenPlanner) (*ScreenSessions, *time.Time) {
+	now := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+	sessions := NewScreenSessions(planner)
+	sessions.now = func() time.Time { return now }
+	return sessions, &now
+}
+
+// step reports s and, if the session asks to let it settle, waits on the
+// fake clock and reports it again.
+func step(t *testing.T, sessions *ScreenSessions, now *time.Time, id string, s *screen.Screen) protocol.GoalStep {
+	t.Helper()
+	r := sessions.Step(context.Background(), id, s)
+	if r.Action != nil && r.Action.Reason == ReasonSettling {
+		*now = now.Add(time.Duration(r.Action.DurationMs) * time.Millisecond)
+		r = sessions.Step(context.Background(), id, s)
+	}
+	return r
+}
+
+// clickFirstRow is a rules-only planner that always taps the first row.
+func clickFirstRow() *ScreenPlanner {
+	return &ScreenPlanner{Rules: []ScreenRule{func(ScreenRequest) (screen.Action, bool) {
+		return screen.Action{Action: screen.ActionClick, NodeID: "rowa"}, true
+	}}}
+}
+
+func TestScreenSessions_CompletesMultiStepGoal(t *testing.T) {
+	provider := &scriptedProvider{replies: []string{
+		`{"action":"CLICK","node_id":"rowb","reason":"open display"}`,
+		`{"action":"CLICK","node_id":"rowa","reason":"toggle dark theme"}`,
+		`{"action":"DONE","reason":"dark theme is on"}`,
+	}}
+	sessions, now := newTestSessions(NewScreenPlanner(provider))
+	id, err := sessions.Start("turn on dark mode in Settings", ScreenLimits{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	screens := []*screen.Screen{
+		settingsScreen(t, "Settings", "Network", "Display"),
+		settingsScreen(t, "Display", "Dark theme: off"),
+		settingsScreen(t, "Display", "Dark theme: on"),
+	}
+	var got []protocol.GoalStep
+	for _, s := range screens {
+		got = append(got, step(t, sessions, now, id, s))
+	}
+
+	if got[0].Status != protocol.GoalRunning || got[0].Step != 1 || got[0].Action.NodeID != "rowb" {
+		t.Fatalf("step 1 = %+v", got[0])
+	}
+	if got[1].Status != protocol.GoalRunning || got[1].Step != 2 || got[1].Action.NodeID != "rowa" {
+		t.Fatalf("step 2 = %+v", got[1])
+	}
+	if got[2].Status != protocol.GoalCompleted || got[2].Reason != ReasonGoalReached || got[2].Message != "dark theme is on" {
+		t.Fatalf("step 3 = %+v", got[2])
+	}
+	last := provider.seen[2][1].Content
+	if !strings.Contains(last, "Steps taken so far:") || !strings.Contains(last, "open display") {
+		t.Fatalf("planner did not see the history:\n%s", last)
+	}
+	if !strings.Contains(last, `~ [rowa] button "Dark theme: on": text`) {
+		t.Fatalf("planner did not see what changed:\n%s", last)
+	}
+	if sessions.Cancel(id) {
+		t.Fatal("completed session was kept")
+	}
+}
+
+func TestScreenSessions_DetectsStuckSessions(t *testing.T) {
+	a := settingsScreen(t, "Settings", "Network")
+	b := settingsScreen(t, "Network", "Wi-Fi")
+
+	cases := []struct {
+		name    string
+		screens []*screen.Screen
+		reason  string
+	}{
+		{"unchanged screen", []*screen.Screen{a, a, a}, ReasonNoProgress},
+		{"bouncing screens", []*screen.Screen{a, b, a, b, a}, ReasonLoopDetected},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			sessions, now := newTestSessions(clickFirstRow())
+			id, _ := sessions.Start("find the hidden setting", ScreenLimits{MaxRepeats: 2})
+			var result protocol.GoalStep
+			for i, s := range tc.screens {
+				result = step(t, sessions, now, id, s)
+				if i < len(tc.screens)-1 && result.Status != protocol.GoalRunning {
+					t.Fatalf("step %d ended early: %+v", i+1, result)
+				}
+			}
+			if result.Status != protocol.GoalFailed || result.Reason != tc.reason {
+				t.Fatalf("result = %+v, want failed with %s", result, tc.reason)
+			}
+		})
+	}
+}
+
+func TestScreenSessions_WaitingDoesNotCountAsRepeat(t *testing.T) {
+	waitRule := func(ScreenRequest) (screen.Action, bool) {
+		return screen.Action{Action: screen.ActionWait}, true
+	}
+	sessions, now := newTestSessions(&ScreenPlanner{Rules: []ScreenRule{waitRule}})
+	id, _ := sessions.Start("wait for the download", ScreenLimits{MaxRepeats: 1, MaxSteps: 4})
+	loading := settingsScreen(t, "Loading")
+	for i := 0; i < 4; i++ {
+		if r := step(t, sessions, now, id, loading); r.Status != protocol.GoalRunning {
+			t.Fatalf("step %d = %+v", i+1, r)
+		}
+	}
+	if r := step(t, sessions, now, id, loading); r.Reason != ReasonMaxSteps {
+		t.Fatalf("result = %+v, want %s", r, ReasonMaxSteps)
+	}
+}
+
+func TestScreenSessions_EnforcesTimeout(t *testing.T) {
+	sessions, now := newTestSessions(clickFirstRow())
+	id, _ := sessions.Start("open network", ScreenLimits{Timeout: time.Minute})
+
+	if r := step(t, sessions, now, id, settingsScreen(t, "Settings", "Network")); r.Status != protocol.GoalRunning {
+		t.Fatalf("first step = %+v", r)
+	}
+	*now = now.Add(time.Minute)
+	if r := step(t, sessions, now, id, settingsScreen(t, "Network", "Wi-Fi")); r.Reason != ReasonTimeout {
+		t.Fatalf("late step = %+v", r)
+	}
+	if r := step(t, sessions, now, id, settingsScreen(t, "Network", "Wi-Fi")); r.Reason != ReasonUnknownSession {
+		t.Fatalf("timed-out session was kept: %+v", r)
+	}
+}
+
+func TestAgent_ScreenGoalJSON(t *testing.T) {
+	sessions, now := newTestSessions(NewScreenPlanner(nil))
+	agent := &Agent{Sessions: sessions}
+	if _, err := agent.StartScreenGoal("  ", ScreenLimits{}); err == nil {
+		t.Fatal("empty goal accepted")
+	}
+	id, err := agent.StartScreenGoal("tap Display", ScreenLimits{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decode := func(raw string) protocol.GoalStep {
+		t.Helper()
+		var r protocol.GoalStep
+		if err := json.Unmarshal([]byte(raw), &r); err != nil {
+			t.Fatalf("reply %q: %v", raw, err)
+		}
+		return r
+	}
+	if r := decode(agent.StepScreenGoal(id, "{")); r.Status != protocol.GoalRunning || r.Reason != ReasonInvalidScreen {
+		t.Fatalf("invalid screen = %+v", r)
+	}
+	report := func(s *screen.Screen) protocol.GoalStep {
+		t.Helper()
+		raw, _ := json.Marshal(s)
+		r := decode(agent.StepScreenGoal(id, string(raw)))
+		if r.Action != nil && r.Action.Action == screen.ActionWait {
+			*now = now.Add(time.Duration(r.Action.DurationMs) * time.Millisecond)
+			r = decode(agent.StepScreenGoal(id, string(raw)))
+		}
+		return r
+	}
+	if r := report(settingsScreen(t, "Settings", "Network", "Display")); r.Action == nil || r.Action.NodeID != "rowb" {
+		t.Fatalf("tap step = %+v", r)
+	}
+	if r := report(settingsScreen(t, "Display", "Dark theme")); r.Status != protocol.GoalCompleted {
+		t.Fatalf("after tap = %+v", r)
+	}
+	if r := decode(agent.StepScreenGoal("goal-missing", "{")); r.Reason != ReasonUnknownSession {
+		t.Fatalf("unknown session = %+v", r)
+	}
+	if agent.CancelScreenGoal(id) {
+		t.Fatal("finished session still cancellable")
+	}
+}
@@ -0,0 +1,110 @@
+package engine
+
+import (
+	"sync"
+	"time"
+
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/screen"
+)
+
+const (
+	defaultStableFor   = 300 * time.Millisecond
+	defaultMaxSettling = 2 * time.Second
+)
+
+// Reasons a screen report is not planned on.
+const (
+	ReasonSettling  = "settling"
+	ReasonUnchanged = "unchanged"
+)
+
+// ScreenObservation is the stabilizer's verdict on one screen report.
+type ScreenObservation struct {
+	// Ready is true when the screen should be planned on now.
+	Ready bool
+	// Diff compares the screen with the last one planned on.
+	Diff screen.Diff
+	// Wait, when not ready because the screen is still changing, is how
+	// long it must stay put before it counts as settled.
+	Wait   time.Duration
+	Reason string
+}
+
+// ScreenStabilizer debounces the burst of accessibility events an animation
+// produces: a screen is planned on once it has gone StableFor without any
+// change, or once it has been changing for MaxSettling, so endless
+// animations such as spinners cannot stall planning forever.
+type ScreenStabilizer struct {
+	// StableFor is the quiet period before planning; zero means 300ms.
+	StableFor time.Duration
+	// MaxSettling caps how long a changing screen is waited on; zero means
+	// two seconds.
+	MaxSettling time.Duration
+	// RequireChange skips screens whose content matches the last planned
+	// one, so repeated events for an unchanged screen are not re-planned.
+	RequireChange bool
+
+	mu          sync.Mutex
+	last        *screen.Screen
+	planned     *screen.Screen
+	changedAt   time.Time
+	settlingFor time.Time
+	now         func() time.Time
+}
+
+// Observe records a screen report and decides whether to plan on it. A
+// ready screen becomes the baseline for the next diff.
+func (st *ScreenStabilizer) Observe(s *screen.Screen) ScreenObservation {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	now := st.clock()
+	if st.last == nil || !screen.Compare(st.last, s).Empty() {
+		st.changedAt = now
+		if st.settlingFor.IsZero() {
+			st.settlingFor = now
+		}
+	}
+	st.last = s
+
+	diff := screen.Compare(st.planned, s)
+	if st.RequireChange && !diff.Meaningful() {
+		st.settlingFor = time.Time{}
+		return ScreenObservation{Diff: diff, Reason: ReasonUnchanged}
+	}
+
+	quiet := now.Sub(st.changedAt)
+	if quiet < st.stableFor() && now.Sub(st.settlingFor) < st.maxSettling() {
+		return ScreenObservation{Diff: diff, Wait: st.stableFor() - quiet, Reason: ReasonSettling}
+	}
+	st.planned = s
+	st.settlingFor = time.Time{}
+	return ScreenObservation{Ready: true, Diff: diff}
+}
+
+// waitMillis rounds a settling wait up to whole milliseconds for a WAIT
+// envelope.
+func waitMillis(d time.Duration) int {
+	return int((d + time.Millisecond - 1) / time.Millisecond)
+}
+
+func (st *ScreenStabilizer) stableFor() time.Duration {
+	if st.StableFor > 0 {
+		return st.StableFor
+	}
+	return defaultStableFor
+}
+
+func (st *ScreenStabilizer) maxSettling() time.Duration {
+	if st.MaxSettling > 0 {
+		return st.MaxSettling
+	}
+	return defaultMaxSettling
+}
+
+func (st *ScreenStabilizer) clock() time.Time {
+	if st.now != nil {
+		return st.now()
+	}
+	return time.Now()
+}
@@ -0,0 +1,93 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/screen"
+)
+
+func TestScreenStabilizer_WaitsForAnimationsToSettle(t *testing.T) {
+	now := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+	st := &ScreenStabilizer{StableFor: 300 * time.Millisecond, now: func() time.Time { return now }}
+
+	sliding := settingsScreen(t, "Settings", "Network")
+	obs := st.Observe(sliding)
+	if obs.Ready || obs.Reason != ReasonSettling || obs.Wait != 300*time.Millisecond {
+		t.Fatalf("first report = %+v", obs)
+	}
+
+	// The row slides in: only bounds change, which still resets the timer.
+	now = now.Add(100 * time.Millisecond)
+	moved := settingsScreen(t, "Settings", "Network")
+	moved.Find("rowa").Bounds = screen.Bounds{Top: 40, Right: 1080, Bottom: 120}
+	if obs := st.Observe(moved); obs.Ready || obs.Wait != 300*time.Millisecond {
+		t.Fatalf("moving screen = %+v", obs)
+	}
+
+	now = now.Add(300 * time.Millisecond)
+	if obs := st.Observe(moved); !obs.Ready || !obs.Diff.Initial() {
+		t.Fatalf("settled screen = %+v", obs)
+	}
+
+	// The next report diffs against the planned screen.
+	now = now.Add(time.Second)
+	if obs := st.Observe(moved); !obs.Ready || !obs.Diff.Empty() {
+		t.Fatalf("repeat report = %+v", obs)
+	}
+}
+
+func TestScreenStabilizer_GivesUpOnEndlessAnimation(t *testing.T) {
+	now := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+	st := &ScreenStabilizer{now: func() time.Time { return now }}
+	spinner := settingsScreen(t, "Loading", "Loading")
+	for i := 0; ; i++ {
+		spinner.Find("rowa").Bounds.Top = i
+		if obs := st.Observe(spinner); obs.Ready {
+			break
+		}
+		if elapsed := time.Duration(i) * 100 * time.Millisecond; elapsed > defaultMaxSettling {
+			t.Fatalf("still settling after %s", elapsed)
+		}
+		now = now.Add(100 * time.Millisecond)
+	}
+}
+
+func TestScreenStabilizer_SkipsUnchangedContentWhenRequired(t *testing.T) {
+	now := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+	st := &ScreenStabilizer{RequireChange: true, now: func() time.Time { return now }}
+	s := settingsScreen(t, "Settings", "Network")
+	st.Observe(s)
+	now = now.Add(time.Second)
+	if obs := st.Observe(s); !obs.Ready {
+		t.Fatalf("settled screen = %+v", obs)
+	}
+
+	now = now.Add(time.Second)
+	scrolled := settingsScreen(t, "Settings", "Network")
+	scrolled.Find("rowa").Bounds.Top = 200
+	if obs := st.Observe(scrolled); obs.Ready || obs.Reason != ReasonUnchanged {
+		t.Fatalf("layout-only change = %+v", obs)
+	}
+}
+
+func TestAgent_HandleScreenInputDebouncesReports(t *testing.T) {
+	now := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+	agent := &Agent{
+		Screen:    NewScreenPlanner(nil),
+		Stability: &ScreenStabilizer{RequireChange: true, now: func() time.Time { return now }},
+	}
+
+	if got := agent.HandleScreenInput(playerScreenJSON); got != `{"v":1,"kind":"screen_action","action":"WAIT","duration_ms":300,"reason":"settling"}` {
+		t.Fatalf("first report = %s", got)
+	}
+	now = now.Add(300 * time.Millisecond)
+	if got := agent.HandleScreenInput(playerScreenJSON); !strings.Contains(got, `"node_id":"play"`) {
+		t.Fatalf("settled report = %s", got)
+	}
+	now = now.Add(time.Second)
+	if got := agent.HandleScreenInput(playerScreenJSON); got != `{"v":1,"kind":"screen_action","action":"NOOP","reason":"unchanged"}` {
+		t.Fatalf("repeat report = %s", got)
+	}
+}
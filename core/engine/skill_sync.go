@@ -0,0 +1,168 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/memory"
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/skills"
+)
+
+// SkillSyncEnv sets Agent.SyncInterval as a duration such as "15m"; unset
+// or zero disables background resync.
+const SkillSyncEnv = "UPCRAFT_SKILL_SYNC_INTERVAL"
+
+// SkillSet is what the planner may use: synced skill descriptions
+// reconciled with the actions implemented locally.
+type SkillSet struct {
+	// Exposed lists skills that are both described and implemented, with
+	// the synced description and the locally registered actions.
+	Exposed []skills.SkillDefinition
+	// Missing names synced skills without a local implementation.
+	Missing []string
+	// Unlisted names local skills the sync did not describe.
+	Unlisted []string
+
+	// registry holds only the exposed actions, so the planner cannot call
+	// a skill it was not shown.
+	registry *Registry
+}
+
+// ReconcileSkills matches synced skills to the actions in registry by
+// skill name, ignoring case.
+func ReconcileSkills(remote []memory.RemoteSkill, registry *Registry) SkillSet {
+	described := map[string]memory.RemoteSkill{}
+	for _, r := range remote {
+		if key := skillKey(r.Name); key != "" {
+			described[key] = r
+		}
+	}
+
+	set := SkillSet{registry: NewRegistry()}
+	implemented := map[string]bool{}
+	for _, def := range registry.SkillDefinitions() {
+		implemented[skillKey(def.Name)] = true
+		r, ok := described[skillKey(def.Name)]
+		if !ok {
+			set.Unlisted = append(set.Unlisted, def.Name)
+			continue
+		}
+		if d := strings.TrimSpace(r.Description); d != "" {
+			def.Description = d
+		}
+		set.Exposed = append(set.Exposed, def)
+	}
+	for _, a := range registry.Actions() {
+		if _, ok := described[skillKey(a.Skill)]; ok {
+			// Keys come from a registry, so they are already unique.
+			_ = set.registry.Register(a)
+		}
+	}
+	for _, r := range remote {
+		if key := skillKey(r.Name); key != "" && !implemented[key] {
+			set.Missing = append(set.Missing, r.Name)
+		}
+	}
+	sort.Strings(set.Missing)
+	return set
+}
+
+func skillKey(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// SyncSkills brings the skill catalog up to date. On failure the last
+// good catalog, possibly from an earlier run, stays in effect.
+func (a *Agent) SyncSkills(ctx context.Context) error {
+	if a.RAG == nil {
+		return errors.New("no skill backend configured")
+	}
+	return a.RAG.Sync(ctx)
+}
+
+// SyncStatus reports the latest skill sync: when the catalog was last
+// confirmed by the backend and the error of the latest attempt.
+func (a *Agent) SyncStatus() memory.SyncStatus {
+	if a.RAG == nil {
+		return memory.SyncStatus{}
+	}
+	return a.RAG.Status()
+}
+
+// Skills reconciles the catalog, plus skills added with AddSkill, with the
+// registry as it is now, so plugins started or stopped since are accounted
+// for. Without any catalog, synced or cached, every registered skill is
+// exposed.
+func (a *Agent) Skills() SkillSet {
+	var catalog *memory.SkillCatalog
+	if a.RAG != nil {
+		catalog = a.RAG.Catalog()
+	}
+	var remote []memory.RemoteSkill
+	if catalog == nil {
+		// Describe every local skill so that none is left unlisted.
+		for _, def := range a.Registry.SkillDefinitions() {
+			remote = append(remote, memory.RemoteSkill{Name: def.Name})
+		}
+	} else {
+		remote = append(remote, catalog.Skills...)
+	}
+	// Host descriptions come last so they win over the catalog's.
+	a.mu.Lock()
+	for _, s := range a.hostSkills {
+		remote = append(remote, s)
+	}
+	a.mu.Unlock()
+	return ReconcileSkills(remote, a.Registry)
+}
+
+// startResync syncs every SyncInterval until ctx ends or Stop is called.
+func (a *Agent) startResync(ctx context.Context) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.stopSync != nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	a.stopSync, a.syncDone = cancel, done
+
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(a.SyncInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				// Failures are kept for SyncStatus; the last good sync stays.
+				_ = a.SyncSkills(ctx)
+			}
+		}
+	}()
+}
+
+func (a *Agent) stopResync() {
+	a.mu.Lock()
+	cancel, done := a.stopSync, a.syncDone
+	a.stopSync, a.syncDone = nil, nil
+	a.mu.Unlock()
+	if cancel != nil {
+		cancel()
+		<-done
+	}
+}
+
+// syncIntervalFromEnv reads SkillSyncEnv; a malformed value disables resync.
+func syncIntervalFromEnv() time.Duration {
+	d, err := time.ParseDuration(strings.TrimSpace(os.Getenv(SkillSyncEnv)))
+	if err != nil || d < 0 {
+		return 0
+	}
+	return d
+}
@@ -0,0 +1,192 @@
+package engine
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/memory"
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/protocol"
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/skills"
+)
+
+// notesPlugin implements a skill the cloud does not describe.
+type notesPlugin struct{ added *atomic.Int32 }
+
+func (p *notesPlugin) BindActions(registry ActionRegistrar) error {
+	return registry.Register(RegisteredAction{
+		Skill:  "Notes",
+		Action: "Add",
+		Handler: func(context.Context, map[string]interface{}) *ActionResult {
+			p.added.Add(1)
+			return SuccessResult("added", "Added")
+		},
+	})
+}
+
+// skillBackend serves body on /sync-skills, or a 500 when body is empty.
+func skillBackend(t *testing.T, body *atomic.Value) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		raw, _ := body.Load().(string)
+		if raw == "" {
+			http.Error(w, "down", http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte(raw))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newSyncedAgent(t *testing.T, body *atomic.Value, provider LLMProvider, notes *atomic.Int32) *Agent {
+	t.Helper()
+	a := &Agent{
+		RAG:      memory.NewRAGClient(skillBackend(t, body).URL),
+		Provider: provider,
+		Registry: NewRegistry(),
+		Plugins:  NewPluginManager(),
+	}
+	var events []string
+	mustRegister(t, a.Plugins, "music", &fakeMusic{lifecyclePlugin{name: "music", events: &events}})
+	mustRegister(t, a.Plugins, "notes", &notesPlugin{added: notes})
+	t.Cleanup(func() { a.Stop(context.Background()) })
+	return a
+}
+
+func TestReconcileSkills_ExposesDescribedAndImplemented(t *testing.T) {
+	registry := NewRegistry()
+	if err := RegisterMusicPlayer(registry, &fakeMusic{}); err != nil {
+		t.Fatal(err)
+	}
+	var added atomic.Int32
+	if err := (&notesPlugin{added: &added}).BindActions(registry); err != nil {
+		t.Fatal(err)
+	}
+
+	set := ReconcileSkills([]memory.RemoteSkill{
+		{Name: "musicplayer", Description: "Control music playback"},
+		{Name: "Weather", Description: "Forecasts"},
+	}, registry)
+
+	if len(set.Exposed) != 1 || set.Exposed[0].Name != "MusicPlayer" || set.Exposed[0].Description != "Control music playback" {
+		t.Fatalf("exposed = %+v", set.Exposed)
+	}
+	if len(set.Exposed[0].Actions) == 0 {
+		t.Fatal("exposed skill lost its local actions")
+	}
+	if !reflect.DeepEqual(set.Missing, []string{"Weather"}) || !reflect.DeepEqual(set.Unlisted, []string{"Notes"}) {
+		t.Fatalf("missing = %v, unlisted = %v", set.Missing, set.Unlisted)
+	}
+	if res := set.registry.Execute(context.Background(), "Notes", "Add", nil); !res.IsError || added.Load() != 0 {
+		t.Fatalf("unlisted skill executed: %+v", res)
+	}
+}
+
+func TestAgent_RunUsesOnlySyncedSkills(t *testing.T) {
+	var body atomic.Value
+	body.Store(`[{"name":"MusicPlayer","description":"Control music playback"}]`)
+	provider := &scriptedProvider{replies: []string{
+		`{"tool":"Notes","action":"Add"}`,
+		`{"response":"Could not add a note","done":true}`,
+	}}
+	var added atomic.Int32
+	a := newSyncedAgent(t, &body, provider, &added)
+
+	a.Start(context.Background())
+	if _, err := a.Run(context.Background(), "note that I like Yellow", LoopHooks{}); err != nil {
+		t.Fatal(err)
+	}
+	system := provider.seen[0][0].Content
+	if !strings.Contains(system, "Control music playback") || strings.Contains(system, "Notes") {
+		t.Fatalf("system prompt exposes the wrong skills: %s", system)
+	}
+	if added.Load() != 0 {
+		t.Fatal("the planner ran a skill it was not shown")
+	}
+}
+
+func TestAgent_ResyncsInBackgroundAndKeepsLastGoodSync(t *testing.T) {
+	var body atomic.Value
+	var added atomic.Int32
+	a := newSyncedAgent(t, &body, nil, &added)
+	a.SyncInterval = 5 * time.Millisecond
+
+	// Offline at start: local skills are used as they are.
+	a.Start(context.Background())
+	if a.SyncStatus().Error == "" {
+		t.Fatal("sync against a failing backend succeeded")
+	}
+	if n := len(a.Skills().Exposed); n != 2 {
+		t.Fatalf("offline exposes %d skills, want 2", n)
+	}
+
+	body.Store(`[{"name":"Notes","description":"Jot things down"}]`)
+	waitFor(t, func() bool {
+		exposed := a.Skills().Exposed
+		return len(exposed) == 1 && exposed[0].Name == "Notes"
+	})
+
+	body.Store("")
+	waitFor(t, func() bool { return a.SyncStatus().Error != "" })
+	if exposed := a.Skills().Exposed; len(exposed) != 1 || exposed[0].Description != "Jot things down" {
+		t.Fatalf("failed resync dropped the last sync: %+v", exposed)
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(2 * time.Millisecond)
+	}
+}
+
+func TestAgent_AddSkillIsExposedAndConfirmActionsNeedApproval(t *testing.T) {
+	var body atomic.Value
+	body.Store(`[{"name":"MusicPlayer","description":"Control music playback"}]`)
+	provider := &scriptedProvider{replies: []string{
+		`{"tool":"Alarm","action":"Set","input":{"at":"07:00"}}`,
+		`{"response":"Could not set the alarm","done":true}`,
+	}}
+	var added atomic.Int32
+	a := newSyncedAgent(t, &body, provider, &added)
+	a.Start(context.Background())
+
+	var set atomic.Int32
+	err := a.AddSkill(skills.SkillDefinition{Name: "Alarm", Description: "Wake-up alarms"}, []RegisteredAction{{
+		Action:  "Set",
+		Confirm: true,
+		Handler: func(context.Context, map[string]interface{}) *ActionResult {
+			set.Add(1)
+			return SuccessResult("set", "Alarm set")
+		},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	exposed := a.Skills().Exposed
+	if len(exposed) != 2 || exposed[0].Name != "Alarm" || exposed[0].Description != "Wake-up alarms" {
+		t.Fatalf("exposed = %+v", exposed)
+	}
+
+	var finished []protocol.Event
+	hooks := LoopHooks{Events: func(ev protocol.Event) {
+		if ev.Type == protocol.EventActionFinished {
+			finished = append(finished, ev)
+		}
+	}}
+	if _, err := a.Run(context.Background(), "wake me at 7", hooks); err != nil {
+		t.Fatal(err)
+	}
+	if set.Load() != 0 || len(finished) != 1 || finished[0].Error == "" {
+		t.Fatalf("unconfirmed action ran: set=%d finished=%+v", set.Load(), finished)
+	}
+}
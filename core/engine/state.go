@@ -0,0 +1,179 @@
+package engine
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/skills"
+)
+
+const (
+	defaultStateTTL     = 5 * time.Second
+	defaultStateTimeout = 2 * time.Second
+)
+
+// StateProvider reports live state the planner should see before acting, so
+// references like "this song" resolve. StateKey names the section in the
+// planner prompt; CurrentState returns a JSON-serializable snapshot.
+//
+// Plugins implementing skills.MusicStatus provide the "playback" state
+// without implementing this interface.
+type StateProvider interface {
+	StateKey() string
+	CurrentState(ctx context.Context) (interface{}, error)
+}
+
+// PlaybackStateKey is the state section filled from skills.MusicStatus.
+const PlaybackStateKey = "playback"
+
+type playbackState struct {
+	status skills.MusicStatus
+}
+
+func (p playbackState) StateKey() string { return PlaybackStateKey }
+
+func (p playbackState) CurrentState(ctx context.Context) (interface{}, error) {
+	return p.status.NowPlaying(ctx)
+}
+
+type stateEntry struct {
+	value     interface{}
+	fetchedAt time.Time
+}
+
+// StateCache snapshots state providers and reuses each snapshot for TTL,
+// so repeated planner runs do not hit the network every time. A provider
+// that fails or exceeds Timeout is left out rather than delaying the plan.
+type StateCache struct {
+	// TTL is how long a snapshot stays fresh; zero means five seconds.
+	TTL time.Duration
+	// Timeout bounds each provider call; zero means two seconds.
+	Timeout time.Duration
+
+	providers func() []StateProvider
+
+	mu      sync.Mutex
+	entries map[string]stateEntry
+	now     func() time.Time
+}
+
+// NewStateCache serves a fixed set of providers.
+func NewStateCache(providers ...StateProvider) *StateCache {
+	return &StateCache{
+		providers: func() []StateProvider { return providers },
+		entries:   map[string]stateEntry{},
+	}
+}
+
+// NewPluginStateCache serves whatever started plugins provide at snapshot
+// time, in registration order. The first provider of each key wins.
+func NewPluginStateCache(m *PluginManager) *StateCache {
+	return &StateCache{
+		providers: func() []StateProvider {
+			out := LookupPlugins[StateProvider](m)
+			for _, status := range LookupPlugins[skills.MusicStatus](m) {
+				out = append(out, playbackState{status: status})
+			}
+			return out
+		},
+		entries: map[string]stateEntry{},
+	}
+}
+
+// Snapshot returns the current state by key, fetching stale entries
+// concurrently. It never fails; an empty map means nothing is known.
+func (c *StateCache) Snapshot(ctx context.Context) map[string]interface{} {
+	providers := map[string]StateProvider{}
+	for _, p := range c.providers() {
+		if key := p.StateKey(); key != "" && providers[key] == nil {
+			providers[key] = p
+		}
+	}
+
+	out := map[string]interface{}{}
+	stale := map[string]StateProvider{}
+	c.mu.Lock()
+	for key, p := range providers {
+		if e, ok := c.entries[key]; ok && c.clock().Sub(e.fetchedAt) < c.ttl() {
+			out[key] = e.value
+		} else {
+			stale[key] = p
+		}
+	}
+	c.mu.Unlock()
+
+	var (
+		wg sync.WaitGroup
+		mu sync.Mutex
+	)
+	for key, p := range stale {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			fetchCtx, cancel := context.WithTimeout(ctx, c.timeout())
+			defer cancel()
+			value, err := fetchState(fetchCtx, p)
+			if err != nil {
+				return
+			}
+			mu.Lock()
+			out[key] = value
+			mu.Unlock()
+
+			c.mu.Lock()
+			c.entries[key] = stateEntry{value: value, fetchedAt: c.clock()}
+			c.mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	return out
+}
+
+// Invalidate drops every cached snapshot, e.g. after an action changed state.
+func (c *StateCache) Invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = map[string]stateEntry{}
+}
+
+// fetchState returns when the provider answers or ctx ends, whichever is
+// first, so a provider ignoring ctx cannot stall the planner.
+func fetchState(ctx context.Context, p StateProvider) (interface{}, error) {
+	type result struct {
+		value interface{}
+		err   error
+	}
+	done := make(chan result, 1)
+	go func() {
+		value, err := p.CurrentState(ctx)
+		done <- result{value, err}
+	}()
+	select {
+	case r := <-done:
+		return r.value, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (c *StateCache) ttl() time.Duration {
+	if c.TTL > 0 {
+		return c.TTL
+	}
+	return defaultStateTTL
+}
+
+func (c *StateCache) timeout() time.Duration {
+	if c.Timeout > 0 {
+		return c.Timeout
+	}
+	return defaultStateTimeout
+}
+
+func (c *StateCache) clock() time.Time {
+	if c.now != nil {
+		return c.now()
+	}
+	return time.Now()
+}
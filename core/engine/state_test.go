@@ -0,0 +1,139 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/skills"
+)
+
+type countingState struct {
+	key   string
+	calls atomic.Int32
+	value interface{}
+	err   error
+	delay time.Duration
+}
+
+func (s *countingState) StateKey() string { return s.key }
+
+func (s *countingState) CurrentState(ctx context.Context) (interface{}, error) {
+	s.calls.Add(1)
+	if s.delay > 0 {
+		time.Sleep(s.delay)
+	}
+	return s.value, s.err
+}
+
+// statusMusic adds skills.MusicStatus to the fake player.
+type statusMusic struct {
+	fakeMusic
+	track string
+}
+
+func (s *statusMusic) NowPlaying(context.Context) (*skills.NowPlaying, error) {
+	return &skills.NowPlaying{Playing: true, Track: s.track, Artists: []string{"Coldplay"}}, nil
+}
+
+func TestStateCache_ReusesFreshSnapshots(t *testing.T) {
+	weather := &countingState{key: "weather", value: "sunny"}
+	cache := NewStateCache(weather)
+	now := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+	cache.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		if got := cache.Snapshot(context.Background())["weather"]; got != "sunny" {
+			t.Fatalf("snapshot = %v", got)
+		}
+	}
+	if n := weather.calls.Load(); n != 1 {
+		t.Fatalf("provider called %d times within TTL, want 1", n)
+	}
+
+	now = now.Add(defaultStateTTL)
+	cache.Snapshot(context.Background())
+	cache.Invalidate()
+	cache.Snapshot(context.Background())
+	if n := weather.calls.Load(); n != 3 {
+		t.Fatalf("provider called %d times, want 3 after expiry and invalidation", n)
+	}
+}
+
+func TestStateCache_LeavesOutSlowAndFailingProviders(t *testing.T) {
+	slow := &countingState{key: "slow", value: 1, delay: time.Second}
+	broken := &countingState{key: "broken", err: errors.New("offline")}
+	ok := &countingState{key: "ok", value: true}
+	cache := NewStateCache(slow, broken, ok)
+	cache.Timeout = 20 * time.Millisecond
+
+	start := time.Now()
+	snapshot := cache.Snapshot(context.Background())
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("slow provider stalled the snapshot for %s", elapsed)
+	}
+	if len(snapshot) != 1 || snapshot["ok"] != true {
+		t.Fatalf("snapshot = %v", snapshot)
+	}
+}
+
+func TestPluginStateCache_ExposesPlaybackFromMusicStatus(t *testing.T) {
+	var events []string
+	m := NewPluginManager()
+	mustRegister(t, m, "music", &statusMusic{fakeMusic: fakeMusic{lifecyclePlugin{name: "music", events: &events}}, track: "Yellow"})
+	if err := m.StartAll(context.Background(), NewRegistry()); err != nil {
+		t.Fatal(err)
+	}
+
+	snapshot := NewPluginStateCache(m).Snapshot(context.Background())
+	now, ok := snapshot[PlaybackStateKey].(*skills.NowPlaying)
+	if !ok || now.Track != "Yellow" {
+		t.Fatalf("snapshot = %#v", snapshot)
+	}
+}
+
+// scriptedProvider replays responses and records the conversation it saw.
+type scriptedProvider struct {
+	replies []string
+	seen    [][]Message
+}
+
+func (p *scriptedProvider) Chat(_ context.Context, messages []Message, _ []ToolDefinition, _ string, _ map[string]interface{}) (*LLMResponse, error) {
+	p.seen = append(p.seen, append([]Message(nil), messages...))
+	reply := p.replies[0]
+	p.replies = p.replies[1:]
+	return &LLMResponse{Content: reply}, nil
+}
+
+func (p *scriptedProvider) GetDefaultModel() string { return "test" }
+
+func TestRunDeterministicLoop_InjectsStateAndRefreshesAfterActions(t *testing.T) {
+	playback := &countingState{key: PlaybackStateKey, value: map[string]string{"track": "Yellow"}}
+	cache := NewStateCache(playback)
+	registry := NewRegistry()
+	if err := RegisterMusicPlayer(registry, &fakeMusic{}); err != nil {
+		t.Fatal(err)
+	}
+	provider := &scriptedProvider{replies: []string{
+		`{"tool":"MusicPlayer","action":"Next"}`,
+		`{"response":"Skipped Yellow","done":true}`,
+	}}
+
+	cfg := LoopConfig{Provider: provider, Registry: registry, State: cache}
+	if _, err := RunDeterministicLoop(context.Background(), cfg, "skip this song", registry.SkillDefinitions()); err != nil {
+		t.Fatalf("loop: %v", err)
+	}
+	system := provider.seen[0][0].Content
+	if !strings.Contains(system, `"playback":{"track":"Yellow"}`) {
+		t.Fatalf("state missing from system prompt: %s", system)
+	}
+
+	// Next succeeded, so the following request fetches fresh state.
+	cache.Snapshot(context.Background())
+	if n := playback.calls.Load(); n != 2 {
+		t.Fatalf("provider called %d times, want 2", n)
+	}
+}
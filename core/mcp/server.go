@@ -0,0 +1,338 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/engine"
+)
+
+const (
+	// LatestProtocolVersion is advertised when the client asks for a version we do not know.
+	LatestProtocolVersion = "2025-06-18"
+
+	defaultServerName    = "upcraft-agent"
+	defaultServerVersion = "0.1.0"
+	maxMessageBytes      = 4 * 1024 * 1024
+)
+
+var supportedProtocolVersions = map[string]bool{
+	"2025-06-18": true,
+	"2025-03-26": true,
+	"2024-11-05": true,
+}
+
+// JSON-RPC 2.0 error codes used by the server.
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+)
+
+// Server publishes every action in an engine.Registry as an MCP tool.
+type Server struct {
+	Registry *engine.Registry
+	Name     string
+	Version  string
+
+	writeMu sync.Mutex
+}
+
+func NewServer(registry *engine.Registry) *Server {
+	return &Server{
+		Registry: registry,
+		Name:     defaultServerName,
+		Version:  defaultServerVersion,
+	}
+}
+
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Tool is the MCP tools/list entry for one registered action.
+type Tool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description,omitempty"`
+	InputSchema map[string]interface{} `json:"inputSchema"`
+}
+
+// Content is one MCP text content block.
+type Content struct {
+	Type        string       `json:"type"`
+	Text        string       `json:"text,omitempty"`
+	URI         string       `json:"uri,omitempty"`
+	Name        string       `json:"name,omitempty"`
+	MIMEType    string       `json:"mimeType,omitempty"`
+	Annotations *Annotations `json:"annotations,omitempty"`
+}
+
+// Annotations tells the client who a content block is meant for.
+type Annotations struct {
+	Audience []string `json:"audience,omitempty"`
+}
+
+// CallToolResult is the MCP tools/call result.
+type CallToolResult struct {
+	Content           []Content              `json:"content"`
+	StructuredContent map[string]interface{} `json:"structuredContent,omitempty"`
+	IsError           bool                   `json:"isError"`
+}
+
+// Serve reads newline-delimited JSON-RPC messages from in and writes responses to out
+// until in is exhausted or ctx is cancelled.
+func (s *Server) Serve(ctx context.Context, in io.Reader, out io.Writer) error {
+	if s.Registry == nil {
+		return fmt.Errorf("registry is required")
+	}
+
+	lines := make(chan string)
+	readErr := make(chan error, 1)
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(in)
+		scanner.Buffer(make([]byte, 0, 64*1024), maxMessageBytes)
+		for scanner.Scan() {
+			select {
+			case lines <- scanner.Text():
+			case <-ctx.Done():
+				readErr <- ctx.Err()
+				return
+			}
+		}
+		readErr <- scanner.Err()
+	}()
+
+	// Reading happens on its own goroutine so cancellation is honoured even
+	// while the client is idle and stdin is blocked.
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case line, ok := <-lines:
+			if !ok {
+				if err := <-readErr; err != nil {
+					if ctx.Err() != nil {
+						return ctx.Err()
+					}
+					return fmt.Errorf("read mcp input: %w", err)
+				}
+				return nil
+			}
+			line = strings.TrimSpace(line)
+			if line == "" {
+				continue
+			}
+			reply := s.HandleMessage(ctx, []byte(line))
+			if reply == nil {
+				continue
+			}
+			if err := s.write(out, reply); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// HandleMessage processes one JSON-RPC message and returns the encoded response,
+// or nil when the message is a notification.
+func (s *Server) HandleMessage(ctx context.Context, raw []byte) []byte {
+	var req request
+	if err := json.Unmarshal(raw, &req); err != nil {
+		return encode(response{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: &rpcError{Code: codeParseError, Message: "parse error: " + err.Error()}})
+	}
+
+	isNotification := len(req.ID) == 0
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		if isNotification {
+			return nil
+		}
+		return encode(response{JSONRPC: "2.0", ID: req.ID, Error: &rpcError{Code: codeInvalidRequest, Message: "invalid request"}})
+	}
+
+	result, rpcErr := s.dispatch(ctx, req)
+	if isNotification {
+		return nil
+	}
+	if rpcErr != nil {
+		return encode(response{JSONRPC: "2.0", ID: req.ID, Error: rpcErr})
+	}
+	return encode(response{JSONRPC: "2.0", ID: req.ID, Result: result})
+}
+
+func (s *Server) dispatch(ctx context.Context, req request) (interface{}, *rpcError) {
+	switch req.Method {
+	case "initialize":
+		return s.initialize(req.Params)
+	case "notifications/initialized", "notifications/cancelled", "ping":
+		return map[string]interface{}{}, nil
+	case "tools/list":
+		return map[string]interface{}{"tools": s.Tools()}, nil
+	case "tools/call":
+		return s.callTool(ctx, req.Params)
+	default:
+		return nil, &rpcError{Code: codeMethodNotFound, Message: "method not found: " + req.Method}
+	}
+}
+
+func (s *Server) initialize(params json.RawMessage) (interface{}, *rpcError) {
+	var p struct {
+		ProtocolVersion string `json:"protocolVersion"`
+	}
+	if len(params) > 0 {
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, &rpcError{Code: codeInvalidParams, Message: "invalid initialize params: " + err.Error()}
+		}
+	}
+
+	version := LatestProtocolVersion
+	if supportedProtocolVersions[p.ProtocolVersion] {
+		version = p.ProtocolVersion
+	}
+
+	return map[string]interface{}{
+		"protocolVersion": version,
+		"capabilities": map[string]interface{}{
+			"tools": map[string]interface{}{"listChanged": false},
+		},
+		"serverInfo": map[string]interface{}{
+			"name":    s.Name,
+			"version": s.Version,
+		},
+	}, nil
+}
+
+// Tools lists every registered action as an MCP tool named "<Skill>.<Action>".
+func (s *Server) Tools() []Tool {
+	actions := s.Registry.Actions()
+	tools := make([]Tool, 0, len(actions))
+	for _, a := range actions {
+		tools = append(tools, Tool{
+			Name:        ToolName(a.Skill, a.Action),
+			Description: a.Description,
+			InputSchema: a.InputSchema,
+		})
+	}
+	return tools
+}
+
+func (s *Server) callTool(ctx context.Context, params json.RawMessage) (interface{}, *rpcError) {
+	var p struct {
+		Name      string                 `json:"name"`
+		Arguments map[string]interface{} `json:"arguments"`
+	}
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, &rpcError{Code: codeInvalidParams, Message: "invalid tools/call params: " + err.Error()}
+	}
+
+	skillName, actionName, ok := SplitToolName(p.Name)
+	if !ok {
+		return nil, &rpcError{Code: codeInvalidParams, Message: fmt.Sprintf("unknown tool: %q", p.Name)}
+	}
+
+	result := s.Registry.Execute(ctx, skillName, actionName, p.Arguments)
+	return ToCallToolResult(result), nil
+}
+
+// ToCallToolResult maps an engine.ActionResult onto MCP content blocks.
+// The compact model text is addressed to the assistant and the user text (or
+// card fallback) to the user. Artifacts become resource links, and Data and
+// Card travel as structured content.
+func ToCallToolResult(result *engine.ActionResult) CallToolResult {
+	if result == nil {
+		return CallToolResult{
+			Content: []Content{{Type: "text", Text: "action returned nil result"}},
+			IsError: true,
+		}
+	}
+
+	content := []Content{{
+		Type:        "text",
+		Text:        result.ModelText(),
+		Annotations: &Annotations{Audience: []string{"assistant"}},
+	}}
+	if userText := result.UserText(); strings.TrimSpace(userText) != "" {
+		content = append(content, Content{
+			Type:        "text",
+			Text:        userText,
+			Annotations: &Annotations{Audience: []string{"user"}},
+		})
+	}
+	for _, a := range result.Artifacts {
+		name := a.Name
+		if name == "" {
+			name = a.URI
+		}
+		content = append(content, Content{
+			Type:     "resource_link",
+			URI:      a.URI,
+			Name:     name,
+			MIMEType: a.MIMEType,
+		})
+	}
+
+	var structured map[string]interface{}
+	if len(result.Data) > 0 || result.Card != nil {
+		structured = map[string]interface{}{}
+		if len(result.Data) > 0 {
+			structured["data"] = result.Data
+		}
+		if result.Card != nil {
+			structured["card"] = result.Card
+		}
+	}
+	return CallToolResult{Content: content, StructuredContent: structured, IsError: result.IsError}
+}
+
+// ToolName joins a skill and action into the published MCP tool name.
+func ToolName(skillName, actionName string) string {
+	return skillName + "." + actionName
+}
+
+// SplitToolName reverses ToolName.
+func SplitToolName(name string) (string, string, bool) {
+	skillName, actionName, ok := strings.Cut(strings.TrimSpace(name), ".")
+	if !ok || skillName == "" || actionName == "" {
+		return "", "", false
+	}
+	return skillName, actionName, true
+}
+
+func (s *Server) write(out io.Writer, msg []byte) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	if _, err := out.Write(append(msg, '\n')); err != nil {
+		return fmt.Errorf("write mcp output: %w", err)
+	}
+	return nil
+}
+
+func encode(resp response) []byte {
+	encoded, err := json.Marshal(resp)
+	if err != nil {
+		fallback, _ := json.Marshal(response{JSONRPC: "2.0", ID: resp.ID, Error: &rpcError{Code: codeInvalidRequest, Message: "encode response: " + err.Error()}})
+		return fallback
+	}
+	return encoded
+}
@@ -0,0 +1,170 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/engine"
+)
+
+func newTestRegistry(t *testing.T) *engine.Registry {
+	t.Helper()
+	registry := engine.NewRegistry()
+	err := registry.Register(engine.RegisteredAction{
+		Skill:       "Echo",
+		Action:      "Say",
+		Description: "Echo text back",
+		InputSchema: map[string]interface{}{
+			"type":       "object",
+			"properties": map[string]interface{}{"text": map[string]interface{}{"type": "string"}},
+			"required":   []string{"text"},
+		},
+		Handler: func(_ context.Context, input map[string]interface{}) *engine.ActionResult {
+			text, _ := input["text"].(string)
+			if text == "" {
+				return engine.ErrorResult("missing required field: text", fmt.Errorf("text is required"))
+			}
+			return engine.SuccessResult("echoed "+text, text)
+		},
+	})
+	if err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	return registry
+}
+
+func TestServe_InitializeListAndCall(t *testing.T) {
+	server := NewServer(newTestRegistry(t))
+
+	in := strings.Join([]string{
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2024-11-05"}}`,
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/list"}`,
+		`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"Echo.Say","arguments":{"text":"hi"}}}`,
+		`{"jsonrpc":"2.0","id":4,"method":"tools/call","params":{"name":"Echo.Say","arguments":{}}}`,
+	}, "\n")
+
+	var out bytes.Buffer
+	if err := server.Serve(context.Background(), strings.NewReader(in), &out); err != nil {
+		t.Fatalf("Serve: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("expected 4 responses (notification has none), got %d: %s", len(lines), out.String())
+	}
+
+	var initResp struct {
+		Result struct {
+			ProtocolVersion string `json:"protocolVersion"`
+		} `json:"result"`
+	}
+	mustDecode(t, lines[0], &initResp)
+	if initResp.Result.ProtocolVersion != "2024-11-05" {
+		t.Errorf("protocolVersion = %q, want 2024-11-05", initResp.Result.ProtocolVersion)
+	}
+
+	var listResp struct {
+		Result struct {
+			Tools []Tool `json:"tools"`
+		} `json:"result"`
+	}
+	mustDecode(t, lines[1], &listResp)
+	if len(listResp.Result.Tools) != 1 || listResp.Result.Tools[0].Name != "Echo.Say" {
+		t.Fatalf("unexpected tools: %+v", listResp.Result.Tools)
+	}
+	if listResp.Result.Tools[0].InputSchema["type"] != "object" {
+		t.Errorf("input schema not published: %+v", listResp.Result.Tools[0].InputSchema)
+	}
+
+	var okResp struct {
+		Result CallToolResult `json:"result"`
+	}
+	mustDecode(t, lines[2], &okResp)
+	if okResp.Result.IsError {
+		t.Fatalf("expected success, got %+v", okResp.Result)
+	}
+	if len(okResp.Result.Content) != 2 || okResp.Result.Content[0].Text != "echoed hi" || okResp.Result.Content[1].Text != "hi" {
+		t.Errorf("unexpected content: %+v", okResp.Result.Content)
+	}
+
+	var errResp struct {
+		Result CallToolResult `json:"result"`
+	}
+	mustDecode(t, lines[3], &errResp)
+	if !errResp.Result.IsError {
+		t.Fatalf("expected isError, got %+v", errResp.Result)
+	}
+	if !strings.Contains(errResp.Result.Content[0].Text, "text is required") {
+		t.Errorf("error detail missing from content: %q", errResp.Result.Content[0].Text)
+	}
+}
+
+func TestHandleMessage_Errors(t *testing.T) {
+	server := NewServer(newTestRegistry(t))
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		in   string
+		code int
+	}{
+		{"parse error", `{not json`, codeParseError},
+		{"unknown method", `{"jsonrpc":"2.0","id":1,"method":"resources/list"}`, codeMethodNotFound},
+		{"bad tool name", `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"NoDot"}}`, codeInvalidParams},
+		{"wrong version", `{"jsonrpc":"1.0","id":1,"method":"ping"}`, codeInvalidRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var resp struct {
+				Error *rpcError `json:"error"`
+			}
+			mustDecode(t, string(server.HandleMessage(ctx, []byte(tt.in))), &resp)
+			if resp.Error == nil || resp.Error.Code != tt.code {
+				t.Errorf("error = %+v, want code %d", resp.Error, tt.code)
+			}
+		})
+	}
+}
+
+func mustDecode(t *testing.T, raw string, v interface{}) {
+	t.Helper()
+	if err := json.Unmarshal([]byte(raw), v); err != nil {
+		t.Fatalf("decode %s: %v", raw, err)
+	}
+}
+
+func TestToCallToolResult_RichPayloads(t *testing.T) {
+	result := engine.SuccessResult("1 track", "").
+		WithData(map[string]string{"uri": "spotify:track:1"}).
+		WithCard(&engine.Card{Title: "Now playing", Items: []engine.CardItem{{Title: "Yellow"}}}).
+		WithArtifacts(engine.Artifact{Kind: engine.ArtifactImage, MIMEType: "image/png", URI: "https://img.example/cover.png"})
+
+	got := ToCallToolResult(result)
+	if len(got.Content) != 3 {
+		t.Fatalf("content = %+v", got.Content)
+	}
+	if got.Content[0].Text != "1 track\n[artifacts: https://img.example/cover.png (image/png)]" {
+		t.Errorf("model text = %q", got.Content[0].Text)
+	}
+	if got.Content[1].Text != "Now playing\n• Yellow" {
+		t.Errorf("user text = %q", got.Content[1].Text)
+	}
+	link := got.Content[2]
+	if link.Type != "resource_link" || link.URI != "https://img.example/cover.png" || link.Name != link.URI || link.MIMEType != "image/png" {
+		t.Errorf("resource link = %+v", link)
+	}
+
+	encoded, err := json.Marshal(got.StructuredContent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"card":{"title":"Now playing","items":[{"title":"Yellow"}]},"data":{"uri":"spotify:track:1"}}`
+	if string(encoded) != want {
+		t.Errorf("structuredContent = %s, want %s", encoded, want)
+	}
+}
@@ -0,0 +1,74 @@
+package memory
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Session groups the messages and actions of one conversation. Its ID is
+// chosen by the caller, e.g. the host's session ID.
+type Session struct {
+	ID        string    `json:"id"`
+	Title     string    `json:"title,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	// UpdatedAt is the time of the latest message or action.
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Message is one stored conversation turn. ToolCalls keeps the provider's
+// tool calls as JSON.
+type Message struct {
+	ID         int64           `json:"id"`
+	SessionID  string          `json:"session_id"`
+	Role       string          `json:"role"`
+	Content    string          `json:"content"`
+	ToolCalls  json.RawMessage `json:"tool_calls,omitempty"`
+	ToolCallID string          `json:"tool_call_id,omitempty"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+// ActionRecord is one executed skill action with its input and outcome.
+// Error is set when the action failed.
+type ActionRecord struct {
+	ID         int64           `json:"id"`
+	SessionID  string          `json:"session_id"`
+	Skill      string          `json:"skill"`
+	Action     string          `json:"action"`
+	Input      json.RawMessage `json:"input,omitempty"`
+	Result     string          `json:"result,omitempty"`
+	Error      string          `json:"error,omitempty"`
+	StartedAt  time.Time       `json:"started_at"`
+	FinishedAt time.Time       `json:"finished_at"`
+}
+
+// HistoryQuery narrows history reads. Zero fields do not filter; Since is
+// inclusive and Until exclusive. Skill, matched ignoring case, applies to
+// actions only, so a search with Skill set returns no messages.
+type HistoryQuery struct {
+	SessionID string
+	Skill     string
+	Since     time.Time
+	Until     time.Time
+	// Limit caps the results; zero means 100.
+	Limit int
+}
+
+// SearchHit is one full-text match. Kind is "message" or "action" and ID
+// refers to the matching Message or ActionRecord. Snippet marks the
+// matched terms with [ and ].
+type SearchHit struct {
+	Kind      string    `json:"kind"`
+	ID        int64     `json:"id"`
+	SessionID string    `json:"session_id"`
+	At        time.Time `json:"at"`
+	Snippet   string    `json:"snippet"`
+}
+
+// RetentionPolicy bounds how much history is kept. Whole sessions are
+// removed, with their messages and actions. Zero fields keep everything.
+type RetentionPolicy struct {
+	// MaxAge removes sessions idle for longer than this.
+	MaxAge time.Duration
+	// MaxSessions keeps only the most recently active sessions.
+	MaxSessions int
+}
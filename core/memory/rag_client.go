@@ -1,46 +1,312 @@
 package memory
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 )
 
+// SkillCachePathEnv overrides where the skill catalog is cached.
+const SkillCachePathEnv = "UPCRAFT_SKILL_CACHE_PATH"
+
+// RAGClient syncs the skill catalog from the backend. The last good catalog
+// is kept, on disk when CachePath is set, so an offline start still has
+// skills. Syncs are conditional: an unchanged catalog costs a 304, and a
+// backend that remembers the cached version answers with only the changes.
 type RAGClient struct {
 	BaseURL string
 	Client  *http.Client
+	// CachePath persists the catalog; empty keeps it in memory only.
+	CachePath string
+
+	mu      sync.Mutex
+	catalog *SkillCatalog
+	loaded  bool
+	status  SyncStatus
+	now     func() time.Time
 }
 
 type RemoteSkill struct {
 	Name        string `json:"name"`
 	Description string `json:"description"`
 	JSONSchema  string `json:"json_schema"`
+
+	// Set only for skills shipped as WASM bundles (see plugins/wasmhost).
+	WasmManifest json.RawMessage `json:"wasm_manifest,omitempty"`
+	WasmURL      string          `json:"wasm_url,omitempty"`
+	WasmSHA256   string          `json:"wasm_sha256,omitempty"`
+}
+
+// SkillCatalog is the synced skill list with the version it was served as.
+// ETag is the validator the backend sent with it, Version the same value
+// unquoted.
+type SkillCatalog struct {
+	Version  string        `json:"version,omitempty"`
+	ETag     string        `json:"etag,omitempty"`
+	SyncedAt time.Time     `json:"synced_at"`
+	Skills   []RemoteSkill `json:"skills"`
+}
+
+// SkillDelta is a /sync-skills reply listing only the changes since Base.
+// Full replies are a plain JSON array of skills.
+type SkillDelta struct {
+	Version string        `json:"version"`
+	Base    string        `json:"base"`
+	Upserts []RemoteSkill `json:"upserts,omitempty"`
+	Deletes []string      `json:"deletes,omitempty"`
+}
+
+// SyncStatus describes the latest sync attempt. SyncedAt is the last time
+// the backend confirmed the catalog, whether it changed or not.
+type SyncStatus struct {
+	Version   string    `json:"version,omitempty"`
+	SyncedAt  time.Time `json:"synced_at,omitempty"`
+	CheckedAt time.Time `json:"checked_at,omitempty"`
+	Error     string    `json:"error,omitempty"`
 }
 
 func NewRAGClient(baseURL string) *RAGClient {
 	return &RAGClient{
 		BaseURL: strings.TrimRight(baseURL, "/"),
-		Client:  &http.Client{Timeout: 5 * time.Second},
+		Client:  &http.Client{Timeout: 30 * time.Second},
 	}
 }
 
+// SetBaseURL points later syncs at another backend. The cached catalog is
+// kept until that backend replaces it.
+func (c *RAGClient) SetBaseURL(baseURL string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.BaseURL = strings.TrimRight(baseURL, "/")
+}
+
+// DefaultSkillCachePath honors UPCRAFT_SKILL_CACHE_PATH and otherwise uses
+// the user config directory.
+func DefaultSkillCachePath() (string, error) {
+	if path := strings.TrimSpace(os.Getenv(SkillCachePathEnv)); path != "" {
+		return path, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("locate config dir: %w", err)
+	}
+	return filepath.Join(dir, "upcraft", "skill_catalog.json"), nil
+}
+
+// FetchSkills syncs and returns the catalog; it fails when the sync does,
+// even if a cached catalog exists.
 func (c *RAGClient) FetchSkills() ([]RemoteSkill, error) {
-	resp, err := c.Client.Get(c.BaseURL + "/sync-skills")
+	if err := c.Sync(context.Background()); err != nil {
+		return nil, err
+	}
+	return c.Catalog().Skills, nil
+}
+
+// Catalog returns the last good catalog, loading the disk cache on first
+// use, or nil when there has never been one. An unreadable cache counts as
+// none; the next sync replaces it.
+func (c *RAGClient) Catalog() *SkillCatalog {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.loadLocked()
+	return c.catalog
+}
+
+// Status reports the latest sync attempt. Before any attempt it reflects
+// the cached catalog, if there is one.
+func (c *RAGClient) Status() SyncStatus {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.loadLocked()
+	return c.status
+}
+
+// Sync brings the catalog up to date. On failure the cached catalog stays
+// in effect and the error is kept in Status; if only writing the disk cache
+// fails, the new catalog is still used.
+func (c *RAGClient) Sync(ctx context.Context) error {
+	c.mu.Lock()
+	c.loadLocked()
+	cached, base := c.catalog, c.BaseURL
+	c.mu.Unlock()
+
+	catalog, err := c.fetch(ctx, base, cached)
+	if errors.Is(err, errStaleBase) {
+		// The delta was against another version: start over.
+		catalog, err = c.fetch(ctx, base, nil)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.status.CheckedAt = c.clock()
+	if err != nil {
+		c.status.Error = err.Error()
+		return err
+	}
+	c.catalog = catalog
+	c.status = SyncStatus{Version: catalog.Version, SyncedAt: catalog.SyncedAt, CheckedAt: c.status.CheckedAt}
+	if c.CachePath != "" {
+		if err := c.store(catalog); err != nil {
+			c.status.Error = err.Error()
+			return err
+		}
+	}
+	return nil
+}
+
+var errStaleBase = errors.New("skill delta does not apply to the cached catalog")
+
+// fetch asks for the changes since cached, or the full catalog when cached
+// is nil, and returns the resulting catalog.
+func (c *RAGClient) fetch(ctx context.Context, base string, cached *SkillCatalog) (*SkillCatalog, error) {
+	endpoint := base + "/sync-skills"
+	if cached != nil && cached.Version != "" {
+		endpoint += "?since=" + url.QueryEscape(cached.Version)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	if cached != nil && cached.ETag != "" {
+		req.Header.Set("If-None-Match", cached.ETag)
+	}
+	resp, err := c.Client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to sync skills: %w", err)
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
+	now := c.clock()
+	switch {
+	case resp.StatusCode == http.StatusNotModified && cached != nil:
+		confirmed := *cached
+		confirmed.SyncedAt = now
+		return &confirmed, nil
+	case resp.StatusCode != http.StatusOK:
 		return nil, fmt.Errorf("backend returned status: %d", resp.StatusCode)
 	}
 
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read skills: %w", err)
+	}
+	etag := resp.Header.Get("ETag")
+	if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] == '{' {
+		var delta SkillDelta
+		if err := json.Unmarshal(trimmed, &delta); err != nil {
+			return nil, fmt.Errorf("failed to decode skill delta: %w", err)
+		}
+		if cached == nil || delta.Base != cached.Version {
+			return nil, errStaleBase
+		}
+		return &SkillCatalog{
+			Version:  delta.Version,
+			ETag:     orQuoted(etag, delta.Version),
+			SyncedAt: now,
+			Skills:   applyDelta(cached.Skills, delta),
+		}, nil
+	}
+
 	var skills []RemoteSkill
-	if err := json.NewDecoder(resp.Body).Decode(&skills); err != nil {
+	if err := json.Unmarshal(body, &skills); err != nil {
 		return nil, fmt.Errorf("failed to decode skills: %w", err)
 	}
+	return &SkillCatalog{Version: unquoteETag(etag), ETag: etag, SyncedAt: now, Skills: skills}, nil
+}
+
+// applyDelta returns skills with delta applied, sorted by name.
+func applyDelta(skills []RemoteSkill, delta SkillDelta) []RemoteSkill {
+	byName := make(map[string]RemoteSkill, len(skills)+len(delta.Upserts))
+	for _, s := range skills {
+		byName[s.Name] = s
+	}
+	for _, name := range delta.Deletes {
+		delete(byName, name)
+	}
+	for _, s := range delta.Upserts {
+		byName[s.Name] = s
+	}
+	out := make([]RemoteSkill, 0, len(byName))
+	for _, s := range byName {
+		out = append(out, s)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// unquoteETag strips the quotes and weak prefix of an ETag.
+func unquoteETag(etag string) string {
+	return strings.Trim(strings.TrimPrefix(strings.TrimSpace(etag), "W/"), `"`)
+}
 
-	return skills, nil
+func orQuoted(etag, version string) string {
+	if etag != "" {
+		return etag
+	}
+	return `"` + version + `"`
+}
+
+func (c *RAGClient) clock() time.Time {
+	if c.now != nil {
+		return c.now()
+	}
+	return time.Now()
+}
+
+// loadLocked reads the disk cache once. The caller holds c.mu.
+func (c *RAGClient) loadLocked() {
+	if c.loaded {
+		return
+	}
+	c.loaded = true
+	if c.CachePath == "" || c.catalog != nil {
+		return
+	}
+	raw, err := os.ReadFile(c.CachePath)
+	if err != nil {
+		return
+	}
+	var catalog SkillCatalog
+	if json.Unmarshal(raw, &catalog) != nil {
+		return
+	}
+	c.catalog = &catalog
+	c.status = SyncStatus{Version: catalog.Version, SyncedAt: catalog.SyncedAt}
+}
+
+func (c *RAGClient) store(catalog *SkillCatalog) error {
+	raw, err := json.MarshalIndent(catalog, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.CachePath), 0o700); err != nil {
+		return fmt.Errorf("create skill cache dir: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(c.CachePath), ".skill-catalog-*")
+	if err != nil {
+		return fmt.Errorf("write skill cache: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(raw); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write skill cache: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write skill cache: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), c.CachePath); err != nil {
+		return fmt.Errorf("write skill cache: %w", err)
+	}
+	return nil
 }
@@ -0,0 +1,120 @@
+package memory
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// catalogServer plays the backend's side of conditional sync. It answers
+// If-None-Match "v2" with 304, since=v1 with a delta, and anything else
+// with the full v2 catalog.
+type catalogServer struct {
+	requests []*http.Request
+	down     bool
+}
+
+func (s *catalogServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.requests = append(s.requests, r)
+	switch {
+	case s.down:
+		http.Error(w, "down", http.StatusBadGateway)
+	case r.Header.Get("If-None-Match") == `"v2"`:
+		w.WriteHeader(http.StatusNotModified)
+	case r.URL.Query().Get("since") == "v1":
+		w.Header().Set("ETag", `"v2"`)
+		w.Write([]byte(`{"version":"v2","base":"v1","upserts":[{"name":"Weather","description":"Forecasts"}],"deletes":["Notes"]}`))
+	case r.URL.Query().Get("since") != "":
+		w.Write([]byte(`{"version":"v2","base":"v0"}`))
+	default:
+		w.Header().Set("ETag", `"v2"`)
+		w.Write([]byte(`[{"name":"MusicPlayer"},{"name":"Weather","description":"Forecasts"}]`))
+	}
+}
+
+func newCatalogClient(t *testing.T, srv *catalogServer, cachePath string) (*RAGClient, *time.Time) {
+	t.Helper()
+	ts := httptest.NewServer(srv)
+	t.Cleanup(ts.Close)
+	now := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+	c := NewRAGClient(ts.URL)
+	c.CachePath = cachePath
+	c.now = func() time.Time { return now }
+	return c, &now
+}
+
+func names(skills []RemoteSkill) []string {
+	var out []string
+	for _, s := range skills {
+		out = append(out, s.Name)
+	}
+	return out
+}
+
+func TestRAGClient_AppliesDeltasAndConditionalRequests(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "catalog.json")
+	srv := &catalogServer{}
+	c, now := newCatalogClient(t, srv, path)
+	c.catalog = &SkillCatalog{Version: "v1", ETag: `"v1"`, Skills: []RemoteSkill{{Name: "MusicPlayer"}, {Name: "Notes"}}}
+	c.loaded = true
+
+	if err := c.Sync(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := names(c.Catalog().Skills); len(got) != 2 || got[0] != "MusicPlayer" || got[1] != "Weather" {
+		t.Fatalf("skills after delta = %v", got)
+	}
+	if r := srv.requests[0]; r.URL.Query().Get("since") != "v1" || r.Header.Get("If-None-Match") != `"v1"` {
+		t.Fatalf("request = %s %v", r.URL, r.Header)
+	}
+
+	*now = now.Add(time.Hour)
+	if err := c.Sync(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if st := c.Status(); st.Version != "v2" || !st.SyncedAt.Equal(*now) || st.Error != "" {
+		t.Fatalf("status after 304 = %+v", st)
+	}
+}
+
+func TestRAGClient_RefetchesWhenDeltaBaseIsStale(t *testing.T) {
+	srv := &catalogServer{}
+	c, _ := newCatalogClient(t, srv, "")
+	c.catalog = &SkillCatalog{Version: "v9", Skills: []RemoteSkill{{Name: "Old"}}}
+	c.loaded = true
+
+	if err := c.Sync(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(srv.requests) != 2 || srv.requests[1].URL.RawQuery != "" {
+		t.Fatalf("requests = %d, last %s", len(srv.requests), srv.requests[len(srv.requests)-1].URL)
+	}
+	if got := names(c.Catalog().Skills); len(got) != 2 || got[0] != "MusicPlayer" {
+		t.Fatalf("skills = %v", got)
+	}
+}
+
+func TestRAGClient_ServesTheCacheOffline(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "upcraft", "catalog.json")
+	online, syncedAt := newCatalogClient(t, &catalogServer{}, path)
+	if _, err := online.FetchSkills(); err != nil {
+		t.Fatal(err)
+	}
+
+	offline, _ := newCatalogClient(t, &catalogServer{down: true}, path)
+	if st := offline.Status(); st.Version != "v2" || !st.SyncedAt.Equal(*syncedAt) {
+		t.Fatalf("status from cache = %+v", st)
+	}
+	if err := offline.Sync(context.Background()); err == nil {
+		t.Fatal("sync against a failing backend succeeded")
+	}
+	if got := names(offline.Catalog().Skills); len(got) != 2 {
+		t.Fatalf("cached skills = %v", got)
+	}
+	if st := offline.Status(); st.Error == "" || !st.SyncedAt.Equal(*syncedAt) {
+		t.Fatalf("status after failed sync = %+v", st)
+	}
+}
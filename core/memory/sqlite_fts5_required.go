@@ -0,0 +1,8 @@
+//go:build sqlite && !sqlite_fts5
+
+package memory
+
+// Store searches history with FTS5, which go-sqlite3 only compiles in with
+// the sqlite_fts5 tag. Without it every Open would fail at migration, so
+// the build fails here instead: use -tags "sqlite sqlite_fts5".
+var _ = sqliteStoreRequiresTheSqliteFts5BuildTag
@@ -0,0 +1,149 @@
+//go:build sqlite
+
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// migrations upgrade the schema one version at a time; the database's
+// user_version is the number applied. Append new steps, never edit old
+// ones.
+var migrations = []func(tx *sql.Tx) error{
+	migrateHistory,
+	migrateSearch,
+}
+
+// migrate applies every pending migration, each in its own transaction.
+func migrate(ctx context.Context, db *sql.DB) error {
+	var version int
+	if err := db.QueryRowContext(ctx, "PRAGMA user_version").Scan(&version); err != nil {
+		return fmt.Errorf("read schema version: %w", err)
+	}
+	if version > len(migrations) {
+		return fmt.Errorf("schema version %d is newer than this build (%d)", version, len(migrations))
+	}
+	for v := version; v < len(migrations); v++ {
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		if err := migrations[v](tx); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migrate schema to version %d: %w", v+1, err)
+		}
+		// PRAGMA takes no bound parameters.
+		if _, err := tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", v+1)); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migrate schema to version %d: %w", v+1, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("migrate schema to version %d: %w", v+1, err)
+		}
+	}
+	return nil
+}
+
+// migrateHistory creates sessions, messages and actions, and moves rows
+// from the old logs table into a "legacy" session. Times are Unix
+// milliseconds.
+func migrateHistory(tx *sql.Tx) error {
+	stmts := []string{
+		`CREATE TABLE sessions (
+			id TEXT PRIMARY KEY,
+			title TEXT NOT NULL DEFAULT '',
+			created_at INTEGER NOT NULL,
+			updated_at INTEGER NOT NULL
+		)`,
+		`CREATE INDEX sessions_updated ON sessions(updated_at)`,
+		`CREATE TABLE messages (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			session_id TEXT NOT NULL REFERENCES sessions(id) ON DELETE CASCADE,
+			role TEXT NOT NULL,
+			content TEXT NOT NULL DEFAULT '',
+			tool_calls TEXT,
+			tool_call_id TEXT NOT NULL DEFAULT '',
+			created_at INTEGER NOT NULL
+		)`,
+		`CREATE INDEX messages_session ON messages(session_id, created_at)`,
+		`CREATE INDEX messages_created ON messages(created_at)`,
+		`CREATE TABLE actions (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			session_id TEXT NOT NULL REFERENCES sessions(id) ON DELETE CASCADE,
+			skill TEXT NOT NULL,
+			action TEXT NOT NULL,
+			input TEXT,
+			result TEXT NOT NULL DEFAULT '',
+			error TEXT NOT NULL DEFAULT '',
+			started_at INTEGER NOT NULL,
+			finished_at INTEGER NOT NULL
+		)`,
+		`CREATE INDEX actions_session ON actions(session_id, started_at)`,
+		`CREATE INDEX actions_skill ON actions(skill COLLATE NOCASE, started_at)`,
+	}
+	for _, stmt := range stmts {
+		if _, err := tx.Exec(stmt); err != nil {
+			return err
+		}
+	}
+
+	var legacy int
+	if err := tx.QueryRow(`SELECT count(*) FROM sqlite_master WHERE type = 'table' AND name = 'logs'`).Scan(&legacy); err != nil {
+		return err
+	}
+	if legacy == 0 {
+		return nil
+	}
+	stmts = []string{
+		`INSERT INTO sessions (id, title, created_at, updated_at)
+			SELECT 'legacy', 'Imported log',
+				coalesce(min(CAST(strftime('%s', timestamp) AS INTEGER)), 0) * 1000,
+				coalesce(max(CAST(strftime('%s', timestamp) AS INTEGER)), 0) * 1000
+			FROM logs HAVING count(*) > 0`,
+		`INSERT INTO messages (session_id, role, content, created_at)
+			SELECT 'legacy', coalesce(role, ''), coalesce(content, ''),
+				coalesce(CAST(strftime('%s', timestamp) AS INTEGER), 0) * 1000
+			FROM logs ORDER BY id`,
+		`DROP TABLE logs`,
+	}
+	for _, stmt := range stmts {
+		if _, err := tx.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// migrateSearch adds FTS5 indexes over message content and action fields,
+// kept in sync by triggers. Deletes, including cascades from sessions,
+// remove the indexed rows too.
+func migrateSearch(tx *sql.Tx) error {
+	stmts := []string{
+		`CREATE VIRTUAL TABLE messages_fts USING fts5(content, content='messages', content_rowid='id')`,
+		`CREATE TRIGGER messages_fts_insert AFTER INSERT ON messages BEGIN
+			INSERT INTO messages_fts(rowid, content) VALUES (new.id, new.content);
+		END`,
+		`CREATE TRIGGER messages_fts_delete AFTER DELETE ON messages BEGIN
+			INSERT INTO messages_fts(messages_fts, rowid, content) VALUES ('delete', old.id, old.content);
+		END`,
+		`INSERT INTO messages_fts(messages_fts) VALUES ('rebuild')`,
+		`CREATE VIRTUAL TABLE actions_fts USING fts5(skill, action, input, result, error, content='actions', content_rowid='id')`,
+		`CREATE TRIGGER actions_fts_insert AFTER INSERT ON actions BEGIN
+			INSERT INTO actions_fts(rowid, skill, action, input, result, error)
+				VALUES (new.id, new.skill, new.action, new.input, new.result, new.error);
+		END`,
+		`CREATE TRIGGER actions_fts_delete AFTER DELETE ON actions BEGIN
+			INSERT INTO actions_fts(actions_fts, rowid, skill, action, input, result, error)
+				VALUES ('delete', old.id, old.skill, old.action, old.input, old.result, old.error);
+		END`,
+		`INSERT INTO actions_fts(actions_fts) VALUES ('rebuild')`,
+	}
+	for _, stmt := range stmts {
+		if _, err := tx.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,206 @@
+//go:build sqlite
+
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Sessions lists sessions active in the query's time range, most recent
+// first. SessionID and Skill are ignored.
+func (s *Store) Sessions(ctx context.Context, q HistoryQuery) ([]Session, error) {
+	var w where
+	w.timeRange("updated_at", q)
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT id, title, created_at, updated_at FROM sessions`+w.sql()+
+			` ORDER BY updated_at DESC, id LIMIT ?`, append(w.args, q.limit())...)
+	if err != nil {
+		return nil, fmt.Errorf("list sessions: %w", err)
+	}
+	defer rows.Close()
+	var out []Session
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, fmt.Errorf("list sessions: %w", err)
+		}
+		out = append(out, *session)
+	}
+	return out, rows.Err()
+}
+
+// Messages returns messages in the order they were stored. With Skill set
+// there are none, since messages do not belong to a skill.
+func (s *Store) Messages(ctx context.Context, q HistoryQuery) ([]Message, error) {
+	if q.Skill != "" {
+		return nil, nil
+	}
+	var w where
+	w.session("session_id", q)
+	w.timeRange("created_at", q)
+	// The newest Limit messages, returned oldest first.
+	rows, err := s.db.QueryContext(ctx, `SELECT * FROM (
+			SELECT id, session_id, role, content, tool_calls, tool_call_id, created_at
+			FROM messages`+w.sql()+` ORDER BY created_at DESC, id DESC LIMIT ?
+		) ORDER BY created_at, id`, append(w.args, q.limit())...)
+	if err != nil {
+		return nil, fmt.Errorf("read messages: %w", err)
+	}
+	defer rows.Close()
+	var out []Message
+	for rows.Next() {
+		var (
+			m         Message
+			toolCalls sql.NullString
+			created   int64
+		)
+		if err := rows.Scan(&m.ID, &m.SessionID, &m.Role, &m.Content, &toolCalls, &m.ToolCallID, &created); err != nil {
+			return nil, fmt.Errorf("read messages: %w", err)
+		}
+		m.ToolCalls, m.CreatedAt = rawJSON(toolCalls), fromMillis(created)
+		out = append(out, m)
+	}
+	return out, rows.Err()
+}
+
+// Actions returns executed actions in the order they started.
+func (s *Store) Actions(ctx context.Context, q HistoryQuery) ([]ActionRecord, error) {
+	var w where
+	w.session("session_id", q)
+	w.skill("skill", q)
+	w.timeRange("started_at", q)
+	rows, err := s.db.QueryContext(ctx, `SELECT * FROM (
+			SELECT id, session_id, skill, action, input, result, error, started_at, finished_at
+			FROM actions`+w.sql()+` ORDER BY started_at DESC, id DESC LIMIT ?
+		) ORDER BY started_at, id`, append(w.args, q.limit())...)
+	if err != nil {
+		return nil, fmt.Errorf("read actions: %w", err)
+	}
+	defer rows.Close()
+	var out []ActionRecord
+	for rows.Next() {
+		var (
+			a                 ActionRecord
+			input             sql.NullString
+			started, finished int64
+		)
+		if err := rows.Scan(&a.ID, &a.SessionID, &a.Skill, &a.Action, &input, &a.Result, &a.Error, &started, &finished); err != nil {
+			return nil, fmt.Errorf("read actions: %w", err)
+		}
+		a.Input, a.StartedAt, a.FinishedAt = rawJSON(input), fromMillis(started), fromMillis(finished)
+		out = append(out, a)
+	}
+	return out, rows.Err()
+}
+
+// Search finds messages and actions containing every word of text, best
+// matches first. Words are matched as typed, so FTS5 operators in text
+// have no special meaning.
+func (s *Store) Search(ctx context.Context, text string, q HistoryQuery) ([]SearchHit, error) {
+	match := ftsQuery(text)
+	if match == "" {
+		return nil, errors.New("search text is empty")
+	}
+
+	var parts []string
+	var args []interface{}
+	if q.Skill == "" {
+		w := where{clauses: []string{"messages_fts MATCH ?"}, args: []interface{}{match}}
+		w.session("m.session_id", q)
+		w.timeRange("m.created_at", q)
+		parts = append(parts, `SELECT 'message', m.id, m.session_id, m.created_at,
+				snippet(messages_fts, 0, '[', ']', '…', 12), bm25(messages_fts)
+			FROM messages_fts JOIN messages m ON m.id = messages_fts.rowid`+w.sql())
+		args = append(args, w.args...)
+	}
+	w := where{clauses: []string{"actions_fts MATCH ?"}, args: []interface{}{match}}
+	w.session("a.session_id", q)
+	w.skill("a.skill", q)
+	w.timeRange("a.started_at", q)
+	parts = append(parts, `SELECT 'action', a.id, a.session_id, a.started_at,
+			snippet(actions_fts, -1, '[', ']', '…', 12), bm25(actions_fts)
+		FROM actions_fts JOIN actions a ON a.id = actions_fts.rowid`+w.sql())
+	args = append(args, w.args...)
+
+	// bm25 is lower for better matches.
+	rows, err := s.db.QueryContext(ctx,
+		strings.Join(parts, " UNION ALL ")+" ORDER BY 6, 4 DESC LIMIT ?", append(args, q.limit())...)
+	if err != nil {
+		return nil, fmt.Errorf("search history: %w", err)
+	}
+	defer rows.Close()
+	var out []SearchHit
+	for rows.Next() {
+		var (
+			hit  SearchHit
+			at   int64
+			rank float64
+		)
+		if err := rows.Scan(&hit.Kind, &hit.ID, &hit.SessionID, &at, &hit.Snippet, &rank); err != nil {
+			return nil, fmt.Errorf("search history: %w", err)
+		}
+		hit.At = fromMillis(at)
+		out = append(out, hit)
+	}
+	return out, rows.Err()
+}
+
+// ftsQuery quotes every word of text so FTS5 matches them literally and
+// requires them all.
+func ftsQuery(text string) string {
+	words := strings.Fields(text)
+	for i, word := range words {
+		words[i] = `"` + strings.ReplaceAll(word, `"`, `""`) + `"`
+	}
+	return strings.Join(words, " ")
+}
+
+func (q HistoryQuery) limit() int {
+	if q.Limit > 0 {
+		return q.Limit
+	}
+	return defaultHistoryLimit
+}
+
+// where builds a WHERE clause from HistoryQuery filters.
+type where struct {
+	clauses []string
+	args    []interface{}
+}
+
+func (w *where) add(clause string, arg interface{}) {
+	w.clauses = append(w.clauses, clause)
+	w.args = append(w.args, arg)
+}
+
+func (w *where) session(column string, q HistoryQuery) {
+	if q.SessionID != "" {
+		w.add(column+" = ?", q.SessionID)
+	}
+}
+
+func (w *where) skill(column string, q HistoryQuery) {
+	if q.Skill != "" {
+		w.add(column+" = ? COLLATE NOCASE", q.Skill)
+	}
+}
+
+func (w *where) timeRange(column string, q HistoryQuery) {
+	if !q.Since.IsZero() {
+		w.add(column+" >= ?", toMillis(q.Since))
+	}
+	if !q.Until.IsZero() {
+		w.add(column+" < ?", toMillis(q.Until))
+	}
+}
+
+func (w *where) sql() string {
+	if len(w.clauses) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(w.clauses, " AND ")
+}
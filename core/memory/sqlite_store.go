@@ -3,40 +3,217 @@
 package memory
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"strings"
+	"time"
 
 	_ "github.com/mattn/go-sqlite3"
 )
 
+// defaultHistoryLimit caps reads when HistoryQuery.Limit is zero.
+const defaultHistoryLimit = 100
+
+// Store keeps conversation history on device: sessions, messages and the
+// skill actions they ran, searchable with FTS5. Build with
+// -tags "sqlite sqlite_fts5"; the schema is migrated on open.
 type Store struct {
 	db *sql.DB
+	// Retention is what Prune enforces.
+	Retention RetentionPolicy
+
+	now func() time.Time
 }
 
 func NewStore(path string) (*Store, error) {
-	db, err := sql.Open("sqlite3", path)
+	// Foreign keys cascade session deletes; the busy timeout lets a
+	// second connection wait instead of failing with SQLITE_BUSY.
+	db, err := sql.Open("sqlite3", "file:"+path+"?_foreign_keys=on&_busy_timeout=5000")
 	if err != nil {
 		return nil, err
 	}
-
-	query := `
-	CREATE TABLE IF NOT EXISTS logs (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		timestamp DATETIME DEFAULT CURRENT_TIMESTAMP,
-		role TEXT,
-		content TEXT
-	);`
-	if _, err := db.Exec(query); err != nil {
+	if err := migrate(context.Background(), db); err != nil {
+		db.Close()
 		return nil, fmt.Errorf("failed to init db: %w", err)
 	}
-
 	return &Store{db: db}, nil
 }
 
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// LogInteraction appends a message to the "default" session. Failures are
+// logged, not returned; use AppendMessage to handle them.
 func (s *Store) LogInteraction(role, content string) {
-	_, err := s.db.Exec("INSERT INTO logs (role, content) VALUES (?, ?)", role, content)
+	_, err := s.AppendMessage(context.Background(), Message{SessionID: "default", Role: role, Content: content})
 	if err != nil {
 		log.Printf("failed to log interaction: %v", err)
 	}
 }
+
+// OpenSession creates session id, or updates its title when title is not
+// empty, and returns it.
+func (s *Store) OpenSession(ctx context.Context, id, title string) (*Session, error) {
+	if strings.TrimSpace(id) == "" {
+		return nil, errors.New("session id is required")
+	}
+	now := toMillis(s.clock())
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO sessions (id, title, created_at, updated_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET title = CASE WHEN excluded.title = '' THEN title ELSE excluded.title END`,
+		id, title, now, now)
+	if err != nil {
+		return nil, fmt.Errorf("open session: %w", err)
+	}
+	return s.Session(ctx, id)
+}
+
+// Session returns session id, or nil when there is none.
+func (s *Store) Session(ctx context.Context, id string) (*Session, error) {
+	row := s.db.QueryRowContext(ctx, `SELECT id, title, created_at, updated_at FROM sessions WHERE id = ?`, id)
+	session, err := scanSession(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read session: %w", err)
+	}
+	return session, nil
+}
+
+// AppendMessage stores m, creating its session when needed, and returns
+// its ID. A zero CreatedAt means now.
+func (s *Store) AppendMessage(ctx context.Context, m Message) (int64, error) {
+	if m.CreatedAt.IsZero() {
+		m.CreatedAt = s.clock()
+	}
+	var toolCalls interface{}
+	if len(m.ToolCalls) > 0 {
+		toolCalls = string(m.ToolCalls)
+	}
+	return s.insert(ctx, m.SessionID, m.CreatedAt, `
+		INSERT INTO messages (session_id, role, content, tool_calls, tool_call_id, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		m.SessionID, m.Role, m.Content, toolCalls, m.ToolCallID, toMillis(m.CreatedAt))
+}
+
+// RecordAction stores a, creating its session when needed, and returns its
+// ID. A zero StartedAt means now, and a zero FinishedAt means StartedAt.
+func (s *Store) RecordAction(ctx context.Context, a ActionRecord) (int64, error) {
+	if strings.TrimSpace(a.Skill) == "" || strings.TrimSpace(a.Action) == "" {
+		return 0, errors.New("skill and action are required")
+	}
+	if a.StartedAt.IsZero() {
+		a.StartedAt = s.clock()
+	}
+	if a.FinishedAt.IsZero() {
+		a.FinishedAt = a.StartedAt
+	}
+	var input interface{}
+	if len(a.Input) > 0 {
+		input = string(a.Input)
+	}
+	return s.insert(ctx, a.SessionID, a.FinishedAt, `
+		INSERT INTO actions (session_id, skill, action, input, result, error, started_at, finished_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		a.SessionID, a.Skill, a.Action, input, a.Result, a.Error, toMillis(a.StartedAt), toMillis(a.FinishedAt))
+}
+
+// insert runs one INSERT in a transaction that also creates or touches
+// sessionID.
+func (s *Store) insert(ctx context.Context, sessionID string, at time.Time, query string, args ...interface{}) (int64, error) {
+	if strings.TrimSpace(sessionID) == "" {
+		return 0, errors.New("session id is required")
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	ms := toMillis(at)
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO sessions (id, created_at, updated_at) VALUES (?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET updated_at = max(updated_at, excluded.updated_at)`,
+		sessionID, ms, ms); err != nil {
+		return 0, fmt.Errorf("touch session: %w", err)
+	}
+	res, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("store history: %w", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	return id, tx.Commit()
+}
+
+// Prune removes sessions outside Retention and reports how many it
+// removed.
+func (s *Store) Prune(ctx context.Context) (int64, error) {
+	var removed int64
+	if s.Retention.MaxAge > 0 {
+		cutoff := toMillis(s.clock().Add(-s.Retention.MaxAge))
+		res, err := s.db.ExecContext(ctx, `DELETE FROM sessions WHERE updated_at < ?`, cutoff)
+		if err != nil {
+			return removed, fmt.Errorf("prune history: %w", err)
+		}
+		n, _ := res.RowsAffected()
+		removed += n
+	}
+	if s.Retention.MaxSessions > 0 {
+		res, err := s.db.ExecContext(ctx, `
+			DELETE FROM sessions WHERE id NOT IN (
+				SELECT id FROM sessions ORDER BY updated_at DESC, id LIMIT ?
+			)`, s.Retention.MaxSessions)
+		if err != nil {
+			return removed, fmt.Errorf("prune history: %w", err)
+		}
+		n, _ := res.RowsAffected()
+		removed += n
+	}
+	return removed, nil
+}
+
+func (s *Store) clock() time.Time {
+	if s.now != nil {
+		return s.now()
+	}
+	return time.Now()
+}
+
+func toMillis(t time.Time) int64 {
+	return t.UnixMilli()
+}
+
+func fromMillis(ms int64) time.Time {
+	return time.UnixMilli(ms).UTC()
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanSession(row rowScanner) (*Session, error) {
+	var (
+		session          Session
+		created, updated int64
+	)
+	if err := row.Scan(&session.ID, &session.Title, &created, &updated); err != nil {
+		return nil, err
+	}
+	session.CreatedAt, session.UpdatedAt = fromMillis(created), fromMillis(updated)
+	return &session, nil
+}
+
+func rawJSON(v sql.NullString) json.RawMessage {
+	if !v.Valid || v.String == "" {
+		return nil
+	}
+	return json.RawMessage(v.String)
+}
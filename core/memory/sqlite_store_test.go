@@ -0,0 +1,163 @@
+//go:build sqlite
+
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestStore(t *testing.T) (*Store, *time.Time) {
+	t.Helper()
+	s, err := NewStore(filepath.Join(t.TempDir(), "memory.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+	now := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+	return s, &now
+}
+
+func TestStore_RecordsAndQueriesHistory(t *testing.T) {
+	s, now := newTestStore(t)
+	ctx := context.Background()
+	if _, err := s.OpenSession(ctx, "s1", "Music"); err != nil {
+		t.Fatal(err)
+	}
+	mustAppend(t, s, Message{SessionID: "s1", Role: "user", Content: "play Yellow by Coldplay"})
+	mustAppend(t, s, Message{SessionID: "s1", Role: "assistant", ToolCalls: json.RawMessage(`[{"id":"1","name":"MusicPlayer.Play"}]`)})
+	if _, err := s.RecordAction(ctx, ActionRecord{SessionID: "s1", Skill: "MusicPlayer", Action: "Play", Input: json.RawMessage(`{"query":"Yellow"}`), Result: "Playing Yellow"}); err != nil {
+		t.Fatal(err)
+	}
+	*now = now.Add(time.Hour)
+	mustAppend(t, s, Message{SessionID: "s2", Role: "user", Content: "remind me to buy milk"})
+	if _, err := s.RecordAction(ctx, ActionRecord{SessionID: "s2", Skill: "Reminders", Action: "Add", Error: "no reminders app"}); err != nil {
+		t.Fatal(err)
+	}
+
+	msgs, err := s.Messages(ctx, HistoryQuery{SessionID: "s1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(msgs) != 2 || msgs[0].Content != "play Yellow by Coldplay" || string(msgs[1].ToolCalls) != `[{"id":"1","name":"MusicPlayer.Play"}]` {
+		t.Fatalf("messages = %+v", msgs)
+	}
+	actions, err := s.Actions(ctx, HistoryQuery{Skill: "musicplayer"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(actions) != 1 || string(actions[0].Input) != `{"query":"Yellow"}` || actions[0].Result != "Playing Yellow" {
+		t.Fatalf("actions = %+v", actions)
+	}
+	recent, err := s.Sessions(ctx, HistoryQuery{Since: now.Add(-time.Minute)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recent) != 1 || recent[0].ID != "s2" {
+		t.Fatalf("sessions = %+v", recent)
+	}
+	if session, _ := s.Session(ctx, "s1"); session == nil || session.Title != "Music" {
+		t.Fatalf("session = %+v", session)
+	}
+
+	hits, err := s.Search(ctx, "yellow", HistoryQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hits) != 2 {
+		t.Fatalf("hits = %+v", hits)
+	}
+	for _, hit := range hits {
+		if hit.SessionID != "s1" {
+			t.Fatalf("hit from the wrong session: %+v", hit)
+		}
+	}
+	// FTS5 syntax in the text is matched as words, not parsed.
+	hits, err = s.Search(ctx, `milk" OR`, HistoryQuery{SessionID: "s2"})
+	if err != nil || len(hits) != 0 {
+		t.Fatalf("search with operators = %+v, %v", hits, err)
+	}
+	hits, err = s.Search(ctx, `"milk`, HistoryQuery{SessionID: "s2"})
+	if err != nil || len(hits) != 1 || hits[0].Kind != "message" || hits[0].Snippet != "remind me to buy [milk]" {
+		t.Fatalf("milk = %+v, %v", hits, err)
+	}
+	hits, err = s.Search(ctx, "reminders", HistoryQuery{Skill: "Reminders"})
+	if err != nil || len(hits) != 1 || hits[0].Kind != "action" {
+		t.Fatalf("skill search = %+v, %v", hits, err)
+	}
+}
+
+func TestStore_PruneRemovesWholeSessions(t *testing.T) {
+	s, now := newTestStore(t)
+	ctx := context.Background()
+	for _, id := range []string{"old", "mid", "new"} {
+		mustAppend(t, s, Message{SessionID: id, Role: "user", Content: "hello from " + id})
+		*now = now.Add(24 * time.Hour)
+	}
+
+	s.Retention = RetentionPolicy{MaxAge: 60 * time.Hour, MaxSessions: 1}
+	removed, err := s.Prune(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if removed != 2 {
+		t.Fatalf("removed %d sessions, want 2", removed)
+	}
+	if msgs, _ := s.Messages(ctx, HistoryQuery{}); len(msgs) != 1 || msgs[0].SessionID != "new" {
+		t.Fatalf("messages left = %+v", msgs)
+	}
+	if hits, _ := s.Search(ctx, "hello", HistoryQuery{}); len(hits) != 1 {
+		t.Fatalf("pruned messages still searchable: %+v", hits)
+	}
+}
+
+func TestNewStore_MigratesLegacyLogs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "memory.db")
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, stmt := range []string{
+		`CREATE TABLE logs (id INTEGER PRIMARY KEY AUTOINCREMENT, timestamp DATETIME DEFAULT CURRENT_TIMESTAMP, role TEXT, content TEXT)`,
+		`INSERT INTO logs (timestamp, role, content) VALUES ('2026-01-02 03:04:05', 'user', 'play some jazz')`,
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	db.Close()
+
+	s, err := NewStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	msgs, err := s.Messages(context.Background(), HistoryQuery{SessionID: "legacy"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	if len(msgs) != 1 || msgs[0].Content != "play some jazz" || !msgs[0].CreatedAt.Equal(want) {
+		t.Fatalf("migrated = %+v", msgs)
+	}
+	if hits, _ := s.Search(context.Background(), "jazz", HistoryQuery{}); len(hits) != 1 {
+		t.Fatalf("migrated log not searchable: %+v", hits)
+	}
+	var version int
+	s.db.QueryRow("PRAGMA user_version").Scan(&version)
+	if version != len(migrations) {
+		t.Fatalf("user_version = %d", version)
+	}
+}
+
+func mustAppend(t *testing.T, s *Store, m Message) {
+	t.Helper()
+	if _, err := s.AppendMessage(context.Background(), m); err != nil {
+		t.Fatal(err)
+	}
+}
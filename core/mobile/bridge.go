@@ -1,23 +1,152 @@
 package mobile
 
-import "github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/engine"
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/engine"
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/plugins/android"
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/protocol"
+)
+
+// maxPolledCommands caps one PollCommands batch.
+const maxPolledCommands = 16
 
 // UpCraftBridge is a gomobile-exported entrypoint that accepts simple string I/O.
 type UpCraftBridge struct {
-	agent *engine.Agent
+	agent    *engine.Agent
+	commands *android.CommandQueue
+	conv     conversations
+	// provider lets Configure swap the LLM provider under running planners.
+	provider *engine.ProviderSlot
+
+	cfgMu   sync.Mutex
+	store   SecureStore
+	secrets secrets
+	loaded  bool
+	model   string
 }
 
 func NewBridge() *UpCraftBridge {
-	return &UpCraftBridge{
-		agent: engine.NewAgent(),
+	b := &UpCraftBridge{
+		agent:    engine.NewAgent(),
+		commands: android.NewCommandQueue(),
+		provider: &engine.ProviderSlot{},
+	}
+	if b.agent.Provider != nil {
+		b.provider.Set(b.agent.Provider)
 	}
+	b.agent.Provider, b.agent.Screen.Provider = b.provider, b.provider
+	// Names are fixed and the manager is fresh, so this cannot fail.
+	_ = registerPlatformPlugins(b.agent.Plugins, b.commands)
+	return b
 }
 
+// Start brings the agent up in the background: plugins start and skills
+// sync from the cloud.
 func (b *UpCraftBridge) Start() {
-	go b.agent.Start()
+	go b.agent.Start(context.Background())
 }
 
-// ProcessScreenEvent receives screen-tree JSON and returns JSON action commands.
+// ProcessScreenEvent receives a screen report and returns a
+// protocol.ScreenAction envelope.
 func (b *UpCraftBridge) ProcessScreenEvent(inputJSON string) string {
 	return b.agent.HandleScreenInput(inputJSON)
 }
+
+// StartGoal opens a multi-step screen session for goal, e.g. "turn on dark
+// mode in Settings", and returns its ID. Zero maxSteps or timeoutMillis use
+// the engine defaults. Feed every screen the host then shows to StepGoal.
+func (b *UpCraftBridge) StartGoal(goal string, maxSteps, timeoutMillis int) (string, error) {
+	return b.agent.StartScreenGoal(goal, engine.ScreenLimits{
+		MaxSteps: maxSteps,
+		Timeout:  time.Duration(timeoutMillis) * time.Millisecond,
+	})
+}
+
+// StepGoal receives the current screen report of a goal session and
+// returns a protocol.GoalStep envelope: while "status" is "running" the
+// host executes "action" and reports the next screen; "completed" and
+// "failed" end the session and carry a "reason".
+func (b *UpCraftBridge) StepGoal(sessionID, screenJSON string) string {
+	return b.agent.StepScreenGoal(sessionID, screenJSON)
+}
+
+// CancelGoal abandons a goal session; it reports whether it was running.
+func (b *UpCraftBridge) CancelGoal(sessionID string) bool {
+	return b.agent.CancelScreenGoal(sessionID)
+}
+
+// PollCommands waits up to timeoutMillis for plugin commands and returns them
+// as a JSON array of protocol.Command envelopes in execution order, or "[]"
+// when none arrived. The host must report every returned command with
+// ReportCommand, AckCommand or FailCommand.
+func (b *UpCraftBridge) PollCommands(timeoutMillis int) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeoutMillis)*time.Millisecond)
+	defer cancel()
+	cmds, err := b.commands.Pull(ctx, maxPolledCommands)
+	if errors.Is(err, context.DeadlineExceeded) {
+		return "[]", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	raw, err := json.Marshal(cmds)
+	if err != nil {
+		return "", err
+	}
+	return string(raw), nil
+}
+
+// ReportCommand accepts a protocol.CommandAck envelope from the host.
+func (b *UpCraftBridge) ReportCommand(ackJSON string) error {
+	var ack protocol.CommandAck
+	if err := protocol.Decode([]byte(ackJSON), &ack); err != nil {
+		return err
+	}
+	return b.report(&ack)
+}
+
+// AckCommand reports that the host executed command id. resultJSON is handed
+// back to the plugin and may be empty.
+func (b *UpCraftBridge) AckCommand(id, resultJSON string) error {
+	ack := &protocol.CommandAck{ID: id, OK: true}
+	if resultJSON != "" {
+		ack.Result = json.RawMessage(resultJSON)
+	}
+	return b.report(ack)
+}
+
+// FailCommand reports that the host could not execute command id; message
+// becomes the action's error.
+func (b *UpCraftBridge) FailCommand(id, message string) error {
+	if message == "" {
+		message = "host reported failure"
+	}
+	return b.report(&protocol.CommandAck{ID: id, Error: message})
+}
+
+func (b *UpCraftBridge) report(ack *protocol.CommandAck) error {
+	protocol.Stamp(ack)
+	if err := ack.Validate(); err != nil {
+		return err
+	}
+	if !ack.OK {
+		return b.commands.Fail(ack.ID, ack.Error)
+	}
+	return b.commands.Ack(ack.ID, ack.Result)
+}
+
+// ProtocolVersion is the envelope protocol version this core speaks; hosts
+// built against another version should refuse to start.
+func (b *UpCraftBridge) ProtocolVersion() int {
+	return protocol.Version
+}
+
+// ProtocolSchema returns the JSON Schema describing every envelope.
+func (b *UpCraftBridge) ProtocolSchema() string {
+	return string(protocol.Schema())
+}
@@ -0,0 +1,287 @@
+package mobile
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/engine"
+)
+
+// ConfigVersion is the configuration document version this core reads.
+const ConfigVersion = 1
+
+// secretsKey is the one entry the core keeps in the host's SecureStore.
+const secretsKey = "upcraft.secrets"
+
+// Config is the document hosts pass to Configure, replacing the environment
+// variables the desktop build reads. Fields left out keep their current
+// value; secrets left out are taken from the SecureStore.
+//
+//	{
+//	  "version": 1,
+//	  "provider": {"api_key": "sk-or-...", "model": "openai/gpt-4o-mini"},
+//	  "rag_url": "https://rag.example.com",
+//	  "plugins": {"music": {"client_id": "...", "refresh_token": "..."}}
+//	}
+type Config struct {
+	Version  int            `json:"version"`
+	Provider ProviderConfig `json:"provider,omitempty"`
+	// RAGURL is the skill sync backend.
+	RAGURL string `json:"rag_url,omitempty"`
+	// Plugins holds settings, usually credentials, per registered plugin
+	// name. They are all treated as secrets. On Android the "music" plugin
+	// takes a Spotify login; see android.MusicPlugin.Configure.
+	Plugins map[string]map[string]string `json:"plugins,omitempty"`
+}
+
+// ProviderConfig selects the OpenRouter model and its API key.
+type ProviderConfig struct {
+	APIKey string `json:"api_key,omitempty"`
+	Model  string `json:"model,omitempty"`
+}
+
+// ConfigError lists every problem found in a configuration document.
+type ConfigError struct {
+	Problems []string
+}
+
+func (e *ConfigError) Error() string {
+	return "invalid config: " + strings.Join(e.Problems, "; ")
+}
+
+// SecureStore is implemented by the host on top of platform secure storage,
+// such as the Android Keystore. Load returns "" for a missing key. Without a
+// store, secrets live in memory only and must be sent again after a restart.
+type SecureStore interface {
+	Load(key string) (string, error)
+	Save(key, value string) error
+}
+
+// secrets are the parts of Config that are never written to disk by the
+// core.
+type secrets struct {
+	APIKey  string                       `json:"api_key,omitempty"`
+	Plugins map[string]map[string]string `json:"plugins,omitempty"`
+}
+
+// SetSecureStore sets where secrets are kept across restarts. Secrets
+// already held in memory are written to it on the next Configure.
+func (b *UpCraftBridge) SetSecureStore(store SecureStore) {
+	b.cfgMu.Lock()
+	defer b.cfgMu.Unlock()
+	b.store = store
+	b.loaded = false
+}
+
+// Configure applies a Config document. It can be called before Start and
+// again whenever settings change; runs already in flight keep the provider
+// they started with. An invalid document changes nothing and returns a
+// ConfigError naming every problem. Plugin settings are applied last, and
+// a plugin rejecting its settings does not undo the rest.
+func (b *UpCraftBridge) Configure(configJSON string) error {
+	if b.conv.isClosed() {
+		return ErrShutdown
+	}
+	cfg, err := parseConfig(configJSON)
+	if err != nil {
+		return err
+	}
+
+	b.cfgMu.Lock()
+	defer b.cfgMu.Unlock()
+	if err := b.loadSecrets(); err != nil {
+		return err
+	}
+	if cfg.Provider.APIKey == "" {
+		cfg.Provider.APIKey = b.secrets.APIKey
+	}
+	if cfg.Provider.Model == "" {
+		cfg.Provider.Model = b.model
+	}
+	if err := cfg.validate(b.agent.Plugins); err != nil {
+		return err
+	}
+
+	if cfg.Provider.APIKey != "" {
+		// Validated above, so this cannot fail.
+		provider, _ := engine.NewOpenRouterProvider(cfg.Provider.APIKey, cfg.Provider.Model)
+		b.provider.Set(provider)
+		b.secrets.APIKey, b.model = cfg.Provider.APIKey, cfg.Provider.Model
+	}
+	if cfg.RAGURL != "" && b.agent.RAG != nil && cfg.RAGURL != b.agent.RAG.BaseURL {
+		b.agent.RAG.SetBaseURL(cfg.RAGURL)
+		b.resync()
+	}
+
+	var problems []string
+	for name := range b.secrets.Plugins {
+		if _, ok := cfg.Plugins[name]; ok {
+			continue
+		}
+		// Stored credentials are reapplied so a restart needs no secrets;
+		// plugins no longer registered are skipped.
+		if _, ok := b.agent.Plugins.Get(name); ok {
+			if err := b.agent.Plugins.Configure(name, b.secrets.Plugins[name]); err != nil {
+				problems = append(problems, fmt.Sprintf("plugins.%s (stored): %v", name, err))
+			}
+		}
+	}
+	for name, settings := range cfg.Plugins {
+		merged := map[string]string{}
+		for k, v := range b.secrets.Plugins[name] {
+			merged[k] = v
+		}
+		for k, v := range settings {
+			merged[k] = v
+		}
+		if err := b.agent.Plugins.Configure(name, merged); err != nil {
+			problems = append(problems, fmt.Sprintf("plugins.%s: %v", name, err))
+			continue
+		}
+		if b.secrets.Plugins == nil {
+			b.secrets.Plugins = map[string]map[string]string{}
+		}
+		b.secrets.Plugins[name] = merged
+	}
+	if err := b.saveSecrets(); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return &ConfigError{Problems: problems}
+	}
+	return nil
+}
+
+// ClearSecrets forgets every secret, in memory and in the SecureStore, and
+// leaves the agent without a provider until the next Configure.
+func (b *UpCraftBridge) ClearSecrets() error {
+	b.cfgMu.Lock()
+	defer b.cfgMu.Unlock()
+	b.secrets, b.loaded = secrets{}, true
+	b.provider.Set(nil)
+	if b.store == nil {
+		return nil
+	}
+	if err := b.store.Save(secretsKey, ""); err != nil {
+		return fmt.Errorf("clear secure storage: %w", err)
+	}
+	return nil
+}
+
+// parseConfig strictly decodes raw, so misspelled fields are reported
+// rather than ignored.
+func parseConfig(raw string) (*Config, error) {
+	dec := json.NewDecoder(bytes.NewReader([]byte(raw)))
+	dec.DisallowUnknownFields()
+	var cfg Config
+	if err := dec.Decode(&cfg); err != nil {
+		return nil, &ConfigError{Problems: []string{"not a valid config document: " + err.Error()}}
+	}
+	cfg.Provider.APIKey = strings.TrimSpace(cfg.Provider.APIKey)
+	cfg.Provider.Model = strings.TrimSpace(cfg.Provider.Model)
+	cfg.RAGURL = strings.TrimRight(strings.TrimSpace(cfg.RAGURL), "/")
+	return &cfg, nil
+}
+
+// validate checks cfg, with secrets already filled in, against the
+// plugins registered on plugins.
+func (c *Config) validate(plugins *engine.PluginManager) error {
+	var problems []string
+	if c.Version != ConfigVersion {
+		problems = append(problems, fmt.Sprintf("version: must be %d, got %d", ConfigVersion, c.Version))
+	}
+	if c.Provider.Model != "" && c.Provider.APIKey == "" {
+		problems = append(problems, "provider.api_key: required to use provider.model")
+	}
+	if strings.ContainsAny(c.Provider.Model, " \t\n") {
+		problems = append(problems, fmt.Sprintf("provider.model: %q contains spaces", c.Provider.Model))
+	}
+	if c.RAGURL != "" {
+		if u, err := url.Parse(c.RAGURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			problems = append(problems, fmt.Sprintf("rag_url: %q is not an http or https URL", c.RAGURL))
+		}
+	}
+	for name, settings := range c.Plugins {
+		impl, ok := plugins.Get(name)
+		if !ok {
+			problems = append(problems, fmt.Sprintf("plugins.%s: no such plugin", name))
+			continue
+		}
+		if _, ok := impl.(engine.PluginConfigurer); !ok {
+			problems = append(problems, fmt.Sprintf("plugins.%s: takes no settings", name))
+		}
+		for key, value := range settings {
+			if strings.TrimSpace(value) == "" {
+				problems = append(problems, fmt.Sprintf("plugins.%s.%s: is empty", name, key))
+			}
+		}
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return &ConfigError{Problems: problems}
+	}
+	return nil
+}
+
+// loadSecrets reads the SecureStore once. The caller holds b.cfgMu.
+func (b *UpCraftBridge) loadSecrets() error {
+	if b.loaded || b.store == nil {
+		return nil
+	}
+	raw, err := b.store.Load(secretsKey)
+	if err != nil {
+		return fmt.Errorf("read secure storage: %w", err)
+	}
+	if raw != "" {
+		var stored secrets
+		if err := json.Unmarshal([]byte(raw), &stored); err != nil {
+			return fmt.Errorf("read secure storage: %w", err)
+		}
+		// Secrets given since the store was set win over stored ones.
+		if b.secrets.APIKey == "" {
+			b.secrets.APIKey = stored.APIKey
+		}
+		for name, settings := range stored.Plugins {
+			if _, ok := b.secrets.Plugins[name]; ok {
+				continue
+			}
+			if b.secrets.Plugins == nil {
+				b.secrets.Plugins = map[string]map[string]string{}
+			}
+			b.secrets.Plugins[name] = settings
+		}
+	}
+	b.loaded = true
+	return nil
+}
+
+// saveSecrets writes the secrets to the SecureStore, if there is one. The
+// caller holds b.cfgMu.
+func (b *UpCraftBridge) saveSecrets() error {
+	if b.store == nil {
+		return nil
+	}
+	raw, err := json.Marshal(b.secrets)
+	if err != nil {
+		return err
+	}
+	if err := b.store.Save(secretsKey, string(raw)); err != nil {
+		return fmt.Errorf("write secure storage: %w", err)
+	}
+	return nil
+}
+
+// resync syncs skills from a new backend in the background; Shutdown
+// cancels it and waits for it.
+func (b *UpCraftBridge) resync() {
+	b.conv.background(func(ctx context.Context) {
+		// Failures are kept for SyncStatus; the cached catalog stays.
+		_ = b.agent.SyncSkills(ctx)
+	})
+}
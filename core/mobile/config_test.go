@@ -0,0 +1,140 @@
+package mobile
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/engine"
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/memory"
+)
+
+type memoryStore map[string]string
+
+func (s memoryStore) Load(key string) (string, error) { return s[key], nil }
+
+func (s memoryStore) Save(key, value string) error {
+	s[key] = value
+	return nil
+}
+
+// tokenPlugin accepts a "token" setting.
+type tokenPlugin struct{ token string }
+
+func (p *tokenPlugin) Configure(settings map[string]string) error {
+	if settings["token"] == "" {
+		return errors.New("token is required")
+	}
+	p.token = settings["token"]
+	return nil
+}
+
+func newConfigBridge(t *testing.T) (*UpCraftBridge, *tokenPlugin) {
+	t.Helper()
+	b, _ := newTestBridge(t)
+	b.provider = &engine.ProviderSlot{}
+	b.agent.RAG = memory.NewRAGClient("http://localhost:8080")
+	plugin := &tokenPlugin{}
+	if err := b.agent.Plugins.Register("notes", plugin); err != nil {
+		t.Fatal(err)
+	}
+	return b, plugin
+}
+
+func TestConfigure_ReportsEveryProblem(t *testing.T) {
+	b, _ := newConfigBridge(t)
+	err := b.Configure(`{"version":2,"provider":{"model":"gpt 4"},"rag_url":"ftp://x","plugins":{"weather":{"key":"k"},"notes":{"token":" "}}}`)
+	var cfgErr *ConfigError
+	if !errors.As(err, &cfgErr) {
+		t.Fatalf("err = %v", err)
+	}
+	want := []string{
+		"plugins.notes.token: is empty",
+		"plugins.weather: no such plugin",
+		"provider.api_key: required to use provider.model",
+		`provider.model: "gpt 4" contains spaces`,
+		`rag_url: "ftp://x" is not an http or https URL`,
+		"version: must be 1, got 2",
+	}
+	if strings.Join(cfgErr.Problems, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("problems:\n%s", strings.Join(cfgErr.Problems, "\n"))
+	}
+	if b.provider.Get() != nil || b.agent.RAG.BaseURL != "http://localhost:8080" {
+		t.Fatal("an invalid config was partly applied")
+	}
+
+	if err := b.Configure(`{"version":1,"rag_ur":"https://rag.example.com"}`); err == nil || !strings.Contains(err.Error(), `unknown field "rag_ur"`) {
+		t.Fatalf("misspelled field: %v", err)
+	}
+}
+
+func TestConfigure_KeepsSecretsInTheSecureStore(t *testing.T) {
+	store := memoryStore{}
+	b, plugin := newConfigBridge(t)
+	b.SetSecureStore(store)
+	err := b.Configure(`{"version":1,"provider":{"api_key":"sk-1","model":"openai/gpt-4o-mini"},"rag_url":"https://rag.example.com/","plugins":{"notes":{"token":"t-1"}}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := b.provider.GetDefaultModel(); got != "openai/gpt-4o-mini" {
+		t.Fatalf("model = %q", got)
+	}
+	if b.agent.RAG.BaseURL != "https://rag.example.com" || plugin.token != "t-1" {
+		t.Fatalf("rag = %s, token = %q", b.agent.RAG.BaseURL, plugin.token)
+	}
+	if raw := store[secretsKey]; !strings.Contains(raw, "sk-1") || !strings.Contains(raw, "t-1") {
+		t.Fatalf("stored secrets = %s", raw)
+	}
+
+	// A restarted host sends no secrets; they come back from the store.
+	restarted, plugin := newConfigBridge(t)
+	restarted.SetSecureStore(store)
+	if err := restarted.Configure(`{"version":1}`); err != nil {
+		t.Fatal(err)
+	}
+	if restarted.provider.Get() == nil || plugin.token != "t-1" {
+		t.Fatalf("secrets not restored: provider %v, token %q", restarted.provider.Get(), plugin.token)
+	}
+
+	if err := restarted.ClearSecrets(); err != nil {
+		t.Fatal(err)
+	}
+	if restarted.provider.Get() != nil || store[secretsKey] != "" {
+		t.Fatal("secrets survived ClearSecrets")
+	}
+}
+
+func TestShutdown_CancelsSkillResync(t *testing.T) {
+	requested := make(chan struct{}, 1)
+	rag := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case requested <- struct{}{}:
+		default:
+		}
+		<-r.Context().Done()
+	}))
+	defer rag.Close()
+
+	b, _ := newConfigBridge(t)
+	if err := b.Configure(`{"version":1,"rag_url":"` + rag.URL + `"}`); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-requested:
+	case <-time.After(2 * time.Second):
+		t.Fatal("no skill sync started")
+	}
+	done := make(chan struct{})
+	go func() {
+		b.Shutdown()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Shutdown waited for the skill sync")
+	}
+}
@@ -0,0 +1,344 @@
+package mobile
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/engine"
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/protocol"
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/skills"
+)
+
+const (
+	// confirmTimeout declines a confirmation request nobody answered.
+	confirmTimeout = 2 * time.Minute
+	// shutdownTimeout bounds stopping the plugins in Shutdown.
+	shutdownTimeout = 10 * time.Second
+)
+
+// ErrShutdown is returned by calls made after Shutdown.
+var ErrShutdown = errors.New("bridge is shut down")
+
+// EventListener is implemented by the host to follow prompts. OnEvent
+// receives one protocol.Event envelope per call, in seq order for each
+// session; it is called from a background thread and should return quickly.
+type EventListener interface {
+	OnEvent(eventJSON string)
+}
+
+// HostSkill is a skill implemented by the host. Call runs one action with
+// its input as a JSON object and returns the text reported to the model and
+// shown to the user; an error fails the action.
+type HostSkill interface {
+	Call(action, inputJSON string) (string, error)
+}
+
+// HostAction describes one action in RegisterSkill's actionsJSON. Actions
+// with side effects the user should approve set Confirm.
+type HostAction struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	InputSchema map[string]interface{} `json:"input_schema,omitempty"`
+	Confirm     bool                   `json:"confirm,omitempty"`
+}
+
+// conversations tracks running prompts and the confirmations they wait on.
+type conversations struct {
+	mu       sync.Mutex
+	listener EventListener
+	running  map[string]context.CancelFunc
+	pending  map[string]chan bool
+	nextID   int
+	closed   bool
+	wg       sync.WaitGroup
+	// stopBackground cancels work started with background.
+	stopBackground context.CancelFunc
+	backgroundCtx  context.Context
+}
+
+// background runs fn on its own goroutine with a context Shutdown cancels,
+// and Shutdown waits for it. After Shutdown it does nothing.
+func (c *conversations) background(fn func(ctx context.Context)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return
+	}
+	if c.backgroundCtx == nil {
+		c.backgroundCtx, c.stopBackground = context.WithCancel(context.Background())
+	}
+	ctx := c.backgroundCtx
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		fn(ctx)
+	}()
+}
+
+// SetListener sets the receiver of prompt events; nil drops them.
+func (b *UpCraftBridge) SetListener(l EventListener) {
+	b.conv.mu.Lock()
+	defer b.conv.mu.Unlock()
+	b.conv.listener = l
+}
+
+// SubmitPrompt starts answering prompt in the background and returns at
+// once. Progress arrives on the listener as events for sessionID, ending
+// with "final_answer", "error" or "cancelled". A session runs one prompt
+// at a time.
+func (b *UpCraftBridge) SubmitPrompt(sessionID, prompt string) error {
+	if strings.TrimSpace(sessionID) == "" {
+		return errors.New("session id is required")
+	}
+	if strings.TrimSpace(prompt) == "" {
+		return errors.New("prompt is empty")
+	}
+	c := &b.conv
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return ErrShutdown
+	}
+	if _, busy := c.running[sessionID]; busy {
+		return fmt.Errorf("session %s is already running a prompt", sessionID)
+	}
+	if c.running == nil {
+		c.running = map[string]context.CancelFunc{}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	c.running[sessionID] = cancel
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		b.run(ctx, cancel, sessionID, prompt)
+	}()
+	return nil
+}
+
+// CancelPrompt stops the prompt running in sessionID; it reports whether
+// there was one. The session still ends with a "cancelled" event.
+func (b *UpCraftBridge) CancelPrompt(sessionID string) bool {
+	b.conv.mu.Lock()
+	defer b.conv.mu.Unlock()
+	cancel, ok := b.conv.running[sessionID]
+	if ok {
+		cancel()
+	}
+	return ok
+}
+
+// Confirm answers a "confirmation_request" event. Unanswered requests are
+// declined after two minutes or when their prompt is cancelled.
+func (b *UpCraftBridge) Confirm(confirmationID string, approved bool) error {
+	b.conv.mu.Lock()
+	defer b.conv.mu.Unlock()
+	answer, ok := b.conv.pending[confirmationID]
+	if !ok {
+		return fmt.Errorf("no pending confirmation %s", confirmationID)
+	}
+	delete(b.conv.pending, confirmationID)
+	answer <- approved
+	return nil
+}
+
+// ListSkills returns the skills the agent may use as a JSON array of skill
+// definitions.
+func (b *UpCraftBridge) ListSkills() (string, error) {
+	if b.conv.isClosed() {
+		return "", ErrShutdown
+	}
+	exposed := b.agent.Skills().Exposed
+	if exposed == nil {
+		exposed = []skills.SkillDefinition{}
+	}
+	raw, err := json.Marshal(exposed)
+	if err != nil {
+		return "", err
+	}
+	return string(raw), nil
+}
+
+// RegisterSkill adds a skill implemented by the host. actionsJSON is a JSON
+// array of HostAction. The skill is available to the next prompt whether
+// or not the cloud catalog describes it.
+func (b *UpCraftBridge) RegisterSkill(name, description, actionsJSON string, skill HostSkill) error {
+	if b.conv.isClosed() {
+		return ErrShutdown
+	}
+	if skill == nil {
+		return errors.New("skill implementation is required")
+	}
+	var defs []HostAction
+	if err := json.Unmarshal([]byte(actionsJSON), &defs); err != nil {
+		return fmt.Errorf("decode actions: %w", err)
+	}
+	actions := make([]engine.RegisteredAction, 0, len(defs))
+	for _, def := range defs {
+		actions = append(actions, engine.RegisteredAction{
+			Action:      def.Name,
+			Description: def.Description,
+			InputSchema: def.InputSchema,
+			Confirm:     def.Confirm,
+			Handler:     hostHandler(skill, name, def.Name),
+		})
+	}
+	return b.agent.AddSkill(skills.SkillDefinition{Name: name, Description: description}, actions)
+}
+
+func hostHandler(skill HostSkill, name, action string) engine.ActionHandler {
+	return func(_ context.Context, input map[string]interface{}) *engine.ActionResult {
+		raw, err := json.Marshal(input)
+		if err != nil {
+			return engine.ErrorResult("action input is not encodable", err)
+		}
+		out, err := skill.Call(action, string(raw))
+		if err != nil {
+			return engine.ErrorResult(fmt.Sprintf("%s.%s failed", name, action), err)
+		}
+		return engine.SuccessResult(out, out)
+	}
+}
+
+// Shutdown cancels running prompts and background syncs, waits for them to
+// end, stops the plugins and releases hosts blocked in PollCommands. Later
+// calls fail with ErrShutdown; calling it again does nothing.
+func (b *UpCraftBridge) Shutdown() error {
+	c := &b.conv
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil
+	}
+	c.closed = true
+	for _, cancel := range c.running {
+		cancel()
+	}
+	if c.stopBackground != nil {
+		c.stopBackground()
+	}
+	c.mu.Unlock()
+	c.wg.Wait()
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	err := b.agent.Stop(ctx)
+	b.commands.Close()
+	return err
+}
+
+// run answers one prompt and reports how it ended. The session is freed
+// before the last event is sent, so the host may submit again from it.
+func (b *UpCraftBridge) run(ctx context.Context, cancel context.CancelFunc, sessionID, prompt string) {
+	seq := 0
+	// invalid is set when an event fails validation. The prompt is then
+	// cancelled and ends with this error instead, so the host sees the
+	// session end rather than an event go missing.
+	var invalid *protocol.Event
+	send := func(ev protocol.Event) {
+		if invalid != nil {
+			return
+		}
+		seq++
+		ev.SessionID, ev.Seq = sessionID, seq
+		if err := b.conv.send(&ev); err != nil {
+			invalid = &protocol.Event{Type: protocol.EventError, Error: err.Error()}
+			seq--
+			cancel()
+		}
+	}
+	var final *protocol.Event
+	hooks := engine.LoopHooks{
+		Events: func(ev protocol.Event) {
+			if ev.Type == protocol.EventFinalAnswer {
+				final = &ev
+				return
+			}
+			send(ev)
+		},
+		Confirm: func(ctx context.Context, tool, action string, input map[string]interface{}) bool {
+			return b.conv.confirm(ctx, send, sessionID, tool, action, input)
+		},
+	}
+	_, err := b.agent.Run(ctx, prompt, hooks)
+	switch {
+	case err == nil:
+	case ctx.Err() != nil:
+		final = &protocol.Event{Type: protocol.EventCancelled}
+	case err != nil:
+		final = &protocol.Event{Type: protocol.EventError, Error: err.Error()}
+	}
+	if invalid != nil {
+		final, invalid = invalid, nil
+	}
+	b.conv.finish(sessionID)
+	if final != nil {
+		send(*final)
+	}
+}
+
+// confirm sends a confirmation request and waits for Confirm.
+func (c *conversations) confirm(ctx context.Context, send func(protocol.Event), sessionID, tool, action string, input map[string]interface{}) bool {
+	answer := make(chan bool, 1)
+	c.mu.Lock()
+	c.nextID++
+	id := sessionID + "-" + strconv.Itoa(c.nextID)
+	if c.pending == nil {
+		c.pending = map[string]chan bool{}
+	}
+	c.pending[id] = answer
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+	}()
+
+	send(protocol.Event{Type: protocol.EventConfirmation, ConfirmationID: id, Tool: tool, Action: action, Input: input})
+	timer := time.NewTimer(confirmTimeout)
+	defer timer.Stop()
+	select {
+	case approved := <-answer:
+		return approved
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return false
+	}
+}
+
+// send delivers ev to the listener, or reports why it is not a valid
+// event.
+func (c *conversations) send(ev *protocol.Event) error {
+	raw, err := protocol.Encode(ev)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	l := c.listener
+	c.mu.Unlock()
+	if l != nil {
+		l.OnEvent(string(raw))
+	}
+	return nil
+}
+
+func (c *conversations) finish(sessionID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if cancel, ok := c.running[sessionID]; ok {
+		cancel()
+		delete(c.running, sessionID)
+	}
+}
+
+func (c *conversations) isClosed() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.closed
+}
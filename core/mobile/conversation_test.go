@@ -0,0 +1,156 @@
+package mobile
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/engine"
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/plugins/android"
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/protocol"
+)
+
+// scriptedProvider replies in order and, once out of replies, blocks until
+// the run is cancelled.
+type scriptedProvider struct{ replies []string }
+
+func (p *scriptedProvider) Chat(ctx context.Context, _ []engine.Message, _ []engine.ToolDefinition, _ string, _ map[string]interface{}) (*engine.LLMResponse, error) {
+	if len(p.replies) == 0 {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	reply := p.replies[0]
+	p.replies = p.replies[1:]
+	return &engine.LLMResponse{Content: reply}, nil
+}
+
+func (p *scriptedProvider) GetDefaultModel() string { return "test" }
+
+type eventRecorder chan protocol.Event
+
+func (r eventRecorder) OnEvent(eventJSON string) {
+	var ev protocol.Event
+	if err := protocol.Decode([]byte(eventJSON), &ev); err != nil {
+		panic(err)
+	}
+	r <- ev
+}
+
+func (r eventRecorder) next(t *testing.T) protocol.Event {
+	t.Helper()
+	select {
+	case ev := <-r:
+		return ev
+	case <-time.After(2 * time.Second):
+		t.Fatal("no event")
+		return protocol.Event{}
+	}
+}
+
+type notesSkill struct{ inputs chan string }
+
+func (s notesSkill) Call(action, inputJSON string) (string, error) {
+	s.inputs <- action + " " + inputJSON
+	return "Noted", nil
+}
+
+func newTestBridge(t *testing.T, replies ...string) (*UpCraftBridge, eventRecorder) {
+	t.Helper()
+	b := &UpCraftBridge{
+		agent: &engine.Agent{
+			Provider: &scriptedProvider{replies: replies},
+			Registry: engine.NewRegistry(),
+			Plugins:  engine.NewPluginManager(),
+		},
+		commands: android.NewCommandQueue(),
+	}
+	events := make(eventRecorder, 16)
+	b.SetListener(events)
+	t.Cleanup(func() { b.Shutdown() })
+	return b, events
+}
+
+func TestBridge_StreamsPromptAndAsksBeforeHostSkill(t *testing.T) {
+	b, events := newTestBridge(t,
+		`{"tool":"Notes","action":"Add","input":{"text":"milk"},"response":"Adding it"}`,
+		`{"response":"Added milk","done":true}`,
+	)
+	notes := notesSkill{inputs: make(chan string, 1)}
+	if err := b.RegisterSkill("Notes", "Jot things down", `[{"name":"Add","description":"Add a note","confirm":true}]`, notes); err != nil {
+		t.Fatal(err)
+	}
+	raw, err := b.ListSkills()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var listed []struct{ Name, Description string }
+	if err := json.Unmarshal([]byte(raw), &listed); err != nil || len(listed) != 1 || listed[0].Description != "Jot things down" {
+		t.Fatalf("skills = %s (%v)", raw, err)
+	}
+
+	if err := b.SubmitPrompt("s1", "remember milk"); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []protocol.EventType{protocol.EventPartialText, protocol.EventActionStarted} {
+		if ev := events.next(t); ev.Type != want {
+			t.Fatalf("event = %+v, want %s", ev, want)
+		}
+	}
+	ask := events.next(t)
+	if ask.Type != protocol.EventConfirmation || ask.Tool != "Notes" || ask.Input["text"] != "milk" {
+		t.Fatalf("confirmation = %+v", ask)
+	}
+	select {
+	case got := <-notes.inputs:
+		t.Fatalf("skill ran before approval: %s", got)
+	default:
+	}
+	if err := b.Confirm(ask.ConfirmationID, true); err != nil {
+		t.Fatal(err)
+	}
+	if got := <-notes.inputs; got != `Add {"text":"milk"}` {
+		t.Fatalf("skill call = %s", got)
+	}
+	if ev := events.next(t); ev.Type != protocol.EventActionFinished || ev.Text != "Noted" || ev.Error != "" {
+		t.Fatalf("finished = %+v", ev)
+	}
+	final := events.next(t)
+	if final.Type != protocol.EventFinalAnswer || final.Text != "Added milk" || final.Seq != 5 {
+		t.Fatalf("final = %+v", final)
+	}
+	// The session is free as soon as its last event arrives.
+	if err := b.SubmitPrompt("s1", "again"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBridge_CancelAndShutdown(t *testing.T) {
+	b, events := newTestBridge(t)
+	if err := b.SubmitPrompt("s1", "play something"); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.SubmitPrompt("s1", "and more"); err == nil {
+		t.Fatal("second prompt in a busy session was accepted")
+	}
+	if !b.CancelPrompt("s1") {
+		t.Fatal("no prompt to cancel")
+	}
+	if ev := events.next(t); ev.Type != protocol.EventCancelled || ev.SessionID != "s1" {
+		t.Fatalf("event = %+v", ev)
+	}
+
+	if err := b.SubmitPrompt("s2", "play something"); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Shutdown(); err != nil {
+		t.Fatal(err)
+	}
+	if ev := events.next(t); ev.Type != protocol.EventCancelled || ev.SessionID != "s2" {
+		t.Fatalf("event = %+v", ev)
+	}
+	if err := b.SubmitPrompt("s3", "hello"); !errors.Is(err, ErrShutdown) {
+		t.Fatalf("submit after shutdown = %v", err)
+	}
+}
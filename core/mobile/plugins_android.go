@@ -0,0 +1,17 @@
+//go:build android
+
+package mobile
+
+import (
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/engine"
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/plugins/android"
+)
+
+// registerPlatformPlugins registers the plugins that run their actions on
+// the host through queue.
+func registerPlatformPlugins(plugins *engine.PluginManager, queue *android.CommandQueue) error {
+	if err := plugins.Register("music", android.NewMusicPlugin(queue)); err != nil {
+		return err
+	}
+	return plugins.Register("calendar", android.NewCalendarPlugin(queue))
+}
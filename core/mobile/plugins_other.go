@@ -0,0 +1,14 @@
+//go:build !android
+
+package mobile
+
+import (
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/engine"
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/plugins/android"
+)
+
+// registerPlatformPlugins has nothing to register off Android; the bridge
+// still builds for host-side tooling.
+func registerPlatformPlugins(*engine.PluginManager, *android.CommandQueue) error {
+	return nil
+}
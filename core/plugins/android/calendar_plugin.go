@@ -0,0 +1,275 @@
+//go:build android
+
+package android
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/skills"
+)
+
+const maxListedOccurrences = 500
+
+// CalendarPlugin turns calendar and reminder actions into command envelopes
+// the host app applies to the platform calendar (CalendarContract). It keeps a
+// mirror of entries so List works offline; the host refreshes the mirror with
+// SyncEvents and SyncReminders and must keep the ids it was given, e.g. in the
+// platform entry's sync id. The mirror changes only after the host acks.
+type CalendarPlugin struct {
+	queue *CommandQueue
+
+	mu        sync.Mutex
+	events    map[string]skills.Event
+	reminders map[string]skills.Reminder
+}
+
+var (
+	_ skills.Calendar  = (*CalendarPlugin)(nil)
+	_ skills.Reminders = (*CalendarPlugin)(nil)
+)
+
+func NewCalendarPlugin(queue *CommandQueue) *CalendarPlugin {
+	return &CalendarPlugin{
+		queue:     queue,
+		events:    map[string]skills.Event{},
+		reminders: map[string]skills.Reminder{},
+	}
+}
+
+func (p *CalendarPlugin) CreateEvent(ctx context.Context, event skills.Event) (*skills.Event, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if err := event.Normalize(); err != nil {
+		return nil, err
+	}
+	event.ID = newEntryID()
+	if _, err := p.queue.Submit(ctx, "Calendar", "CREATE_EVENT", map[string]interface{}{"event": event}); err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	p.events[event.ID] = event
+	p.mu.Unlock()
+	return &event, nil
+}
+
+func (p *CalendarPlugin) ListEvents(ctx context.Context, from, to time.Time) ([]skills.Event, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if !to.After(from) {
+		return nil, fmt.Errorf("to must be after from")
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var out []skills.Event
+	for _, e := range p.events {
+		out = append(out, e.Occurrences(from, to, maxListedOccurrences)...)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Start.Before(out[j].Start) })
+	if len(out) > maxListedOccurrences {
+		out = out[:maxListedOccurrences]
+	}
+	return out, nil
+}
+
+func (p *CalendarPlugin) UpdateEvent(ctx context.Context, event skills.Event) (*skills.Event, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(event.ID) == "" {
+		return nil, fmt.Errorf("id is required")
+	}
+	if err := event.Normalize(); err != nil {
+		return nil, err
+	}
+
+	if !p.hasEvent(event.ID) {
+		return nil, fmt.Errorf("event %s not found", event.ID)
+	}
+	if _, err := p.queue.Submit(ctx, "Calendar", "UPDATE_EVENT", map[string]interface{}{"event": event}); err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	p.events[event.ID] = event
+	p.mu.Unlock()
+	return &event, nil
+}
+
+func (p *CalendarPlugin) DeleteEvent(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if !p.hasEvent(id) {
+		return fmt.Errorf("event %s not found", id)
+	}
+	if _, err := p.queue.Submit(ctx, "Calendar", "DELETE_EVENT", map[string]interface{}{"id": id}); err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	delete(p.events, id)
+	p.mu.Unlock()
+	return nil
+}
+
+func (p *CalendarPlugin) CreateReminder(ctx context.Context, reminder skills.Reminder) (*skills.Reminder, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if err := reminder.Normalize(); err != nil {
+		return nil, err
+	}
+	reminder.ID = newEntryID()
+	if _, err := p.queue.Submit(ctx, "Reminders", "CREATE_REMINDER", map[string]interface{}{"reminder": reminder}); err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	p.reminders[reminder.ID] = reminder
+	p.mu.Unlock()
+	return &reminder, nil
+}
+
+func (p *CalendarPlugin) ListReminders(ctx context.Context, includeDone bool) ([]skills.Reminder, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var out []skills.Reminder
+	for _, r := range p.reminders {
+		if r.Done && !includeDone {
+			continue
+		}
+		out = append(out, r)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Due.Before(out[j].Due) })
+	return out, nil
+}
+
+// UpdateReminder completing a recurring reminder moves it to its next due
+// time instead of closing it, matching the desktop calendar.
+func (p *CalendarPlugin) UpdateReminder(ctx context.Context, reminder skills.Reminder) (*skills.Reminder, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(reminder.ID) == "" {
+		return nil, fmt.Errorf("id is required")
+	}
+	if err := reminder.Normalize(); err != nil {
+		return nil, err
+	}
+	if reminder.Done && reminder.Recurrence != nil {
+		if next, ok := reminder.NextDue(reminder.Due.Add(time.Second)); ok {
+			reminder.Due, reminder.Done = next, false
+			if reminder.Recurrence.Count > 0 {
+				reminder.Recurrence.Count--
+			}
+		}
+	}
+
+	if !p.hasReminder(reminder.ID) {
+		return nil, fmt.Errorf("reminder %s not found", reminder.ID)
+	}
+	if _, err := p.queue.Submit(ctx, "Reminders", "UPDATE_REMINDER", map[string]interface{}{"reminder": reminder}); err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	p.reminders[reminder.ID] = reminder
+	p.mu.Unlock()
+	return &reminder, nil
+}
+
+func (p *CalendarPlugin) DeleteReminder(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if !p.hasReminder(id) {
+		return fmt.Errorf("reminder %s not found", id)
+	}
+	if _, err := p.queue.Submit(ctx, "Reminders", "DELETE_REMINDER", map[string]interface{}{"id": id}); err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	delete(p.reminders, id)
+	p.mu.Unlock()
+	return nil
+}
+
+// SyncEvents replaces the mirror with the host's JSON array of skills.Event.
+func (p *CalendarPlugin) SyncEvents(eventsJSON string) error {
+	var events []skills.Event
+	if err := json.Unmarshal([]byte(eventsJSON), &events); err != nil {
+		return fmt.Errorf("decode events: %w", err)
+	}
+	mirror := make(map[string]skills.Event, len(events))
+	for _, e := range events {
+		if strings.TrimSpace(e.ID) == "" {
+			return fmt.Errorf("synced event %q has no id", e.Title)
+		}
+		if err := e.Normalize(); err != nil {
+			return fmt.Errorf("synced event %s: %w", e.ID, err)
+		}
+		mirror[e.ID] = e
+	}
+	p.mu.Lock()
+	p.events = mirror
+	p.mu.Unlock()
+	return nil
+}
+
+// SyncReminders replaces the mirror with the host's JSON array of skills.Reminder.
+func (p *CalendarPlugin) SyncReminders(remindersJSON string) error {
+	var reminders []skills.Reminder
+	if err := json.Unmarshal([]byte(remindersJSON), &reminders); err != nil {
+		return fmt.Errorf("decode reminders: %w", err)
+	}
+	mirror := make(map[string]skills.Reminder, len(reminders))
+	for _, r := range reminders {
+		if strings.TrimSpace(r.ID) == "" {
+			return fmt.Errorf("synced reminder %q has no id", r.Title)
+		}
+		if err := r.Normalize(); err != nil {
+			return fmt.Errorf("synced reminder %s: %w", r.ID, err)
+		}
+		mirror[r.ID] = r
+	}
+	p.mu.Lock()
+	p.reminders = mirror
+	p.mu.Unlock()
+	return nil
+}
+
+func (p *CalendarPlugin) hasEvent(id string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, ok := p.events[id]
+	return ok
+}
+
+func (p *CalendarPlugin) hasReminder(id string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, ok := p.reminders[id]
+	return ok
+}
+
+func newEntryID() string {
+	var b [12]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:]) + "@upcraft"
+}
@@ -0,0 +1,84 @@
+//go:build android
+
+package android
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/protocol"
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/skills"
+)
+
+func TestCalendarPlugin_EmitsOrderedEnvelopes(t *testing.T) {
+	q := NewCommandQueue()
+	seen := runFakeHost(t, q, ackAll)
+	p := NewCalendarPlugin(q)
+	ctx := context.Background()
+	start := time.Date(2026, 10, 19, 18, 0, 0, 0, time.UTC)
+
+	event, err := p.CreateEvent(ctx, skills.Event{Title: "Dinner", Start: start})
+	if err != nil {
+		t.Fatalf("CreateEvent: %v", err)
+	}
+	if err := p.DeleteEvent(ctx, event.ID); err != nil {
+		t.Fatalf("DeleteEvent: %v", err)
+	}
+	if _, err := p.CreateReminder(ctx, skills.Reminder{Title: "Call mom", Due: start}); err != nil {
+		t.Fatalf("CreateReminder: %v", err)
+	}
+
+	want := []string{"Calendar/CREATE_EVENT", "Calendar/DELETE_EVENT", "Reminders/CREATE_REMINDER"}
+	if len(*seen) != len(want) {
+		t.Fatalf("commands = %+v", *seen)
+	}
+	for i, cmd := range *seen {
+		if got := cmd.Tool + "/" + cmd.Action; got != want[i] {
+			t.Errorf("command %d = %s, want %s", i, got, want[i])
+		}
+	}
+	if (*seen)[1].Params["id"] != event.ID {
+		t.Errorf("delete envelope id = %v, want %s", (*seen)[1].Params["id"], event.ID)
+	}
+}
+
+func TestCalendarPlugin_HostFailureLeavesMirrorUnchanged(t *testing.T) {
+	q := NewCommandQueue()
+	runFakeHost(t, q, func(protocol.Command) (json.RawMessage, error) {
+		return nil, errors.New("calendar permission denied")
+	})
+	p := NewCalendarPlugin(q)
+	ctx := context.Background()
+	start := time.Date(2026, 10, 19, 18, 0, 0, 0, time.UTC)
+
+	if _, err := p.CreateEvent(ctx, skills.Event{Title: "Dinner", Start: start}); err == nil {
+		t.Fatal("expected host failure to surface")
+	}
+	events, _ := p.ListEvents(ctx, start.Add(-time.Hour), start.Add(time.Hour))
+	if len(events) != 0 {
+		t.Fatalf("mirror changed despite failure: %+v", events)
+	}
+}
+
+func TestCalendarPlugin_ListsSyncedEntries(t *testing.T) {
+	p := NewCalendarPlugin(NewCommandQueue())
+	err := p.SyncEvents(`[{"id":"a","title":"Yoga","start":"2026-10-19T07:00:00+05:30","time_zone":"Asia/Kolkata","recurrence":{"frequency":"daily","count":3}}]`)
+	if err != nil {
+		t.Fatalf("SyncEvents: %v", err)
+	}
+	from := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+	events, err := p.ListEvents(context.Background(), from, from.AddDate(0, 0, 7))
+	if err != nil || len(events) != 3 {
+		t.Fatalf("ListEvents = %+v, %v", events, err)
+	}
+
+	if err := p.SyncEvents(`[{"title":"no id","start":"2026-10-19T07:00:00Z"}]`); err == nil {
+		t.Fatal("expected entry without id to be rejected")
+	}
+	if err := p.DeleteEvent(context.Background(), "missing"); err == nil {
+		t.Fatal("expected unknown id to fail")
+	}
+}
@@ -4,60 +4,81 @@ package android
 
 import (
 	"context"
-	"encoding/json"
+	"fmt"
+	"strings"
 	"sync"
+
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/plugins/spotify"
 )
 
-// MusicPlugin records deterministic playback intents for Android UI execution.
+// MusicPlugin sends playback intents to the host app for Android UI
+// execution. Once the host configures a Spotify login it drives the user's
+// Spotify account through the Web API instead.
 type MusicPlugin struct {
-	mu          sync.Mutex
-	lastCommand string
-}
+	queue *CommandQueue
 
-func NewMusicPlugin() *MusicPlugin {
-	return &MusicPlugin{}
+	mu      sync.Mutex
+	spotify *spotify.PlayerClient
 }
 
-func (p *MusicPlugin) Play(_ context.Context, query string) error {
-	p.setCommand("PLAY", query)
-	return nil
+func NewMusicPlugin(queue *CommandQueue) *MusicPlugin {
+	return &MusicPlugin{queue: queue}
 }
 
-func (p *MusicPlugin) Pause(_ context.Context) error {
-	p.setCommand("PAUSE", "")
+// Configure takes Spotify settings: "token", or "refresh_token" with
+// "client_id", and an optional "device_id". See
+// spotify.NewPlayerClientFromSettings.
+func (p *MusicPlugin) Configure(settings map[string]string) error {
+	client, err := spotify.NewPlayerClientFromSettings(settings)
+	if err != nil {
+		return err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.spotify = client
 	return nil
 }
 
-func (p *MusicPlugin) Resume(_ context.Context) error {
-	p.setCommand("RESUME", "")
-	return nil
+func (p *MusicPlugin) Play(ctx context.Context, query string) error {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return fmt.Errorf("query is required")
+	}
+	if c := p.client(); c != nil {
+		return c.Play(ctx, query)
+	}
+	return p.send(ctx, "PLAY", map[string]interface{}{"query": query})
 }
 
-func (p *MusicPlugin) Next(_ context.Context) error {
-	p.setCommand("NEXT", "")
-	return nil
+func (p *MusicPlugin) Pause(ctx context.Context) error {
+	if c := p.client(); c != nil {
+		return c.Pause(ctx)
+	}
+	return p.send(ctx, "PAUSE", nil)
 }
 
-// ConsumeLastCommand returns and clears the latest Android action JSON.
-func (p *MusicPlugin) ConsumeLastCommand() string {
-	p.mu.Lock()
-	defer p.mu.Unlock()
-	out := p.lastCommand
-	p.lastCommand = ""
-	return out
+func (p *MusicPlugin) Resume(ctx context.Context) error {
+	if c := p.client(); c != nil {
+		return c.Resume(ctx)
+	}
+	return p.send(ctx, "RESUME", nil)
 }
 
-func (p *MusicPlugin) setCommand(action, query string) {
-	cmd := map[string]string{
-		"tool":   "MusicPlayer",
-		"action": action,
-	}
-	if query != "" {
-		cmd["query"] = query
+func (p *MusicPlugin) Next(ctx context.Context) error {
+	if c := p.client(); c != nil {
+		return c.Next(ctx)
 	}
-	encoded, _ := json.Marshal(cmd)
+	return p.send(ctx, "NEXT", nil)
+}
 
+// client returns the configured Spotify client, or nil to use the host.
+func (p *MusicPlugin) client() *spotify.PlayerClient {
 	p.mu.Lock()
-	p.lastCommand = string(encoded)
-	p.mu.Unlock()
+	defer p.mu.Unlock()
+	return p.spotify
+}
+
+func (p *MusicPlugin) send(ctx context.Context, action string, params map[string]interface{}) error {
+	_, err := p.queue.Submit(ctx, "MusicPlayer", action, params)
+	return err
 }
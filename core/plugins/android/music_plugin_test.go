@@ -0,0 +1,65 @@
+//go:build android
+
+package android
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/plugins/spotify/spotifytest"
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/skills"
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/skills/skilltest"
+)
+
+func TestMusicPlugin_Conformance(t *testing.T) {
+	skilltest.TestMusicPlayer(t, func(t *testing.T) skills.MusicPlayer {
+		q := NewCommandQueue()
+		runFakeHost(t, q, ackAll)
+		return NewMusicPlugin(q)
+	})
+}
+
+func TestMusicPlugin_KeepsEveryCommandInOrder(t *testing.T) {
+	q := NewCommandQueue()
+	seen := runFakeHost(t, q, ackAll)
+	p := NewMusicPlugin(q)
+	ctx := context.Background()
+
+	if err := p.Pause(ctx); err != nil {
+		t.Fatalf("Pause: %v", err)
+	}
+	if err := p.Play(ctx, " Yellow "); err != nil {
+		t.Fatalf("Play: %v", err)
+	}
+
+	if len(*seen) != 2 {
+		t.Fatalf("host saw %d commands, want 2", len(*seen))
+	}
+	encoded, _ := json.Marshal((*seen)[1].Params)
+	if (*seen)[0].Action != "PAUSE" || (*seen)[1].Action != "PLAY" || string(encoded) != `{"query":"Yellow"}` {
+		t.Fatalf("unexpected commands: %+v", *seen)
+	}
+}
+
+func TestMusicPlugin_UsesConfiguredSpotifyLogin(t *testing.T) {
+	q := NewCommandQueue()
+	seen := runFakeHost(t, q, ackAll)
+	p := NewMusicPlugin(q)
+	if err := p.Configure(map[string]string{"refresh_token": "r"}); err == nil {
+		t.Fatal("settings without client_id accepted")
+	}
+
+	srv := spotifytest.NewServer(t)
+	if err := p.Configure(map[string]string{"token": spotifytest.Token}); err != nil {
+		t.Fatal(err)
+	}
+	client := p.client()
+	client.BaseURL, client.HTTPClient = srv.URL, srv.Client()
+	if err := p.Play(context.Background(), "Hymn for the Weekend"); err != nil {
+		t.Fatalf("Play: %v", err)
+	}
+	if srv.Track() != "spotify:track:hymn-for-the-weekend" || len(*seen) != 0 {
+		t.Fatalf("track = %q, host commands = %d", srv.Track(), len(*seen))
+	}
+}
@@ -0,0 +1,242 @@
+package android
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/protocol"
+)
+
+// DefaultCommandTimeout bounds how long a plugin waits for the host app.
+const DefaultCommandTimeout = 15 * time.Second
+
+var (
+	// ErrCommandTimeout is returned when the host does not report in time.
+	ErrCommandTimeout = errors.New("timed out waiting for host acknowledgement")
+	// ErrQueueClosed is returned for commands still open when the queue closes.
+	ErrQueueClosed = errors.New("command queue closed")
+)
+
+// outcome is the host's report for one command.
+type outcome struct {
+	result json.RawMessage
+	err    error
+}
+
+type ticket struct {
+	cmd  protocol.Command
+	done chan outcome
+}
+
+// CommandQueue is the ordered channel between Android plugins and the host
+// app. Plugins Submit and block until the host acknowledges, fails or times
+// out the command; the host Pulls protocol.Command envelopes through the
+// mobile bridge and runs them in Seq order.
+type CommandQueue struct {
+	// Timeout applies to each command; zero means DefaultCommandTimeout.
+	Timeout time.Duration
+
+	mu     sync.Mutex
+	seq    uint64
+	queued []*ticket
+	open   map[string]*ticket
+	ready  chan struct{}
+	closed bool
+}
+
+func NewCommandQueue() *CommandQueue {
+	return &CommandQueue{
+		open:  map[string]*ticket{},
+		ready: make(chan struct{}, 1),
+	}
+}
+
+// Submit enqueues tool/action and waits for its outcome. A host failure is
+// returned as an error carrying the host's message.
+func (q *CommandQueue) Submit(ctx context.Context, tool, action string, params map[string]interface{}) (json.RawMessage, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	t, err := q.enqueue(tool, action, params)
+	if err != nil {
+		return nil, err
+	}
+
+	timer := time.NewTimer(time.Until(t.cmd.Deadline))
+	defer timer.Stop()
+	select {
+	case out := <-t.done:
+		return out.result, out.err
+	case <-timer.C:
+		err = fmt.Errorf("%s.%s: %w", tool, action, ErrCommandTimeout)
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	q.forget(t.cmd.ID)
+	// An outcome that raced the timeout still wins.
+	select {
+	case out := <-t.done:
+		return out.result, out.err
+	default:
+		return nil, err
+	}
+}
+
+// Pull returns up to max queued commands in order, waiting until at least
+// one is available or ctx ends. Pulled commands stay open until reported.
+func (q *CommandQueue) Pull(ctx context.Context, max int) ([]protocol.Command, error) {
+	if max <= 0 {
+		max = 1
+	}
+	for {
+		q.mu.Lock()
+		if q.closed {
+			q.mu.Unlock()
+			return nil, ErrQueueClosed
+		}
+		if len(q.queued) > 0 {
+			n := min(max, len(q.queued))
+			out := make([]protocol.Command, n)
+			for i, t := range q.queued[:n] {
+				out[i] = t.cmd
+			}
+			q.queued = q.queued[n:]
+			if len(q.queued) > 0 {
+				q.signal()
+			}
+			q.mu.Unlock()
+			return out, nil
+		}
+		q.mu.Unlock()
+
+		select {
+		case <-q.ready:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// Ack reports that the host executed the command. result may be empty.
+func (q *CommandQueue) Ack(id string, result json.RawMessage) error {
+	return q.resolve(id, outcome{result: result})
+}
+
+// Fail reports that the host could not execute the command.
+func (q *CommandQueue) Fail(id, message string) error {
+	q.mu.Lock()
+	t, ok := q.open[id]
+	q.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("unknown or expired command %s", id)
+	}
+	if message == "" {
+		message = "host reported failure"
+	}
+	return q.resolve(id, outcome{err: fmt.Errorf("%s.%s failed on device: %s", t.cmd.Tool, t.cmd.Action, message)})
+}
+
+// Close fails every open command and wakes pending Pull calls.
+func (q *CommandQueue) Close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return
+	}
+	q.closed = true
+	for id, t := range q.open {
+		t.done <- outcome{err: ErrQueueClosed}
+		delete(q.open, id)
+	}
+	q.queued = nil
+	close(q.ready)
+}
+
+// Len reports commands waiting to be pulled.
+func (q *CommandQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.queued)
+}
+
+func (q *CommandQueue) enqueue(tool, action string, params map[string]interface{}) (*ticket, error) {
+	timeout := q.Timeout
+	if timeout <= 0 {
+		timeout = DefaultCommandTimeout
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return nil, ErrQueueClosed
+	}
+	t := &ticket{
+		cmd: protocol.Command{
+			Header:   protocol.Header{V: protocol.Version, Kind: protocol.KindCommand},
+			ID:       newCommandID(),
+			Seq:      q.seq + 1,
+			Tool:     tool,
+			Action:   action,
+			Params:   params,
+			Deadline: time.Now().Add(timeout),
+		},
+		done: make(chan outcome, 1),
+	}
+	// An invalid command fails its caller here rather than reaching Pull,
+	// where the host would have to reject it along with its batch.
+	if err := t.cmd.Validate(); err != nil {
+		return nil, err
+	}
+	q.seq++
+	q.queued = append(q.queued, t)
+	q.open[t.cmd.ID] = t
+	q.signal()
+	return t, nil
+}
+
+func (q *CommandQueue) resolve(id string, out outcome) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	t, ok := q.open[id]
+	if !ok {
+		return fmt.Errorf("unknown or expired command %s", id)
+	}
+	delete(q.open, id)
+	t.done <- out
+	return nil
+}
+
+// forget drops a command that timed out or was cancelled, including from
+// the undelivered backlog so the host never runs it.
+func (q *CommandQueue) forget(id string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	delete(q.open, id)
+	for i, t := range q.queued {
+		if t.cmd.ID == id {
+			q.queued = append(q.queued[:i:i], q.queued[i+1:]...)
+			break
+		}
+	}
+}
+
+// signal must be called with q.mu held.
+func (q *CommandQueue) signal() {
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+}
+
+func newCommandID() string {
+	var b [8]byte
+	_, _ = rand.Read(b[:])
+	return "cmd-" + hex.EncodeToString(b[:])
+}
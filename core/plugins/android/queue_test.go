@@ -0,0 +1,171 @@
+package android
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/protocol"
+)
+
+// runFakeHost pulls and answers commands until the test ends. respond returns
+// an ack payload, or an error to report a failure.
+func runFakeHost(t *testing.T, q *CommandQueue, respond func(protocol.Command) (json.RawMessage, error)) *[]protocol.Command {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	var (
+		mu   sync.Mutex
+		seen []protocol.Command
+		wg   sync.WaitGroup
+	)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			cmds, err := q.Pull(ctx, 10)
+			if err != nil {
+				return
+			}
+			for _, cmd := range cmds {
+				mu.Lock()
+				seen = append(seen, cmd)
+				mu.Unlock()
+				if result, err := respond(cmd); err != nil {
+					_ = q.Fail(cmd.ID, err.Error())
+				} else {
+					_ = q.Ack(cmd.ID, result)
+				}
+			}
+		}
+	}()
+	t.Cleanup(func() {
+		cancel()
+		wg.Wait()
+	})
+	return &seen
+}
+
+func ackAll(protocol.Command) (json.RawMessage, error) { return nil, nil }
+
+func TestCommandQueue_OrderedEnvelopesAndAcks(t *testing.T) {
+	q := NewCommandQueue()
+	seen := runFakeHost(t, q, func(cmd protocol.Command) (json.RawMessage, error) {
+		return json.RawMessage(`{"done":"` + cmd.Action + `"}`), nil
+	})
+	ctx := context.Background()
+
+	for _, action := range []string{"PAUSE", "PLAY"} {
+		result, err := q.Submit(ctx, "MusicPlayer", action, map[string]interface{}{"n": 1})
+		if err != nil {
+			t.Fatalf("Submit %s: %v", action, err)
+		}
+		if string(result) != `{"done":"`+action+`"}` {
+			t.Fatalf("result = %s", result)
+		}
+	}
+
+	if len(*seen) != 2 || (*seen)[0].Action != "PAUSE" || (*seen)[1].Action != "PLAY" {
+		t.Fatalf("host saw %+v", *seen)
+	}
+	if (*seen)[0].Seq >= (*seen)[1].Seq || (*seen)[0].ID == (*seen)[1].ID {
+		t.Fatalf("commands not ordered with unique ids: %+v", *seen)
+	}
+}
+
+func TestCommandQueue_HostFailureReachesCaller(t *testing.T) {
+	q := NewCommandQueue()
+	runFakeHost(t, q, func(protocol.Command) (json.RawMessage, error) {
+		return nil, errors.New("no music app installed")
+	})
+	_, err := q.Submit(context.Background(), "MusicPlayer", "PLAY", nil)
+	if err == nil || !strings.Contains(err.Error(), "MusicPlayer.PLAY failed on device: no music app installed") {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+func TestCommandQueue_InvalidCommandIsNotQueued(t *testing.T) {
+	q := NewCommandQueue()
+	q.Timeout = 50 * time.Millisecond
+	if _, err := q.Submit(context.Background(), "MusicPlayer", "", nil); err == nil || errors.Is(err, ErrCommandTimeout) {
+		t.Fatalf("err = %v, want the command rejected", err)
+	}
+	if q.Len() != 0 {
+		t.Fatalf("invalid command queued")
+	}
+
+	runFakeHost(t, q, func(protocol.Command) (json.RawMessage, error) { return nil, nil })
+	if _, err := q.Submit(context.Background(), "MusicPlayer", "PLAY", nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCommandQueue_TimeoutDropsUndeliveredCommand(t *testing.T) {
+	q := NewCommandQueue()
+	q.Timeout = 20 * time.Millisecond
+
+	_, err := q.Submit(context.Background(), "MusicPlayer", "PLAY", nil)
+	if !errors.Is(err, ErrCommandTimeout) {
+		t.Fatalf("err = %v, want timeout", err)
+	}
+	if q.Len() != 0 {
+		t.Fatalf("timed out command still queued")
+	}
+}
+
+func TestCommandQueue_LateAckIsRejected(t *testing.T) {
+	q := NewCommandQueue()
+	q.Timeout = 20 * time.Millisecond
+
+	pulled := make(chan protocol.Command, 1)
+	go func() {
+		cmds, err := q.Pull(context.Background(), 1)
+		if err == nil {
+			pulled <- cmds[0]
+		}
+	}()
+	if _, err := q.Submit(context.Background(), "MusicPlayer", "NEXT", nil); !errors.Is(err, ErrCommandTimeout) {
+		t.Fatalf("err = %v, want timeout", err)
+	}
+	cmd := <-pulled
+	if err := q.Ack(cmd.ID, nil); err == nil {
+		t.Fatal("expected ack after timeout to fail")
+	}
+}
+
+func TestCommandQueue_CancelAndClose(t *testing.T) {
+	q := NewCommandQueue()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := q.Submit(ctx, "MusicPlayer", "PLAY", nil); !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want canceled", err)
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		_, err := q.Submit(context.Background(), "MusicPlayer", "PLAY", nil)
+		errs <- err
+	}()
+	for q.Len() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	q.Close()
+	if err := <-errs; !errors.Is(err, ErrQueueClosed) {
+		t.Fatalf("err = %v, want closed", err)
+	}
+	if _, err := q.Pull(context.Background(), 1); !errors.Is(err, ErrQueueClosed) {
+		t.Fatalf("Pull after close = %v", err)
+	}
+}
+
+func TestCommandQueue_PullWaitsForWork(t *testing.T) {
+	q := NewCommandQueue()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := q.Pull(ctx, 1); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Pull on empty queue = %v", err)
+	}
+}
@@ -0,0 +1,317 @@
+package desktop
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"os"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strings"
+	"syscall"
+	"time"
+	"unicode/utf8"
+
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/skills"
+)
+
+const (
+	// SearchURLEnv overrides the search engine; "%s" is replaced by the escaped query.
+	SearchURLEnv = "UPCRAFT_SEARCH_URL"
+
+	defaultSearchURL    = "https://duckduckgo.com/?q=%s"
+	defaultFetchMaxText = 12000
+	defaultFetchMaxLink = 40
+	maxFetchBodyBytes   = 2 * 1024 * 1024
+	browserUserAgent    = "Mozilla/5.0 (compatible; UpCraftAgent/1.0)"
+)
+
+var (
+	titlePattern  = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+	anchorPattern = regexp.MustCompile(`(?is)<a\s[^>]*href\s*=\s*["']([^"']+)["'][^>]*>(.*?)</a>`)
+	scriptPattern = regexp.MustCompile(`(?is)<(script|style|noscript|svg|head)[^>]*>.*?</(script|style|noscript|svg|head)>`)
+	blockPattern  = regexp.MustCompile(`(?i)<(br|/p|/div|/li|/h[1-6]|/tr|/section|/article)[^>]*>`)
+	tagPattern    = regexp.MustCompile(`<[^>]+>`)
+	spacePattern  = regexp.MustCompile(`[ \t\f\v]+`)
+)
+
+// BrowserPlugin opens pages in the system browser and fetches them headlessly.
+// The default HTTPClient only connects to public addresses, so a page the
+// model asks for cannot reach the device's own services or local network;
+// a client set by the caller replaces that check.
+type BrowserPlugin struct {
+	SearchURL  string
+	HTTPClient *http.Client
+	MaxText    int
+	MaxLinks   int
+
+	open func(target string) error
+}
+
+func NewBrowserPlugin() *BrowserPlugin {
+	searchURL := strings.TrimSpace(os.Getenv(SearchURLEnv))
+	if searchURL == "" {
+		searchURL = defaultSearchURL
+	}
+	return &BrowserPlugin{
+		SearchURL:  searchURL,
+		HTTPClient: newFetchClient(),
+		MaxText:    defaultFetchMaxText,
+		MaxLinks:   defaultFetchMaxLink,
+		open:       openInSystemBrowser,
+	}
+}
+
+func (p *BrowserPlugin) Visit(ctx context.Context, target string) error {
+	u, err := parseWebURL(target)
+	if err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return p.open(u.String())
+}
+
+func (p *BrowserPlugin) Search(ctx context.Context, query string) error {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return fmt.Errorf("query is required")
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return p.open(p.searchURL(query))
+}
+
+func (p *BrowserPlugin) Fetch(ctx context.Context, target string) (*skills.Page, error) {
+	u, err := parseWebURL(target)
+	if err != nil {
+		return nil, err
+	}
+	client := p.HTTPClient
+	if client == nil {
+		client = defaultFetchClient
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("User-Agent", browserUserAgent)
+	req.Header.Set("Accept", "text/html,text/plain;q=0.9,*/*;q=0.5")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetch page: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxFetchBodyBytes))
+	if err != nil {
+		return nil, fmt.Errorf("read page: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("fetch page failed status=%d", resp.StatusCode)
+	}
+
+	page := &skills.Page{URL: resp.Request.URL.String(), Status: resp.StatusCode}
+	content := string(body)
+	if isHTML(resp.Header.Get("Content-Type"), content) {
+		page.Title = cleanText(firstSubmatch(titlePattern, content))
+		page.Links = extractLinks(resp.Request.URL, content, p.maxLinks())
+		page.Text = extractReadableText(content)
+	} else {
+		page.Text = strings.TrimSpace(content)
+	}
+
+	if maxText := p.maxText(); len(page.Text) > maxText {
+		// Cut at a rune boundary so the text stays valid UTF-8.
+		for maxText > 0 && !utf8.RuneStart(page.Text[maxText]) {
+			maxText--
+		}
+		page.Text = page.Text[:maxText]
+		page.Truncated = true
+	}
+	return page, nil
+}
+
+// defaultFetchClient serves plugins built without NewBrowserPlugin.
+var defaultFetchClient = newFetchClient()
+
+// newFetchClient returns a client that refuses to connect to loopback,
+// private, link-local and other non-public addresses. The check runs on
+// the resolved address at dial time, so it also covers redirects and DNS
+// names pointing inward. Proxies are not used: they would dial for us.
+func newFetchClient() *http.Client {
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second, Control: dialPublicOnly}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Timeout: 30 * time.Second, Transport: transport}
+}
+
+func dialPublicOnly(network, address string, _ syscall.RawConn) error {
+	addr, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("dial %s: %w", address, err)
+	}
+	if !isPublicAddr(addr.Addr()) {
+		return fmt.Errorf("refusing to fetch from non-public address %s", addr.Addr())
+	}
+	return nil
+}
+
+// localPrefixes are non-public ranges netip does not classify: "this
+// network" (RFC 1122) and carrier-grade NAT (RFC 6598).
+var localPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+}
+
+func isPublicAddr(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	if !ip.IsGlobalUnicast() || ip.IsPrivate() {
+		return false
+	}
+	for _, prefix := range localPrefixes {
+		if prefix.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *BrowserPlugin) searchURL(query string) string {
+	tmpl := p.SearchURL
+	if tmpl == "" {
+		tmpl = defaultSearchURL
+	}
+	escaped := url.QueryEscape(query)
+	if strings.Contains(tmpl, "%s") {
+		return strings.ReplaceAll(tmpl, "%s", escaped)
+	}
+	return tmpl + escaped
+}
+
+func (p *BrowserPlugin) maxText() int {
+	if p.MaxText > 0 {
+		return p.MaxText
+	}
+	return defaultFetchMaxText
+}
+
+func (p *BrowserPlugin) maxLinks() int {
+	if p.MaxLinks > 0 {
+		return p.MaxLinks
+	}
+	return defaultFetchMaxLink
+}
+
+func parseWebURL(raw string) (*url.URL, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, fmt.Errorf("url is required")
+	}
+	if !strings.Contains(raw, "://") {
+		raw = "https://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid url: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("only http/https URLs are allowed")
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("missing domain in url")
+	}
+	return u, nil
+}
+
+func openInSystemBrowser(target string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", target)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", target)
+	default:
+		cmd = exec.Command("xdg-open", target)
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("open system browser: %w", err)
+	}
+	go func() { _ = cmd.Wait() }()
+	return nil
+}
+
+func isHTML(contentType, body string) bool {
+	if strings.Contains(contentType, "text/html") || strings.Contains(contentType, "application/xhtml") {
+		return true
+	}
+	head := strings.ToLower(strings.TrimSpace(body))
+	return strings.HasPrefix(head, "<!doctype html") || strings.HasPrefix(head, "<html")
+}
+
+func extractReadableText(content string) string {
+	content = scriptPattern.ReplaceAllString(content, "")
+	content = blockPattern.ReplaceAllString(content, "\n")
+	content = tagPattern.ReplaceAllString(content, "")
+	content = html.UnescapeString(content)
+
+	lines := strings.Split(content, "\n")
+	clean := make([]string, 0, len(lines))
+	for _, line := range lines {
+		line = strings.TrimSpace(spacePattern.ReplaceAllString(line, " "))
+		if line != "" {
+			clean = append(clean, line)
+		}
+	}
+	return strings.Join(clean, "\n")
+}
+
+func extractLinks(base *url.URL, content string, limit int) []skills.Link {
+	seen := map[string]bool{}
+	links := make([]skills.Link, 0, limit)
+	for _, m := range anchorPattern.FindAllStringSubmatch(content, -1) {
+		if len(links) >= limit {
+			break
+		}
+		ref, err := url.Parse(strings.TrimSpace(html.UnescapeString(m[1])))
+		if err != nil {
+			continue
+		}
+		abs := base.ResolveReference(ref)
+		if abs.Scheme != "http" && abs.Scheme != "https" {
+			continue
+		}
+		abs.Fragment = ""
+		key := abs.String()
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		links = append(links, skills.Link{Text: cleanText(m[2]), URL: key})
+	}
+	return links
+}
+
+func firstSubmatch(re *regexp.Regexp, content string) string {
+	m := re.FindStringSubmatch(content)
+	if len(m) < 2 {
+		return ""
+	}
+	return m[1]
+}
+
+func cleanText(fragment string) string {
+	fragment = html.UnescapeString(tagPattern.ReplaceAllString(fragment, ""))
+	return strings.Join(strings.Fields(fragment), " ")
+}
@@ -0,0 +1,161 @@
+package desktop
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/skills"
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/skills/skilltest"
+)
+
+func TestBrowserPlugin_VisitAndSearchOpenSystemBrowser(t *testing.T) {
+	var opened []string
+	p := NewBrowserPlugin()
+	p.SearchURL = "https://search.example/?q=%s"
+	p.open = func(target string) error {
+		opened = append(opened, target)
+		return nil
+	}
+
+	ctx := context.Background()
+	if err := p.Visit(ctx, "example.com/docs"); err != nil {
+		t.Fatalf("Visit: %v", err)
+	}
+	if err := p.Search(ctx, "go generics & you"); err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if err := p.Visit(ctx, "file:///etc/passwd"); err == nil {
+		t.Error("expected non-http scheme to be rejected")
+	}
+	if err := p.Search(ctx, "  "); err == nil {
+		t.Error("expected empty query to be rejected")
+	}
+
+	want := []string{"https://example.com/docs", "https://search.example/?q=go+generics+%26+you"}
+	if strings.Join(opened, "|") != strings.Join(want, "|") {
+		t.Errorf("opened = %v, want %v", opened, want)
+	}
+}
+
+func TestBrowserPlugin_FetchExtractsTextAndLinks(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte(`<!DOCTYPE html><html><head><title>Tea &amp; Biscuits</title>
+<style>body{color:red}</style></head><body>
+<script>alert("x")</script>
+<h1>Brewing</h1><p>Steep for <b>three</b> minutes.</p>
+<a href="/next">Next page</a> <a href="https://other.example/a#frag">Other</a>
+<a href="/next">Duplicate</a> <a href="mailto:me@example.com">Mail</a>
+</body></html>`))
+	}))
+	defer srv.Close()
+
+	p := NewBrowserPlugin()
+	p.HTTPClient = srv.Client()
+	page, err := p.Fetch(context.Background(), srv.URL)
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+
+	if page.Title != "Tea & Biscuits" {
+		t.Errorf("Title = %q", page.Title)
+	}
+	if !strings.Contains(page.Text, "Steep for three minutes.") || strings.Contains(page.Text, "alert") || strings.Contains(page.Text, "color:red") {
+		t.Errorf("unexpected text: %q", page.Text)
+	}
+	if len(page.Links) != 2 || page.Links[0].URL != srv.URL+"/next" || page.Links[1].URL != "https://other.example/a" {
+		t.Errorf("unexpected links: %+v", page.Links)
+	}
+}
+
+func TestBrowserPlugin_FetchTruncatesAndReportsStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		if r.URL.Path == "/utf8" {
+			_, _ = w.Write([]byte("caféscafé"))
+			return
+		}
+		_, _ = w.Write([]byte(strings.Repeat("a", 100)))
+	}))
+	defer srv.Close()
+
+	p := NewBrowserPlugin()
+	p.HTTPClient = srv.Client()
+	p.MaxText = 10
+	page, err := p.Fetch(context.Background(), srv.URL)
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if !page.Truncated || len(page.Text) != 10 {
+		t.Errorf("expected truncation to 10 chars, got %d (truncated=%v)", len(page.Text), page.Truncated)
+	}
+
+	// "é" is two bytes; a limit inside it drops the whole rune.
+	p.MaxText = 10
+	page, err = p.Fetch(context.Background(), srv.URL+"/utf8")
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if page.Text != "caféscaf" || !utf8.ValidString(page.Text) || !page.Truncated {
+		t.Errorf("non-ASCII truncation = %q (truncated=%v)", page.Text, page.Truncated)
+	}
+
+	if _, err := p.Fetch(context.Background(), srv.URL+"/missing"); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("expected 404 error, got %v", err)
+	}
+}
+
+func TestBrowserPlugin_FetchRefusesNonPublicAddresses(t *testing.T) {
+	var hits int
+	srv := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) { hits++ }))
+	defer srv.Close()
+
+	for _, p := range []*BrowserPlugin{NewBrowserPlugin(), {}} {
+		_, err := p.Fetch(context.Background(), srv.URL)
+		if err == nil || !strings.Contains(err.Error(), "non-public address 127.0.0.1") {
+			t.Errorf("Fetch of a loopback server: err = %v", err)
+		}
+	}
+	if hits != 0 {
+		t.Errorf("loopback server was reached %d times", hits)
+	}
+
+	for addr, public := range map[string]bool{
+		"93.184.216.34":    true,
+		"2606:4700::1111":  true,
+		"127.0.0.1":        false,
+		"::1":              false,
+		"10.1.2.3":         false,
+		"172.16.0.1":       false,
+		"192.168.1.1":      false,
+		"169.254.169.254":  false,
+		"fe80::1":          false,
+		"fd00::1":          false,
+		"0.0.0.0":          false,
+		"100.64.0.1":       false,
+		"::ffff:127.0.0.1": false,
+		"224.0.0.1":        false,
+	} {
+		if got := isPublicAddr(netip.MustParseAddr(addr)); got != public {
+			t.Errorf("isPublicAddr(%s) = %v, want %v", addr, got, public)
+		}
+	}
+}
+
+func TestBrowserPlugin_Conformance(t *testing.T) {
+	skilltest.TestBrowser(t, func(t *testing.T) skills.Browser {
+		p := NewBrowserPlugin()
+		p.HTTPClient = &http.Client{}
+		p.open = func(string) error { return nil }
+		return p
+	})
+}
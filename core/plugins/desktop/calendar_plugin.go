@@ -0,0 +1,504 @@
+package desktop
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	// Windows has no system zoneinfo; embed it so TimeZone always resolves.
+	_ "time/tzdata"
+
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/skills"
+)
+
+// CalendarPathEnv overrides where the desktop calendar file lives.
+const CalendarPathEnv = "UPCRAFT_CALENDAR_PATH"
+
+const (
+	maxListedOccurrences = 500
+	// zoneProp keeps the zone of all-day entries, whose DATE values cannot
+	// carry a TZID.
+	zoneProp = "X-UPCRAFT-TIMEZONE"
+)
+
+var (
+	eventProps    = []string{"DTSTAMP", "SUMMARY", "DTSTART", "DTEND", "DURATION", "LOCATION", "DESCRIPTION", "RRULE", zoneProp, "VALARM"}
+	reminderProps = []string{"DTSTAMP", "SUMMARY", "DUE", "DESCRIPTION", "RRULE", "STATUS", "COMPLETED", zoneProp, "VALARM"}
+)
+
+// CalendarPlugin stores events and reminders in a local iCalendar file that
+// other calendar apps can import or subscribe to.
+type CalendarPlugin struct {
+	Path string
+
+	mu    sync.Mutex
+	now   func() time.Time
+	newID func() string
+}
+
+var (
+	_ skills.Calendar  = (*CalendarPlugin)(nil)
+	_ skills.Reminders = (*CalendarPlugin)(nil)
+)
+
+func NewCalendarPlugin(path string) *CalendarPlugin {
+	return &CalendarPlugin{Path: path, now: time.Now, newID: newCalendarUID}
+}
+
+// NewCalendarPluginFromEnv uses UPCRAFT_CALENDAR_PATH or calendar.ics in the
+// user config directory.
+func NewCalendarPluginFromEnv() (*CalendarPlugin, error) {
+	path := strings.TrimSpace(os.Getenv(CalendarPathEnv))
+	if path == "" {
+		dir, err := os.UserConfigDir()
+		if err != nil {
+			return nil, fmt.Errorf("locate config dir: %w", err)
+		}
+		path = filepath.Join(dir, "upcraft", "calendar.ics")
+	}
+	return NewCalendarPlugin(path), nil
+}
+
+// Health reports whether the calendar file can be read.
+func (p *CalendarPlugin) Health(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, err := p.load()
+	return err
+}
+
+func (p *CalendarPlugin) CreateEvent(ctx context.Context, event skills.Event) (*skills.Event, error) {
+	if err := event.Normalize(); err != nil {
+		return nil, err
+	}
+	event.ID = p.newID()
+	err := p.update(ctx, func(cal *icsComponent) error {
+		comp := &icsComponent{Name: "VEVENT"}
+		comp.add("UID", event.ID)
+		p.fillEvent(comp, event)
+		cal.Components = append(cal.Components, comp)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+func (p *CalendarPlugin) ListEvents(ctx context.Context, from, to time.Time) ([]skills.Event, error) {
+	if !to.After(from) {
+		return nil, fmt.Errorf("to must be after from")
+	}
+	cal, err := p.read(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []skills.Event
+	for _, comp := range cal.Components {
+		if comp.Name != "VEVENT" {
+			continue
+		}
+		event, err := eventFromICS(comp)
+		if err != nil {
+			continue // foreign entries we cannot interpret are left alone
+		}
+		out = append(out, event.Occurrences(from, to, maxListedOccurrences)...)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Start.Before(out[j].Start) })
+	if len(out) > maxListedOccurrences {
+		out = out[:maxListedOccurrences]
+	}
+	return out, nil
+}
+
+func (p *CalendarPlugin) UpdateEvent(ctx context.Context, event skills.Event) (*skills.Event, error) {
+	if strings.TrimSpace(event.ID) == "" {
+		return nil, fmt.Errorf("id is required")
+	}
+	if err := event.Normalize(); err != nil {
+		return nil, err
+	}
+	err := p.update(ctx, func(cal *icsComponent) error {
+		comp := findComponent(cal, "VEVENT", event.ID)
+		if comp == nil {
+			return fmt.Errorf("event %s not found", event.ID)
+		}
+		comp.removeProps(eventProps...)
+		p.fillEvent(comp, event)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+func (p *CalendarPlugin) DeleteEvent(ctx context.Context, id string) error {
+	return p.update(ctx, func(cal *icsComponent) error {
+		return removeComponent(cal, "VEVENT", id)
+	})
+}
+
+func (p *CalendarPlugin) CreateReminder(ctx context.Context, reminder skills.Reminder) (*skills.Reminder, error) {
+	if err := reminder.Normalize(); err != nil {
+		return nil, err
+	}
+	reminder.ID = p.newID()
+	err := p.update(ctx, func(cal *icsComponent) error {
+		comp := &icsComponent{Name: "VTODO"}
+		comp.add("UID", reminder.ID)
+		p.fillReminder(comp, reminder)
+		cal.Components = append(cal.Components, comp)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &reminder, nil
+}
+
+func (p *CalendarPlugin) ListReminders(ctx context.Context, includeDone bool) ([]skills.Reminder, error) {
+	cal, err := p.read(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []skills.Reminder
+	for _, comp := range cal.Components {
+		if comp.Name != "VTODO" {
+			continue
+		}
+		reminder, err := reminderFromICS(comp)
+		if err != nil || (reminder.Done && !includeDone) {
+			continue
+		}
+		out = append(out, reminder)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Due.Before(out[j].Due) })
+	return out, nil
+}
+
+// UpdateReminder completing a recurring reminder moves it to its next due
+// time instead of closing it.
+func (p *CalendarPlugin) UpdateReminder(ctx context.Context, reminder skills.Reminder) (*skills.Reminder, error) {
+	if strings.TrimSpace(reminder.ID) == "" {
+		return nil, fmt.Errorf("id is required")
+	}
+	if err := reminder.Normalize(); err != nil {
+		return nil, err
+	}
+	if reminder.Done && reminder.Recurrence != nil {
+		if next, ok := reminder.NextDue(reminder.Due.Add(time.Second)); ok {
+			reminder.Due, reminder.Done = next, false
+			if reminder.Recurrence.Count > 0 {
+				reminder.Recurrence.Count--
+			}
+		}
+	}
+	err := p.update(ctx, func(cal *icsComponent) error {
+		comp := findComponent(cal, "VTODO", reminder.ID)
+		if comp == nil {
+			return fmt.Errorf("reminder %s not found", reminder.ID)
+		}
+		comp.removeProps(reminderProps...)
+		p.fillReminder(comp, reminder)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &reminder, nil
+}
+
+func (p *CalendarPlugin) DeleteReminder(ctx context.Context, id string) error {
+	return p.update(ctx, func(cal *icsComponent) error {
+		return removeComponent(cal, "VTODO", id)
+	})
+}
+
+func (p *CalendarPlugin) read(ctx context.Context) (*icsComponent, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.load()
+}
+
+// update applies fn to the calendar and writes it back atomically.
+func (p *CalendarPlugin) update(ctx context.Context, fn func(cal *icsComponent) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	cal, err := p.load()
+	if err != nil {
+		return err
+	}
+	if err := fn(cal); err != nil {
+		return err
+	}
+	return p.save(cal)
+}
+
+func (p *CalendarPlugin) load() (*icsComponent, error) {
+	if strings.TrimSpace(p.Path) == "" {
+		return nil, fmt.Errorf("calendar path is not configured")
+	}
+	data, err := os.ReadFile(p.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return newCalendarComponent(), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read calendar: %w", err)
+	}
+	cal, err := parseICS(data)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", p.Path, err)
+	}
+	return cal, nil
+}
+
+func (p *CalendarPlugin) save(cal *icsComponent) error {
+	if err := os.MkdirAll(filepath.Dir(p.Path), 0o755); err != nil {
+		return fmt.Errorf("create calendar dir: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(p.Path), ".calendar-*.ics")
+	if err != nil {
+		return fmt.Errorf("write calendar: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(cal.encode()); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write calendar: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write calendar: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), p.Path); err != nil {
+		return fmt.Errorf("write calendar: %w", err)
+	}
+	return nil
+}
+
+func (p *CalendarPlugin) fillEvent(comp *icsComponent, e skills.Event) {
+	comp.add("DTSTAMP", p.now().UTC().Format(icsUTCDateTimeLayout))
+	comp.addText("SUMMARY", e.Title)
+	comp.Props = append(comp.Props, icsTime("DTSTART", e.Start, e.TimeZone, e.AllDay), icsTime("DTEND", e.End, e.TimeZone, e.AllDay))
+	if e.AllDay && e.TimeZone != "" {
+		comp.add(zoneProp, e.TimeZone)
+	}
+	comp.addText("LOCATION", e.Location)
+	comp.addText("DESCRIPTION", e.Notes)
+	if e.Recurrence != nil {
+		comp.add("RRULE", encodeRRule(e.Recurrence))
+	}
+	for _, minutes := range e.AlertMinutes {
+		alarm := &icsComponent{Name: "VALARM"}
+		alarm.add("ACTION", "DISPLAY")
+		alarm.addText("DESCRIPTION", e.Title)
+		alarm.add("TRIGGER", formatICSDuration(-time.Duration(minutes)*time.Minute))
+		comp.Components = append(comp.Components, alarm)
+	}
+}
+
+func (p *CalendarPlugin) fillReminder(comp *icsComponent, r skills.Reminder) {
+	comp.add("DTSTAMP", p.now().UTC().Format(icsUTCDateTimeLayout))
+	comp.addText("SUMMARY", r.Title)
+	comp.Props = append(comp.Props, icsTime("DUE", r.Due, r.TimeZone, false))
+	comp.addText("DESCRIPTION", r.Notes)
+	if r.Recurrence != nil {
+		comp.add("RRULE", encodeRRule(r.Recurrence))
+	}
+	if r.Done {
+		comp.add("STATUS", "COMPLETED")
+		comp.add("COMPLETED", p.now().UTC().Format(icsUTCDateTimeLayout))
+		return
+	}
+	comp.add("STATUS", "NEEDS-ACTION")
+	alarm := &icsComponent{Name: "VALARM"}
+	alarm.add("ACTION", "DISPLAY")
+	alarm.addText("DESCRIPTION", r.Title)
+	alarm.add("TRIGGER", "PT0S", icsParam{Name: "RELATED", Value: "END"})
+	comp.Components = append(comp.Components, alarm)
+}
+
+func eventFromICS(comp *icsComponent) (skills.Event, error) {
+	e := skills.Event{
+		ID:       comp.value("UID"),
+		Title:    comp.text("SUMMARY"),
+		Location: comp.text("LOCATION"),
+		Notes:    comp.text("DESCRIPTION"),
+	}
+	startProp, ok := comp.prop("DTSTART")
+	if !ok {
+		return e, fmt.Errorf("event %s has no DTSTART", e.ID)
+	}
+	start, zone, allDay, err := parseICSTime(startProp)
+	if err != nil {
+		return e, err
+	}
+	e.Start, e.TimeZone, e.AllDay = start, zone, allDay
+
+	if endProp, ok := comp.prop("DTEND"); ok {
+		if e.End, _, _, err = parseICSTime(endProp); err != nil {
+			return e, err
+		}
+	} else if d := comp.value("DURATION"); d != "" {
+		dur, err := parseICSDuration(d)
+		if err != nil {
+			return e, err
+		}
+		e.End = e.Start.Add(dur)
+	}
+	if allDay {
+		e.TimeZone = comp.value(zoneProp)
+		e.Start, e.End = rebaseDate(e.Start, e.TimeZone), rebaseDate(e.End, e.TimeZone)
+	}
+	if rule := comp.value("RRULE"); rule != "" {
+		if e.Recurrence, err = decodeRRule(rule); err != nil {
+			return e, err
+		}
+	}
+	for _, alarm := range comp.Components {
+		if alarm.Name != "VALARM" {
+			continue
+		}
+		if d, err := parseICSDuration(alarm.value("TRIGGER")); err == nil && d <= 0 {
+			e.AlertMinutes = append(e.AlertMinutes, int(-d/time.Minute))
+		}
+	}
+	return e, e.Normalize()
+}
+
+func reminderFromICS(comp *icsComponent) (skills.Reminder, error) {
+	r := skills.Reminder{
+		ID:    comp.value("UID"),
+		Title: comp.text("SUMMARY"),
+		Notes: comp.text("DESCRIPTION"),
+		Done:  strings.EqualFold(comp.value("STATUS"), "COMPLETED"),
+	}
+	dueProp, ok := comp.prop("DUE")
+	if !ok {
+		return r, fmt.Errorf("reminder %s has no DUE", r.ID)
+	}
+	due, zone, allDay, err := parseICSTime(dueProp)
+	if err != nil {
+		return r, err
+	}
+	r.Due, r.TimeZone = due, zone
+	if allDay {
+		r.TimeZone = comp.value(zoneProp)
+		r.Due = rebaseDate(r.Due, r.TimeZone)
+	}
+	if rule := comp.value("RRULE"); rule != "" {
+		if r.Recurrence, err = decodeRRule(rule); err != nil {
+			return r, err
+		}
+	}
+	return r, r.Normalize()
+}
+
+// rebaseDate moves a date read in the local zone to midnight in zone.
+func rebaseDate(t time.Time, zone string) time.Time {
+	loc, err := time.LoadLocation(zone)
+	if err != nil || zone == "" {
+		loc = time.UTC
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+}
+
+func encodeRRule(rec *skills.Recurrence) string {
+	parts := []string{"FREQ=" + strings.ToUpper(rec.Frequency)}
+	if rec.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(rec.Interval))
+	}
+	if rec.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(rec.Count))
+	}
+	if rec.Until != nil {
+		parts = append(parts, "UNTIL="+rec.Until.UTC().Format(icsUTCDateTimeLayout))
+	}
+	if len(rec.ByDay) > 0 {
+		parts = append(parts, "BYDAY="+strings.Join(rec.ByDay, ","))
+	}
+	return strings.Join(parts, ";")
+}
+
+// decodeRRule accepts the RRULE subset skills.Recurrence models and rejects
+// anything else rather than expanding it wrongly.
+func decodeRRule(rule string) (*skills.Recurrence, error) {
+	rec := &skills.Recurrence{}
+	for _, part := range strings.Split(rule, ";") {
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("malformed RRULE part %q", part)
+		}
+		var err error
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			rec.Frequency = strings.ToLower(value)
+		case "INTERVAL":
+			rec.Interval, err = strconv.Atoi(value)
+		case "COUNT":
+			rec.Count, err = strconv.Atoi(value)
+		case "UNTIL":
+			var until time.Time
+			if len(value) == len(icsDateLayout) {
+				until, err = time.Parse(icsDateLayout, value)
+			} else {
+				until, err = time.Parse(icsUTCDateTimeLayout, value)
+			}
+			rec.Until = &until
+		case "BYDAY":
+			rec.ByDay = strings.Split(value, ",")
+		case "WKST":
+		default:
+			return nil, fmt.Errorf("unsupported RRULE part %s", key)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("RRULE %s: %w", key, err)
+		}
+	}
+	return rec, nil
+}
+
+func findComponent(cal *icsComponent, name, uid string) *icsComponent {
+	for _, comp := range cal.Components {
+		if comp.Name == name && comp.value("UID") == uid {
+			return comp
+		}
+	}
+	return nil
+}
+
+func removeComponent(cal *icsComponent, name, uid string) error {
+	for i, comp := range cal.Components {
+		if comp.Name == name && comp.value("UID") == uid {
+			cal.Components = append(cal.Components[:i], cal.Components[i+1:]...)
+			return nil
+		}
+	}
+	if name == "VTODO" {
+		return fmt.Errorf("reminder %s not found", uid)
+	}
+	return fmt.Errorf("event %s not found", uid)
+}
+
+func newCalendarUID() string {
+	var b [12]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:]) + "@upcraft"
+}
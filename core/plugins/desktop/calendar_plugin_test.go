@@ -0,0 +1,305 @@
+package desktop
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/skills"
+)
+
+func newTestCalendar(t *testing.T) *CalendarPlugin {
+	t.Helper()
+	p := NewCalendarPlugin(filepath.Join(t.TempDir(), "calendar.ics"))
+	p.now = func() time.Time { return time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC) }
+	n := 0
+	p.newID = func() string {
+		n++
+		return fmt.Sprintf("id-%d@upcraft", n)
+	}
+	return p
+}
+
+func mustZone(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return loc
+}
+
+func TestCalendarPlugin_EventLifecycle(t *testing.T) {
+	p := newTestCalendar(t)
+	ctx := context.Background()
+	kolkata := mustZone(t, "Asia/Kolkata")
+
+	created, err := p.CreateEvent(ctx, skills.Event{
+		Title:        "Standup; daily, team",
+		Start:        time.Date(2026, 10, 19, 10, 0, 0, 0, kolkata),
+		TimeZone:     "Asia/Kolkata",
+		Notes:        "line one\nline two",
+		Recurrence:   &skills.Recurrence{Frequency: "weekly", ByDay: []string{"mo", "we"}, Count: 4},
+		AlertMinutes: []int{10},
+	})
+	if err != nil {
+		t.Fatalf("CreateEvent: %v", err)
+	}
+	if created.ID != "id-1@upcraft" || !created.End.Equal(created.Start.Add(time.Hour)) {
+		t.Fatalf("unexpected created event: %+v", created)
+	}
+
+	raw, err := os.ReadFile(p.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"DTSTART;TZID=Asia/Kolkata:20261019T100000",
+		"RRULE:FREQ=WEEKLY;COUNT=4;BYDAY=MO,WE",
+		`SUMMARY:Standup\; daily\, team`,
+		`DESCRIPTION:line one\nline two`,
+		"TRIGGER:-PT10M",
+	} {
+		if !strings.Contains(string(raw), want) {
+			t.Fatalf("calendar file missing %q:\n%s", want, raw)
+		}
+	}
+
+	from := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+	events, err := p.ListEvents(ctx, from, from.AddDate(0, 1, 0))
+	if err != nil {
+		t.Fatalf("ListEvents: %v", err)
+	}
+	var days []string
+	for _, e := range events {
+		days = append(days, e.Start.Format("Mon 02 15:04"))
+	}
+	if got := strings.Join(days, ", "); got != "Mon 19 10:00, Wed 21 10:00, Mon 26 10:00, Wed 28 10:00" {
+		t.Fatalf("occurrences = %s", got)
+	}
+	if events[0].Title != "Standup; daily, team" || events[0].Notes != "line one\nline two" || events[0].AlertMinutes[0] != 10 {
+		t.Fatalf("fields did not round trip: %+v", events[0])
+	}
+
+	update := *created
+	update.Title = "Standup"
+	update.Recurrence = nil
+	if _, err := p.UpdateEvent(ctx, update); err != nil {
+		t.Fatalf("UpdateEvent: %v", err)
+	}
+	events, _ = p.ListEvents(ctx, from, from.AddDate(0, 1, 0))
+	if len(events) != 1 || events[0].Title != "Standup" {
+		t.Fatalf("after update = %+v", events)
+	}
+
+	if err := p.DeleteEvent(ctx, created.ID); err != nil {
+		t.Fatalf("DeleteEvent: %v", err)
+	}
+	if err := p.DeleteEvent(ctx, created.ID); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("second delete should report not found, got %v", err)
+	}
+}
+
+func TestCalendarPlugin_RecurrenceKeepsWallClockAcrossDST(t *testing.T) {
+	p := newTestCalendar(t)
+	ny := mustZone(t, "America/New_York")
+	_, err := p.CreateEvent(context.Background(), skills.Event{
+		Title:      "Gym",
+		Start:      time.Date(2026, 10, 30, 18, 0, 0, 0, ny),
+		TimeZone:   "America/New_York",
+		Recurrence: &skills.Recurrence{Frequency: "daily"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	from := time.Date(2026, 10, 30, 0, 0, 0, 0, ny)
+	events, err := p.ListEvents(context.Background(), from, from.AddDate(0, 0, 4))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 4 {
+		t.Fatalf("got %d occurrences, want 4", len(events))
+	}
+	for _, e := range events {
+		if e.Start.Hour() != 18 {
+			t.Fatalf("occurrence moved off 18:00 local: %s", e.Start)
+		}
+	}
+}
+
+func TestCalendarPlugin_Validation(t *testing.T) {
+	p := newTestCalendar(t)
+	ctx := context.Background()
+	start := time.Date(2026, 10, 19, 18, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		event skills.Event
+	}{
+		{"missing title", skills.Event{Start: start}},
+		{"missing start", skills.Event{Title: "x"}},
+		{"end before start", skills.Event{Title: "x", Start: start, End: start.Add(-time.Hour)}},
+		{"unknown zone", skills.Event{Title: "x", Start: start, TimeZone: "Mars/Olympus"}},
+		{"bad frequency", skills.Event{Title: "x", Start: start, Recurrence: &skills.Recurrence{Frequency: "hourly"}}},
+		{"by_day on monthly", skills.Event{Title: "x", Start: start, Recurrence: &skills.Recurrence{Frequency: "monthly", ByDay: []string{"MO"}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := p.CreateEvent(ctx, tt.event); err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+
+	if _, err := p.UpdateEvent(ctx, skills.Event{ID: "missing", Title: "x", Start: start}); err == nil {
+		t.Fatal("expected update of unknown event to fail")
+	}
+}
+
+func TestCalendarPlugin_Reminders(t *testing.T) {
+	p := newTestCalendar(t)
+	ctx := context.Background()
+	kolkata := mustZone(t, "Asia/Kolkata")
+	due := time.Date(2026, 10, 19, 18, 0, 0, 0, kolkata)
+
+	once, err := p.CreateReminder(ctx, skills.Reminder{Title: "Call mom", Due: due, TimeZone: "Asia/Kolkata"})
+	if err != nil {
+		t.Fatalf("CreateReminder: %v", err)
+	}
+	daily, err := p.CreateReminder(ctx, skills.Reminder{Title: "Water plants", Due: due.Add(-time.Hour), Recurrence: &skills.Recurrence{Frequency: "daily"}})
+	if err != nil {
+		t.Fatalf("CreateReminder: %v", err)
+	}
+
+	list, err := p.ListReminders(ctx, false)
+	if err != nil || len(list) != 2 || list[0].ID != daily.ID {
+		t.Fatalf("ListReminders = %+v, %v", list, err)
+	}
+	if list[1].TimeZone != "Asia/Kolkata" || !list[1].Due.Equal(due) {
+		t.Fatalf("zone did not round trip: %+v", list[1])
+	}
+
+	completed := *daily
+	completed.Done = true
+	advanced, err := p.UpdateReminder(ctx, completed)
+	if err != nil {
+		t.Fatalf("UpdateReminder: %v", err)
+	}
+	if advanced.Done || !advanced.Due.Equal(daily.Due.AddDate(0, 0, 1)) {
+		t.Fatalf("recurring reminder should advance a day, got %+v", advanced)
+	}
+
+	done := *once
+	done.Done = true
+	if _, err := p.UpdateReminder(ctx, done); err != nil {
+		t.Fatalf("UpdateReminder: %v", err)
+	}
+	if list, _ := p.ListReminders(ctx, false); len(list) != 1 {
+		t.Fatalf("done reminder should be hidden, got %+v", list)
+	}
+	if list, _ := p.ListReminders(ctx, true); len(list) != 2 {
+		t.Fatalf("include_done should return both, got %+v", list)
+	}
+
+	if err := p.DeleteReminder(ctx, once.ID); err != nil {
+		t.Fatalf("DeleteReminder: %v", err)
+	}
+}
+
+func TestCalendarPlugin_PreservesForeignEntries(t *testing.T) {
+	p := newTestCalendar(t)
+	foreign := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//Other//App//EN",
+		"BEGIN:VEVENT",
+		"UID:other-1",
+		"SUMMARY:Imported",
+		"DTSTART;VALUE=DATE:20261020",
+		"DTEND;VALUE=DATE:20261021",
+		"X-OTHER-APP:keep me",
+		"END:VEVENT",
+		"BEGIN:VJOURNAL",
+		"UID:journal-1",
+		"END:VJOURNAL",
+		"END:VCALENDAR",
+		"",
+	}, "\r\n")
+	if err := os.WriteFile(p.Path, []byte(foreign), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	from := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+	events, err := p.ListEvents(ctx, from, from.AddDate(0, 0, 7))
+	if err != nil || len(events) != 1 || !events[0].AllDay || events[0].Title != "Imported" {
+		t.Fatalf("ListEvents = %+v, %v", events, err)
+	}
+
+	renamed := events[0]
+	renamed.Title = "Renamed"
+	if _, err := p.UpdateEvent(ctx, renamed); err != nil {
+		t.Fatalf("UpdateEvent: %v", err)
+	}
+	raw, _ := os.ReadFile(p.Path)
+	for _, want := range []string{"PRODID:-//Other//App//EN", "X-OTHER-APP:keep me", "BEGIN:VJOURNAL", "SUMMARY:Renamed", "DTSTART;VALUE=DATE:20261020"} {
+		if !strings.Contains(string(raw), want) {
+			t.Fatalf("rewritten calendar missing %q:\n%s", want, raw)
+		}
+	}
+}
+
+func TestCalendarPlugin_HonorsCanceledContext(t *testing.T) {
+	p := newTestCalendar(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := p.CreateReminder(ctx, skills.Reminder{Title: "x", Due: time.Now()}); err == nil {
+		t.Fatal("expected canceled context to fail")
+	}
+}
+
+func TestICS_FoldingAndDurations(t *testing.T) {
+	cal := newCalendarComponent()
+	event := &icsComponent{Name: "VEVENT"}
+	long := strings.Repeat("ü", 60)
+	event.addText("SUMMARY", long)
+	cal.Components = append(cal.Components, event)
+
+	encoded := cal.encode()
+	for _, line := range strings.Split(string(encoded), "\r\n") {
+		if len(line) > icsMaxLineOctets {
+			t.Fatalf("line exceeds %d octets: %q", icsMaxLineOctets, line)
+		}
+	}
+	parsed, err := parseICS(encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := parsed.Components[0].text("SUMMARY"); got != long {
+		t.Fatalf("folded text did not round trip: %q", got)
+	}
+
+	for in, want := range map[string]time.Duration{
+		"-PT15M":  -15 * time.Minute,
+		"P1DT2H":  26 * time.Hour,
+		"P1W":     7 * 24 * time.Hour,
+		"PT0S":    0,
+		"+PT1H5S": time.Hour + 5*time.Second,
+	} {
+		got, err := parseICSDuration(in)
+		if err != nil || got != want {
+			t.Errorf("parseICSDuration(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	if got := formatICSDuration(-(26*time.Hour + 30*time.Minute)); got != "-P1DT2H30M" {
+		t.Errorf("formatICSDuration = %q", got)
+	}
+	if _, err := parseICSDuration("PT1X"); err == nil {
+		t.Error("expected invalid duration to fail")
+	}
+}
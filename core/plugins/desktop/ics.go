@@ -0,0 +1,416 @@
+package desktop
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A minimal iCalendar (RFC 5545) reader and writer. Components and properties
+// the calendar plugin does not understand are kept verbatim so a file shared
+// with other calendar apps survives a round trip.
+
+const (
+	icsDateTimeLayout    = "20060102T150405"
+	icsUTCDateTimeLayout = "20060102T150405Z"
+	icsDateLayout        = "20060102"
+	icsMaxLineOctets     = 75
+)
+
+type icsParam struct {
+	Name  string
+	Value string
+}
+
+type icsProp struct {
+	Name   string
+	Params []icsParam
+	Value  string
+}
+
+type icsComponent struct {
+	Name       string
+	Props      []icsProp
+	Components []*icsComponent
+}
+
+func newCalendarComponent() *icsComponent {
+	return &icsComponent{
+		Name: "VCALENDAR",
+		Props: []icsProp{
+			{Name: "VERSION", Value: "2.0"},
+			{Name: "PRODID", Value: "-//UpCraft//Agent Calendar//EN"},
+			{Name: "CALSCALE", Value: "GREGORIAN"},
+		},
+	}
+}
+
+func (c *icsComponent) prop(name string) (icsProp, bool) {
+	for _, p := range c.Props {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return icsProp{}, false
+}
+
+func (c *icsComponent) value(name string) string {
+	p, _ := c.prop(name)
+	return p.Value
+}
+
+func (c *icsComponent) text(name string) string {
+	return unescapeICSText(c.value(name))
+}
+
+// removeProps drops every property and child component named in names.
+func (c *icsComponent) removeProps(names ...string) {
+	drop := map[string]bool{}
+	for _, n := range names {
+		drop[n] = true
+	}
+	props := c.Props[:0]
+	for _, p := range c.Props {
+		if !drop[p.Name] {
+			props = append(props, p)
+		}
+	}
+	c.Props = props
+	children := c.Components[:0]
+	for _, child := range c.Components {
+		if !drop[child.Name] {
+			children = append(children, child)
+		}
+	}
+	c.Components = children
+}
+
+func (c *icsComponent) add(name, value string, params ...icsParam) {
+	c.Props = append(c.Props, icsProp{Name: name, Params: params, Value: value})
+}
+
+func (c *icsComponent) addText(name, value string) {
+	if value != "" {
+		c.add(name, escapeICSText(value))
+	}
+}
+
+func (p icsProp) param(name string) string {
+	for _, param := range p.Params {
+		if param.Name == name {
+			return param.Value
+		}
+	}
+	return ""
+}
+
+// parseICS reads one VCALENDAR object.
+func parseICS(data []byte) (*icsComponent, error) {
+	lines, err := unfoldICS(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var stack []*icsComponent
+	var root *icsComponent
+	for n, line := range lines {
+		if line == "" {
+			continue
+		}
+		prop, err := parseICSLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("ics line %d: %w", n+1, err)
+		}
+		switch prop.Name {
+		case "BEGIN":
+			comp := &icsComponent{Name: strings.ToUpper(prop.Value)}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.Components = append(parent.Components, comp)
+			} else if root != nil {
+				return nil, fmt.Errorf("ics line %d: more than one top-level component", n+1)
+			} else {
+				root = comp
+			}
+			stack = append(stack, comp)
+		case "END":
+			if len(stack) == 0 || stack[len(stack)-1].Name != strings.ToUpper(prop.Value) {
+				return nil, fmt.Errorf("ics line %d: unexpected END:%s", n+1, prop.Value)
+			}
+			stack = stack[:len(stack)-1]
+		default:
+			if len(stack) == 0 {
+				return nil, fmt.Errorf("ics line %d: property outside a component", n+1)
+			}
+			cur := stack[len(stack)-1]
+			cur.Props = append(cur.Props, prop)
+		}
+	}
+	if len(stack) > 0 {
+		return nil, fmt.Errorf("ics: unterminated %s", stack[len(stack)-1].Name)
+	}
+	if root == nil || root.Name != "VCALENDAR" {
+		return nil, fmt.Errorf("ics: missing VCALENDAR")
+	}
+	return root, nil
+}
+
+func unfoldICS(data []byte) ([]string, error) {
+	var lines []string
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	sc.Buffer(make([]byte, 64*1024), 4<<20)
+	for sc.Scan() {
+		line := strings.TrimRight(sc.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, sc.Err()
+}
+
+// parseICSLine splits NAME;PARAM=VALUE:VALUE, honoring quoted parameter values.
+func parseICSLine(line string) (icsProp, error) {
+	inQuotes := false
+	colon := -1
+	for i, r := range line {
+		if r == '"' {
+			inQuotes = !inQuotes
+		} else if r == ':' && !inQuotes {
+			colon = i
+			break
+		}
+	}
+	if colon < 0 {
+		return icsProp{}, fmt.Errorf("missing ':' in %q", line)
+	}
+
+	head, value := line[:colon], line[colon+1:]
+	parts := splitUnquoted(head, ';')
+	prop := icsProp{Name: strings.ToUpper(parts[0]), Value: value}
+	if prop.Name == "" {
+		return icsProp{}, fmt.Errorf("empty property name")
+	}
+	for _, raw := range parts[1:] {
+		name, val, ok := strings.Cut(raw, "=")
+		if !ok {
+			return icsProp{}, fmt.Errorf("malformed parameter %q", raw)
+		}
+		prop.Params = append(prop.Params, icsParam{Name: strings.ToUpper(name), Value: strings.Trim(val, `"`)})
+	}
+	return prop, nil
+}
+
+func splitUnquoted(s string, sep rune) []string {
+	var parts []string
+	inQuotes := false
+	start := 0
+	for i, r := range s {
+		switch {
+		case r == '"':
+			inQuotes = !inQuotes
+		case r == sep && !inQuotes:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+func (c *icsComponent) encode() []byte {
+	var b bytes.Buffer
+	c.write(&b)
+	return b.Bytes()
+}
+
+func (c *icsComponent) write(b *bytes.Buffer) {
+	writeICSLine(b, "BEGIN:"+c.Name)
+	for _, p := range c.Props {
+		var line strings.Builder
+		line.WriteString(p.Name)
+		for _, param := range p.Params {
+			line.WriteString(";" + param.Name + "=")
+			if strings.ContainsAny(param.Value, ":;,") {
+				line.WriteString(`"` + param.Value + `"`)
+			} else {
+				line.WriteString(param.Value)
+			}
+		}
+		line.WriteString(":" + p.Value)
+		writeICSLine(b, line.String())
+	}
+	for _, child := range c.Components {
+		child.write(b)
+	}
+	writeICSLine(b, "END:"+c.Name)
+}
+
+// writeICSLine folds at 75 octets without splitting UTF-8 sequences.
+func writeICSLine(b *bytes.Buffer, line string) {
+	limit := icsMaxLineOctets
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && line[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		b.WriteString(line[:cut] + "\r\n ")
+		line = line[cut:]
+		limit = icsMaxLineOctets - 1
+	}
+	b.WriteString(line + "\r\n")
+}
+
+var icsTextEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+func escapeICSText(s string) string {
+	return icsTextEscaper.Replace(s)
+}
+
+func unescapeICSText(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n', 'N':
+			b.WriteByte('\n')
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
+
+// icsTime encodes t as a DATE-TIME (or DATE) value. Zoned times carry an
+// IANA TZID, which mainstream calendar apps resolve without a VTIMEZONE.
+func icsTime(name string, t time.Time, zone string, allDay bool) icsProp {
+	switch {
+	case allDay:
+		return icsProp{Name: name, Params: []icsParam{{Name: "VALUE", Value: "DATE"}}, Value: t.Format(icsDateLayout)}
+	case zone == "":
+		return icsProp{Name: name, Value: t.UTC().Format(icsUTCDateTimeLayout)}
+	default:
+		return icsProp{Name: name, Params: []icsParam{{Name: "TZID", Value: zone}}, Value: t.Format(icsDateTimeLayout)}
+	}
+}
+
+// parseICSTime decodes a DATE or DATE-TIME property. Floating times are
+// read in the local zone, as RFC 5545 specifies.
+func parseICSTime(p icsProp) (t time.Time, zone string, allDay bool, err error) {
+	value := strings.TrimSpace(p.Value)
+	if p.param("VALUE") == "DATE" || len(value) == len(icsDateLayout) {
+		loc := time.Local
+		if tzid := p.param("TZID"); tzid != "" {
+			if l, err := time.LoadLocation(tzid); err == nil {
+				loc, zone = l, tzid
+			}
+		}
+		t, err = time.ParseInLocation(icsDateLayout, value, loc)
+		return t, zone, true, err
+	}
+	if strings.HasSuffix(value, "Z") {
+		t, err = time.Parse(icsUTCDateTimeLayout, value)
+		return t, "", false, err
+	}
+	loc := time.Local
+	if tzid := p.param("TZID"); tzid != "" {
+		l, lerr := time.LoadLocation(tzid)
+		if lerr != nil {
+			return time.Time{}, "", false, fmt.Errorf("unknown TZID %q", tzid)
+		}
+		loc, zone = l, tzid
+	}
+	t, err = time.ParseInLocation(icsDateTimeLayout, value, loc)
+	return t, zone, false, err
+}
+
+// formatICSDuration encodes d as an RFC 5545 duration such as -PT15M.
+func formatICSDuration(d time.Duration) string {
+	sign := ""
+	if d < 0 {
+		sign, d = "-", -d
+	}
+	if d == 0 {
+		return "PT0S"
+	}
+	var b strings.Builder
+	b.WriteString(sign + "P")
+	if days := d / (24 * time.Hour); days > 0 {
+		b.WriteString(strconv.Itoa(int(days)) + "D")
+		d -= days * 24 * time.Hour
+	}
+	if d > 0 {
+		b.WriteString("T")
+		if h := d / time.Hour; h > 0 {
+			b.WriteString(strconv.Itoa(int(h)) + "H")
+			d -= h * time.Hour
+		}
+		if m := d / time.Minute; m > 0 {
+			b.WriteString(strconv.Itoa(int(m)) + "M")
+			d -= m * time.Minute
+		}
+		if s := d / time.Second; s > 0 {
+			b.WriteString(strconv.Itoa(int(s)) + "S")
+		}
+	}
+	return b.String()
+}
+
+// parseICSDuration decodes [+|-]P[nW][nD][T[nH][nM][nS]].
+func parseICSDuration(s string) (time.Duration, error) {
+	orig := s
+	sign := time.Duration(1)
+	switch {
+	case strings.HasPrefix(s, "-"):
+		sign, s = -1, s[1:]
+	case strings.HasPrefix(s, "+"):
+		s = s[1:]
+	}
+	if !strings.HasPrefix(s, "P") {
+		return 0, fmt.Errorf("invalid duration %q", orig)
+	}
+	s = s[1:]
+
+	var total time.Duration
+	inTime := false
+	num := ""
+	for _, r := range s {
+		switch {
+		case r == 'T':
+			inTime = true
+		case r >= '0' && r <= '9':
+			num += string(r)
+		default:
+			n, err := strconv.Atoi(num)
+			if err != nil {
+				return 0, fmt.Errorf("invalid duration %q", orig)
+			}
+			num = ""
+			switch {
+			case r == 'W' && !inTime:
+				total += time.Duration(n) * 7 * 24 * time.Hour
+			case r == 'D' && !inTime:
+				total += time.Duration(n) * 24 * time.Hour
+			case r == 'H' && inTime:
+				total += time.Duration(n) * time.Hour
+			case r == 'M' && inTime:
+				total += time.Duration(n) * time.Minute
+			case r == 'S' && inTime:
+				total += time.Duration(n) * time.Second
+			default:
+				return 0, fmt.Errorf("invalid duration %q", orig)
+			}
+		}
+	}
+	if num != "" {
+		return 0, fmt.Errorf("invalid duration %q", orig)
+	}
+	return sign * total, nil
+}
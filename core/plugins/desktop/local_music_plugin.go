@@ -0,0 +1,407 @@
+package desktop
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/skills"
+)
+
+const (
+	// MusicDirEnv is the folder the local player indexes.
+	MusicDirEnv = "UPCRAFT_MUSIC_DIR"
+	// MPVSocketEnv overrides the mpv JSON IPC socket path.
+	MPVSocketEnv = "UPCRAFT_MPV_SOCKET"
+	// MPVPathEnv overrides the mpv binary started when no player is listening.
+	MPVPathEnv = "UPCRAFT_MPV_PATH"
+)
+
+// LocalMusicPlugin plays files from a local folder through mpv. It needs
+// no account or network, and offers every music capability except devices.
+type LocalMusicPlugin struct {
+	Dir string
+	// CatalogPath caches the index between runs; empty disables the cache.
+	CatalogPath string
+
+	player *mpvClient
+
+	mu      sync.Mutex
+	catalog *MusicCatalog
+	shuffle bool
+}
+
+var (
+	_ skills.MusicPlayer    = (*LocalMusicPlugin)(nil)
+	_ skills.MusicNavigator = (*LocalMusicPlugin)(nil)
+	_ skills.MusicVolume    = (*LocalMusicPlugin)(nil)
+	_ skills.MusicModes     = (*LocalMusicPlugin)(nil)
+	_ skills.MusicQueue     = (*LocalMusicPlugin)(nil)
+	_ skills.MusicLibrary   = (*LocalMusicPlugin)(nil)
+	_ skills.MusicStatus    = (*LocalMusicPlugin)(nil)
+)
+
+// NewLocalMusicPlugin controls the mpv listening on socket, starting
+// mpvBinary there first when it is set and nothing is listening.
+func NewLocalMusicPlugin(dir, socket, mpvBinary string) *LocalMusicPlugin {
+	return &LocalMusicPlugin{
+		Dir:    dir,
+		player: &mpvClient{Socket: socket, Binary: mpvBinary},
+	}
+}
+
+// NewLocalMusicPluginFromEnv requires UPCRAFT_MUSIC_DIR. mpv is looked up on
+// PATH unless UPCRAFT_MPV_PATH is set.
+func NewLocalMusicPluginFromEnv() (*LocalMusicPlugin, error) {
+	dir := strings.TrimSpace(os.Getenv(MusicDirEnv))
+	if dir == "" {
+		return nil, fmt.Errorf("%s is required", MusicDirEnv)
+	}
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("%s=%s is not a folder", MusicDirEnv, dir)
+	}
+
+	socket := strings.TrimSpace(os.Getenv(MPVSocketEnv))
+	if socket == "" {
+		var err error
+		if socket, err = defaultMPVSocket(); err != nil {
+			return nil, err
+		}
+	}
+	binary := strings.TrimSpace(os.Getenv(MPVPathEnv))
+	if binary == "" {
+		// Without mpv on PATH the plugin can still drive a player started by hand.
+		binary, _ = exec.LookPath("mpv")
+	}
+
+	p := NewLocalMusicPlugin(dir, socket, binary)
+	if cfg, err := os.UserConfigDir(); err == nil {
+		p.CatalogPath = filepath.Join(cfg, "upcraft", "music_catalog.json")
+	}
+	return p, nil
+}
+
+// defaultMPVSocket is a socket path in a folder only the current user can
+// write to, since the plugin removes whatever is at the path before
+// starting mpv.
+func defaultMPVSocket() (string, error) {
+	dir := os.Getenv("XDG_RUNTIME_DIR")
+	if dir == "" {
+		cache, err := os.UserCacheDir()
+		if err != nil {
+			return "", fmt.Errorf("choose mpv socket path: %w (set %s)", err, MPVSocketEnv)
+		}
+		dir = filepath.Join(cache, "upcraft")
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", fmt.Errorf("choose mpv socket path: %w", err)
+	}
+	return filepath.Join(dir, "upcraft-mpv.sock"), nil
+}
+
+// Start indexes the library so the first query is fast.
+func (p *LocalMusicPlugin) Start(ctx context.Context) error {
+	return p.Reindex(ctx)
+}
+
+// Stop closes the IPC connection and any mpv the plugin started.
+func (p *LocalMusicPlugin) Stop(context.Context) error {
+	return p.player.Close()
+}
+
+func (p *LocalMusicPlugin) Health(context.Context) error {
+	if _, err := os.Stat(p.Dir); err != nil {
+		return fmt.Errorf("music folder unavailable: %w", err)
+	}
+	return nil
+}
+
+// Reindex rescans the music folder, reusing cached tags for unchanged files.
+func (p *LocalMusicPlugin) Reindex(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	p.mu.Lock()
+	previous := p.catalog
+	p.mu.Unlock()
+	if previous == nil && p.CatalogPath != "" {
+		previous, _ = LoadMusicCatalog(p.CatalogPath)
+	}
+
+	catalog, err := IndexMusicLibrary(p.Dir, previous)
+	if err != nil {
+		return err
+	}
+	if p.CatalogPath != "" {
+		if err := catalog.Save(p.CatalogPath); err != nil {
+			return fmt.Errorf("save music catalog: %w", err)
+		}
+	}
+	p.mu.Lock()
+	p.catalog = catalog
+	p.mu.Unlock()
+	return nil
+}
+
+func (p *LocalMusicPlugin) Play(ctx context.Context, query string) error {
+	track, err := p.findTrack(ctx, query)
+	if err != nil {
+		return err
+	}
+	if _, err := p.player.command(ctx, "loadfile", track.Path, "replace"); err != nil {
+		return err
+	}
+	return p.player.setProperty(ctx, "pause", false)
+}
+
+func (p *LocalMusicPlugin) Pause(ctx context.Context) error {
+	return p.player.setProperty(ctx, "pause", true)
+}
+
+func (p *LocalMusicPlugin) Resume(ctx context.Context) error {
+	return p.player.setProperty(ctx, "pause", false)
+}
+
+// Next stops playback at the end of the list instead of failing.
+func (p *LocalMusicPlugin) Next(ctx context.Context) error {
+	_, err := p.player.command(ctx, "playlist-next", "force")
+	return err
+}
+
+// Previous restarts the track when it is the first in the list.
+func (p *LocalMusicPlugin) Previous(ctx context.Context) error {
+	var pos int
+	if _, err := p.player.getProperty(ctx, "playlist-pos", &pos); err != nil {
+		return err
+	}
+	if pos <= 0 {
+		_, err := p.player.command(ctx, "seek", 0, "absolute")
+		return err
+	}
+	_, err := p.player.command(ctx, "playlist-prev")
+	return err
+}
+
+func (p *LocalMusicPlugin) Seek(ctx context.Context, positionMs int) error {
+	if positionMs < 0 {
+		return fmt.Errorf("position_ms must not be negative")
+	}
+	_, err := p.player.command(ctx, "seek", float64(positionMs)/1000, "absolute")
+	return err
+}
+
+func (p *LocalMusicPlugin) SetVolume(ctx context.Context, percent int) error {
+	if percent < 0 || percent > 100 {
+		return fmt.Errorf("volume must be between 0 and 100")
+	}
+	return p.player.setProperty(ctx, "volume", percent)
+}
+
+func (p *LocalMusicPlugin) SetShuffle(ctx context.Context, enabled bool) error {
+	cmd := "playlist-unshuffle"
+	if enabled {
+		cmd = "playlist-shuffle"
+	}
+	if _, err := p.player.command(ctx, cmd); err != nil {
+		return err
+	}
+	p.mu.Lock()
+	p.shuffle = enabled
+	p.mu.Unlock()
+	return nil
+}
+
+func (p *LocalMusicPlugin) SetRepeat(ctx context.Context, mode string) error {
+	loopFile, loopList := "no", "no"
+	switch mode {
+	case skills.RepeatOff:
+	case skills.RepeatTrack:
+		loopFile = "inf"
+	case skills.RepeatAll:
+		loopList = "inf"
+	default:
+		return fmt.Errorf("unknown repeat mode %q", mode)
+	}
+	if err := p.player.setProperty(ctx, "loop-file", loopFile); err != nil {
+		return err
+	}
+	return p.player.setProperty(ctx, "loop-playlist", loopList)
+}
+
+func (p *LocalMusicPlugin) AddToQueue(ctx context.Context, query string) error {
+	track, err := p.findTrack(ctx, query)
+	if err != nil {
+		return err
+	}
+	_, err = p.player.command(ctx, "loadfile", track.Path, "append-play")
+	return err
+}
+
+func (p *LocalMusicPlugin) PlayAlbum(ctx context.Context, name string) error {
+	catalog, err := p.ensureCatalog(ctx, name)
+	if err != nil {
+		return err
+	}
+	tracks := catalog.FindAlbum(name)
+	if len(tracks) == 0 {
+		return fmt.Errorf("no local album matches %q", name)
+	}
+	return p.playTracks(ctx, tracks)
+}
+
+func (p *LocalMusicPlugin) PlayArtist(ctx context.Context, name string) error {
+	catalog, err := p.ensureCatalog(ctx, name)
+	if err != nil {
+		return err
+	}
+	tracks := catalog.FindArtist(name)
+	if len(tracks) == 0 {
+		return fmt.Errorf("no local artist matches %q", name)
+	}
+	return p.playTracks(ctx, tracks)
+}
+
+func (p *LocalMusicPlugin) PlayPlaylist(ctx context.Context, name string) error {
+	catalog, err := p.ensureCatalog(ctx, name)
+	if err != nil {
+		return err
+	}
+	playlist, ok := catalog.FindPlaylist(name)
+	if !ok {
+		return fmt.Errorf("no local playlist matches %q", name)
+	}
+	if _, err := p.player.command(ctx, "loadlist", playlist.Path, "replace"); err != nil {
+		return err
+	}
+	return p.player.setProperty(ctx, "pause", false)
+}
+
+// NowPlaying describes the loaded file from the catalog, falling back to
+// mpv's media title for files outside the library.
+func (p *LocalMusicPlugin) NowPlaying(ctx context.Context) (*skills.NowPlaying, error) {
+	var path string
+	ok, err := p.player.getProperty(ctx, "path", &path)
+	if err != nil {
+		return nil, err
+	}
+	if !ok || path == "" {
+		return &skills.NowPlaying{}, nil
+	}
+
+	var (
+		paused            bool
+		position, length  float64
+		loopFile, loopAll interface{}
+	)
+	for name, out := range map[string]interface{}{
+		"pause": &paused, "time-pos": &position, "duration": &length,
+		"loop-file": &loopFile, "loop-playlist": &loopAll,
+	} {
+		if _, err := p.player.getProperty(ctx, name, out); err != nil {
+			return nil, err
+		}
+	}
+
+	p.mu.Lock()
+	now := &skills.NowPlaying{
+		Playing:    !paused,
+		URI:        path,
+		PositionMs: int(position * 1000),
+		DurationMs: int(length * 1000),
+		Device:     "This computer",
+		Shuffle:    p.shuffle,
+		Repeat:     repeatMode(loopFile, loopAll),
+	}
+	catalog := p.catalog
+	p.mu.Unlock()
+
+	if track, ok := catalogLookup(catalog, path); ok {
+		now.Track, now.Album = track.Title, track.Album
+		if track.Artist != "" {
+			now.Artists = []string{track.Artist}
+		}
+	} else if _, err := p.player.getProperty(ctx, "media-title", &now.Track); err != nil {
+		return nil, err
+	}
+	return now, nil
+}
+
+func (p *LocalMusicPlugin) playTracks(ctx context.Context, tracks []LibraryTrack) error {
+	for i, t := range tracks {
+		mode := "append"
+		if i == 0 {
+			mode = "replace"
+		}
+		if _, err := p.player.command(ctx, "loadfile", t.Path, mode); err != nil {
+			return err
+		}
+	}
+	return p.player.setProperty(ctx, "pause", false)
+}
+
+func (p *LocalMusicPlugin) findTrack(ctx context.Context, query string) (LibraryTrack, error) {
+	catalog, err := p.ensureCatalog(ctx, query)
+	if err != nil {
+		return LibraryTrack{}, err
+	}
+	track, ok := catalog.FindTrack(query)
+	if !ok {
+		return LibraryTrack{}, fmt.Errorf("no local track matches %q", query)
+	}
+	return track, nil
+}
+
+// ensureCatalog validates the query and indexes lazily when Start was skipped.
+func (p *LocalMusicPlugin) ensureCatalog(ctx context.Context, query string) (*MusicCatalog, error) {
+	if strings.TrimSpace(query) == "" {
+		return nil, fmt.Errorf("query is required")
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	p.mu.Lock()
+	catalog := p.catalog
+	p.mu.Unlock()
+	if catalog != nil {
+		return catalog, nil
+	}
+	if err := p.Reindex(ctx); err != nil {
+		return nil, err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.catalog, nil
+}
+
+func catalogLookup(c *MusicCatalog, path string) (LibraryTrack, bool) {
+	if c == nil {
+		return LibraryTrack{}, false
+	}
+	return c.Lookup(path)
+}
+
+// repeatMode maps mpv's loop options, which are "no", "inf" or a count.
+func repeatMode(loopFile, loopPlaylist interface{}) string {
+	looping := func(v interface{}) bool {
+		switch v := v.(type) {
+		case bool:
+			return v
+		case string:
+			return v != "no" && v != ""
+		case float64:
+			return v > 0
+		}
+		return false
+	}
+	switch {
+	case looping(loopFile):
+		return skills.RepeatTrack
+	case looping(loopPlaylist):
+		return skills.RepeatAll
+	}
+	return skills.RepeatOff
+}
@@ -0,0 +1,291 @@
+package desktop
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/skills"
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/skills/skilltest"
+)
+
+// fakeMPV answers the subset of mpv's JSON IPC the plugin uses. It interleaves
+// an event before every reply, as a real player does.
+type fakeMPV struct {
+	socket string
+
+	mu       sync.Mutex
+	playlist []string
+	pos      int
+	paused   bool
+	timePos  float64
+	props    map[string]interface{}
+	commands []string
+}
+
+func startFakeMPV(t *testing.T) *fakeMPV {
+	t.Helper()
+	// Unix socket paths are short; test temp dirs with subtest names are not.
+	dir, err := os.MkdirTemp("", "mpv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &fakeMPV{
+		socket: filepath.Join(dir, "mpv.sock"),
+		pos:    -1,
+		props:  map[string]interface{}{"volume": 100.0, "loop-file": "no", "loop-playlist": "no"},
+	}
+	l, err := net.Listen("unix", m.socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		l.Close()
+		os.RemoveAll(dir)
+	})
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go m.serve(conn)
+		}
+	}()
+	return m
+}
+
+func (m *fakeMPV) serve(conn net.Conn) {
+	defer conn.Close()
+	scanner := bufio.NewScanner(conn)
+	enc := json.NewEncoder(conn)
+	for scanner.Scan() {
+		var req struct {
+			Command   []interface{} `json:"command"`
+			RequestID int64         `json:"request_id"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil || len(req.Command) == 0 {
+			_ = enc.Encode(map[string]interface{}{"error": "invalid parameter"})
+			continue
+		}
+		data, errText := m.apply(req.Command)
+		_ = enc.Encode(map[string]interface{}{"event": "property-change", "name": "time-pos"})
+		_ = enc.Encode(map[string]interface{}{"request_id": req.RequestID, "error": errText, "data": data})
+	}
+}
+
+func (m *fakeMPV) apply(cmd []interface{}) (interface{}, string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	name, _ := cmd[0].(string)
+	arg := func(i int) string {
+		if i < len(cmd) {
+			s, _ := cmd[i].(string)
+			return s
+		}
+		return ""
+	}
+	m.commands = append(m.commands, strings.TrimSpace(name+" "+arg(1)+" "+arg(2)))
+
+	switch name {
+	case "loadfile":
+		if _, err := os.Stat(arg(1)); err != nil {
+			return nil, "error running command"
+		}
+		switch arg(2) {
+		case "replace":
+			m.playlist, m.pos, m.timePos = []string{arg(1)}, 0, 0
+		case "append-play":
+			m.playlist = append(m.playlist, arg(1))
+			if m.pos < 0 {
+				m.pos = len(m.playlist) - 1
+			}
+		default:
+			m.playlist = append(m.playlist, arg(1))
+		}
+	case "loadlist":
+		raw, err := os.ReadFile(arg(1))
+		if err != nil {
+			return nil, "error running command"
+		}
+		m.playlist, m.pos = nil, 0
+		for _, line := range strings.Split(string(raw), "\n") {
+			if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "#") {
+				m.playlist = append(m.playlist, filepath.Join(filepath.Dir(arg(1)), line))
+			}
+		}
+	case "playlist-next":
+		if m.pos < 0 {
+			return nil, "error running command"
+		}
+		if m.pos++; m.pos >= len(m.playlist) {
+			m.pos = -1
+		}
+	case "playlist-prev":
+		if m.pos <= 0 {
+			return nil, "error running command"
+		}
+		m.pos--
+	case "seek":
+		if m.pos < 0 {
+			return nil, "error running command"
+		}
+		m.timePos, _ = cmd[1].(float64)
+	case "playlist-shuffle", "playlist-unshuffle", "quit":
+	case "set_property":
+		if arg(1) == "pause" {
+			m.paused, _ = cmd[2].(bool)
+		} else {
+			m.props[arg(1)] = cmd[2]
+		}
+	case "get_property":
+		switch arg(1) {
+		case "playlist-pos":
+			return m.pos, "success"
+		case "pause":
+			return m.paused, "success"
+		case "path", "time-pos", "duration", "media-title":
+			if m.pos < 0 {
+				return nil, "property unavailable"
+			}
+			return map[string]interface{}{
+				"path": m.playlist[m.pos], "time-pos": m.timePos, "duration": 215.5,
+				"media-title": filepath.Base(m.playlist[m.pos]),
+			}[arg(1)], "success"
+		default:
+			if v, ok := m.props[arg(1)]; ok {
+				return v, "success"
+			}
+			return nil, "property not found"
+		}
+	default:
+		return nil, "invalid parameter"
+	}
+	return nil, "success"
+}
+
+func (m *fakeMPV) current() (string, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.pos < 0 {
+		return "", false
+	}
+	return m.playlist[m.pos], !m.paused
+}
+
+func (m *fakeMPV) queued() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]string(nil), m.playlist...)
+}
+
+func newTestLocalMusic(t *testing.T) (*LocalMusicPlugin, *fakeMPV) {
+	t.Helper()
+	mpv := startFakeMPV(t)
+	p := NewLocalMusicPlugin(writeTestLibrary(t), mpv.socket, "")
+	t.Cleanup(func() { _ = p.Stop(context.Background()) })
+	return p, mpv
+}
+
+func TestLocalMusicPlugin_Conformance(t *testing.T) {
+	factory := func(t *testing.T) skills.MusicPlayer {
+		p, _ := newTestLocalMusic(t)
+		return p
+	}
+	skilltest.TestMusicPlayer(t, factory)
+	skilltest.TestMusicCapabilities(t, factory)
+}
+
+func TestLocalMusicPlugin_PlaysFuzzyMatchesThroughMPV(t *testing.T) {
+	p, mpv := newTestLocalMusic(t)
+	ctx := context.Background()
+
+	if err := p.Play(ctx, "hymn weeknd"); err != nil {
+		t.Fatalf("Play: %v", err)
+	}
+	path, playing := mpv.current()
+	if !strings.HasSuffix(path, "01 Hymn for the Weekend.mp3") || !playing {
+		t.Fatalf("mpv playing %q (playing=%v)", path, playing)
+	}
+
+	now, err := p.NowPlaying(ctx)
+	if err != nil {
+		t.Fatalf("NowPlaying: %v", err)
+	}
+	if now.Track != "Hymn for the Weekend" || now.Album != "A Head Full of Dreams" || now.DurationMs != 215500 {
+		t.Fatalf("unexpected now playing: %+v", now)
+	}
+
+	if err := p.Play(ctx, "nothing like this"); err == nil || !strings.Contains(err.Error(), "no local track") {
+		t.Fatalf("expected no match, got %v", err)
+	}
+}
+
+func TestLocalMusicPlugin_AlbumQueueAndRepeat(t *testing.T) {
+	p, mpv := newTestLocalMusic(t)
+	ctx := context.Background()
+
+	if err := p.PlayAlbum(ctx, "parachutes"); err != nil {
+		t.Fatalf("PlayAlbum: %v", err)
+	}
+	if err := p.AddToQueue(ctx, "one more time"); err != nil {
+		t.Fatalf("AddToQueue: %v", err)
+	}
+	var names []string
+	for _, path := range mpv.queued() {
+		names = append(names, filepath.Base(path))
+	}
+	if got := strings.Join(names, ", "); got != "01 Don't Panic.mp3, 05 Yellow.flac, Daft Punk - One More Time.mp3" {
+		t.Fatalf("playlist = %s", got)
+	}
+
+	if err := p.SetRepeat(ctx, skills.RepeatTrack); err != nil {
+		t.Fatalf("SetRepeat: %v", err)
+	}
+	now, _ := p.NowPlaying(ctx)
+	if now.Repeat != skills.RepeatTrack || now.Artists[0] != "Coldplay" {
+		t.Fatalf("unexpected now playing: %+v", now)
+	}
+}
+
+func TestLocalMusicPlugin_ReportsMissingPlayer(t *testing.T) {
+	p := NewLocalMusicPlugin(writeTestLibrary(t), filepath.Join(t.TempDir(), "none.sock"), "")
+	err := p.Pause(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "connect to mpv") {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+func TestMPVClient_AbandonedStartupStopsPlayer(t *testing.T) {
+	dir, err := os.MkdirTemp("", "mpv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	// A player that starts but never opens its socket.
+	binary := filepath.Join(dir, "mpv")
+	if err := os.WriteFile(binary, []byte("#!/bin/sh\nexec sleep 60\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	c := &mpvClient{Socket: filepath.Join(dir, "mpv.sock"), Binary: binary}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	c.mu.Lock()
+	err = c.spawnLocked(ctx)
+	proc := c.proc
+	c.mu.Unlock()
+	if err == nil {
+		t.Fatal("spawn succeeded without a socket")
+	}
+	if proc != nil {
+		t.Fatal("stuck player is still tracked")
+	}
+}
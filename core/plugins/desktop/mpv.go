@@ -0,0 +1,226 @@
+package desktop
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+const (
+	mpvDialTimeout    = 2 * time.Second
+	mpvCommandTimeout = 5 * time.Second
+	mpvStartupTimeout = 5 * time.Second
+)
+
+// mpvClient speaks mpv's JSON IPC protocol over a Unix socket. When no
+// player is listening and Binary is set, it starts an idle mpv itself.
+type mpvClient struct {
+	Socket string
+	Binary string
+
+	mu     sync.Mutex
+	conn   net.Conn
+	reader *bufio.Reader
+	nextID int64
+	proc   *exec.Cmd
+}
+
+// mpvError is a command rejected by mpv, e.g. "property unavailable".
+type mpvError struct {
+	Command string
+	Reason  string
+}
+
+func (e *mpvError) Error() string {
+	return fmt.Sprintf("mpv %s: %s", e.Command, e.Reason)
+}
+
+// command runs one IPC command and returns its data field. A dropped
+// connection is redialed once, so a restarted mpv is picked up.
+func (c *mpvClient) command(ctx context.Context, args ...interface{}) (json.RawMessage, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var lastErr error
+	for attempt := 0; attempt < 2; attempt++ {
+		if err := c.connectLocked(ctx); err != nil {
+			return nil, err
+		}
+		data, err := c.roundTripLocked(ctx, args)
+		var rejected *mpvError
+		if err == nil || errors.As(err, &rejected) || ctx.Err() != nil {
+			return data, err
+		}
+		c.closeConnLocked()
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+func (c *mpvClient) roundTripLocked(ctx context.Context, args []interface{}) (json.RawMessage, error) {
+	c.nextID++
+	id := c.nextID
+	line, err := json.Marshal(map[string]interface{}{"command": args, "request_id": id})
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(mpvCommandTimeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	_ = c.conn.SetDeadline(deadline)
+	stop := context.AfterFunc(ctx, func() { _ = c.conn.SetDeadline(time.Unix(1, 0)) })
+	defer stop()
+
+	if _, err := c.conn.Write(append(line, '\n')); err != nil {
+		return nil, c.wrapIOError(ctx, err)
+	}
+	for {
+		raw, err := c.reader.ReadBytes('\n')
+		if err != nil {
+			return nil, c.wrapIOError(ctx, err)
+		}
+		var reply struct {
+			RequestID int64           `json:"request_id"`
+			Error     string          `json:"error"`
+			Data      json.RawMessage `json:"data"`
+			Event     string          `json:"event"`
+		}
+		if err := json.Unmarshal(raw, &reply); err != nil {
+			return nil, fmt.Errorf("decode mpv reply: %w", err)
+		}
+		// Events and replies to abandoned requests share the socket.
+		if reply.Event != "" || reply.RequestID != id {
+			continue
+		}
+		if reply.Error != "success" {
+			return nil, &mpvError{Command: fmt.Sprint(args[0]), Reason: reply.Error}
+		}
+		return reply.Data, nil
+	}
+}
+
+func (c *mpvClient) wrapIOError(ctx context.Context, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	return fmt.Errorf("mpv ipc: %w", err)
+}
+
+func (c *mpvClient) connectLocked(ctx context.Context) error {
+	if c.conn != nil {
+		return nil
+	}
+	conn, err := c.dial(ctx)
+	if err != nil && c.Binary != "" && c.proc == nil {
+		if err = c.spawnLocked(ctx); err == nil {
+			conn, err = c.dial(ctx)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("connect to mpv at %s: %w", c.Socket, err)
+	}
+	c.conn, c.reader = conn, bufio.NewReader(conn)
+	return nil
+}
+
+func (c *mpvClient) dial(ctx context.Context) (net.Conn, error) {
+	d := net.Dialer{Timeout: mpvDialTimeout}
+	return d.DialContext(ctx, "unix", c.Socket)
+}
+
+// spawnLocked starts an idle, windowless mpv and waits for its socket.
+func (c *mpvClient) spawnLocked(ctx context.Context) error {
+	_ = os.Remove(c.Socket)
+	cmd := exec.Command(c.Binary, "--idle=yes", "--no-video", "--no-terminal", "--input-ipc-server="+c.Socket)
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("start mpv: %w", err)
+	}
+	c.proc = cmd
+	exited := make(chan struct{})
+	go func() {
+		_ = cmd.Wait()
+		close(exited)
+	}()
+
+	// An mpv that never opened its socket is stopped, so the next call
+	// spawns a fresh one instead of dialing a socket that will not appear.
+	abort := func(err error) error {
+		_ = cmd.Process.Kill()
+		<-exited
+		c.proc = nil
+		return err
+	}
+	timer := time.NewTimer(mpvStartupTimeout)
+	defer timer.Stop()
+	for {
+		if _, err := os.Stat(c.Socket); err == nil {
+			return nil
+		}
+		select {
+		case <-exited:
+			c.proc = nil
+			return fmt.Errorf("mpv exited during startup")
+		case <-timer.C:
+			return abort(fmt.Errorf("mpv did not open %s in time", c.Socket))
+		case <-ctx.Done():
+			return abort(ctx.Err())
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+}
+
+func (c *mpvClient) closeConnLocked() {
+	if c.conn != nil {
+		_ = c.conn.Close()
+		c.conn, c.reader = nil, nil
+	}
+}
+
+// Close drops the connection and stops an mpv this client started.
+func (c *mpvClient) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.proc != nil && c.conn != nil {
+		_, _ = c.roundTripLocked(context.Background(), []interface{}{"quit"})
+	}
+	c.closeConnLocked()
+	if c.proc != nil {
+		_ = c.proc.Process.Kill()
+		c.proc = nil
+	}
+	return nil
+}
+
+// getProperty decodes a property into out; ok is false when mpv reports it
+// unavailable, as for playback properties while idle.
+func (c *mpvClient) getProperty(ctx context.Context, name string, out interface{}) (bool, error) {
+	data, err := c.command(ctx, "get_property", name)
+	var rejected *mpvError
+	if errors.As(err, &rejected) && rejected.Reason == "property unavailable" {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return false, fmt.Errorf("decode mpv %s: %w", name, err)
+	}
+	return true, nil
+}
+
+func (c *mpvClient) setProperty(ctx context.Context, name string, value interface{}) error {
+	_, err := c.command(ctx, "set_property", name, value)
+	return err
+}
@@ -0,0 +1,580 @@
+package desktop
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf16"
+)
+
+// minMatchScore is the fuzzy score below which a query finds nothing.
+const minMatchScore = 0.6
+
+var audioExtensions = map[string]bool{
+	".mp3": true, ".flac": true, ".ogg": true, ".oga": true, ".opus": true,
+	".m4a": true, ".aac": true, ".wav": true, ".wma": true,
+}
+
+// LibraryTrack is one audio file in the local catalog.
+type LibraryTrack struct {
+	Path    string    `json:"path"`
+	Title   string    `json:"title"`
+	Artist  string    `json:"artist,omitempty"`
+	Album   string    `json:"album,omitempty"`
+	TrackNo int       `json:"track_no,omitempty"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+}
+
+// LibraryPlaylist is an .m3u or .m3u8 file found in the music folder.
+type LibraryPlaylist struct {
+	Name   string   `json:"name"`
+	Path   string   `json:"path"`
+	Tracks []string `json:"tracks"`
+}
+
+// MusicCatalog indexes a music folder by tags and file names.
+type MusicCatalog struct {
+	Root      string            `json:"root"`
+	Tracks    []LibraryTrack    `json:"tracks"`
+	Playlists []LibraryPlaylist `json:"playlists,omitempty"`
+}
+
+// IndexMusicLibrary walks root and reads tags from every audio file. Entries
+// of previous whose size and modification time are unchanged are reused, so
+// reindexing a large library only reads new or edited files.
+func IndexMusicLibrary(root string, previous *MusicCatalog) (*MusicCatalog, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	known := map[string]LibraryTrack{}
+	if previous != nil && previous.Root == root {
+		for _, t := range previous.Tracks {
+			known[t.Path] = t
+		}
+	}
+
+	catalog := &MusicCatalog{Root: root}
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// Unreadable folders are skipped rather than failing the index.
+			if d != nil && d.IsDir() && path != root {
+				return fs.SkipDir
+			}
+			return err
+		}
+		if d.IsDir() {
+			if strings.HasPrefix(d.Name(), ".") && path != root {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		ext := strings.ToLower(filepath.Ext(path))
+		if ext == ".m3u" || ext == ".m3u8" {
+			if pl, err := readPlaylist(path); err == nil {
+				catalog.Playlists = append(catalog.Playlists, *pl)
+			}
+			return nil
+		}
+		if !audioExtensions[ext] {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		if t, ok := known[path]; ok && t.Size == info.Size() && t.ModTime.Equal(info.ModTime()) {
+			catalog.Tracks = append(catalog.Tracks, t)
+			return nil
+		}
+		catalog.Tracks = append(catalog.Tracks, readTrack(root, path, info))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("index music library: %w", err)
+	}
+
+	sort.Slice(catalog.Tracks, func(i, j int) bool {
+		a, b := catalog.Tracks[i], catalog.Tracks[j]
+		if a.Album != b.Album {
+			return a.Album < b.Album
+		}
+		if a.TrackNo != b.TrackNo {
+			return a.TrackNo < b.TrackNo
+		}
+		return a.Path < b.Path
+	})
+	return catalog, nil
+}
+
+// LoadMusicCatalog reads a catalog saved by Save; a missing file is not an error.
+func LoadMusicCatalog(path string) (*MusicCatalog, error) {
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var c MusicCatalog
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return nil, fmt.Errorf("decode music catalog: %w", err)
+	}
+	return &c, nil
+}
+
+func (c *MusicCatalog) Save(path string) error {
+	raw, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// FindTrack returns the track that best matches a free-form query over
+// title, artist and album.
+func (c *MusicCatalog) FindTrack(query string) (LibraryTrack, bool) {
+	q := tokenize(query)
+	if len(q) == 0 {
+		return LibraryTrack{}, false
+	}
+	best, bestScore := -1, 0.0
+	for i, t := range c.Tracks {
+		score := matchScore(q, tokenize(t.Title+" "+t.Artist+" "+t.Album))
+		// Prefer tracks whose title carries the match over album-mates.
+		score += 0.1 * matchScore(q, tokenize(t.Title))
+		if score > bestScore {
+			best, bestScore = i, score
+		}
+	}
+	if best < 0 || bestScore < minMatchScore {
+		return LibraryTrack{}, false
+	}
+	return c.Tracks[best], true
+}
+
+// FindAlbum returns the tracks of the best matching album in track order.
+func (c *MusicCatalog) FindAlbum(name string) []LibraryTrack {
+	return c.findGroup(name, func(t LibraryTrack) string { return t.Album }, func(t LibraryTrack) string { return t.Album + " " + t.Artist })
+}
+
+// FindArtist returns every track of the best matching artist.
+func (c *MusicCatalog) FindArtist(name string) []LibraryTrack {
+	return c.findGroup(name, func(t LibraryTrack) string { return t.Artist }, func(t LibraryTrack) string { return t.Artist })
+}
+
+// FindPlaylist returns the best matching playlist by name.
+func (c *MusicCatalog) FindPlaylist(name string) (LibraryPlaylist, bool) {
+	q := tokenize(name)
+	best, bestScore := -1, 0.0
+	for i, p := range c.Playlists {
+		if score := matchScore(q, tokenize(p.Name)); score > bestScore {
+			best, bestScore = i, score
+		}
+	}
+	if len(q) == 0 || best < 0 || bestScore < minMatchScore {
+		return LibraryPlaylist{}, false
+	}
+	return c.Playlists[best], true
+}
+
+// Lookup returns the catalog entry for path, if indexed.
+func (c *MusicCatalog) Lookup(path string) (LibraryTrack, bool) {
+	for _, t := range c.Tracks {
+		if t.Path == path {
+			return t, true
+		}
+	}
+	return LibraryTrack{}, false
+}
+
+func (c *MusicCatalog) findGroup(name string, key, text func(LibraryTrack) string) []LibraryTrack {
+	q := tokenize(name)
+	if len(q) == 0 {
+		return nil
+	}
+	bestKey, bestScore := "", 0.0
+	for _, t := range c.Tracks {
+		if key(t) == "" {
+			continue
+		}
+		if score := matchScore(q, tokenize(text(t))); score > bestScore {
+			bestKey, bestScore = key(t), score
+		}
+	}
+	if bestScore < minMatchScore {
+		return nil
+	}
+	var out []LibraryTrack
+	for _, t := range c.Tracks {
+		if key(t) == bestKey {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// matchScore is the average best match of each query token against the
+// candidate tokens: exact 1, prefix 0.8, one typo 0.6.
+func matchScore(query, candidate []string) float64 {
+	if len(query) == 0 || len(candidate) == 0 {
+		return 0
+	}
+	total := 0.0
+	for _, q := range query {
+		best := 0.0
+		for _, c := range candidate {
+			switch {
+			case q == c:
+				best = 1
+			case best < 0.8 && len(q) >= 2 && strings.HasPrefix(c, q):
+				best = 0.8
+			case best < 0.6 && len(q) >= 4 && withinOneEdit(q, c):
+				best = 0.6
+			}
+			if best == 1 {
+				break
+			}
+		}
+		total += best
+	}
+	return total / float64(len(query))
+}
+
+func tokenize(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// withinOneEdit reports whether a and b differ by at most one insertion,
+// deletion or substitution.
+func withinOneEdit(a, b string) bool {
+	ra, rb := []rune(a), []rune(b)
+	if len(ra) > len(rb) {
+		ra, rb = rb, ra
+	}
+	if len(rb)-len(ra) > 1 {
+		return false
+	}
+	i, j, edits := 0, 0, 0
+	for i < len(ra) && j < len(rb) {
+		if ra[i] == rb[j] {
+			i++
+			j++
+			continue
+		}
+		edits++
+		if edits > 1 {
+			return false
+		}
+		if len(ra) == len(rb) {
+			i++
+		}
+		j++
+	}
+	return edits+(len(rb)-j)+(len(ra)-i) <= 1
+}
+
+func readPlaylist(path string) (*LibraryPlaylist, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	pl := &LibraryPlaylist{Name: name, Path: path}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(strings.TrimPrefix(scanner.Text(), "\ufeff"))
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if !filepath.IsAbs(line) && !strings.Contains(line, "://") {
+			line = filepath.Join(filepath.Dir(path), filepath.FromSlash(line))
+		}
+		pl.Tracks = append(pl.Tracks, line)
+	}
+	return pl, scanner.Err()
+}
+
+// readTrack fills a catalog entry from embedded tags, falling back to the
+// "Artist/Album/NN Artist - Title.ext" folder layout.
+func readTrack(root, path string, info fs.FileInfo) LibraryTrack {
+	t := LibraryTrack{Path: path, Size: info.Size(), ModTime: info.ModTime()}
+	if tags, err := readTags(path); err == nil {
+		t.Title, t.Artist, t.Album = tags["title"], tags["artist"], tags["album"]
+		t.TrackNo = parseTrackNo(tags["track"])
+	}
+
+	base := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	if t.TrackNo == 0 {
+		t.TrackNo = parseTrackNo(base)
+	}
+	base = strings.TrimLeft(base, "0123456789 .-_")
+	if base == "" {
+		base = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	fileArtist, fileTitle, found := strings.Cut(base, " - ")
+	if !found {
+		fileArtist, fileTitle = "", base
+	}
+	if t.Title == "" {
+		t.Title = strings.TrimSpace(fileTitle)
+	}
+	if t.Artist == "" {
+		t.Artist = strings.TrimSpace(fileArtist)
+	}
+
+	rel, err := filepath.Rel(root, filepath.Dir(path))
+	if err == nil && rel != "." {
+		parts := strings.Split(rel, string(filepath.Separator))
+		if t.Album == "" {
+			t.Album = parts[len(parts)-1]
+		}
+		if t.Artist == "" && len(parts) >= 2 {
+			t.Artist = parts[len(parts)-2]
+		}
+	}
+	return t
+}
+
+func parseTrackNo(s string) int {
+	s = strings.TrimSpace(s)
+	end := 0
+	for end < len(s) && s[end] >= '0' && s[end] <= '9' {
+		end++
+	}
+	n, _ := strconv.Atoi(s[:end])
+	return n
+}
+
+// readTags understands ID3v2 (2.2-2.4), ID3v1 and FLAC Vorbis comments. It
+// returns lower-case keys title, artist, album and track.
+func readTags(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	head := make([]byte, 10)
+	if _, err := io.ReadFull(f, head); err != nil {
+		return nil, err
+	}
+	switch {
+	case bytes.HasPrefix(head, []byte("ID3")):
+		if tags, err := readID3v2(f, head); err == nil && len(tags) > 0 {
+			return tags, nil
+		}
+	case bytes.HasPrefix(head, []byte("fLaC")):
+		return readFLACComments(f)
+	}
+	return readID3v1(f)
+}
+
+func readID3v2(r io.Reader, head []byte) (map[string]string, error) {
+	version, flags := head[3], head[5]
+	size := syncsafe(head[6:10])
+	const maxTagBytes = 4 << 20
+	if size > maxTagBytes {
+		return nil, fmt.Errorf("id3 tag too large")
+	}
+	body := make([]byte, size)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, err
+	}
+	if flags&0x40 != 0 && version >= 3 && len(body) >= 4 {
+		ext := int(binary.BigEndian.Uint32(body[:4]))
+		if version == 4 {
+			ext = syncsafe(body[:4])
+		} else {
+			ext += 4
+		}
+		if ext > len(body) {
+			return nil, fmt.Errorf("bad id3 extended header")
+		}
+		body = body[ext:]
+	}
+
+	ids := map[string]string{"TIT2": "title", "TPE1": "artist", "TALB": "album", "TRCK": "track"}
+	idLen, headLen := 4, 10
+	if version == 2 {
+		ids = map[string]string{"TT2": "title", "TP1": "artist", "TAL": "album", "TRK": "track"}
+		idLen, headLen = 3, 6
+	}
+
+	tags := map[string]string{}
+	for len(body) >= headLen && body[0] != 0 {
+		id := string(body[:idLen])
+		var n int
+		switch version {
+		case 2:
+			n = int(body[3])<<16 | int(body[4])<<8 | int(body[5])
+		case 4:
+			n = syncsafe(body[4:8])
+		default:
+			n = int(binary.BigEndian.Uint32(body[4:8]))
+		}
+		if n < 0 || headLen+n > len(body) {
+			break
+		}
+		if key, ok := ids[id]; ok {
+			tags[key] = decodeID3Text(body[headLen : headLen+n])
+		}
+		body = body[headLen+n:]
+	}
+	return tags, nil
+}
+
+func decodeID3Text(b []byte) string {
+	if len(b) == 0 {
+		return ""
+	}
+	enc, b := b[0], b[1:]
+	var s string
+	switch enc {
+	case 1, 2:
+		bigEndian := enc == 2
+		if len(b) >= 2 && b[0] == 0xFF && b[1] == 0xFE {
+			bigEndian, b = false, b[2:]
+		} else if len(b) >= 2 && b[0] == 0xFE && b[1] == 0xFF {
+			bigEndian, b = true, b[2:]
+		}
+		units := make([]uint16, 0, len(b)/2)
+		for i := 0; i+1 < len(b); i += 2 {
+			if bigEndian {
+				units = append(units, uint16(b[i])<<8|uint16(b[i+1]))
+			} else {
+				units = append(units, uint16(b[i+1])<<8|uint16(b[i]))
+			}
+		}
+		s = string(utf16.Decode(units))
+	case 3:
+		s = string(b)
+	default:
+		runes := make([]rune, len(b))
+		for i, c := range b {
+			runes[i] = rune(c)
+		}
+		s = string(runes)
+	}
+	// Multiple values are NUL separated; keep the first.
+	s, _, _ = strings.Cut(s, "\x00")
+	return strings.TrimSpace(s)
+}
+
+func readID3v1(f *os.File) (map[string]string, error) {
+	info, err := f.Stat()
+	if err != nil || info.Size() < 128 {
+		return nil, fmt.Errorf("no tags")
+	}
+	tag := make([]byte, 128)
+	if _, err := f.ReadAt(tag, info.Size()-128); err != nil {
+		return nil, err
+	}
+	if !bytes.HasPrefix(tag, []byte("TAG")) {
+		return nil, fmt.Errorf("no tags")
+	}
+	field := func(b []byte) string {
+		b, _, _ = bytes.Cut(b, []byte{0})
+		return strings.TrimSpace(decodeID3Text(append([]byte{0}, b...)))
+	}
+	tags := map[string]string{"title": field(tag[3:33]), "artist": field(tag[33:63]), "album": field(tag[63:93])}
+	// ID3v1.1 keeps the track number in the last comment byte.
+	if tag[125] == 0 && tag[126] != 0 {
+		tags["track"] = strconv.Itoa(int(tag[126]))
+	}
+	return tags, nil
+}
+
+func readFLACComments(f *os.File) (map[string]string, error) {
+	if _, err := f.Seek(4, io.SeekStart); err != nil {
+		return nil, err
+	}
+	for {
+		var header [4]byte
+		if _, err := io.ReadFull(f, header[:]); err != nil {
+			return nil, err
+		}
+		last, kind := header[0]&0x80 != 0, header[0]&0x7F
+		n := int(header[1])<<16 | int(header[2])<<8 | int(header[3])
+		if kind != 4 {
+			if last {
+				return nil, fmt.Errorf("no vorbis comment")
+			}
+			if _, err := f.Seek(int64(n), io.SeekCurrent); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		block := make([]byte, n)
+		if _, err := io.ReadFull(f, block); err != nil {
+			return nil, err
+		}
+		return parseVorbisComments(block)
+	}
+}
+
+func parseVorbisComments(b []byte) (map[string]string, error) {
+	next := func() ([]byte, bool) {
+		if len(b) < 4 {
+			return nil, false
+		}
+		n := int(binary.LittleEndian.Uint32(b[:4]))
+		if n < 0 || 4+n > len(b) {
+			return nil, false
+		}
+		v := b[4 : 4+n]
+		b = b[4+n:]
+		return v, true
+	}
+	if _, ok := next(); !ok { // vendor string
+		return nil, fmt.Errorf("bad vorbis comment")
+	}
+	if len(b) < 4 {
+		return nil, fmt.Errorf("bad vorbis comment")
+	}
+	count := int(binary.LittleEndian.Uint32(b[:4]))
+	b = b[4:]
+
+	keys := map[string]string{"TITLE": "title", "ARTIST": "artist", "ALBUM": "album", "TRACKNUMBER": "track"}
+	tags := map[string]string{}
+	for i := 0; i < count; i++ {
+		entry, ok := next()
+		if !ok {
+			break
+		}
+		k, v, _ := strings.Cut(string(entry), "=")
+		if key, ok := keys[strings.ToUpper(k)]; ok && tags[key] == "" {
+			tags[key] = strings.TrimSpace(v)
+		}
+	}
+	return tags, nil
+}
+
+func syncsafe(b []byte) int {
+	return int(b[0]&0x7F)<<21 | int(b[1]&0x7F)<<14 | int(b[2]&0x7F)<<7 | int(b[3]&0x7F)
+}
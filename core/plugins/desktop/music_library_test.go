@@ -0,0 +1,201 @@
+package desktop
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeTestLibrary lays out a small library mixing ID3v2, FLAC and untagged
+// files plus an .m3u playlist, and returns its root.
+func writeTestLibrary(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	write := func(rel string, data []byte) {
+		path := filepath.Join(root, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("Coldplay/A Head Full of Dreams/01 Hymn for the Weekend.mp3", id3v23(map[string]string{
+		"TIT2": "Hymn for the Weekend", "TPE1": "Coldplay", "TALB": "A Head Full of Dreams", "TRCK": "1/11",
+	}))
+	write("Coldplay/Parachutes/05 Yellow.flac", flacWithComments("TITLE=Yellow", "ARTIST=Coldplay", "ALBUM=Parachutes", "TRACKNUMBER=5"))
+	write("Coldplay/Parachutes/01 Don't Panic.mp3", []byte("untagged audio"))
+	write("Daft Punk - One More Time.mp3", []byte("untagged audio"))
+	write("Focus.m3u", []byte("#EXTM3U\nColdplay/Parachutes/05 Yellow.flac\n"))
+	write(".cache/ignored.mp3", []byte("hidden"))
+	return root
+}
+
+func id3v23(frames map[string]string) []byte {
+	var body bytes.Buffer
+	for _, id := range []string{"TIT2", "TPE1", "TALB", "TRCK"} {
+		text, ok := frames[id]
+		if !ok {
+			continue
+		}
+		body.WriteString(id)
+		_ = binary.Write(&body, binary.BigEndian, uint32(len(text)+1))
+		body.Write([]byte{0, 0, 0}) // flags, then ISO-8859-1 encoding
+		body.WriteString(text)
+	}
+	n := body.Len()
+	header := []byte{'I', 'D', '3', 3, 0, 0, byte(n >> 21 & 0x7F), byte(n >> 14 & 0x7F), byte(n >> 7 & 0x7F), byte(n & 0x7F)}
+	return append(append(header, body.Bytes()...), "audio frames"...)
+}
+
+func flacWithComments(comments ...string) []byte {
+	var block bytes.Buffer
+	le := func(n int) { _ = binary.Write(&block, binary.LittleEndian, uint32(n)) }
+	le(len("test"))
+	block.WriteString("test")
+	le(len(comments))
+	for _, c := range comments {
+		le(len(c))
+		block.WriteString(c)
+	}
+
+	out := []byte("fLaC")
+	streamInfo := make([]byte, 34)
+	out = append(out, 0, 0, 0, byte(len(streamInfo)))
+	out = append(out, streamInfo...)
+	n := block.Len()
+	out = append(out, 0x80|4, byte(n>>16), byte(n>>8), byte(n))
+	return append(out, block.Bytes()...)
+}
+
+func TestIndexMusicLibrary_ReadsTagsAndFileNames(t *testing.T) {
+	root := writeTestLibrary(t)
+	catalog, err := IndexMusicLibrary(root, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := map[string]LibraryTrack{}
+	for _, tr := range catalog.Tracks {
+		got[filepath.Base(tr.Path)] = tr
+	}
+	if len(got) != 4 {
+		t.Fatalf("indexed %d tracks, want 4 (hidden folders skipped): %+v", len(got), catalog.Tracks)
+	}
+	check := func(file, title, artist, album string, no int) {
+		t.Helper()
+		tr := got[file]
+		if tr.Title != title || tr.Artist != artist || tr.Album != album || tr.TrackNo != no {
+			t.Errorf("%s = %+v", file, tr)
+		}
+	}
+	check("01 Hymn for the Weekend.mp3", "Hymn for the Weekend", "Coldplay", "A Head Full of Dreams", 1)
+	check("05 Yellow.flac", "Yellow", "Coldplay", "Parachutes", 5)
+	check("01 Don't Panic.mp3", "Don't Panic", "Coldplay", "Parachutes", 1)
+	check("Daft Punk - One More Time.mp3", "One More Time", "Daft Punk", "", 0)
+
+	if len(catalog.Playlists) != 1 || catalog.Playlists[0].Name != "Focus" ||
+		catalog.Playlists[0].Tracks[0] != filepath.Join(root, "Coldplay", "Parachutes", "05 Yellow.flac") {
+		t.Fatalf("playlists = %+v", catalog.Playlists)
+	}
+}
+
+func TestIndexMusicLibrary_ReusesUnchangedEntries(t *testing.T) {
+	root := writeTestLibrary(t)
+	first, err := IndexMusicLibrary(root, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A cached title proves the file was not read again.
+	first.Tracks[0].Title = "From cache"
+	cachePath := filepath.Join(t.TempDir(), "catalog.json")
+	if err := first.Save(cachePath); err != nil {
+		t.Fatal(err)
+	}
+	cached, err := LoadMusicCatalog(cachePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	second, err := IndexMusicLibrary(root, cached)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := findTitle(second, "From cache"); !ok {
+		t.Fatal("unchanged file was re-read instead of reused")
+	}
+
+	touched := first.Tracks[0].Path
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(touched, later, later); err != nil {
+		t.Fatal(err)
+	}
+	third, _ := IndexMusicLibrary(root, cached)
+	if _, ok := findTitle(third, "From cache"); ok {
+		t.Fatal("modified file should be re-read")
+	}
+}
+
+func findTitle(c *MusicCatalog, title string) (LibraryTrack, bool) {
+	for _, t := range c.Tracks {
+		if t.Title == title {
+			return t, true
+		}
+	}
+	return LibraryTrack{}, false
+}
+
+func TestMusicCatalog_FuzzyMatching(t *testing.T) {
+	catalog, err := IndexMusicLibrary(writeTestLibrary(t), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for query, want := range map[string]string{
+		"yellow":             "Yellow",
+		"YELOW coldplay":     "Yellow",
+		"hymn":               "Hymn for the Weekend",
+		"daft punk one more": "One More Time",
+		"dont panic":         "Don't Panic",
+	} {
+		tr, ok := catalog.FindTrack(query)
+		if !ok || tr.Title != want {
+			t.Errorf("FindTrack(%q) = %q, %v; want %q", query, tr.Title, ok, want)
+		}
+	}
+	if tr, ok := catalog.FindTrack("bohemian rhapsody"); ok {
+		t.Errorf("unexpected match %+v", tr)
+	}
+
+	if album := catalog.FindAlbum("parachute"); len(album) != 2 || album[0].TrackNo != 1 {
+		t.Errorf("FindAlbum = %+v", album)
+	}
+	if artist := catalog.FindArtist("coldplay"); len(artist) != 3 {
+		t.Errorf("FindArtist = %d tracks, want 3", len(artist))
+	}
+	if _, ok := catalog.FindPlaylist("focus"); !ok {
+		t.Error("FindPlaylist(focus) found nothing")
+	}
+}
+
+func TestReadTags_ID3v24UTF8(t *testing.T) {
+	text := "Señorita"
+	frame := append([]byte("TIT2"), byte(0), byte(0), byte(0), byte(len(text)+1), 0, 0, 3)
+	frame = append(frame, text...)
+	n := len(frame)
+	data := append([]byte{'I', 'D', '3', 4, 0, 0, 0, 0, 0, byte(n)}, frame...)
+	path := filepath.Join(t.TempDir(), "x.mp3")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tags, err := readTags(path)
+	if err != nil || tags["title"] != text {
+		t.Fatalf("tags = %v, %v", tags, err)
+	}
+	if !strings.EqualFold(decodeID3Text([]byte{1, 0xFF, 0xFE, 'H', 0, 'i', 0}), "hi") {
+		t.Error("UTF-16 text not decoded")
+	}
+}
@@ -0,0 +1,24 @@
+package desktop
+
+import (
+	"testing"
+
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/plugins/spotify"
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/plugins/spotify/spotifytest"
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/skills"
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/skills/skilltest"
+)
+
+func newTestMusicPlugin(t *testing.T) skills.MusicPlayer {
+	srv := spotifytest.NewServer(t)
+	return &MusicPlugin{PlayerClient: &spotify.PlayerClient{
+		AccessToken: spotifytest.Token,
+		HTTPClient:  srv.Client(),
+		BaseURL:     srv.URL,
+	}}
+}
+
+func TestMusicPlugin_Conformance(t *testing.T) {
+	skilltest.TestMusicPlayer(t, newTestMusicPlugin)
+	skilltest.TestMusicCapabilities(t, newTestMusicPlugin)
+}
@@ -0,0 +1,414 @@
+package spotify
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// ClientIDEnv names the Spotify app used for the PKCE login.
+	ClientIDEnv = "SPOTIFY_CLIENT_ID"
+	// RedirectURLEnv overrides the loopback redirect registered with the app.
+	RedirectURLEnv = "SPOTIFY_REDIRECT_URL"
+	// TokenPathEnv overrides where the login is persisted.
+	TokenPathEnv = "SPOTIFY_TOKEN_PATH"
+
+	spotifyAuthURL     = "https://accounts.spotify.com/authorize"
+	spotifyTokenURL    = "https://accounts.spotify.com/api/token"
+	defaultRedirectURL = "http://127.0.0.1:8888/callback"
+	// expiryLeeway refreshes a little early so requests never race the expiry.
+	expiryLeeway = 30 * time.Second
+)
+
+// DefaultScopes covers every player action the plugin performs.
+var DefaultScopes = []string{
+	"user-read-playback-state",
+	"user-modify-playback-state",
+	"user-read-currently-playing",
+	"playlist-read-private",
+}
+
+// ErrNotLoggedIn is returned when no refreshable login is stored.
+var ErrNotLoggedIn = errors.New("spotify: not logged in; run `upcraft-cli spotify login`")
+
+// Token is a persisted Spotify login.
+type Token struct {
+	AccessToken  string    `json:"access_token"`
+	RefreshToken string    `json:"refresh_token"`
+	Scope        string    `json:"scope,omitempty"`
+	Expiry       time.Time `json:"expiry"`
+}
+
+// Expired reports whether the access token is at or near its expiry.
+func (t *Token) Expired(now time.Time) bool {
+	return t.Expiry.IsZero() || !now.Add(expiryLeeway).Before(t.Expiry)
+}
+
+// TokenStore persists the login between runs.
+type TokenStore interface {
+	Load() (*Token, error)
+	Save(*Token) error
+	Delete() error
+}
+
+// FileTokenStore keeps the token as JSON readable only by the current user.
+// Load returns ErrNotLoggedIn when the file does not exist.
+type FileTokenStore struct {
+	Path string
+}
+
+// DefaultTokenPath uses SPOTIFY_TOKEN_PATH or spotify_token.json in the
+// user's config directory.
+func DefaultTokenPath() (string, error) {
+	if path := strings.TrimSpace(os.Getenv(TokenPathEnv)); path != "" {
+		return path, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("locate config dir: %w", err)
+	}
+	return filepath.Join(dir, "upcraft", "spotify_token.json"), nil
+}
+
+func (s *FileTokenStore) Load() (*Token, error) {
+	raw, err := os.ReadFile(s.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotLoggedIn
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read spotify token: %w", err)
+	}
+	var tok Token
+	if err := json.Unmarshal(raw, &tok); err != nil {
+		return nil, fmt.Errorf("decode spotify token: %w", err)
+	}
+	return &tok, nil
+}
+
+func (s *FileTokenStore) Save(tok *Token) error {
+	raw, err := json.MarshalIndent(tok, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.Path), 0o700); err != nil {
+		return fmt.Errorf("create token dir: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.Path), ".spotify-token-*")
+	if err != nil {
+		return fmt.Errorf("save spotify token: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(raw); err != nil {
+		tmp.Close()
+		return fmt.Errorf("save spotify token: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("save spotify token: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.Path); err != nil {
+		return fmt.Errorf("save spotify token: %w", err)
+	}
+	return nil
+}
+
+func (s *FileTokenStore) Delete() error {
+	if err := os.Remove(s.Path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("delete spotify token: %w", err)
+	}
+	return nil
+}
+
+// Authenticator runs the authorization code flow with PKCE and keeps the
+// stored token fresh. It is safe for concurrent use.
+type Authenticator struct {
+	ClientID    string
+	RedirectURL string
+	Scopes      []string
+	Store       TokenStore
+	HTTPClient  *http.Client
+	// AuthURL and TokenURL override the Spotify accounts endpoints.
+	AuthURL  string
+	TokenURL string
+
+	mu  sync.Mutex
+	now func() time.Time
+}
+
+// NewAuthenticatorFromEnv reads SPOTIFY_CLIENT_ID, SPOTIFY_REDIRECT_URL and
+// SPOTIFY_TOKEN_PATH.
+func NewAuthenticatorFromEnv() (*Authenticator, error) {
+	clientID := strings.TrimSpace(os.Getenv(ClientIDEnv))
+	if clientID == "" {
+		return nil, fmt.Errorf("%s is required", ClientIDEnv)
+	}
+	path, err := DefaultTokenPath()
+	if err != nil {
+		return nil, err
+	}
+	return &Authenticator{
+		ClientID:    clientID,
+		RedirectURL: strings.TrimSpace(os.Getenv(RedirectURLEnv)),
+		Store:       &FileTokenStore{Path: path},
+		HTTPClient:  &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+// Login opens the consent page with open, waits on the loopback redirect for
+// the authorization code and stores the resulting token.
+func (a *Authenticator) Login(ctx context.Context, open func(authURL string) error) (*Token, error) {
+	redirect, err := url.Parse(a.redirectURL())
+	if err != nil {
+		return nil, fmt.Errorf("invalid redirect url: %w", err)
+	}
+	if host := redirect.Hostname(); host != "127.0.0.1" && host != "localhost" && host != "::1" {
+		return nil, fmt.Errorf("redirect url must be a loopback address, got %s", redirect.Host)
+	}
+	listener, err := net.Listen("tcp", redirect.Host)
+	if err != nil {
+		return nil, fmt.Errorf("listen for spotify redirect: %w", err)
+	}
+	defer listener.Close()
+
+	verifier, err := randomString(64)
+	if err != nil {
+		return nil, err
+	}
+	state, err := randomString(16)
+	if err != nil {
+		return nil, err
+	}
+
+	type callback struct {
+		code string
+		err  error
+	}
+	results := make(chan callback, 1)
+	mux := http.NewServeMux()
+	mux.HandleFunc(redirect.Path, func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		var res callback
+		switch {
+		case q.Get("state") != state:
+			res.err = fmt.Errorf("spotify login: state mismatch")
+		case q.Get("error") != "":
+			res.err = fmt.Errorf("spotify login denied: %s", q.Get("error"))
+		case q.Get("code") == "":
+			res.err = fmt.Errorf("spotify login: missing authorization code")
+		default:
+			res.code = q.Get("code")
+		}
+		if res.err != nil {
+			http.Error(w, res.err.Error(), http.StatusBadRequest)
+		} else {
+			fmt.Fprintln(w, "UpCraft is connected to Spotify. You can close this tab.")
+		}
+		select {
+		case results <- res:
+		default:
+		}
+	})
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() { _ = server.Serve(listener) }()
+	defer server.Close()
+
+	if err := open(a.authCodeURL(state, challengeS256(verifier))); err != nil {
+		return nil, err
+	}
+
+	var res callback
+	select {
+	case res = <-results:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if res.err != nil {
+		return nil, res.err
+	}
+
+	tok, err := a.exchange(ctx, url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {res.code},
+		"redirect_uri":  {a.redirectURL()},
+		"code_verifier": {verifier},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := a.Store.Save(tok); err != nil {
+		return nil, err
+	}
+	return tok, nil
+}
+
+// Token returns a stored token, refreshing it first if it has expired.
+func (a *Authenticator) Token(ctx context.Context) (*Token, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	tok, err := a.Store.Load()
+	if err != nil {
+		return nil, err
+	}
+	if !tok.Expired(a.clock()) {
+		return tok, nil
+	}
+	return a.refreshLocked(ctx, tok)
+}
+
+// Refresh exchanges the stored refresh token for a new access token unless
+// another caller already replaced stale. It is used after a 401.
+func (a *Authenticator) Refresh(ctx context.Context, stale string) (*Token, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	tok, err := a.Store.Load()
+	if err != nil {
+		return nil, err
+	}
+	if tok.AccessToken != stale && !tok.Expired(a.clock()) {
+		return tok, nil
+	}
+	return a.refreshLocked(ctx, tok)
+}
+
+func (a *Authenticator) refreshLocked(ctx context.Context, tok *Token) (*Token, error) {
+	if tok.RefreshToken == "" {
+		return nil, ErrNotLoggedIn
+	}
+	fresh, err := a.exchange(ctx, url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {tok.RefreshToken},
+	})
+	if err != nil {
+		return nil, err
+	}
+	// Spotify may omit the refresh token, in which case the old one stays valid.
+	if fresh.RefreshToken == "" {
+		fresh.RefreshToken = tok.RefreshToken
+	}
+	if err := a.Store.Save(fresh); err != nil {
+		return nil, err
+	}
+	return fresh, nil
+}
+
+func (a *Authenticator) exchange(ctx context.Context, form url.Values) (*Token, error) {
+	form.Set("client_id", a.ClientID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.tokenURL(), strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	client := a.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("send token request: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read token response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		var oauthErr struct {
+			Error       string `json:"error"`
+			Description string `json:"error_description"`
+		}
+		if json.Unmarshal(body, &oauthErr) == nil && oauthErr.Error == "invalid_grant" {
+			return nil, fmt.Errorf("%w (%s)", ErrNotLoggedIn, oauthErr.Description)
+		}
+		return nil, fmt.Errorf("spotify token request failed status=%d body=%s", resp.StatusCode, string(body))
+	}
+
+	var decoded struct {
+		AccessToken  string `json:"access_token"`
+		RefreshToken string `json:"refresh_token"`
+		Scope        string `json:"scope"`
+		ExpiresIn    int    `json:"expires_in"`
+	}
+	if err := json.Unmarshal(body, &decoded); err != nil {
+		return nil, fmt.Errorf("decode token response: %w", err)
+	}
+	if decoded.AccessToken == "" {
+		return nil, fmt.Errorf("token response has no access_token")
+	}
+	return &Token{
+		AccessToken:  decoded.AccessToken,
+		RefreshToken: decoded.RefreshToken,
+		Scope:        decoded.Scope,
+		Expiry:       a.clock().Add(time.Duration(decoded.ExpiresIn) * time.Second),
+	}, nil
+}
+
+func (a *Authenticator) authCodeURL(state, challenge string) string {
+	scopes := a.Scopes
+	if len(scopes) == 0 {
+		scopes = DefaultScopes
+	}
+	q := url.Values{
+		"client_id":             {a.ClientID},
+		"response_type":         {"code"},
+		"redirect_uri":          {a.redirectURL()},
+		"scope":                 {strings.Join(scopes, " ")},
+		"state":                 {state},
+		"code_challenge_method": {"S256"},
+		"code_challenge":        {challenge},
+	}
+	base := a.AuthURL
+	if base == "" {
+		base = spotifyAuthURL
+	}
+	return base + "?" + q.Encode()
+}
+
+func (a *Authenticator) redirectURL() string {
+	if a.RedirectURL != "" {
+		return a.RedirectURL
+	}
+	return defaultRedirectURL
+}
+
+func (a *Authenticator) tokenURL() string {
+	if a.TokenURL != "" {
+		return a.TokenURL
+	}
+	return spotifyTokenURL
+}
+
+func (a *Authenticator) clock() time.Time {
+	if a.now != nil {
+		return a.now()
+	}
+	return time.Now()
+}
+
+// challengeS256 derives the PKCE code challenge for verifier (RFC 7636).
+func challengeS256(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// randomString returns n URL-safe characters, valid as a PKCE verifier.
+func randomString(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate random string: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)[:n], nil
+}
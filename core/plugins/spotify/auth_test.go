@@ -0,0 +1,177 @@
+package spotify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/plugins/spotify/spotifytest"
+)
+
+func newTestAuth(t *testing.T, srv *spotifytest.Server) *Authenticator {
+	t.Helper()
+	return &Authenticator{
+		ClientID:   spotifytest.ClientID,
+		Store:      &FileTokenStore{Path: filepath.Join(t.TempDir(), "token.json")},
+		HTTPClient: srv.Client(),
+		AuthURL:    srv.URL + "/authorize",
+		TokenURL:   srv.URL + "/api/token",
+	}
+}
+
+func freeLoopbackURL(t *testing.T) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	return fmt.Sprintf("http://%s/callback", l.Addr())
+}
+
+func TestAuthenticator_LoginWithPKCE(t *testing.T) {
+	srv := spotifytest.NewServer(t)
+	auth := newTestAuth(t, srv)
+	auth.RedirectURL = freeLoopbackURL(t)
+
+	// The "browser" follows the consent redirect back to the loopback server.
+	browser := func(authURL string) error {
+		if !strings.Contains(authURL, "code_challenge_method=S256") {
+			return fmt.Errorf("auth url lacks PKCE: %s", authURL)
+		}
+		resp, err := http.Get(authURL)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			body, _ := io.ReadAll(resp.Body)
+			return fmt.Errorf("callback status=%d body=%s", resp.StatusCode, body)
+		}
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	tok, err := auth.Login(ctx, browser)
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	if tok.AccessToken != spotifytest.Token || tok.RefreshToken != spotifytest.RefreshToken {
+		t.Fatalf("unexpected token: %+v", tok)
+	}
+	stored, err := auth.Store.Load()
+	if err != nil || stored.RefreshToken != spotifytest.RefreshToken {
+		t.Fatalf("stored token = %+v, %v", stored, err)
+	}
+}
+
+func TestAuthenticator_RejectsNonLoopbackRedirect(t *testing.T) {
+	auth := &Authenticator{ClientID: "x", RedirectURL: "https://example.com/callback"}
+	_, err := auth.Login(context.Background(), func(string) error { return nil })
+	if err == nil || !strings.Contains(err.Error(), "loopback") {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+func TestPlayerClient_RefreshesOn401AndRetries(t *testing.T) {
+	srv := spotifytest.NewServer(t)
+	auth := newTestAuth(t, srv)
+	if err := auth.Store.Save(&Token{
+		AccessToken:  spotifytest.Token,
+		RefreshToken: spotifytest.RefreshToken,
+		Expiry:       time.Now().Add(time.Hour),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	srv.ExpireToken()
+
+	client := &PlayerClient{Auth: auth, HTTPClient: srv.Client(), BaseURL: srv.URL}
+	if err := client.Play(context.Background(), "Yellow"); err != nil {
+		t.Fatalf("Play: %v", err)
+	}
+	if srv.Refreshes() != 1 || !srv.Playing() {
+		t.Fatalf("refreshes = %d, playing = %v", srv.Refreshes(), srv.Playing())
+	}
+
+	stored, _ := auth.Store.Load()
+	if stored.AccessToken != srv.AccessToken() || stored.RefreshToken != spotifytest.RefreshToken {
+		t.Fatalf("refreshed token not persisted: %+v", stored)
+	}
+
+	// The new token is reused instead of refreshing again.
+	if err := client.Pause(context.Background()); err != nil {
+		t.Fatalf("Pause: %v", err)
+	}
+	if srv.Refreshes() != 1 {
+		t.Fatalf("refreshes = %d, want 1", srv.Refreshes())
+	}
+}
+
+// failRetry lets the first Web API call through and fails the next one
+// before it reaches the server; token requests always pass.
+type failRetry struct {
+	next  http.RoundTripper
+	calls int
+}
+
+func (f *failRetry) RoundTrip(r *http.Request) (*http.Response, error) {
+	if r.URL.Path != "/api/token" {
+		f.calls++
+		if f.calls > 1 {
+			return nil, context.Canceled
+		}
+	}
+	return f.next.RoundTrip(r)
+}
+
+func TestPlayerClient_RetryTransportErrorIsReturned(t *testing.T) {
+	srv := spotifytest.NewServer(t)
+	auth := newTestAuth(t, srv)
+	_ = auth.Store.Save(&Token{AccessToken: spotifytest.Token, RefreshToken: spotifytest.RefreshToken, Expiry: time.Now().Add(time.Hour)})
+	srv.ExpireToken()
+
+	httpClient := srv.Client()
+	httpClient.Transport = &failRetry{next: httpClient.Transport}
+	client := &PlayerClient{Auth: auth, HTTPClient: httpClient, BaseURL: srv.URL}
+	err := client.Pause(context.Background())
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want the retry's transport error", err)
+	}
+	if srv.Refreshes() != 1 {
+		t.Fatalf("refreshes = %d, want 1", srv.Refreshes())
+	}
+}
+
+func TestPlayerClient_RefreshesExpiredTokenBeforeUse(t *testing.T) {
+	srv := spotifytest.NewServer(t)
+	auth := newTestAuth(t, srv)
+	_ = auth.Store.Save(&Token{AccessToken: "old", RefreshToken: spotifytest.RefreshToken, Expiry: time.Now().Add(-time.Minute)})
+
+	client := &PlayerClient{Auth: auth, HTTPClient: srv.Client(), BaseURL: srv.URL}
+	if err := client.Play(context.Background(), "Yellow"); err != nil {
+		t.Fatalf("Play: %v", err)
+	}
+	if got := len(srv.Requests()); got != 2 {
+		t.Fatalf("expected search and play only, got %v", srv.Requests())
+	}
+}
+
+func TestPlayerClient_RevokedLoginAsksToLogInAgain(t *testing.T) {
+	srv := spotifytest.NewServer(t)
+	auth := newTestAuth(t, srv)
+	_ = auth.Store.Save(&Token{AccessToken: "old", RefreshToken: "revoked", Expiry: time.Now().Add(time.Hour)})
+
+	client := &PlayerClient{Auth: auth, HTTPClient: srv.Client(), BaseURL: srv.URL}
+	err := client.Pause(context.Background())
+	if !errors.Is(err, ErrNotLoggedIn) {
+		t.Fatalf("err = %v, want ErrNotLoggedIn", err)
+	}
+}
@@ -0,0 +1,213 @@
+package spotify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/skills"
+)
+
+var (
+	_ skills.MusicPlayer    = (*PlayerClient)(nil)
+	_ skills.MusicNavigator = (*PlayerClient)(nil)
+	_ skills.MusicVolume    = (*PlayerClient)(nil)
+	_ skills.MusicModes     = (*PlayerClient)(nil)
+	_ skills.MusicQueue     = (*PlayerClient)(nil)
+	_ skills.MusicLibrary   = (*PlayerClient)(nil)
+	_ skills.MusicDevices   = (*PlayerClient)(nil)
+	_ skills.MusicStatus    = (*PlayerClient)(nil)
+)
+
+func (c *PlayerClient) Previous(ctx context.Context) error {
+	_, err := c.request(ctx, http.MethodPost, "/me/player/previous", nil)
+	return err
+}
+
+func (c *PlayerClient) Seek(ctx context.Context, positionMs int) error {
+	if positionMs < 0 {
+		return fmt.Errorf("position_ms must not be negative")
+	}
+	_, err := c.request(ctx, http.MethodPut, "/me/player/seek?position_ms="+strconv.Itoa(positionMs), nil)
+	return err
+}
+
+func (c *PlayerClient) SetVolume(ctx context.Context, percent int) error {
+	if percent < 0 || percent > 100 {
+		return fmt.Errorf("volume must be between 0 and 100")
+	}
+	_, err := c.request(ctx, http.MethodPut, "/me/player/volume?volume_percent="+strconv.Itoa(percent), nil)
+	return err
+}
+
+func (c *PlayerClient) SetShuffle(ctx context.Context, enabled bool) error {
+	_, err := c.request(ctx, http.MethodPut, "/me/player/shuffle?state="+strconv.FormatBool(enabled), nil)
+	return err
+}
+
+func (c *PlayerClient) SetRepeat(ctx context.Context, mode string) error {
+	switch mode {
+	case skills.RepeatOff, skills.RepeatTrack, skills.RepeatAll:
+	default:
+		return fmt.Errorf("unknown repeat mode %q", mode)
+	}
+	_, err := c.request(ctx, http.MethodPut, "/me/player/repeat?state="+mode, nil)
+	return err
+}
+
+func (c *PlayerClient) AddToQueue(ctx context.Context, query string) error {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return fmt.Errorf("query is required")
+	}
+	uri, err := c.searchTopURI(ctx, "track", query)
+	if err != nil {
+		return err
+	}
+	_, err = c.request(ctx, http.MethodPost, "/me/player/queue?uri="+url.QueryEscape(uri), nil)
+	return err
+}
+
+func (c *PlayerClient) PlayAlbum(ctx context.Context, name string) error {
+	return c.playContext(ctx, "album", name)
+}
+
+func (c *PlayerClient) PlayArtist(ctx context.Context, name string) error {
+	return c.playContext(ctx, "artist", name)
+}
+
+func (c *PlayerClient) PlayPlaylist(ctx context.Context, name string) error {
+	return c.playContext(ctx, "playlist", name)
+}
+
+func (c *PlayerClient) playContext(ctx context.Context, kind, name string) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return fmt.Errorf("name is required")
+	}
+	uri, err := c.searchTopURI(ctx, kind, name)
+	if err != nil {
+		return err
+	}
+	_, err = c.request(ctx, http.MethodPut, "/me/player/play", map[string]interface{}{"context_uri": uri})
+	return err
+}
+
+func (c *PlayerClient) ListDevices(ctx context.Context) ([]skills.PlaybackDevice, error) {
+	body, err := c.request(ctx, http.MethodGet, "/me/player/devices", nil)
+	if err != nil {
+		return nil, err
+	}
+	var decoded struct {
+		Devices []struct {
+			ID            string `json:"id"`
+			Name          string `json:"name"`
+			Type          string `json:"type"`
+			IsActive      bool   `json:"is_active"`
+			VolumePercent *int   `json:"volume_percent"`
+		} `json:"devices"`
+	}
+	if err := json.Unmarshal(body, &decoded); err != nil {
+		return nil, fmt.Errorf("decode devices response: %w", err)
+	}
+	devices := make([]skills.PlaybackDevice, 0, len(decoded.Devices))
+	for _, d := range decoded.Devices {
+		devices = append(devices, skills.PlaybackDevice{
+			ID:            d.ID,
+			Name:          d.Name,
+			Type:          d.Type,
+			Active:        d.IsActive,
+			VolumePercent: d.VolumePercent,
+		})
+	}
+	return devices, nil
+}
+
+// TransferPlayback matches device against ids first, then names ignoring case.
+func (c *PlayerClient) TransferPlayback(ctx context.Context, device string) error {
+	device = strings.TrimSpace(device)
+	if device == "" {
+		return fmt.Errorf("device is required")
+	}
+	devices, err := c.ListDevices(ctx)
+	if err != nil {
+		return err
+	}
+	id := ""
+	for _, d := range devices {
+		if d.ID == device {
+			id = d.ID
+			break
+		}
+	}
+	if id == "" {
+		for _, d := range devices {
+			if strings.EqualFold(d.Name, device) {
+				id = d.ID
+				break
+			}
+		}
+	}
+	if id == "" {
+		return fmt.Errorf("no device named %q", device)
+	}
+
+	payload := map[string]interface{}{"device_ids": []string{id}, "play": true}
+	_, err = c.request(ctx, http.MethodPut, "/me/player", payload)
+	return err
+}
+
+// NowPlaying returns a zero NowPlaying when nothing is playing.
+func (c *PlayerClient) NowPlaying(ctx context.Context) (*skills.NowPlaying, error) {
+	body, err := c.request(ctx, http.MethodGet, "/me/player", nil)
+	if err != nil {
+		return nil, err
+	}
+	// 204 No Content means no active playback.
+	if len(body) == 0 {
+		return &skills.NowPlaying{}, nil
+	}
+
+	var decoded struct {
+		IsPlaying    bool   `json:"is_playing"`
+		ProgressMs   int    `json:"progress_ms"`
+		ShuffleState bool   `json:"shuffle_state"`
+		RepeatState  string `json:"repeat_state"`
+		Device       struct {
+			Name string `json:"name"`
+		} `json:"device"`
+		Item *struct {
+			Name       string `json:"name"`
+			URI        string `json:"uri"`
+			DurationMs int    `json:"duration_ms"`
+			Album      struct {
+				Name string `json:"name"`
+			} `json:"album"`
+			Artists []struct {
+				Name string `json:"name"`
+			} `json:"artists"`
+		} `json:"item"`
+	}
+	if err := json.Unmarshal(body, &decoded); err != nil {
+		return nil, fmt.Errorf("decode playback state: %w", err)
+	}
+
+	now := &skills.NowPlaying{
+		Playing:    decoded.IsPlaying,
+		PositionMs: decoded.ProgressMs,
+		Device:     decoded.Device.Name,
+		Shuffle:    decoded.ShuffleState,
+		Repeat:     decoded.RepeatState,
+	}
+	if item := decoded.Item; item != nil {
+		now.Track, now.URI, now.DurationMs, now.Album = item.Name, item.URI, item.DurationMs, item.Album.Name
+		for _, a := range item.Artists {
+			now.Artists = append(now.Artists, a.Name)
+		}
+	}
+	return now, nil
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -19,21 +20,39 @@ const (
 
 // PlayerClient executes real Spotify Web API requests.
 type PlayerClient struct {
+	// AccessToken is used as is when Auth is nil.
 	AccessToken string
-	DeviceID    string
-	HTTPClient  *http.Client
+	// Auth, when set, supplies the token and refreshes it after a 401.
+	Auth       *Authenticator
+	DeviceID   string
+	HTTPClient *http.Client
+	// BaseURL overrides the Web API root, e.g. for a fake server in tests.
+	BaseURL string
 }
 
+// NewPlayerClientFromEnv prefers the login stored by `upcraft-cli spotify
+// login` and falls back to a raw SPOTIFY_ACCESS_TOKEN.
 func NewPlayerClientFromEnv() (*PlayerClient, error) {
-	token := strings.TrimSpace(os.Getenv("SPOTIFY_ACCESS_TOKEN"))
-	if token == "" {
-		return nil, fmt.Errorf("SPOTIFY_ACCESS_TOKEN is required")
-	}
-	return &PlayerClient{
-		AccessToken: token,
-		DeviceID:    strings.TrimSpace(os.Getenv("SPOTIFY_DEVICE_ID")),
-		HTTPClient:  &http.Client{Timeout: 30 * time.Second},
-	}, nil
+	client := &PlayerClient{
+		DeviceID:   strings.TrimSpace(os.Getenv("SPOTIFY_DEVICE_ID")),
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+	}
+	if auth, err := NewAuthenticatorFromEnv(); err == nil {
+		_, err := auth.Store.Load()
+		if err == nil {
+			client.Auth = auth
+			return client, nil
+		}
+		if !errors.Is(err, ErrNotLoggedIn) {
+			return nil, err
+		}
+	}
+
+	client.AccessToken = strings.TrimSpace(os.Getenv("SPOTIFY_ACCESS_TOKEN"))
+	if client.AccessToken == "" {
+		return nil, fmt.Errorf("spotify is not configured: set %s and run `upcraft-cli spotify login`, or set SPOTIFY_ACCESS_TOKEN", ClientIDEnv)
+	}
+	return client, nil
 }
 
 func (c *PlayerClient) Play(ctx context.Context, query string) error {
@@ -42,7 +61,7 @@ func (c *PlayerClient) Play(ctx context.Context, query string) error {
 		return fmt.Errorf("query is required")
 	}
 
-	trackURI, err := c.searchTopTrackURI(ctx, query)
+	trackURI, err := c.searchTopURI(ctx, "track", query)
 	if err != nil {
 		return err
 	}
@@ -67,99 +86,131 @@ func (c *PlayerClient) Next(ctx context.Context) error {
 	return err
 }
 
-func (c *PlayerClient) request(ctx context.Context, method, endpoint string, payload interface{}) ([]byte, error) {
-	if c.HTTPClient == nil {
-		c.HTTPClient = &http.Client{Timeout: 30 * time.Second}
+func (c *PlayerClient) apiBase() string {
+	if c.BaseURL != "" {
+		return strings.TrimRight(c.BaseURL, "/")
 	}
+	return spotifyAPIBase
+}
 
-	u, err := url.Parse(spotifyAPIBase + endpoint)
-	if err != nil {
-		return nil, err
-	}
-	if c.DeviceID != "" {
-		q := u.Query()
-		q.Set("device_id", c.DeviceID)
-		u.RawQuery = q.Encode()
+func (c *PlayerClient) httpClient() *http.Client {
+	if c.HTTPClient == nil {
+		return http.DefaultClient
 	}
+	return c.HTTPClient
+}
 
-	var body io.Reader
+// request sends one Web API call. With Auth set, a 401 triggers a token
+// refresh and a single retry.
+func (c *PlayerClient) request(ctx context.Context, method, endpoint string, payload interface{}) ([]byte, error) {
+	var encoded []byte
 	if payload != nil {
-		encoded, err := json.Marshal(payload)
-		if err != nil {
+		var err error
+		if encoded, err = json.Marshal(payload); err != nil {
 			return nil, fmt.Errorf("marshal payload: %w", err)
 		}
-		body = bytes.NewReader(encoded)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	token, err := c.accessToken(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("create request: %w", err)
+		return nil, err
 	}
-	req.Header.Set("Authorization", "Bearer "+c.AccessToken)
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.HTTPClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("send spotify request: %w", err)
+	status, respBody, err := c.send(ctx, method, endpoint, encoded, token)
+	if err == nil && status == http.StatusUnauthorized && c.Auth != nil {
+		var fresh *Token
+		fresh, err = c.Auth.Refresh(ctx, token)
+		if err != nil {
+			return nil, fmt.Errorf("refresh spotify token: %w", err)
+		}
+		status, respBody, err = c.send(ctx, method, endpoint, encoded, fresh.AccessToken)
 	}
-	defer resp.Body.Close()
-
-	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("read spotify response: %w", err)
+		return nil, err
 	}
 
-	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+	if status >= 200 && status < 300 {
 		return respBody, nil
 	}
-
 	if len(respBody) == 0 {
-		return nil, fmt.Errorf("spotify request failed status=%d", resp.StatusCode)
+		return nil, fmt.Errorf("spotify request failed status=%d", status)
 	}
-	return nil, fmt.Errorf("spotify request failed status=%d body=%s", resp.StatusCode, string(respBody))
+	return nil, fmt.Errorf("spotify request failed status=%d body=%s", status, string(respBody))
 }
 
-func (c *PlayerClient) searchTopTrackURI(ctx context.Context, query string) (string, error) {
-	u, _ := url.Parse(spotifyAPIBase + "/search")
-	q := u.Query()
-	q.Set("q", query)
-	q.Set("type", "track")
-	q.Set("limit", "1")
-	u.RawQuery = q.Encode()
+func (c *PlayerClient) accessToken(ctx context.Context) (string, error) {
+	if c.Auth == nil {
+		return c.AccessToken, nil
+	}
+	tok, err := c.Auth.Token(ctx)
+	if err != nil {
+		return "", err
+	}
+	return tok.AccessToken, nil
+}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+func (c *PlayerClient) send(ctx context.Context, method, endpoint string, payload []byte, token string) (int, []byte, error) {
+	u, err := url.Parse(c.apiBase() + endpoint)
 	if err != nil {
-		return "", fmt.Errorf("create search request: %w", err)
+		return 0, nil, err
+	}
+	// Only player commands take a target device; state and transfer do not.
+	if c.DeviceID != "" && strings.HasPrefix(u.Path, "/me/player/") && u.Path != "/me/player/devices" {
+		q := u.Query()
+		q.Set("device_id", c.DeviceID)
+		u.RawQuery = q.Encode()
 	}
-	req.Header.Set("Authorization", "Bearer "+c.AccessToken)
 
-	resp, err := c.HTTPClient.Do(req)
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
 	if err != nil {
-		return "", fmt.Errorf("send search request: %w", err)
+		return 0, nil, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient().Do(req)
+	if err != nil {
+		return 0, nil, fmt.Errorf("send spotify request: %w", err)
 	}
 	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
+	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", fmt.Errorf("read search response: %w", err)
+		return 0, nil, fmt.Errorf("read spotify response: %w", err)
 	}
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("spotify search failed status=%d body=%s", resp.StatusCode, string(body))
+	return resp.StatusCode, respBody, nil
+}
+
+// searchTopURI returns the URI of the best match of kind: track, album,
+// artist or playlist.
+func (c *PlayerClient) searchTopURI(ctx context.Context, kind, query string) (string, error) {
+	q := url.Values{}
+	q.Set("q", query)
+	q.Set("type", kind)
+	q.Set("limit", "5")
+	body, err := c.request(ctx, http.MethodGet, "/search?"+q.Encode(), nil)
+	if err != nil {
+		return "", err
 	}
 
-	var decoded struct {
-		Tracks struct {
-			Items []struct {
-				URI string `json:"uri"`
-			} `json:"items"`
-		} `json:"tracks"`
+	// Results are keyed by the plural kind; playlist results may hold nulls.
+	var decoded map[string]struct {
+		Items []*struct {
+			URI string `json:"uri"`
+		} `json:"items"`
 	}
 	if err := json.Unmarshal(body, &decoded); err != nil {
 		return "", fmt.Errorf("decode search response: %w", err)
 	}
-	if len(decoded.Tracks.Items) == 0 || strings.TrimSpace(decoded.Tracks.Items[0].URI) == "" {
-		return "", fmt.Errorf("no track found for query %q", query)
+	for _, item := range decoded[kind+"s"].Items {
+		if item != nil && strings.TrimSpace(item.URI) != "" {
+			return item.URI, nil
+		}
 	}
-
-	return decoded.Tracks.Items[0].URI, nil
+	return "", fmt.Errorf("no %s found for query %q", kind, query)
 }
@@ -0,0 +1,131 @@
+package spotify
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/plugins/spotify/spotifytest"
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/skills"
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/skills/skilltest"
+)
+
+func newTestClient(t *testing.T) (*PlayerClient, *spotifytest.Server) {
+	srv := spotifytest.NewServer(t)
+	return &PlayerClient{
+		AccessToken: spotifytest.Token,
+		HTTPClient:  srv.Client(),
+		BaseURL:     srv.URL,
+	}, srv
+}
+
+func TestPlayerClient_Conformance(t *testing.T) {
+	skilltest.TestMusicPlayer(t, func(t *testing.T) skills.MusicPlayer {
+		client, _ := newTestClient(t)
+		return client
+	})
+}
+
+func TestPlayerClient_Capabilities(t *testing.T) {
+	skilltest.TestMusicCapabilities(t, func(t *testing.T) skills.MusicPlayer {
+		client, _ := newTestClient(t)
+		return client
+	})
+}
+
+func TestPlayerClient_PlayStartsTopSearchResult(t *testing.T) {
+	client, srv := newTestClient(t)
+	if err := client.Play(context.Background(), "Hymn for the Weekend"); err != nil {
+		t.Fatalf("Play: %v", err)
+	}
+	if got := srv.Track(); got != "spotify:track:hymn-for-the-weekend" {
+		t.Fatalf("track = %q", got)
+	}
+	if !srv.Playing() {
+		t.Fatal("expected playback to be active")
+	}
+}
+
+func TestPlayerClient_SurfacesAPIErrors(t *testing.T) {
+	client, _ := newTestClient(t)
+	client.AccessToken = "expired"
+	err := client.Pause(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "status=401") {
+		t.Fatalf("expected 401 error, got %v", err)
+	}
+}
+
+func TestPlayerClient_CollectionsQueueAndDevices(t *testing.T) {
+	client, srv := newTestClient(t)
+	ctx := context.Background()
+
+	if err := client.PlayPlaylist(ctx, "Deep Focus"); err != nil {
+		t.Fatalf("PlayPlaylist: %v", err)
+	}
+	if got := srv.Context(); got != "spotify:playlist:deep-focus" {
+		t.Fatalf("context = %q", got)
+	}
+
+	if err := client.AddToQueue(ctx, "Yellow"); err != nil {
+		t.Fatalf("AddToQueue: %v", err)
+	}
+	if err := client.Next(ctx); err != nil {
+		t.Fatalf("Next: %v", err)
+	}
+	if got := srv.Track(); got != "spotify:track:yellow" {
+		t.Fatalf("queued track not played next, got %q", got)
+	}
+
+	if err := client.TransferPlayback(ctx, "pixel"); err != nil {
+		t.Fatalf("TransferPlayback: %v", err)
+	}
+	if srv.ActiveDevice() != spotifytest.PhoneDevice {
+		t.Fatalf("active device = %q", srv.ActiveDevice())
+	}
+
+	now, err := client.NowPlaying(ctx)
+	if err != nil {
+		t.Fatalf("NowPlaying: %v", err)
+	}
+	if now.Track != "yellow" || now.Device != "Pixel" || len(now.Artists) != 1 {
+		t.Fatalf("unexpected now playing: %+v", now)
+	}
+}
+
+func TestNewPlayerClientFromSettings(t *testing.T) {
+	for _, tc := range []struct {
+		settings map[string]string
+		want     string
+	}{
+		{map[string]string{}, "token or refresh_token is required"},
+		{map[string]string{"refresh_token": "r"}, "client_id is required with refresh_token"},
+		{map[string]string{"token": "t", "secret": "s"}, `unknown setting "secret"`},
+	} {
+		if _, err := NewPlayerClientFromSettings(tc.settings); err == nil || err.Error() != tc.want {
+			t.Errorf("settings %v: err = %v, want %q", tc.settings, err, tc.want)
+		}
+	}
+
+	// A host login refreshes in memory.
+	srv := spotifytest.NewServer(t)
+	client, err := NewPlayerClientFromSettings(map[string]string{
+		"client_id":     spotifytest.ClientID,
+		"token":         "stale",
+		"refresh_token": spotifytest.RefreshToken,
+		"device_id":     spotifytest.PhoneDevice,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.BaseURL, client.HTTPClient = srv.URL, srv.Client()
+	client.Auth.HTTPClient, client.Auth.TokenURL = srv.Client(), srv.URL+"/api/token"
+	if err := client.Play(context.Background(), "Hymn for the Weekend"); err != nil {
+		t.Fatalf("Play: %v", err)
+	}
+	if srv.Refreshes() != 1 || client.DeviceID != spotifytest.PhoneDevice {
+		t.Fatalf("refreshes = %d, device = %s", srv.Refreshes(), client.DeviceID)
+	}
+	if err := client.Pause(context.Background()); err != nil || srv.Refreshes() != 1 {
+		t.Fatalf("Pause: %v after %d refreshes", err, srv.Refreshes())
+	}
+}
@@ -0,0 +1,94 @@
+package spotify
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Setting keys read by NewPlayerClientFromSettings, the host-provided
+// counterparts of the SPOTIFY_* environment variables.
+const (
+	SettingClientID     = "client_id"
+	SettingToken        = "token"
+	SettingRefreshToken = "refresh_token"
+	SettingDeviceID     = "device_id"
+)
+
+// NewPlayerClientFromSettings builds a client from settings a host app
+// passes in, such as a login made with the Spotify SDK. "token" is an
+// access token used as is; "refresh_token" with "client_id" lets the client
+// refresh it. Refreshed tokens are kept in memory only. "device_id" picks
+// the playback device.
+func NewPlayerClientFromSettings(settings map[string]string) (*PlayerClient, error) {
+	var problems []string
+	for key := range settings {
+		switch key {
+		case SettingClientID, SettingToken, SettingRefreshToken, SettingDeviceID:
+		default:
+			problems = append(problems, fmt.Sprintf("unknown setting %q", key))
+		}
+	}
+	get := func(key string) string { return strings.TrimSpace(settings[key]) }
+	token, refresh, clientID := get(SettingToken), get(SettingRefreshToken), get(SettingClientID)
+	switch {
+	case token == "" && refresh == "":
+		problems = append(problems, SettingToken+" or "+SettingRefreshToken+" is required")
+	case refresh != "" && clientID == "":
+		problems = append(problems, SettingClientID+" is required with "+SettingRefreshToken)
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return nil, errors.New(strings.Join(problems, "; "))
+	}
+
+	client := &PlayerClient{
+		DeviceID:   get(SettingDeviceID),
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+	}
+	if refresh == "" {
+		client.AccessToken = token
+		return client, nil
+	}
+	client.Auth = &Authenticator{
+		ClientID:   clientID,
+		Store:      &memoryTokenStore{tok: &Token{AccessToken: token, RefreshToken: refresh}},
+		HTTPClient: client.HTTPClient,
+	}
+	return client, nil
+}
+
+// memoryTokenStore keeps a login for the life of the process.
+type memoryTokenStore struct {
+	mu  sync.Mutex
+	tok *Token
+}
+
+func (s *memoryTokenStore) Load() (*Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.tok == nil {
+		return nil, ErrNotLoggedIn
+	}
+	tok := *s.tok
+	return &tok, nil
+}
+
+func (s *memoryTokenStore) Save(tok *Token) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	saved := *tok
+	s.tok = &saved
+	return nil
+}
+
+func (s *memoryTokenStore) Delete() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tok = nil
+	return nil
+}
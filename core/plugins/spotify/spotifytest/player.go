@@ -0,0 +1,289 @@
+package spotifytest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Device ids of the two fake outputs; DeskDevice starts active.
+const (
+	DeskDevice  = "desk-1"
+	PhoneDevice = "phone-1"
+)
+
+type device struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Type   string `json:"type"`
+	Volume int    `json:"volume_percent"`
+}
+
+// player is the playback state; Server.mu guards it.
+type player struct {
+	track    string
+	context  string
+	playing  bool
+	skips    int
+	position int
+	shuffle  bool
+	repeat   string
+	history  []string
+	queue    []string
+	devices  []*device
+	active   *device
+}
+
+func newPlayer() player {
+	desk := &device{ID: DeskDevice, Name: "Desk Speaker", Type: "Speaker", Volume: 40}
+	phone := &device{ID: PhoneDevice, Name: "Pixel", Type: "Smartphone", Volume: 70}
+	return player{repeat: "off", devices: []*device{desk, phone}, active: desk}
+}
+
+func (s *Server) routePlayer(mux *http.ServeMux) {
+	mux.HandleFunc("GET /search", s.handleSearch)
+	mux.HandleFunc("GET /me/player", s.handleState)
+	mux.HandleFunc("PUT /me/player", s.handleTransfer)
+	mux.HandleFunc("GET /me/player/devices", s.handleDevices)
+	mux.HandleFunc("PUT /me/player/play", s.handlePlay)
+	mux.HandleFunc("PUT /me/player/pause", s.withTrack(func(p *player, _ *http.Request) error {
+		p.playing = false
+		return nil
+	}))
+	mux.HandleFunc("POST /me/player/next", s.withTrack(func(p *player, _ *http.Request) error {
+		p.history = append(p.history, p.track)
+		if len(p.queue) > 0 {
+			p.track, p.queue = p.queue[0], p.queue[1:]
+		} else {
+			p.skips++
+			p.track = fmt.Sprintf("%s#next-%d", strings.SplitN(p.track, "#", 2)[0], p.skips)
+		}
+		p.playing, p.position = true, 0
+		return nil
+	}))
+	mux.HandleFunc("POST /me/player/previous", s.withTrack(func(p *player, _ *http.Request) error {
+		if n := len(p.history); n > 0 {
+			p.track, p.history = p.history[n-1], p.history[:n-1]
+		}
+		p.playing, p.position = true, 0
+		return nil
+	}))
+	mux.HandleFunc("PUT /me/player/seek", s.withTrack(func(p *player, r *http.Request) error {
+		pos, err := strconv.Atoi(r.URL.Query().Get("position_ms"))
+		if err != nil || pos < 0 {
+			return fmt.Errorf("Invalid position_ms")
+		}
+		p.position = pos
+		return nil
+	}))
+	mux.HandleFunc("PUT /me/player/volume", s.withTrack(func(p *player, r *http.Request) error {
+		v, err := strconv.Atoi(r.URL.Query().Get("volume_percent"))
+		if err != nil || v < 0 || v > 100 {
+			return fmt.Errorf("Invalid volume_percent")
+		}
+		p.active.Volume = v
+		return nil
+	}))
+	mux.HandleFunc("PUT /me/player/shuffle", s.withTrack(func(p *player, r *http.Request) error {
+		on, err := strconv.ParseBool(r.URL.Query().Get("state"))
+		if err != nil {
+			return fmt.Errorf("Invalid state")
+		}
+		p.shuffle = on
+		return nil
+	}))
+	mux.HandleFunc("PUT /me/player/repeat", s.withTrack(func(p *player, r *http.Request) error {
+		switch mode := r.URL.Query().Get("state"); mode {
+		case "off", "track", "context":
+			p.repeat = mode
+			return nil
+		default:
+			return fmt.Errorf("Invalid state")
+		}
+	}))
+	mux.HandleFunc("POST /me/player/queue", s.withTrack(func(p *player, r *http.Request) error {
+		uri := r.URL.Query().Get("uri")
+		if !strings.HasPrefix(uri, "spotify:track:") {
+			return fmt.Errorf("Invalid uri")
+		}
+		p.queue = append(p.queue, uri)
+		return nil
+	}))
+}
+
+// Track returns the URI of the current track, if any.
+func (s *Server) Track() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.player.track
+}
+
+// Context returns the album, artist or playlist URI being played, if any.
+func (s *Server) Context() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.player.context
+}
+
+// Playing reports whether playback is active.
+func (s *Server) Playing() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.player.playing
+}
+
+// Queue returns the URIs queued after the current track.
+func (s *Server) Queue() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.player.queue...)
+}
+
+// ActiveDevice returns the id of the device playing now.
+func (s *Server) ActiveDevice() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.player.active.ID
+}
+
+// Requests returns "METHOD /path" for every request received so far.
+func (s *Server) Requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.requests...)
+}
+
+// withTrack wraps a command that needs something loaded, answering 404 like
+// Spotify does when there is no active playback and 400 when apply fails.
+func (s *Server) withTrack(apply func(p *player, r *http.Request) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.player.track == "" {
+			writeError(w, http.StatusNotFound, "Player command failed: No active device found")
+			return
+		}
+		if err := apply(&s.player, r); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
+	q := strings.TrimSpace(r.URL.Query().Get("q"))
+	kind := r.URL.Query().Get("type")
+	switch {
+	case q == "":
+		writeError(w, http.StatusBadRequest, "No search query")
+		return
+	case kind != "track" && kind != "album" && kind != "artist" && kind != "playlist":
+		writeError(w, http.StatusBadRequest, "Bad search type field")
+		return
+	}
+	slug := strings.ToLower(strings.Join(strings.Fields(q), "-"))
+	w.Header().Set("Content-Type", "application/json")
+	// Real playlist searches can contain null items; clients must skip them.
+	fmt.Fprintf(w, `{%q:{"items":[null,{"uri":%q}]}}`, kind+"s", "spotify:"+kind+":"+slug)
+}
+
+func (s *Server) handlePlay(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		URIs       []string `json:"uris"`
+		ContextURI string   `json:"context_uri"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeError(w, http.StatusBadRequest, "Malformed json")
+			return
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p := &s.player
+	switch {
+	case len(body.URIs) > 0:
+		p.track, p.context, p.position = body.URIs[0], "", 0
+	case body.ContextURI != "":
+		parts := strings.Split(body.ContextURI, ":")
+		p.context, p.position = body.ContextURI, 0
+		p.track = "spotify:track:" + parts[len(parts)-1] + "-1"
+	}
+	if p.track == "" {
+		writeError(w, http.StatusNotFound, "Player command failed: No active device found")
+		return
+	}
+	p.playing = true
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleState(w http.ResponseWriter, _ *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p := &s.player
+	if p.track == "" {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	name := p.track[strings.LastIndex(p.track, ":")+1:]
+	state := map[string]interface{}{
+		"device":        p.active,
+		"is_playing":    p.playing,
+		"progress_ms":   p.position,
+		"shuffle_state": p.shuffle,
+		"repeat_state":  p.repeat,
+		"item": map[string]interface{}{
+			"name":        name,
+			"uri":         p.track,
+			"duration_ms": 200000,
+			"album":       map[string]string{"name": "Album of " + name},
+			"artists":     []map[string]string{{"name": "Artist of " + name}},
+		},
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(state)
+}
+
+func (s *Server) handleDevices(w http.ResponseWriter, _ *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	type listed struct {
+		*device
+		IsActive bool `json:"is_active"`
+	}
+	out := make([]listed, 0, len(s.player.devices))
+	for _, d := range s.player.devices {
+		out = append(out, listed{device: d, IsActive: d == s.player.active})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"devices": out})
+}
+
+func (s *Server) handleTransfer(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		DeviceIDs []string `json:"device_ids"`
+		Play      bool     `json:"play"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || len(body.DeviceIDs) != 1 {
+		writeError(w, http.StatusBadRequest, "Exactly one device id is required")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, d := range s.player.devices {
+		if d.ID == body.DeviceIDs[0] {
+			s.player.active = d
+			if body.Play && s.player.track != "" {
+				s.player.playing = true
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "Device not found")
+}
@@ -0,0 +1,166 @@
+// Package spotifytest provides an in-memory stand-in for the Spotify Web API
+// player endpoints so plugins can be tested offline.
+package spotifytest
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+)
+
+const (
+	// Token is the bearer token the fake server accepts until it is expired.
+	Token = "test-token"
+	// ClientID is the only client the fake accounts endpoints accept.
+	ClientID = "test-client"
+	// RefreshToken is issued by the fake login and accepted for refreshes.
+	RefreshToken = "test-refresh"
+)
+
+// Server emulates search and playback for a single user with two devices,
+// plus the /authorize and /api/token accounts endpoints of the PKCE flow.
+type Server struct {
+	*httptest.Server
+
+	mu         sync.Mutex
+	token      string
+	refreshes  int
+	challenges map[string]string // authorization code -> code challenge
+	redirects  map[string]string // authorization code -> redirect uri
+	requests   []string
+	player     player
+}
+
+// NewServer starts a fake server that is closed when the test ends.
+func NewServer(t testing.TB) *Server {
+	s := &Server{
+		token:      Token,
+		challenges: map[string]string{},
+		redirects:  map[string]string{},
+		player:     newPlayer(),
+	}
+	api := http.NewServeMux()
+	s.routePlayer(api)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /authorize", s.handleAuthorize)
+	mux.HandleFunc("POST /api/token", s.handleToken)
+	mux.Handle("/", s.authorize(api))
+	s.Server = httptest.NewServer(mux)
+	t.Cleanup(s.Close)
+	return s
+}
+
+// ExpireToken invalidates the current access token, as if an hour had passed.
+func (s *Server) ExpireToken() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.token = "expired-" + s.token
+}
+
+// AccessToken returns the access token currently accepted.
+func (s *Server) AccessToken() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.token
+}
+
+// Refreshes counts successful refresh_token grants.
+func (s *Server) Refreshes() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.refreshes
+}
+
+func (s *Server) authorize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests = append(s.requests, r.Method+" "+r.URL.Path)
+		valid := r.Header.Get("Authorization") == "Bearer "+s.token
+		s.mu.Unlock()
+
+		if !valid {
+			writeError(w, http.StatusUnauthorized, "The access token expired")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// handleAuthorize skips the consent screen and redirects straight back with
+// a code bound to the PKCE challenge.
+func (s *Server) handleAuthorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if q.Get("client_id") != ClientID || q.Get("response_type") != "code" || q.Get("code_challenge_method") != "S256" || q.Get("code_challenge") == "" {
+		http.Error(w, "invalid authorization request", http.StatusBadRequest)
+		return
+	}
+	redirect, err := url.Parse(q.Get("redirect_uri"))
+	if err != nil || redirect.Host == "" {
+		http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	code := fmt.Sprintf("code-%d", len(s.challenges)+1)
+	s.challenges[code] = q.Get("code_challenge")
+	s.redirects[code] = q.Get("redirect_uri")
+	s.mu.Unlock()
+
+	back := redirect.Query()
+	back.Set("code", code)
+	back.Set("state", q.Get("state"))
+	redirect.RawQuery = back.Encode()
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+func (s *Server) handleToken(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil || r.PostForm.Get("client_id") != ClientID {
+		writeOAuthError(w, "invalid_client", "Invalid client")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch r.PostForm.Get("grant_type") {
+	case "authorization_code":
+		code := r.PostForm.Get("code")
+		challenge, ok := s.challenges[code]
+		sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+		if !ok || base64.RawURLEncoding.EncodeToString(sum[:]) != challenge || s.redirects[code] != r.PostForm.Get("redirect_uri") {
+			writeOAuthError(w, "invalid_grant", "Invalid authorization code")
+			return
+		}
+		delete(s.challenges, code)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token":%q,"token_type":"Bearer","expires_in":3600,"refresh_token":%q,"scope":"user-modify-playback-state"}`, s.token, RefreshToken)
+	case "refresh_token":
+		if r.PostForm.Get("refresh_token") != RefreshToken {
+			writeOAuthError(w, "invalid_grant", "Refresh token revoked")
+			return
+		}
+		s.refreshes++
+		s.token = fmt.Sprintf("%s-%d", Token, s.refreshes+1)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token":%q,"token_type":"Bearer","expires_in":3600,"scope":"user-modify-playback-state"}`, s.token)
+	default:
+		writeOAuthError(w, "unsupported_grant_type", "Unsupported grant type")
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	fmt.Fprintf(w, `{"error":{"status":%d,"message":%q}}`, status, message)
+}
+
+func writeOAuthError(w http.ResponseWriter, code, description string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	fmt.Fprintf(w, `{"error":%q,"error_description":%q}`, code, description)
+}
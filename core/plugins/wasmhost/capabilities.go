@@ -0,0 +1,230 @@
+package wasmhost
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/tetratelabs/wazero/api"
+)
+
+const (
+	maxHTTPResponseBytes = 1 << 20
+	defaultKVMaxBytes    = 64 * 1024
+)
+
+// Host function names per capability.
+var capabilityFunctions = map[string][]string{
+	"log":  {"log"},
+	"http": {"http_request"},
+	"kv":   {"kv_get", "kv_set", "kv_delete"},
+}
+
+// httpRequest is what a guest passes to http_request.
+type httpRequest struct {
+	Method  string            `json:"method"`
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    string            `json:"body,omitempty"`
+}
+
+// httpResponse is what http_request hands back to the guest.
+type httpResponse struct {
+	Status  int               `json:"status,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    string            `json:"body,omitempty"`
+	Error   string            `json:"error,omitempty"`
+}
+
+func (s *Skill) allowedHostFunctions() map[string]bool {
+	caps := s.Manifest.Capabilities
+	allowed := map[string]bool{}
+	grant := func(capability string) {
+		for _, fn := range capabilityFunctions[capability] {
+			allowed[fn] = true
+		}
+	}
+	if caps.Log {
+		grant("log")
+	}
+	if caps.HTTP != nil {
+		grant("http")
+	}
+	if caps.KV != nil {
+		grant("kv")
+	}
+	return allowed
+}
+
+// instantiateHostModule links only the host functions the manifest grants.
+func (s *Skill) instantiateHostModule(ctx context.Context) error {
+	allowed := s.allowedHostFunctions()
+	if len(allowed) == 0 {
+		return nil
+	}
+
+	b := s.runtime.NewHostModuleBuilder(hostModuleName)
+	if allowed["log"] {
+		b.NewFunctionBuilder().WithFunc(s.hostLog).Export("log")
+	}
+	if allowed["http_request"] {
+		b.NewFunctionBuilder().WithFunc(s.hostHTTPRequest).Export("http_request")
+	}
+	if allowed["kv_get"] {
+		b.NewFunctionBuilder().WithFunc(s.hostKVGet).Export("kv_get")
+		b.NewFunctionBuilder().WithFunc(s.hostKVSet).Export("kv_set")
+		b.NewFunctionBuilder().WithFunc(s.hostKVDelete).Export("kv_delete")
+	}
+	if _, err := b.Instantiate(ctx); err != nil {
+		return fmt.Errorf("instantiate host module: %w", err)
+	}
+	return nil
+}
+
+func (s *Skill) hostLog(_ context.Context, mod api.Module, ptr, size uint32) {
+	msg, err := readGuest(mod, ptr, size)
+	if err != nil {
+		return
+	}
+	s.host.logf("[wasm:%s] %s", s.Manifest.Name, string(msg))
+}
+
+func (s *Skill) hostHTTPRequest(ctx context.Context, mod api.Module, ptr, size uint32) uint64 {
+	resp := s.doHTTP(ctx, mod, ptr, size)
+	encoded, _ := json.Marshal(resp)
+	return writePacked(ctx, mod, encoded)
+}
+
+func (s *Skill) doHTTP(ctx context.Context, mod api.Module, ptr, size uint32) httpResponse {
+	raw, err := readGuest(mod, ptr, size)
+	if err != nil {
+		return httpResponse{Error: err.Error()}
+	}
+	var req httpRequest
+	if err := json.Unmarshal(raw, &req); err != nil {
+		return httpResponse{Error: "decode request: " + err.Error()}
+	}
+	if req.Method == "" {
+		req.Method = http.MethodGet
+	}
+
+	target, err := url.Parse(req.URL)
+	if err != nil {
+		return httpResponse{Error: "invalid url: " + err.Error()}
+	}
+	if err := s.checkURL(target); err != nil {
+		return httpResponse{Error: err.Error()}
+	}
+
+	var body io.Reader
+	if req.Body != "" {
+		body = strings.NewReader(req.Body)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, strings.ToUpper(req.Method), target.String(), body)
+	if err != nil {
+		return httpResponse{Error: "create request: " + err.Error()}
+	}
+	for k, v := range req.Headers {
+		httpReq.Header.Set(k, v)
+	}
+
+	client := *s.host.HTTPClient
+	client.CheckRedirect = func(next *http.Request, via []*http.Request) error {
+		if len(via) >= 5 {
+			return fmt.Errorf("stopped after 5 redirects")
+		}
+		return s.checkURL(next.URL)
+	}
+
+	httpResp, err := client.Do(httpReq)
+	if err != nil {
+		return httpResponse{Error: err.Error()}
+	}
+	defer httpResp.Body.Close()
+
+	var buf bytes.Buffer
+	if _, err := io.Copy(&buf, io.LimitReader(httpResp.Body, maxHTTPResponseBytes)); err != nil {
+		return httpResponse{Status: httpResp.StatusCode, Error: "read body: " + err.Error()}
+	}
+	headers := make(map[string]string, len(httpResp.Header))
+	for k := range httpResp.Header {
+		headers[k] = httpResp.Header.Get(k)
+	}
+	return httpResponse{Status: httpResp.StatusCode, Headers: headers, Body: buf.String()}
+}
+
+func (s *Skill) checkURL(u *url.URL) error {
+	switch u.Scheme {
+	case "https":
+	case "http":
+		if !s.host.AllowInsecureHTTP {
+			return fmt.Errorf("only https is allowed")
+		}
+	default:
+		return fmt.Errorf("scheme %q is not allowed", u.Scheme)
+	}
+	if !s.Manifest.Capabilities.HTTP.allowsHost(u.Hostname()) {
+		return fmt.Errorf("host %q is not in allow_domains", u.Hostname())
+	}
+	return nil
+}
+
+func (s *Skill) hostKVGet(ctx context.Context, mod api.Module, keyPtr, keySize uint32) uint64 {
+	key, err := readGuest(mod, keyPtr, keySize)
+	if err != nil {
+		return 0
+	}
+	value, ok, err := s.host.KV.Get(s.Manifest.Name, string(key))
+	if err != nil || !ok {
+		return 0
+	}
+	return writePacked(ctx, mod, value)
+}
+
+// hostKVSet returns 0 on success and 1 on failure, including quota overruns.
+func (s *Skill) hostKVSet(_ context.Context, mod api.Module, keyPtr, keySize, valPtr, valSize uint32) uint32 {
+	key, err := readGuest(mod, keyPtr, keySize)
+	if err != nil || len(key) == 0 {
+		return 1
+	}
+	value, err := readGuest(mod, valPtr, valSize)
+	if err != nil {
+		return 1
+	}
+
+	limit := s.Manifest.Capabilities.KV.MaxBytes
+	if limit <= 0 {
+		limit = defaultKVMaxBytes
+	}
+	used, err := s.host.KV.Size(s.Manifest.Name)
+	if err != nil {
+		return 1
+	}
+	if old, ok, _ := s.host.KV.Get(s.Manifest.Name, string(key)); ok {
+		used -= len(key) + len(old)
+	}
+	if used+len(key)+len(value) > limit {
+		s.host.logf("[wasm:%s] kv quota of %d bytes exceeded", s.Manifest.Name, limit)
+		return 1
+	}
+	if err := s.host.KV.Set(s.Manifest.Name, string(key), value); err != nil {
+		return 1
+	}
+	return 0
+}
+
+func (s *Skill) hostKVDelete(_ context.Context, mod api.Module, keyPtr, keySize uint32) uint32 {
+	key, err := readGuest(mod, keyPtr, keySize)
+	if err != nil {
+		return 1
+	}
+	if err := s.host.KV.Delete(s.Manifest.Name, string(key)); err != nil {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,457 @@
+// Package wasmhost loads sandboxed WebAssembly skills at runtime and binds
+// their actions into engine.Registry.
+//
+// A skill bundle is a directory holding skill.json (see Manifest) and the
+// module it names. The guest ABI is deliberately small:
+//
+//   - the module exports "memory" and "upcraft_alloc(size i32) i32";
+//   - every action export has the signature (ptr i32, len i32) -> i64, receives
+//     the action input as JSON and returns ptr<<32|len of its output;
+//   - output is either an engine.ActionResult envelope
+//     ({"for_model":..,"for_user":..,"data":..,"artifacts":..,"card":..,"is_error":..})
+//     or plain text, which is passed to the model unchanged.
+//
+// Host functions live in the "upcraft" import module and are linked only when
+// the manifest declares the matching capability. WASI is available without
+// filesystem, environment or arguments.
+package wasmhost
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/engine"
+)
+
+const (
+	hostModuleName = "upcraft"
+	wasiModuleName = wasi_snapshot_preview1.ModuleName
+	allocExport    = "upcraft_alloc"
+	maxOutputBytes = 1 << 20
+)
+
+// Host compiles and runs WASM skills.
+type Host struct {
+	KV         KVStore
+	HTTPClient *http.Client
+	Logger     io.Writer
+	// AllowInsecureHTTP lets the http capability use plain http; meant for tests.
+	AllowInsecureHTTP bool
+
+	mu     sync.Mutex
+	skills map[string]*Skill
+}
+
+func NewHost(kv KVStore) *Host {
+	if kv == nil {
+		kv = NewFileKVStore("")
+	}
+	return &Host{
+		KV:         kv,
+		HTTPClient: &http.Client{Timeout: 20 * time.Second},
+		Logger:     os.Stderr,
+		skills:     map[string]*Skill{},
+	}
+}
+
+// LoadDir loads every skill bundle found in the immediate subdirectories of dir.
+// Bundles that fail to load are reported together; the rest are returned.
+func (h *Host) LoadDir(ctx context.Context, dir string) ([]*Skill, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("read skills dir: %w", err)
+	}
+
+	var loaded []*Skill
+	var errs []string
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		bundle := filepath.Join(dir, e.Name())
+		if _, err := os.Stat(filepath.Join(bundle, ManifestFile)); err != nil {
+			continue
+		}
+		skill, err := h.LoadBundle(ctx, bundle)
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		loaded = append(loaded, skill)
+	}
+	if len(errs) > 0 {
+		return loaded, fmt.Errorf("load wasm skills: %s", strings.Join(errs, "; "))
+	}
+	return loaded, nil
+}
+
+// LoadBundle loads one skill directory containing skill.json and its module.
+func (h *Host) LoadBundle(ctx context.Context, dir string) (*Skill, error) {
+	raw, err := os.ReadFile(filepath.Join(dir, ManifestFile))
+	if err != nil {
+		return nil, fmt.Errorf("read manifest in %s: %w", dir, err)
+	}
+	manifest, err := ParseManifest(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", dir, err)
+	}
+	binary, err := os.ReadFile(filepath.Join(dir, manifest.Module))
+	if err != nil {
+		return nil, fmt.Errorf("read module for %s: %w", manifest.Name, err)
+	}
+	return h.Load(ctx, manifest, binary)
+}
+
+// Load compiles binary under manifest and verifies it only imports declared capabilities.
+func (h *Host) Load(ctx context.Context, manifest *Manifest, binary []byte) (*Skill, error) {
+	if manifest == nil {
+		return nil, fmt.Errorf("manifest is required")
+	}
+	if err := manifest.Validate(); err != nil {
+		return nil, err
+	}
+
+	h.mu.Lock()
+	if h.skills == nil {
+		h.skills = map[string]*Skill{}
+	}
+	if _, exists := h.skills[manifest.Name]; exists {
+		h.mu.Unlock()
+		return nil, fmt.Errorf("wasm skill already loaded: %s", manifest.Name)
+	}
+	h.mu.Unlock()
+
+	cfg := wazero.NewRuntimeConfig()
+	if runtime.GOOS == "android" || runtime.GOOS == "ios" {
+		// Mobile platforms may forbid executable pages; interpret instead.
+		cfg = wazero.NewRuntimeConfigInterpreter()
+	}
+	cfg = cfg.WithCloseOnContextDone(true).WithMemoryLimitPages(manifest.memoryPages())
+	rt := wazero.NewRuntimeWithConfig(ctx, cfg)
+
+	skill := &Skill{Manifest: manifest, host: h, runtime: rt}
+	fail := func(err error) (*Skill, error) {
+		_ = rt.Close(ctx)
+		return nil, fmt.Errorf("wasm skill %s: %w", manifest.Name, err)
+	}
+
+	if _, err := wasi_snapshot_preview1.Instantiate(ctx, rt); err != nil {
+		return fail(fmt.Errorf("instantiate wasi: %w", err))
+	}
+	if err := skill.instantiateHostModule(ctx); err != nil {
+		return fail(err)
+	}
+
+	compiled, err := rt.CompileModule(ctx, binary)
+	if err != nil {
+		return fail(fmt.Errorf("compile: %w", err))
+	}
+	skill.compiled = compiled
+	if err := skill.checkImports(); err != nil {
+		return fail(err)
+	}
+	if err := skill.checkExports(); err != nil {
+		return fail(err)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, exists := h.skills[manifest.Name]; exists {
+		_ = rt.Close(ctx)
+		return nil, fmt.Errorf("wasm skill already loaded: %s", manifest.Name)
+	}
+	h.skills[manifest.Name] = skill
+	return skill, nil
+}
+
+// Skills returns the loaded skills sorted by name.
+func (h *Host) Skills() []*Skill {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	out := make([]*Skill, 0, len(h.skills))
+	for _, s := range h.skills {
+		out = append(out, s)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Manifest.Name < out[j].Manifest.Name })
+	return out
+}
+
+// Unload closes a skill and forgets it.
+func (h *Host) Unload(ctx context.Context, name string) error {
+	h.mu.Lock()
+	skill, ok := h.skills[name]
+	delete(h.skills, name)
+	h.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("wasm skill not loaded: %s", name)
+	}
+	return skill.close(ctx)
+}
+
+func (h *Host) logf(format string, args ...interface{}) {
+	if h.Logger != nil {
+		fmt.Fprintf(h.Logger, format+"\n", args...)
+	}
+}
+
+// Skill is one loaded WASM module. It satisfies the engine plugin lifecycle
+// hooks so it can be handed to engine.PluginManager.
+type Skill struct {
+	Manifest *Manifest
+
+	host     *Host
+	runtime  wazero.Runtime
+	compiled wazero.CompiledModule
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+// BindActions registers every manifest action in registry.
+func (s *Skill) BindActions(registry engine.ActionRegistrar) error {
+	for _, a := range s.Manifest.Actions {
+		action := a
+		err := registry.Register(engine.RegisteredAction{
+			Skill:       s.Manifest.Name,
+			Action:      action.Name,
+			Description: action.Description,
+			InputSchema: action.InputSchema,
+			Handler: func(ctx context.Context, input map[string]interface{}) *engine.ActionResult {
+				return s.Call(ctx, action.Name, input)
+			},
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Stop closes the skill's runtime; the host forgets it.
+func (s *Skill) Stop(ctx context.Context) error {
+	s.host.mu.Lock()
+	if s.host.skills[s.Manifest.Name] == s {
+		delete(s.host.skills, s.Manifest.Name)
+	}
+	s.host.mu.Unlock()
+	return s.close(ctx)
+}
+
+// Health fails once the skill has been closed.
+func (s *Skill) Health(context.Context) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return fmt.Errorf("wasm skill %s is closed", s.Manifest.Name)
+	}
+	return nil
+}
+
+// Call runs one action in a fresh module instance, so no guest state leaks
+// between calls; persistent state belongs in the kv capability.
+func (s *Skill) Call(ctx context.Context, actionName string, input map[string]interface{}) *engine.ActionResult {
+	label := s.Manifest.Name + "." + actionName
+
+	var action *ActionManifest
+	for i := range s.Manifest.Actions {
+		if strings.EqualFold(s.Manifest.Actions[i].Name, actionName) {
+			action = &s.Manifest.Actions[i]
+		}
+	}
+	if action == nil {
+		return engine.ErrorResult("unknown action: "+label, fmt.Errorf("action not declared in manifest"))
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return engine.ErrorResult(label+" failed", fmt.Errorf("wasm skill is closed"))
+	}
+
+	if input == nil {
+		input = map[string]interface{}{}
+	}
+	payload, err := json.Marshal(input)
+	if err != nil {
+		return engine.ErrorResult(label+" failed", fmt.Errorf("encode input: %w", err))
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.Manifest.timeout())
+	defer cancel()
+
+	mod, err := s.runtime.InstantiateModule(ctx, s.compiled, s.moduleConfig())
+	if err != nil {
+		return engine.ErrorResult(label+" failed", fmt.Errorf("instantiate: %w", err))
+	}
+	defer mod.Close(context.Background())
+
+	ptr, err := writeGuest(ctx, mod, payload)
+	if err != nil {
+		return engine.ErrorResult(label+" failed", err)
+	}
+
+	results, err := mod.ExportedFunction(action.Export).Call(ctx, uint64(ptr), uint64(len(payload)))
+	if err != nil {
+		if ctx.Err() != nil {
+			err = fmt.Errorf("exceeded %s limit: %w", s.Manifest.timeout(), ctx.Err())
+		}
+		return engine.ErrorResult(label+" failed", err)
+	}
+
+	out, err := readPacked(mod, results[0])
+	if err != nil {
+		return engine.ErrorResult(label+" failed", err)
+	}
+	return decodeOutput(label, out)
+}
+
+func (s *Skill) moduleConfig() wazero.ModuleConfig {
+	cfg := wazero.NewModuleConfig().
+		WithName("").
+		WithStartFunctions("_initialize").
+		WithRandSource(rand.Reader).
+		WithSysWalltime().
+		WithSysNanotime()
+	if s.Manifest.Capabilities.Log && s.host.Logger != nil {
+		cfg = cfg.WithStderr(s.host.Logger)
+	}
+	return cfg
+}
+
+func (s *Skill) checkImports() error {
+	allowed := s.allowedHostFunctions()
+	for _, def := range s.compiled.ImportedFunctions() {
+		module, name, _ := def.Import()
+		switch module {
+		case wasiModuleName:
+		case hostModuleName:
+			if !allowed[name] {
+				return fmt.Errorf("module imports %s.%s but the manifest does not declare that capability", module, name)
+			}
+		default:
+			return fmt.Errorf("module imports unknown host module %q", module)
+		}
+	}
+	return nil
+}
+
+func (s *Skill) checkExports() error {
+	if _, ok := s.compiled.ExportedMemories()["memory"]; !ok {
+		return fmt.Errorf("module must export memory")
+	}
+	exports := s.compiled.ExportedFunctions()
+	if def, ok := exports[allocExport]; !ok || !sameTypes(def, []api.ValueType{api.ValueTypeI32}, []api.ValueType{api.ValueTypeI32}) {
+		return fmt.Errorf("module must export %s(i32) -> i32", allocExport)
+	}
+	for _, a := range s.Manifest.Actions {
+		def, ok := exports[a.Export]
+		if !ok {
+			return fmt.Errorf("action %s: module does not export %q", a.Name, a.Export)
+		}
+		if !sameTypes(def, []api.ValueType{api.ValueTypeI32, api.ValueTypeI32}, []api.ValueType{api.ValueTypeI64}) {
+			return fmt.Errorf("action %s: export %q must have signature (i32, i32) -> i64", a.Name, a.Export)
+		}
+	}
+	return nil
+}
+
+func (s *Skill) close(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	return s.runtime.Close(ctx)
+}
+
+func sameTypes(def api.FunctionDefinition, params, results []api.ValueType) bool {
+	return string(def.ParamTypes()) == string(params) && string(def.ResultTypes()) == string(results)
+}
+
+// writeGuest copies data into guest memory through upcraft_alloc.
+func writeGuest(ctx context.Context, mod api.Module, data []byte) (uint32, error) {
+	res, err := mod.ExportedFunction(allocExport).Call(ctx, uint64(len(data)))
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", allocExport, err)
+	}
+	ptr := uint32(res[0])
+	if !mod.Memory().Write(ptr, data) {
+		return 0, fmt.Errorf("%s returned out-of-range pointer %d for %d bytes", allocExport, ptr, len(data))
+	}
+	return ptr, nil
+}
+
+// writePacked writes data into guest memory and returns ptr<<32|len, or 0 on failure.
+func writePacked(ctx context.Context, mod api.Module, data []byte) uint64 {
+	if len(data) == 0 {
+		return 0
+	}
+	ptr, err := writeGuest(ctx, mod, data)
+	if err != nil {
+		return 0
+	}
+	return uint64(ptr)<<32 | uint64(len(data))
+}
+
+func readPacked(mod api.Module, packed uint64) ([]byte, error) {
+	ptr, size := uint32(packed>>32), uint32(packed)
+	if size > maxOutputBytes {
+		return nil, fmt.Errorf("output of %d bytes exceeds %d byte limit", size, maxOutputBytes)
+	}
+	return readGuest(mod, ptr, size)
+}
+
+func readGuest(mod api.Module, ptr, size uint32) ([]byte, error) {
+	if size == 0 {
+		return nil, nil
+	}
+	buf, ok := mod.Memory().Read(ptr, size)
+	if !ok {
+		return nil, fmt.Errorf("guest pointer %d+%d out of range", ptr, size)
+	}
+	// Memory().Read aliases guest memory, which is reused after the call.
+	return append([]byte(nil), buf...), nil
+}
+
+func decodeOutput(label string, out []byte) *engine.ActionResult {
+	var envelope struct {
+		ForModel  *string           `json:"for_model"`
+		ForUser   string            `json:"for_user"`
+		Data      json.RawMessage   `json:"data"`
+		Artifacts []engine.Artifact `json:"artifacts"`
+		Card      *engine.Card      `json:"card"`
+		IsError   bool              `json:"is_error"`
+		Error     string            `json:"error"`
+	}
+	if err := json.Unmarshal(out, &envelope); err != nil || envelope.ForModel == nil {
+		return engine.SuccessResult(string(out), "")
+	}
+	if envelope.IsError {
+		msg := envelope.Error
+		if msg == "" {
+			msg = *envelope.ForModel
+		}
+		return engine.ErrorResult(label+" failed: "+*envelope.ForModel, fmt.Errorf("%s", msg))
+	}
+	result := engine.SuccessResult(*envelope.ForModel, envelope.ForUser)
+	if len(envelope.Data) > 0 && string(envelope.Data) != "null" {
+		result.Data = envelope.Data
+	}
+	return result.WithArtifacts(envelope.Artifacts...).WithCard(envelope.Card)
+}
@@ -0,0 +1,349 @@
+package wasmhost
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/engine"
+)
+
+// The tests assemble tiny modules by hand so they need no WASM toolchain.
+
+const (
+	typeAlloc  = 0 // (i32) -> i32
+	typeAction = 1 // (i32, i32) -> i64
+	typeKVSet  = 2 // (i32, i32, i32, i32) -> i32
+	typeLog    = 3 // (i32, i32) -> ()
+	typeKVDel  = 4 // (i32, i32) -> i32
+)
+
+type wasmImport struct {
+	module, name string
+	typ          byte
+}
+
+type wasmFunc struct {
+	name string
+	typ  byte
+	body []byte
+}
+
+func uleb(v uint64) []byte {
+	var out []byte
+	for {
+		b := byte(v & 0x7f)
+		v >>= 7
+		if v != 0 {
+			out = append(out, b|0x80)
+			continue
+		}
+		return append(out, b)
+	}
+}
+
+func sleb(v int64) []byte {
+	var out []byte
+	for {
+		b := byte(v & 0x7f)
+		v >>= 7
+		if (v == 0 && b&0x40 == 0) || (v == -1 && b&0x40 != 0) {
+			return append(out, b)
+		}
+		out = append(out, b|0x80)
+	}
+}
+
+func vec(items ...[]byte) []byte {
+	out := uleb(uint64(len(items)))
+	for _, it := range items {
+		out = append(out, it...)
+	}
+	return out
+}
+
+func name(s string) []byte { return append(uleb(uint64(len(s))), s...) }
+
+func section(id byte, payload []byte) []byte {
+	return append(append([]byte{id}, uleb(uint64(len(payload)))...), payload...)
+}
+
+func cat(parts ...[]byte) []byte {
+	var out []byte
+	for _, p := range parts {
+		out = append(out, p...)
+	}
+	return out
+}
+
+// Instruction helpers.
+func localGet(i uint64) []byte { return append([]byte{0x20}, uleb(i)...) }
+func i32Const(v int64) []byte  { return append([]byte{0x41}, sleb(v)...) }
+func call(i uint64) []byte     { return append([]byte{0x10}, uleb(i)...) }
+
+// packArgs returns (local0 << 32) | local1 as i64.
+var packArgs = cat(localGet(0), []byte{0xad, 0x42}, sleb(32), []byte{0x86}, localGet(1), []byte{0xad, 0x84})
+
+const kvKeyOffset = 16
+
+func buildModule(imports []wasmImport, funcs []wasmFunc) []byte {
+	i32, i64 := byte(0x7f), byte(0x7e)
+	types := vec(
+		[]byte{0x60, 1, i32, 1, i32},
+		[]byte{0x60, 2, i32, i32, 1, i64},
+		[]byte{0x60, 4, i32, i32, i32, i32, 1, i32},
+		[]byte{0x60, 2, i32, i32, 0},
+		[]byte{0x60, 2, i32, i32, 1, i32},
+	)
+
+	var importEntries [][]byte
+	for _, im := range imports {
+		importEntries = append(importEntries, cat(name(im.module), name(im.name), []byte{0x00, im.typ}))
+	}
+
+	// Every module starts with the bump allocator.
+	alloc := wasmFunc{name: allocExport, typ: typeAlloc, body: cat(
+		[]byte{0x23, 0x00, 0x23, 0x00}, localGet(0), []byte{0x6a, 0x24, 0x00},
+	)}
+	funcs = append([]wasmFunc{alloc}, funcs...)
+
+	var funcTypes, exports, codes [][]byte
+	for i, f := range funcs {
+		funcTypes = append(funcTypes, []byte{f.typ})
+		idx := uint64(len(imports) + i)
+		exports = append(exports, cat(name(f.name), []byte{0x00}, uleb(idx)))
+		body := cat([]byte{0x00}, f.body, []byte{0x0b})
+		codes = append(codes, append(uleb(uint64(len(body))), body...))
+	}
+	exports = append(exports, cat(name("memory"), []byte{0x02, 0x00}))
+
+	return cat(
+		[]byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00},
+		section(1, types),
+		section(2, vec(importEntries...)),
+		section(3, vec(funcTypes...)),
+		section(5, vec([]byte{0x00, 0x01})),
+		section(6, vec(cat([]byte{i32, 0x01}, i32Const(1024), []byte{0x0b}))),
+		section(7, vec(exports...)),
+		section(10, vec(codes...)),
+		section(11, vec(cat([]byte{0x00}, i32Const(kvKeyOffset), []byte{0x0b}, name("k")))),
+	)
+}
+
+func loadTest(t *testing.T, host *Host, manifestJSON string, binary []byte) *Skill {
+	t.Helper()
+	manifest, err := ParseManifest([]byte(manifestJSON))
+	if err != nil {
+		t.Fatalf("ParseManifest: %v", err)
+	}
+	skill, err := host.Load(context.Background(), manifest, binary)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	t.Cleanup(func() { _ = skill.Stop(context.Background()) })
+	return skill
+}
+
+func newTestHost() *Host {
+	host := NewHost(nil)
+	host.Logger = io.Discard
+	return host
+}
+
+func TestSkill_EchoAndPlainOutput(t *testing.T) {
+	host := newTestHost()
+	binary := buildModule(nil, []wasmFunc{
+		{name: "echo", typ: typeAction, body: packArgs},
+		{name: "boom", typ: typeAction, body: []byte{0x00}},
+	})
+	skill := loadTest(t, host, `{"name":"Echo","actions":[{"name":"Say","export":"echo"},{"name":"Boom"}]}`, binary)
+
+	registry := engine.NewRegistry()
+	if err := skill.BindActions(registry); err != nil {
+		t.Fatalf("BindActions: %v", err)
+	}
+	ctx := context.Background()
+
+	res := registry.Execute(ctx, "Echo", "Say", map[string]interface{}{"for_model": "hello", "for_user": "Hi!"})
+	if res.IsError || res.ForModel != "hello" || res.ForUser != "Hi!" {
+		t.Errorf("envelope output not decoded: %+v", res)
+	}
+
+	res = registry.Execute(ctx, "Echo", "Say", map[string]interface{}{
+		"for_model": "1 result",
+		"data":      map[string]interface{}{"temp_c": 31},
+		"card":      map[string]interface{}{"title": "Pune"},
+	})
+	if res.IsError || string(res.Data) != `{"temp_c":31}` || res.Card == nil || res.Card.Title != "Pune" {
+		t.Errorf("rich envelope fields not decoded: %+v", res)
+	}
+
+	res = registry.Execute(ctx, "Echo", "Say", map[string]interface{}{"city": "Pune"})
+	if res.IsError || res.ForModel != `{"city":"Pune"}` {
+		t.Errorf("plain output should reach the model unchanged: %+v", res)
+	}
+
+	res = registry.Execute(ctx, "Echo", "Say", map[string]interface{}{"for_model": "nope", "is_error": true})
+	if !res.IsError {
+		t.Errorf("guest error not propagated: %+v", res)
+	}
+
+	res = registry.Execute(ctx, "Echo", "Boom", nil)
+	if !res.IsError || res.Err == nil {
+		t.Errorf("trap should become an error result: %+v", res)
+	}
+}
+
+func TestLoad_RejectsUndeclaredCapabilities(t *testing.T) {
+	host := newTestHost()
+	binary := buildModule([]wasmImport{{"upcraft", "kv_get", typeAction}}, []wasmFunc{
+		{name: "get", typ: typeAction, body: cat(i32Const(kvKeyOffset), i32Const(1), call(0))},
+	})
+	manifest, _ := ParseManifest([]byte(`{"name":"Sneaky","actions":[{"name":"Get"}]}`))
+	_, err := host.Load(context.Background(), manifest, binary)
+	if err == nil || !strings.Contains(err.Error(), "does not declare that capability") {
+		t.Fatalf("expected capability error, got %v", err)
+	}
+
+	manifest, _ = ParseManifest([]byte(`{"name":"Missing","actions":[{"name":"Absent"}]}`))
+	_, err = host.Load(context.Background(), manifest, buildModule(nil, nil))
+	if err == nil || !strings.Contains(err.Error(), `does not export "absent"`) {
+		t.Fatalf("expected missing export error, got %v", err)
+	}
+}
+
+func TestSkill_KVCapabilityPersistsAcrossCalls(t *testing.T) {
+	host := newTestHost()
+	binary := buildModule([]wasmImport{
+		{"upcraft", "kv_get", typeAction},
+		{"upcraft", "kv_set", typeKVSet},
+		{"upcraft", "kv_delete", typeKVDel},
+	}, []wasmFunc{
+		// put stores the input under "k" and returns whatever "k" now holds.
+		{name: "put", typ: typeAction, body: cat(
+			i32Const(kvKeyOffset), i32Const(1), localGet(0), localGet(1), call(1), []byte{0x1a},
+			i32Const(kvKeyOffset), i32Const(1), call(0),
+		)},
+		{name: "get", typ: typeAction, body: cat(i32Const(kvKeyOffset), i32Const(1), call(0))},
+	})
+	skill := loadTest(t, host, `{"name":"Notes","actions":[{"name":"Put"},{"name":"Get"}],"capabilities":{"kv":{"max_bytes":64}}}`, binary)
+	ctx := context.Background()
+
+	if res := skill.Call(ctx, "Put", map[string]interface{}{"for_model": "saved"}); res.IsError || res.ForModel != "saved" {
+		t.Fatalf("Put: %+v", res)
+	}
+	if res := skill.Call(ctx, "Get", nil); res.IsError || res.ForModel != "saved" {
+		t.Fatalf("value should survive a fresh instance: %+v", res)
+	}
+	if v, ok, _ := host.KV.Get("Notes", "k"); !ok || !strings.Contains(string(v), "saved") {
+		t.Errorf("kv store not written: %q", v)
+	}
+
+	big := map[string]interface{}{"for_model": strings.Repeat("x", 100)}
+	if res := skill.Call(ctx, "Put", big); res.ForModel != "saved" {
+		t.Errorf("quota overrun must not replace the stored value: %+v", res)
+	}
+}
+
+func TestSkill_HTTPCapabilityEnforcesAllowlist(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("sunny"))
+	}))
+	defer srv.Close()
+
+	host := newTestHost()
+	host.AllowInsecureHTTP = true
+	binary := buildModule([]wasmImport{{"upcraft", "http_request", typeAction}}, []wasmFunc{
+		{name: "fetch", typ: typeAction, body: cat(localGet(0), localGet(1), call(0))},
+	})
+	skill := loadTest(t, host, `{"name":"Weather","actions":[{"name":"Fetch"}],"capabilities":{"http":{"allow_domains":["127.0.0.1"]}}}`, binary)
+	ctx := context.Background()
+
+	var resp httpResponse
+	res := skill.Call(ctx, "Fetch", map[string]interface{}{"url": srv.URL + "/today"})
+	if err := json.Unmarshal([]byte(res.ForModel), &resp); err != nil {
+		t.Fatalf("decode response %q: %v", res.ForModel, err)
+	}
+	if resp.Status != 200 || resp.Body != "sunny" {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+
+	res = skill.Call(ctx, "Fetch", map[string]interface{}{"url": "http://example.com/"})
+	resp = httpResponse{}
+	_ = json.Unmarshal([]byte(res.ForModel), &resp)
+	if !strings.Contains(resp.Error, "not in allow_domains") {
+		t.Errorf("expected allowlist rejection, got %+v", resp)
+	}
+}
+
+func TestSkill_TimeoutStopsRunawayModule(t *testing.T) {
+	host := newTestHost()
+	binary := buildModule(nil, []wasmFunc{
+		{name: "spin", typ: typeAction, body: cat([]byte{0x03, 0x40, 0x0c, 0x00, 0x0b}, []byte{0x42, 0x00})},
+	})
+	skill := loadTest(t, host, `{"name":"Spinner","actions":[{"name":"Spin"}],"limits":{"timeout_ms":50}}`, binary)
+
+	res := skill.Call(context.Background(), "Spin", nil)
+	if !res.IsError || !strings.Contains(res.Err.Error(), "limit") {
+		t.Fatalf("expected timeout error, got %+v", res)
+	}
+}
+
+func TestParseManifest_Validation(t *testing.T) {
+	for name, doc := range map[string]string{
+		"bad name":       `{"name":"no spaces","actions":[{"name":"A"}]}`,
+		"no actions":     `{"name":"X"}`,
+		"path traversal": `{"name":"X","module":"../x.wasm","actions":[{"name":"A"}]}`,
+		"wildcard http":  `{"name":"X","actions":[{"name":"A"}],"capabilities":{"http":{"allow_domains":["*"]}}}`,
+		"unknown field":  `{"name":"X","actions":[{"name":"A"}],"capabilities":{"fs":true}}`,
+		"duplicate":      `{"name":"X","actions":[{"name":"A"},{"name":"a"}]}`,
+	} {
+		if _, err := ParseManifest([]byte(doc)); err == nil {
+			t.Errorf("%s: expected validation error", name)
+		}
+	}
+
+	allow := &HTTPCapability{AllowDomains: []string{"*.example.com", "api.test"}}
+	for host, want := range map[string]bool{"example.com": true, "a.example.com": true, "api.test": true, "x.api.test": false, "evil.com": false} {
+		if got := allow.allowsHost(host); got != want {
+			t.Errorf("allowsHost(%s) = %v, want %v", host, got, want)
+		}
+	}
+}
+
+func TestHost_InstallVerifiesDigest(t *testing.T) {
+	binary := buildModule(nil, []wasmFunc{{name: "echo", typ: typeAction, body: packArgs}})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write(binary)
+	}))
+	defer srv.Close()
+
+	host := newTestHost()
+	host.AllowInsecureHTTP = true
+	dir := t.TempDir()
+	manifest := []byte(`{"name":"Echo","actions":[{"name":"Say","export":"echo"}]}`)
+	ctx := context.Background()
+
+	if _, err := host.Install(ctx, dir, manifest, srv.URL, strings.Repeat("0", 64)); err == nil || !strings.Contains(err.Error(), "digest") {
+		t.Fatalf("expected digest failure, got %v", err)
+	}
+
+	sum := sha256.Sum256(binary)
+	skill, err := host.Install(ctx, dir, manifest, srv.URL, hex.EncodeToString(sum[:]))
+	if err != nil {
+		t.Fatalf("Install: %v", err)
+	}
+	_ = skill.Stop(ctx)
+
+	reloaded, err := host.LoadDir(ctx, dir)
+	if err != nil || len(reloaded) != 1 || reloaded[0].Manifest.Name != "Echo" {
+		t.Fatalf("installed bundle should load from disk: %v %+v", err, reloaded)
+	}
+	_ = reloaded[0].Stop(ctx)
+}
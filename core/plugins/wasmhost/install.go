@@ -0,0 +1,68 @@
+package wasmhost
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const maxModuleBytes = 32 << 20
+
+// Install downloads a module distributed through /sync-skills, verifies its
+// SHA-256 digest, writes the bundle under dir/<name> and loads it.
+func (h *Host) Install(ctx context.Context, dir string, manifestJSON []byte, moduleURL, sha256Hex string) (*Skill, error) {
+	manifest, err := ParseManifest(manifestJSON)
+	if err != nil {
+		return nil, err
+	}
+	want := strings.ToLower(strings.TrimSpace(sha256Hex))
+	if len(want) != sha256.Size*2 {
+		return nil, fmt.Errorf("wasm skill %s: a sha256 digest is required", manifest.Name)
+	}
+	if !strings.HasPrefix(moduleURL, "https://") && !(h.AllowInsecureHTTP && strings.HasPrefix(moduleURL, "http://")) {
+		return nil, fmt.Errorf("wasm skill %s: module url must be https", manifest.Name)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, moduleURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create module request: %w", err)
+	}
+	resp, err := h.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("download module for %s: %w", manifest.Name, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("download module for %s: status %d", manifest.Name, resp.StatusCode)
+	}
+
+	binary, err := io.ReadAll(io.LimitReader(resp.Body, maxModuleBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("read module for %s: %w", manifest.Name, err)
+	}
+	if len(binary) > maxModuleBytes {
+		return nil, fmt.Errorf("module for %s exceeds %d bytes", manifest.Name, maxModuleBytes)
+	}
+	sum := sha256.Sum256(binary)
+	if got := hex.EncodeToString(sum[:]); got != want {
+		return nil, fmt.Errorf("module for %s failed digest check: got %s want %s", manifest.Name, got, want)
+	}
+
+	bundle := filepath.Join(dir, manifest.Name)
+	if err := os.MkdirAll(bundle, 0o755); err != nil {
+		return nil, fmt.Errorf("create bundle dir: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(bundle, manifest.Module), binary, 0o644); err != nil {
+		return nil, fmt.Errorf("write module: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(bundle, ManifestFile), manifestJSON, 0o644); err != nil {
+		return nil, fmt.Errorf("write manifest: %w", err)
+	}
+	return h.Load(ctx, manifest, binary)
+}
@@ -0,0 +1,127 @@
+package wasmhost
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// KVStore is the storage behind the kv capability. Each skill gets its own
+// namespace; implementations must be safe for concurrent use.
+type KVStore interface {
+	Get(namespace, key string) ([]byte, bool, error)
+	Set(namespace, key string, value []byte) error
+	Delete(namespace, key string) error
+	Size(namespace string) (int, error)
+}
+
+// FileKVStore keeps each namespace as a JSON file under Dir. An empty Dir keeps
+// everything in memory.
+type FileKVStore struct {
+	Dir string
+
+	mu    sync.Mutex
+	cache map[string]map[string][]byte
+}
+
+func NewFileKVStore(dir string) *FileKVStore {
+	return &FileKVStore{Dir: dir, cache: map[string]map[string][]byte{}}
+}
+
+func (s *FileKVStore) Get(namespace, key string) ([]byte, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ns, err := s.load(namespace)
+	if err != nil {
+		return nil, false, err
+	}
+	v, ok := ns[key]
+	return append([]byte(nil), v...), ok, nil
+}
+
+func (s *FileKVStore) Set(namespace, key string, value []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ns, err := s.load(namespace)
+	if err != nil {
+		return err
+	}
+	ns[key] = append([]byte(nil), value...)
+	return s.persist(namespace, ns)
+}
+
+func (s *FileKVStore) Delete(namespace, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ns, err := s.load(namespace)
+	if err != nil {
+		return err
+	}
+	if _, ok := ns[key]; !ok {
+		return nil
+	}
+	delete(ns, key)
+	return s.persist(namespace, ns)
+}
+
+func (s *FileKVStore) Size(namespace string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ns, err := s.load(namespace)
+	if err != nil {
+		return 0, err
+	}
+	total := 0
+	for k, v := range ns {
+		total += len(k) + len(v)
+	}
+	return total, nil
+}
+
+func (s *FileKVStore) load(namespace string) (map[string][]byte, error) {
+	if s.cache == nil {
+		s.cache = map[string]map[string][]byte{}
+	}
+	if ns, ok := s.cache[namespace]; ok {
+		return ns, nil
+	}
+
+	ns := map[string][]byte{}
+	if s.Dir != "" {
+		raw, err := os.ReadFile(s.path(namespace))
+		switch {
+		case err == nil:
+			if err := json.Unmarshal(raw, &ns); err != nil {
+				return nil, fmt.Errorf("decode kv namespace %s: %w", namespace, err)
+			}
+		case !os.IsNotExist(err):
+			return nil, fmt.Errorf("read kv namespace %s: %w", namespace, err)
+		}
+	}
+	s.cache[namespace] = ns
+	return ns, nil
+}
+
+func (s *FileKVStore) persist(namespace string, ns map[string][]byte) error {
+	if s.Dir == "" {
+		return nil
+	}
+	if err := os.MkdirAll(s.Dir, 0o700); err != nil {
+		return fmt.Errorf("create kv dir: %w", err)
+	}
+	encoded, err := json.Marshal(ns)
+	if err != nil {
+		return fmt.Errorf("encode kv namespace %s: %w", namespace, err)
+	}
+	tmp := s.path(namespace) + ".tmp"
+	if err := os.WriteFile(tmp, encoded, 0o600); err != nil {
+		return fmt.Errorf("write kv namespace %s: %w", namespace, err)
+	}
+	return os.Rename(tmp, s.path(namespace))
+}
+
+func (s *FileKVStore) path(namespace string) string {
+	return filepath.Join(s.Dir, namespace+".kv.json")
+}
@@ -0,0 +1,169 @@
+package wasmhost
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	// ManifestFile is the manifest name expected next to a module in a skill directory.
+	ManifestFile = "skill.json"
+	// SkillsDirEnv points desktop builds at a directory of skill bundles.
+	SkillsDirEnv = "UPCRAFT_WASM_SKILLS_DIR"
+
+	defaultTimeout     = 5 * time.Second
+	maxTimeout         = 60 * time.Second
+	defaultMemoryPages = 256 // 16 MiB
+	maxMemoryPages     = 1024
+)
+
+var identPattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*$`)
+
+// Manifest describes one WASM skill bundle.
+type Manifest struct {
+	Name         string           `json:"name"`
+	Version      string           `json:"version"`
+	Description  string           `json:"description"`
+	Module       string           `json:"module"`
+	Actions      []ActionManifest `json:"actions"`
+	Capabilities Capabilities     `json:"capabilities"`
+	Limits       Limits           `json:"limits"`
+}
+
+// ActionManifest maps one registry action onto a module export.
+type ActionManifest struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Export      string                 `json:"export"`
+	InputSchema map[string]interface{} `json:"input_schema"`
+}
+
+// Capabilities lists the host functions a module may import. Anything not
+// declared here is not linked, so a module importing it fails to load.
+type Capabilities struct {
+	Log  bool            `json:"log,omitempty"`
+	HTTP *HTTPCapability `json:"http,omitempty"`
+	KV   *KVCapability   `json:"kv,omitempty"`
+}
+
+// HTTPCapability allows outbound requests to listed hosts only.
+// An entry "*.example.com" also matches any subdomain.
+type HTTPCapability struct {
+	AllowDomains []string `json:"allow_domains"`
+}
+
+// KVCapability grants a private key-value namespace.
+type KVCapability struct {
+	MaxBytes int `json:"max_bytes,omitempty"`
+}
+
+// Limits bounds each action invocation.
+type Limits struct {
+	TimeoutMS   int    `json:"timeout_ms,omitempty"`
+	MemoryPages uint32 `json:"memory_pages,omitempty"`
+}
+
+// ParseManifest decodes and validates a manifest document.
+func ParseManifest(raw []byte) (*Manifest, error) {
+	dec := json.NewDecoder(strings.NewReader(string(raw)))
+	dec.DisallowUnknownFields()
+
+	var m Manifest
+	if err := dec.Decode(&m); err != nil {
+		return nil, fmt.Errorf("decode manifest: %w", err)
+	}
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// Validate checks the manifest and fills defaults.
+func (m *Manifest) Validate() error {
+	if !identPattern.MatchString(m.Name) {
+		return fmt.Errorf("manifest: name %q must be an identifier", m.Name)
+	}
+	if strings.TrimSpace(m.Module) == "" {
+		m.Module = strings.ToLower(m.Name) + ".wasm"
+	}
+	if strings.ContainsAny(m.Module, `/\`) || strings.Contains(m.Module, "..") {
+		return fmt.Errorf("manifest %s: module must be a plain file name", m.Name)
+	}
+	if len(m.Actions) == 0 {
+		return fmt.Errorf("manifest %s: at least one action is required", m.Name)
+	}
+
+	seen := map[string]bool{}
+	for i := range m.Actions {
+		a := &m.Actions[i]
+		if !identPattern.MatchString(a.Name) {
+			return fmt.Errorf("manifest %s: action name %q must be an identifier", m.Name, a.Name)
+		}
+		key := strings.ToLower(a.Name)
+		if seen[key] {
+			return fmt.Errorf("manifest %s: duplicate action %s", m.Name, a.Name)
+		}
+		seen[key] = true
+		if a.Export == "" {
+			a.Export = strings.ToLower(a.Name)
+		}
+		if a.InputSchema == nil {
+			a.InputSchema = map[string]interface{}{"type": "object", "properties": map[string]interface{}{}}
+		}
+	}
+
+	if m.Capabilities.HTTP != nil {
+		if len(m.Capabilities.HTTP.AllowDomains) == 0 {
+			return fmt.Errorf("manifest %s: http capability needs allow_domains", m.Name)
+		}
+		for i, d := range m.Capabilities.HTTP.AllowDomains {
+			d = strings.ToLower(strings.TrimSpace(d))
+			if d == "" || d == "*" || strings.Contains(d, "/") {
+				return fmt.Errorf("manifest %s: invalid allowed domain %q", m.Name, m.Capabilities.HTTP.AllowDomains[i])
+			}
+			m.Capabilities.HTTP.AllowDomains[i] = d
+		}
+	}
+
+	if m.Limits.TimeoutMS < 0 || time.Duration(m.Limits.TimeoutMS)*time.Millisecond > maxTimeout {
+		return fmt.Errorf("manifest %s: timeout_ms must be between 0 and %d", m.Name, maxTimeout.Milliseconds())
+	}
+	if m.Limits.MemoryPages > maxMemoryPages {
+		return fmt.Errorf("manifest %s: memory_pages must not exceed %d", m.Name, maxMemoryPages)
+	}
+	return nil
+}
+
+func (m *Manifest) timeout() time.Duration {
+	if m.Limits.TimeoutMS > 0 {
+		return time.Duration(m.Limits.TimeoutMS) * time.Millisecond
+	}
+	return defaultTimeout
+}
+
+func (m *Manifest) memoryPages() uint32 {
+	if m.Limits.MemoryPages > 0 {
+		return m.Limits.MemoryPages
+	}
+	return defaultMemoryPages
+}
+
+// allowsHost reports whether the http capability covers host.
+func (c *HTTPCapability) allowsHost(host string) bool {
+	host = strings.ToLower(host)
+	for _, d := range c.AllowDomains {
+		if suffix, ok := strings.CutPrefix(d, "*."); ok {
+			if host == suffix || strings.HasSuffix(host, "."+suffix) {
+				return true
+			}
+			continue
+		}
+		if host == d {
+			return true
+		}
+	}
+	return false
+}
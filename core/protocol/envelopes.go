@@ -0,0 +1,247 @@
+package protocol
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/screen"
+)
+
+// ScreenAction answers a single screen report with one UI action.
+type ScreenAction struct {
+	Header
+	screen.Action
+}
+
+func (e *ScreenAction) envelope() (*Header, Kind) { return &e.Header, KindScreenAction }
+
+func (e *ScreenAction) Validate() error {
+	if err := e.Header.check(KindScreenAction, false); err != nil {
+		return err
+	}
+	return e.Action.Check()
+}
+
+// GoalStatus is where a goal session stands after a step.
+type GoalStatus string
+
+const (
+	GoalRunning   GoalStatus = "running"
+	GoalCompleted GoalStatus = "completed"
+	GoalFailed    GoalStatus = "failed"
+)
+
+// GoalStep answers one screen of a goal session. While running, Action is
+// the envelope the host executes before reporting the next screen; the
+// terminal statuses carry a Reason instead.
+type GoalStep struct {
+	Header
+	SessionID string         `json:"session_id"`
+	Status    GoalStatus     `json:"status"`
+	Step      int            `json:"step"`
+	Action    *screen.Action `json:"action,omitempty"`
+	Reason    string         `json:"reason,omitempty"`
+	Message   string         `json:"message,omitempty"`
+}
+
+func (e *GoalStep) envelope() (*Header, Kind) { return &e.Header, KindGoalStep }
+
+func (e *GoalStep) Validate() error {
+	if err := e.Header.check(KindGoalStep, false); err != nil {
+		return err
+	}
+	if e.SessionID == "" {
+		return errors.New("goal_step: session_id is required")
+	}
+	if e.Step < 0 {
+		return errors.New("goal_step: step must not be negative")
+	}
+	switch e.Status {
+	case GoalRunning:
+		if e.Action == nil {
+			return errors.New("goal_step: a running step needs an action")
+		}
+		if err := e.Action.Check(); err != nil {
+			return fmt.Errorf("goal_step: %w", err)
+		}
+	case GoalCompleted, GoalFailed:
+		if e.Action != nil {
+			return fmt.Errorf("goal_step: a %s step carries no action", e.Status)
+		}
+		if e.Reason == "" {
+			return fmt.Errorf("goal_step: a %s step needs a reason", e.Status)
+		}
+	default:
+		return fmt.Errorf("goal_step: unknown status %q", e.Status)
+	}
+	return nil
+}
+
+// Command asks the host to run a plugin action. The host runs commands in
+// Seq order and reports each one by ID with a CommandAck before Deadline.
+type Command struct {
+	Header
+	ID       string                 `json:"id"`
+	Seq      uint64                 `json:"seq"`
+	Tool     string                 `json:"tool"`
+	Action   string                 `json:"action"`
+	Params   map[string]interface{} `json:"params,omitempty"`
+	Deadline time.Time              `json:"deadline"`
+}
+
+func (e *Command) envelope() (*Header, Kind) { return &e.Header, KindCommand }
+
+func (e *Command) Validate() error {
+	if err := e.Header.check(KindCommand, false); err != nil {
+		return err
+	}
+	switch {
+	case e.ID == "":
+		return errors.New("command: id is required")
+	case e.Seq == 0:
+		return errors.New("command: seq starts at 1")
+	case e.Tool == "" || e.Action == "":
+		return errors.New("command: tool and action are required")
+	case e.Deadline.IsZero():
+		return errors.New("command: deadline is required")
+	}
+	return nil
+}
+
+// CommandAck reports a command's outcome: OK with an optional JSON Result,
+// or not OK with an Error message.
+type CommandAck struct {
+	Header
+	ID     string          `json:"id"`
+	OK     bool            `json:"ok"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  string          `json:"error,omitempty"`
+}
+
+func (e *CommandAck) envelope() (*Header, Kind) { return &e.Header, KindCommandAck }
+
+func (e *CommandAck) Validate() error {
+	if err := e.Header.check(KindCommandAck, false); err != nil {
+		return err
+	}
+	switch {
+	case e.ID == "":
+		return errors.New("command_ack: id is required")
+	case e.OK && e.Error != "":
+		return errors.New("command_ack: a successful ack carries no error")
+	case !e.OK && e.Error == "":
+		return errors.New("command_ack: a failed ack needs an error")
+	case !e.OK && len(e.Result) > 0:
+		return errors.New("command_ack: a failed ack carries no result")
+	case len(e.Result) > 0 && !json.Valid(e.Result):
+		return errors.New("command_ack: result is not valid JSON")
+	}
+	return nil
+}
+
+// ScreenReport is a screen tree from the host, with the goal it should be
+// planned toward. Reports without a header are accepted from hosts that
+// predate versioning.
+type ScreenReport struct {
+	Header
+	Goal string `json:"goal,omitempty"`
+	screen.Screen
+}
+
+func (e *ScreenReport) envelope() (*Header, Kind) { return &e.Header, KindScreenReport }
+
+func (e *ScreenReport) Validate() error {
+	if err := e.Header.check(KindScreenReport, true); err != nil {
+		return err
+	}
+	return e.Screen.Validate()
+}
+
+// EventType says what an Event reports.
+type EventType string
+
+const (
+	// EventPartialText is interim text the model gave alongside an action.
+	EventPartialText EventType = "partial_text"
+	// EventActionStarted and EventActionFinished bracket one skill action.
+	EventActionStarted  EventType = "action_started"
+	EventActionFinished EventType = "action_finished"
+	// EventConfirmation asks the user to approve an action; the host answers
+	// with the ConfirmationID.
+	EventConfirmation EventType = "confirmation_request"
+	// EventFinalAnswer, EventError and EventCancelled end a prompt.
+	EventFinalAnswer EventType = "final_answer"
+	EventError       EventType = "error"
+	EventCancelled   EventType = "cancelled"
+)
+
+// Event reports the progress of a prompt. Seq orders the events of one
+// session from 1. Action events name the Tool and Action; a finished
+// action that failed carries Error, and Text is its user-facing result.
+// A finished action may also carry the action's structured Data, its UI
+// Card and its Artifacts, as the JSON of engine.ActionResult.
+type Event struct {
+	Header
+	SessionID      string                 `json:"session_id"`
+	Seq            int                    `json:"seq"`
+	Type           EventType              `json:"type"`
+	Text           string                 `json:"text,omitempty"`
+	Tool           string                 `json:"tool,omitempty"`
+	Action         string                 `json:"action,omitempty"`
+	Input          map[string]interface{} `json:"input,omitempty"`
+	Error          string                 `json:"error,omitempty"`
+	ConfirmationID string                 `json:"confirmation_id,omitempty"`
+	Data           json.RawMessage        `json:"data,omitempty"`
+	Card           json.RawMessage        `json:"card,omitempty"`
+	Artifacts      json.RawMessage        `json:"artifacts,omitempty"`
+}
+
+func (e *Event) envelope() (*Header, Kind) { return &e.Header, KindEvent }
+
+func (e *Event) Validate() error {
+	if err := e.Header.check(KindEvent, false); err != nil {
+		return err
+	}
+	switch {
+	case e.SessionID == "":
+		return errors.New("event: session_id is required")
+	case e.Seq < 1:
+		return errors.New("event: seq starts at 1")
+	}
+	for _, field := range []struct {
+		name string
+		raw  json.RawMessage
+	}{{"data", e.Data}, {"card", e.Card}, {"artifacts", e.Artifacts}} {
+		switch {
+		case len(field.raw) == 0:
+		case e.Type != EventActionFinished:
+			return fmt.Errorf("event: %s carries no %s", e.Type, field.name)
+		case !json.Valid(field.raw):
+			return fmt.Errorf("event: %s is not valid JSON", field.name)
+		}
+	}
+	switch e.Type {
+	case EventPartialText, EventFinalAnswer:
+		if e.Text == "" {
+			return fmt.Errorf("event: %s needs text", e.Type)
+		}
+	case EventActionStarted, EventActionFinished:
+		if e.Tool == "" || e.Action == "" {
+			return fmt.Errorf("event: %s needs tool and action", e.Type)
+		}
+	case EventConfirmation:
+		if e.ConfirmationID == "" || e.Tool == "" || e.Action == "" {
+			return errors.New("event: confirmation_request needs confirmation_id, tool and action")
+		}
+	case EventError:
+		if e.Error == "" {
+			return errors.New("event: error needs an error message")
+		}
+	case EventCancelled:
+	default:
+		return fmt.Errorf("event: unknown type %q", e.Type)
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+// Package protocol defines the envelopes exchanged between the core and host
+// apps. Every envelope carries the protocol version and its kind, is checked
+// with Validate when emitted and when received, and is described by the JSON
+// Schema in schema.json, which host apps generate their types from.
+package protocol
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Version is the protocol version the core speaks. It changes only when an
+// envelope changes incompatibly; new optional fields keep the version.
+const Version = 1
+
+// Kind names an envelope type.
+type Kind string
+
+const (
+	// KindScreenAction is a UI action answering a single screen report.
+	KindScreenAction Kind = "screen_action"
+	// KindGoalStep answers one screen of a goal session.
+	KindGoalStep Kind = "goal_step"
+	// KindCommand asks the host to run a plugin action, e.g. play music.
+	KindCommand Kind = "command"
+	// KindCommandAck is the host's report on a command.
+	KindCommandAck Kind = "command_ack"
+	// KindScreenReport is a screen tree sent by the host.
+	KindScreenReport Kind = "screen_report"
+	// KindEvent streams the progress of a prompt to the host.
+	KindEvent Kind = "event"
+)
+
+// ErrVersion is returned for envelopes of another protocol version.
+var ErrVersion = errors.New("unsupported protocol version")
+
+// Header starts every envelope.
+type Header struct {
+	V    int  `json:"v"`
+	Kind Kind `json:"kind"`
+}
+
+func header(kind Kind) Header {
+	return Header{V: Version, Kind: kind}
+}
+
+// check reports a wrong version or kind. A zero version is accepted only
+// where allowLegacy is set, for hosts that predate versioning.
+func (h Header) check(kind Kind, allowLegacy bool) error {
+	if h.V == 0 && allowLegacy {
+		return nil
+	}
+	if h.V != Version {
+		return fmt.Errorf("%w %d, want %d", ErrVersion, h.V, Version)
+	}
+	if h.Kind != kind {
+		return fmt.Errorf("envelope kind %q, want %q", h.Kind, kind)
+	}
+	return nil
+}
+
+// Envelope is implemented by every envelope type. Validate checks the
+// header and the fields the schema cannot express.
+type Envelope interface {
+	Validate() error
+	envelope() (*Header, Kind)
+}
+
+// Stamp sets the current version and the envelope's kind.
+func Stamp(env Envelope) {
+	h, kind := env.envelope()
+	*h = header(kind)
+}
+
+// Encode stamps an envelope with the current version, validates it and
+// marshals it; invalid envelopes are never emitted.
+func Encode(env Envelope) ([]byte, error) {
+	Stamp(env)
+	if err := env.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid envelope: %w", err)
+	}
+	return json.Marshal(env)
+}
+
+// Decode strictly unmarshals raw into env and validates it. Unknown fields
+// are rejected so the two sides notice drift instead of dropping data.
+func Decode(raw []byte, env Envelope) error {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(env); err != nil {
+		return fmt.Errorf("decode envelope: %w", err)
+	}
+	if err := env.Validate(); err != nil {
+		return fmt.Errorf("invalid envelope: %w", err)
+	}
+	return nil
+}
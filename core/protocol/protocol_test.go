@@ -0,0 +1,172 @@
+package protocol
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/screen"
+)
+
+type schemaDef struct {
+	Properties map[string]struct {
+		Enum []string `json:"enum"`
+	} `json:"properties"`
+	Required []string `json:"required"`
+}
+
+func loadSchema(t *testing.T) map[string]schemaDef {
+	t.Helper()
+	var doc struct {
+		Defs map[string]schemaDef `json:"$defs"`
+	}
+	if err := json.Unmarshal(Schema(), &doc); err != nil {
+		t.Fatalf("schema.json: %v", err)
+	}
+	return doc.Defs
+}
+
+// jsonFields lists the JSON names of a struct's fields, flattening embedded
+// structs the way encoding/json does.
+func jsonFields(t reflect.Type) []string {
+	var names []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if f.Anonymous && tag == "" {
+			names = append(names, jsonFields(f.Type)...)
+			continue
+		}
+		if !f.IsExported() || tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = f.Name
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestSchema_MatchesGoTypes(t *testing.T) {
+	defs := loadSchema(t)
+	for def, v := range map[string]interface{}{
+		"ScreenAction": ScreenAction{},
+		"GoalStep":     GoalStep{},
+		"Command":      Command{},
+		"CommandAck":   CommandAck{},
+		"ScreenReport": ScreenReport{},
+		"Event":        Event{},
+		"Action":       screen.Action{},
+		"Node":         screen.Node{},
+		"Bounds":       screen.Bounds{},
+	} {
+		var props []string
+		for name := range defs[def].Properties {
+			props = append(props, name)
+		}
+		sort.Strings(props)
+		if want := jsonFields(reflect.TypeOf(v)); !reflect.DeepEqual(props, want) {
+			t.Errorf("%s: schema properties %v, Go fields %v", def, props, want)
+		}
+	}
+}
+
+func TestSchema_EnumsMatchValidation(t *testing.T) {
+	defs := loadSchema(t)
+	for _, name := range defs["Action"].Properties["action"].Enum {
+		a := screen.Action{Action: screen.ActionType(name), NodeID: "n", Text: "x", Direction: screen.ScrollUp}
+		if err := a.Check(); err != nil {
+			t.Errorf("schema action %s rejected: %v", name, err)
+		}
+	}
+	if err := (screen.Action{Action: "SWIPE"}).Check(); err == nil {
+		t.Error("action outside the schema accepted")
+	}
+	for _, name := range defs["GoalStep"].Properties["status"].Enum {
+		step := GoalStep{Header: header(KindGoalStep), SessionID: "g", Status: GoalStatus(name), Reason: "r"}
+		if name == string(GoalRunning) {
+			step.Action, step.Reason = &screen.Action{Action: screen.ActionBack}, ""
+		}
+		if err := step.Validate(); err != nil {
+			t.Errorf("schema status %s rejected: %v", name, err)
+		}
+	}
+	for _, name := range defs["Event"].Properties["type"].Enum {
+		ev := Event{Header: header(KindEvent), SessionID: "s", Seq: 1, Type: EventType(name),
+			Text: "t", Tool: "MusicPlayer", Action: "Play", Error: "e", ConfirmationID: "c"}
+		if err := ev.Validate(); err != nil {
+			t.Errorf("schema event type %s rejected: %v", name, err)
+		}
+	}
+}
+
+func TestEncode_StampsAndValidates(t *testing.T) {
+	raw, err := Encode(&ScreenAction{Action: screen.Action{Action: screen.ActionClick, NodeID: "play"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(raw); got != `{"v":1,"kind":"screen_action","action":"CLICK","node_id":"play"}` {
+		t.Fatalf("encoded = %s", got)
+	}
+
+	for name, env := range map[string]Envelope{
+		"click without target":    &ScreenAction{Action: screen.Action{Action: screen.ActionClick}},
+		"running without action":  &GoalStep{SessionID: "g", Status: GoalRunning},
+		"failed without reason":   &GoalStep{SessionID: "g", Status: GoalFailed},
+		"command without seq":     &Command{ID: "c", Tool: "MusicPlayer", Action: "PLAY", Deadline: time.Now()},
+		"ack failure with result": &CommandAck{ID: "c", Error: "denied", Result: json.RawMessage(`{}`)},
+		"final answer with data":  &Event{SessionID: "s", Seq: 1, Type: EventFinalAnswer, Text: "done", Data: json.RawMessage(`{}`)},
+		"invalid card":            &Event{SessionID: "s", Seq: 1, Type: EventActionFinished, Tool: "T", Action: "A", Card: json.RawMessage(`{`)},
+	} {
+		if _, err := Encode(env); err == nil {
+			t.Errorf("%s: encoded", name)
+		}
+	}
+}
+
+func TestDecode_RejectsDrift(t *testing.T) {
+	var ack CommandAck
+	if err := Decode([]byte(`{"v":1,"kind":"command_ack","id":"cmd-1","ok":true,"result":{"ok":1}}`), &ack); err != nil {
+		t.Fatal(err)
+	}
+	if ack.ID != "cmd-1" || string(ack.Result) != `{"ok":1}` {
+		t.Fatalf("ack = %+v", ack)
+	}
+
+	for name, raw := range map[string]string{
+		"unknown field":  `{"v":1,"kind":"command_ack","id":"cmd-1","ok":true,"status":"done"}`,
+		"wrong kind":     `{"v":1,"kind":"command","id":"cmd-1","ok":true}`,
+		"no version":     `{"kind":"command_ack","id":"cmd-1","ok":true}`,
+		"bad result":     `{"v":1,"kind":"command_ack","id":"cmd-1","ok":false,"error":""}`,
+		"future version": `{"v":2,"kind":"command_ack","id":"cmd-1","ok":true}`,
+	} {
+		if err := Decode([]byte(raw), &CommandAck{}); err == nil {
+			t.Errorf("%s: decoded", name)
+		}
+	}
+	err := Decode([]byte(`{"v":2,"kind":"command_ack","id":"cmd-1","ok":true}`), &CommandAck{})
+	if !errors.Is(err, ErrVersion) {
+		t.Errorf("future version error = %v", err)
+	}
+}
+
+func TestScreenReport_AcceptsUnversionedHosts(t *testing.T) {
+	for _, raw := range []string{
+		`{"root":{"id":"r"}}`,
+		`{"v":1,"kind":"screen_report","goal":"open wifi","root":{"id":"r"}}`,
+	} {
+		if err := Decode([]byte(raw), &ScreenReport{}); err != nil {
+			t.Errorf("%s: %v", raw, err)
+		}
+	}
+	if err := Decode([]byte(`{"v":1,"kind":"screen_report"}`), &ScreenReport{}); err == nil {
+		t.Error("report without root accepted")
+	}
+}
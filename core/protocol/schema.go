@@ -0,0 +1,13 @@
+package protocol
+
+import _ "embed"
+
+//go:embed schema.json
+var schema []byte
+
+// Schema returns the JSON Schema (draft 2020-12) describing every envelope.
+// Host apps generate their envelope types from it; the protocol tests keep
+// it in step with the Go types.
+func Schema() []byte {
+	return append([]byte(nil), schema...)
+}
@@ -0,0 +1,74 @@
+package redact
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AuditPathEnv overrides where FileAuditLog appends redaction records.
+const AuditPathEnv = "UPCRAFT_REDACTION_AUDIT_PATH"
+
+// AuditEntry records one redaction. It names the placeholder and where the
+// value was found, never the value itself.
+type AuditEntry struct {
+	Time        time.Time `json:"time"`
+	Kind        string    `json:"kind"`
+	Source      string    `json:"source"`
+	Placeholder string    `json:"placeholder"`
+}
+
+// AuditLog receives the redactions of one Redactor call as a batch.
+type AuditLog interface {
+	Record(entries ...AuditEntry) error
+}
+
+// FileAuditLog appends entries to a JSON Lines file.
+type FileAuditLog struct {
+	Path string
+
+	mu sync.Mutex
+}
+
+// DefaultAuditPath honors UPCRAFT_REDACTION_AUDIT_PATH and otherwise uses
+// the user config directory.
+func DefaultAuditPath() (string, error) {
+	if path := strings.TrimSpace(os.Getenv(AuditPathEnv)); path != "" {
+		return path, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("locate config dir: %w", err)
+	}
+	return filepath.Join(dir, "upcraft", "redaction_audit.jsonl"), nil
+}
+
+func (l *FileAuditLog) Record(entries ...AuditEntry) error {
+	var buf []byte
+	for _, e := range entries {
+		line, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		buf = append(append(buf, line...), '\n')
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(l.Path), 0o700); err != nil {
+		return fmt.Errorf("create audit dir: %w", err)
+	}
+	f, err := os.OpenFile(l.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("open redaction audit: %w", err)
+	}
+	if _, err := f.Write(buf); err != nil {
+		f.Close()
+		return fmt.Errorf("write redaction audit: %w", err)
+	}
+	return f.Close()
+}
@@ -0,0 +1,125 @@
+package redact
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Detector finds one kind of sensitive value in free text. When Pattern has
+// a capture group only the group is redacted, so context such as "code:"
+// can anchor a match without being replaced. Valid, when set, vets each
+// match: checksums and digit counts weed out false positives.
+type Detector struct {
+	Kind    string
+	Pattern *regexp.Regexp
+	Valid   func(match string) bool
+}
+
+// Detector kinds, also used for app and field denylist redactions.
+const (
+	KindEmail = "email"
+	KindCard  = "card"
+	KindPhone = "phone"
+	KindOTP   = "otp"
+	KindField = "field"
+	KindApp   = "app"
+)
+
+// DefaultDetectors run in order, cards before phones so a card number is not
+// mistaken for a long phone number.
+var DefaultDetectors = []Detector{
+	{
+		Kind:    KindEmail,
+		Pattern: regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`),
+	},
+	{
+		Kind:    KindCard,
+		Pattern: regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`),
+		Valid:   luhn,
+	},
+	{
+		Kind:    KindPhone,
+		Pattern: regexp.MustCompile(`\+?\(?\d[\d ().-]{5,}\d`),
+		Valid:   phoneLike,
+	},
+	{
+		Kind:    KindOTP,
+		Pattern: regexp.MustCompile(`(?i)\b(?:otp|code|passcode|pin|verification)\b\D{0,20}?\b(\d{4,8})\b`),
+	},
+	{
+		Kind:    KindOTP,
+		Pattern: regexp.MustCompile(`(?i)\b(\d{4,8})\b\s+is\s+your\b`),
+	},
+}
+
+// DefaultDenyFields are resource-id words whose values are always redacted,
+// whatever they contain.
+var DefaultDenyFields = []string{
+	"password", "passwd", "passcode", "otp", "pin", "cvv", "cvc",
+	"card_number", "iban", "ssn", "message_text", "message_body",
+}
+
+func digits(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// luhn reports whether the digits of s pass the card-number checksum.
+func luhn(s string) bool {
+	d := digits(s)
+	if len(d) < 13 || len(d) > 19 {
+		return false
+	}
+	sum := 0
+	for i := len(d) - 1; i >= 0; i-- {
+		n := int(d[i] - '0')
+		if (len(d)-i)%2 == 0 {
+			n *= 2
+			if n > 9 {
+				n -= 9
+			}
+		}
+		sum += n
+	}
+	return sum%10 == 0
+}
+
+var datePattern = regexp.MustCompile(`^\d{1,4}[-/.]\d{1,2}[-/.]\d{1,4}$`)
+
+// phoneLike accepts 8 to 15 digits, the E.164 range minus short numbers
+// that are more often counts or prices, and rejects dates.
+func phoneLike(s string) bool {
+	n := len(digits(s))
+	return n >= 8 && n <= 15 && !datePattern.MatchString(strings.TrimSpace(s))
+}
+
+// fieldDenied reports whether a resource id such as "com.bank:id/otp_input"
+// contains entry as a whole word, with '_', '.', '-', ':' and '/' as
+// separators.
+func fieldDenied(resourceID, entry string) bool {
+	id := strings.ToLower(resourceID)
+	entry = strings.ToLower(entry)
+	if entry == "" {
+		return false
+	}
+	for from := 0; ; {
+		i := strings.Index(id[from:], entry)
+		if i < 0 {
+			return false
+		}
+		start, end := from+i, from+i+len(entry)
+		if (start == 0 || isSeparator(id[start-1])) && (end == len(id) || isSeparator(id[end])) {
+			return true
+		}
+		from = start + 1
+	}
+}
+
+func isSeparator(c byte) bool {
+	return strings.IndexByte("_.-:/", c) >= 0
+}
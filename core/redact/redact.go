@@ -0,0 +1,303 @@
+// Package redact strips personal data from screen trees and prompts before
+// they leave the device. Sensitive values are swapped for stable
+// placeholders such as [EMAIL_1]; the same value always gets the same
+// placeholder, so a model can still refer to it, and Restore maps
+// placeholders in the model's reply back to the real values on-device.
+package redact
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/screen"
+)
+
+// Notice tells a model how to treat placeholders; callers append it to the
+// system prompt of redacted conversations.
+const Notice = "Some values are redacted as placeholders like [EMAIL_1]. " +
+	"Refer to them, and type them, exactly as written; they are restored on the device."
+
+var placeholderPattern = regexp.MustCompile(`\[([A-Z]+)_(\d+)\]`)
+
+// Redactor replaces sensitive values with placeholders. Detectors scan all
+// text; DenyApps (package names) redact every label of those apps and
+// DenyFields (resource-id words) redact the value of matching nodes. Each
+// redaction is recorded to Audit when set.
+//
+// Placeholders stay stable for the Redactor's lifetime; Reset forgets them.
+type Redactor struct {
+	Detectors  []Detector
+	DenyApps   []string
+	DenyFields []string
+	Audit      AuditLog
+
+	mu     sync.Mutex
+	values map[string]string // value -> placeholder
+	origin map[string]string // placeholder -> value
+	counts map[string]int
+	now    func() time.Time
+}
+
+// New returns a Redactor with the default detectors and field denylist.
+func New() *Redactor {
+	return &Redactor{Detectors: DefaultDetectors, DenyFields: DefaultDenyFields}
+}
+
+// Text redacts free text; source names where it came from in the audit
+// trail, such as "goal" or "prompt:user".
+func (r *Redactor) Text(text, source string) string {
+	p := r.pass()
+	out := p.detect(text, source)
+	p.flush()
+	return out
+}
+
+// Screen returns a redacted copy of s; s itself is left untouched so the
+// caller can still resolve actions against the real tree.
+func (r *Redactor) Screen(s *screen.Screen) *screen.Screen {
+	p := r.pass()
+	out := &screen.Screen{App: s.App, Activity: s.Activity, Width: s.Width, Height: s.Height}
+	var copyNode func(n *screen.Node) *screen.Node
+	copyNode = func(n *screen.Node) *screen.Node {
+		if n == nil {
+			return nil
+		}
+		c := *n
+		source := "screen:" + s.App + "/" + n.ID
+		c.Text = p.label(s.App, n.ResourceID, n.Text, source)
+		c.Description = p.label(s.App, n.ResourceID, n.Description, source)
+		c.Children = nil
+		for _, child := range n.Children {
+			c.Children = append(c.Children, copyNode(child))
+		}
+		return &c
+	}
+	out.Root = copyNode(s.Root)
+	p.flush()
+	return out
+}
+
+// Diff redacts the node labels of a diff taken on app. Labels already seen
+// redacted keep their placeholder, which covers removed nodes of screens
+// redacted earlier. After an app switch the removed nodes belong to an app
+// whose denylist status is unknown, so their labels are dropped.
+func (r *Redactor) Diff(d screen.Diff, app string) screen.Diff {
+	p := r.pass()
+	ref := func(n screen.NodeRef) screen.NodeRef {
+		n.Label = p.label(app, n.ResourceID, n.Label, "diff:"+app+"/"+n.ID)
+		return n
+	}
+	out := d
+	out.Added, out.Removed, out.Changed = nil, nil, nil
+	for _, n := range d.Added {
+		out.Added = append(out.Added, ref(n))
+	}
+	for _, n := range d.Removed {
+		if d.AppChanged {
+			n.Label = ""
+			out.Removed = append(out.Removed, n)
+			continue
+		}
+		out.Removed = append(out.Removed, ref(n))
+	}
+	for _, c := range d.Changed {
+		out.Changed = append(out.Changed, screen.NodeChange{NodeRef: ref(c.NodeRef), Fields: c.Fields})
+	}
+	p.flush()
+	return out
+}
+
+// Restore replaces known placeholders in text with the values they stand
+// for. Unknown placeholders are left as they are.
+func (r *Redactor) Restore(text string) string {
+	if !strings.Contains(text, "[") {
+		return text
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return placeholderPattern.ReplaceAllStringFunc(text, func(ph string) string {
+		if v, ok := r.origin[ph]; ok {
+			return v
+		}
+		return ph
+	})
+}
+
+// Sensitive reports whether text, typed into the node resourceID of app,
+// is personal data: the app or field is denied, a detector matches, or it
+// holds a value redacted before, such as a restored OTP.
+func (r *Redactor) Sensitive(app, resourceID, text string) bool {
+	if strings.TrimSpace(text) == "" {
+		return false
+	}
+	if r.appDenied(app) || r.fieldDenied(resourceID) {
+		return true
+	}
+	for _, d := range r.Detectors {
+		if d.matches(text) {
+			return true
+		}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for value := range r.values {
+		if strings.Contains(text, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// Reset forgets every placeholder. Placeholders handed out before no longer
+// restore.
+func (r *Redactor) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.values, r.origin, r.counts = nil, nil, nil
+}
+
+// placeholder returns the stable placeholder for value, minting one the
+// first time. The caller holds r.mu.
+func (r *Redactor) placeholder(kind, value string) string {
+	if ph, ok := r.values[value]; ok {
+		return ph
+	}
+	if r.values == nil {
+		r.values, r.origin, r.counts = map[string]string{}, map[string]string{}, map[string]int{}
+	}
+	r.counts[kind]++
+	ph := fmt.Sprintf("[%s_%d]", strings.ToUpper(kind), r.counts[kind])
+	r.values[value], r.origin[ph] = ph, value
+	return ph
+}
+
+// placeholderKind returns the lower-case kind of a placeholder, or "".
+func placeholderKind(ph string) string {
+	m := placeholderPattern.FindStringSubmatch(ph)
+	if m == nil {
+		return ""
+	}
+	return strings.ToLower(m[1])
+}
+
+func (r *Redactor) appDenied(app string) bool {
+	for _, denied := range r.DenyApps {
+		if app != "" && strings.EqualFold(app, denied) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Redactor) fieldDenied(resourceID string) bool {
+	for _, entry := range r.DenyFields {
+		if resourceID != "" && fieldDenied(resourceID, entry) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Redactor) clock() time.Time {
+	if r.now != nil {
+		return r.now()
+	}
+	return time.Now()
+}
+
+// pass batches the audit entries of one redaction call.
+type pass struct {
+	r       *Redactor
+	entries []AuditEntry
+}
+
+func (r *Redactor) pass() *pass {
+	return &pass{r: r}
+}
+
+func (p *pass) replace(kind, value, source string) string {
+	p.r.mu.Lock()
+	ph := p.r.placeholder(kind, value)
+	p.r.mu.Unlock()
+	p.entries = append(p.entries, AuditEntry{Time: p.r.clock(), Kind: kind, Source: source, Placeholder: ph})
+	return ph
+}
+
+// label redacts one node label: wholesale for denied apps and fields or a
+// label already redacted before, else through the detectors.
+func (p *pass) label(app, resourceID, text, source string) string {
+	if strings.TrimSpace(text) == "" {
+		return text
+	}
+	switch {
+	case p.r.appDenied(app):
+		return p.replace(KindApp, text, source)
+	case p.r.fieldDenied(resourceID):
+		return p.replace(KindField, text, source)
+	}
+	// Whole labels of denied apps are not carried over: common words like
+	// "OK" would otherwise be hidden in every other app too.
+	p.r.mu.Lock()
+	ph, seen := p.r.values[text]
+	p.r.mu.Unlock()
+	if kind := placeholderKind(ph); seen && kind != KindApp {
+		p.entries = append(p.entries, AuditEntry{Time: p.r.clock(), Kind: kind, Source: source, Placeholder: ph})
+		return ph
+	}
+	return p.detect(text, source)
+}
+
+func (p *pass) detect(text, source string) string {
+	for _, d := range p.r.Detectors {
+		text = p.apply(d, text, source)
+	}
+	return text
+}
+
+func (p *pass) apply(d Detector, text, source string) string {
+	spans := d.spans(text)
+	// Replace back to front so earlier offsets stay valid, but mint
+	// placeholders front to back so numbering follows reading order.
+	phs := make([]string, len(spans))
+	for i, s := range spans {
+		phs[i] = p.replace(d.Kind, text[s.start:s.end], source)
+	}
+	for i := len(spans) - 1; i >= 0; i-- {
+		text = text[:spans[i].start] + phs[i] + text[spans[i].end:]
+	}
+	return text
+}
+
+type span struct{ start, end int }
+
+// spans returns the valid matches of d in text.
+func (d Detector) spans(text string) []span {
+	var spans []span
+	for _, m := range d.Pattern.FindAllStringSubmatchIndex(text, -1) {
+		start, end := m[0], m[1]
+		if len(m) > 2 && m[2] >= 0 {
+			start, end = m[2], m[3]
+		}
+		if d.Valid != nil && !d.Valid(text[start:end]) {
+			continue
+		}
+		spans = append(spans, span{start, end})
+	}
+	return spans
+}
+
+func (d Detector) matches(text string) bool {
+	return len(d.spans(text)) > 0
+}
+
+func (p *pass) flush() {
+	if p.r.Audit == nil || len(p.entries) == 0 {
+		return
+	}
+	// Auditing never blocks redaction: the value is already replaced, and
+	// a failed write only loses the record of it.
+	_ = p.r.Audit.Record(p.entries...)
+}
@@ -0,0 +1,153 @@
+package redact
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/screen"
+)
+
+type memoryAudit struct{ entries []AuditEntry }
+
+func (m *memoryAudit) Record(entries ...AuditEntry) error {
+	m.entries = append(m.entries, entries...)
+	return nil
+}
+
+func TestText_DetectsAndKeepsPlaceholdersStable(t *testing.T) {
+	r := New()
+	for _, tc := range []struct{ in, want string }{
+		{"Mail priya.k@example.com today", "Mail [EMAIL_1] today"},
+		{"Call +91 98765 43210 or (555) 123-4567", "Call [PHONE_1] or [PHONE_2]"},
+		{"Card 4111 1111 1111 1111 expires soon", "Card [CARD_1] expires soon"},
+		{"Your OTP is 482913", "Your OTP is [OTP_1]"},
+		{"739201 is your verification code", "[OTP_2] is your verification code"},
+		{"Again: priya.k@example.com", "Again: [EMAIL_1]"},
+		// Near misses stay: a failed Luhn check, dates, counts and years.
+		{"Order 4111 1111 1111 1112 shipped", "Order 4111 1111 1111 1112 shipped"},
+		{"Due 2026-10-19, 1234 songs since 2019", "Due 2026-10-19, 1234 songs since 2019"},
+	} {
+		if got := r.Text(tc.in, "test"); got != tc.want {
+			t.Errorf("Text(%q) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+
+	if got := r.Restore("TYPE [EMAIL_1] and [PHONE_2], not [EMAIL_9]"); got != "TYPE priya.k@example.com and (555) 123-4567, not [EMAIL_9]" {
+		t.Fatalf("Restore = %q", got)
+	}
+	r.Reset()
+	if got := r.Restore("[EMAIL_1]"); got != "[EMAIL_1]" {
+		t.Fatalf("Restore after Reset = %q", got)
+	}
+}
+
+func TestScreen_AppliesDenylistsToACopy(t *testing.T) {
+	s, err := screen.Parse([]byte(`{"app":"com.bank","root":{"id":"root","children":[
+		{"id":"title","text":"Transfer"},
+		{"id":"pin","resource_id":"com.bank:id/pin_input","text":"2468","editable":true},
+		{"id":"spinner","resource_id":"com.bank:id/spinner","text":"Loading"},
+		{"id":"note","description":"Sent to ravi@example.com"}]}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	audit := &memoryAudit{}
+	r := New()
+	r.Audit = audit
+
+	out := r.Screen(s)
+	for id, want := range map[string]string{
+		"title": "Transfer", "pin": "[FIELD_1]", "spinner": "Loading", "note": "Sent to [EMAIL_1]",
+	} {
+		if got := out.Find(id).Label(); got != want {
+			t.Errorf("%s = %q, want %q", id, got, want)
+		}
+	}
+	if s.Find("pin").Text != "2468" {
+		t.Fatal("original screen was modified")
+	}
+	if len(audit.entries) != 2 || audit.entries[0].Source != "screen:com.bank/pin" || audit.entries[0].Kind != KindField {
+		t.Fatalf("audit = %+v", audit.entries)
+	}
+
+	r.DenyApps = []string{"com.bank"}
+	if got := r.Screen(s).Find("title").Label(); got != "[APP_1]" {
+		t.Fatalf("denied app label = %q", got)
+	}
+}
+
+func TestDiff_RedactsLabelsSeenOnEarlierScreens(t *testing.T) {
+	r := New()
+	r.Text("call 0044 20 7946 0958", "test")
+	d := screen.Diff{
+		Previous: "a", Current: "b",
+		Added:   []screen.NodeRef{{ID: "otp", ResourceID: "app:id/otp", Label: "1234"}},
+		Removed: []screen.NodeRef{{ID: "num", Label: "0044 20 7946 0958"}},
+	}
+	out := r.Diff(d, "com.chat")
+	if out.Added[0].Label != "[FIELD_1]" || out.Removed[0].Label != "[PHONE_1]" {
+		t.Fatalf("diff = %+v", out)
+	}
+	if d.Added[0].Label != "1234" {
+		t.Fatal("original diff was modified")
+	}
+
+	d.AppChanged = true
+	if out := r.Diff(d, "com.chat"); out.Removed[0].Label != "" {
+		t.Fatalf("removed label after app switch = %q", out.Removed[0].Label)
+	}
+}
+
+func TestSensitive_CoversDenylistsDetectorsAndRedactedValues(t *testing.T) {
+	r := New()
+	r.DenyApps = []string{"com.whatsapp"}
+	r.Text("Your OTP is 482913", "test")
+	for _, tc := range []struct {
+		app, field, text string
+		want             bool
+	}{
+		{"com.bank", "com.bank:id/password", "hunter2", true},
+		{"com.whatsapp", "", "see you", true},
+		{"com.mail", "", "to priya.k@example.com", true},
+		{"com.bank", "com.bank:id/entry", "482913", true},
+		{"com.bank", "com.bank:id/entry", "dark mode", false},
+		{"com.bank", "com.bank:id/password", "", false},
+	} {
+		if got := r.Sensitive(tc.app, tc.field, tc.text); got != tc.want {
+			t.Errorf("Sensitive(%q, %q, %q) = %v, want %v", tc.app, tc.field, tc.text, got, tc.want)
+		}
+	}
+}
+
+func TestFileAuditLog_AppendsWithoutValues(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit", "redactions.jsonl")
+	r := New()
+	r.Audit = &FileAuditLog{Path: path}
+	r.now = func() time.Time { return time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC) }
+	r.Text("a@example.com", "goal")
+	r.Text("b@example.com", "prompt:user")
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var entries []AuditEntry
+	for sc := bufio.NewScanner(f); sc.Scan(); {
+		if strings.Contains(sc.Text(), "example.com") {
+			t.Fatalf("audit leaks the value: %s", sc.Text())
+		}
+		var e AuditEntry
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			t.Fatal(err)
+		}
+		entries = append(entries, e)
+	}
+	if len(entries) != 2 || entries[1].Placeholder != "[EMAIL_2]" || entries[1].Source != "prompt:user" {
+		t.Fatalf("entries = %+v", entries)
+	}
+}
@@ -0,0 +1,133 @@
+package screen
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ActionType names an envelope the host executes.
+type ActionType string
+
+const (
+	ActionClick    ActionType = "CLICK"
+	ActionTypeText ActionType = "TYPE"
+	ActionScroll   ActionType = "SCROLL"
+	ActionBack     ActionType = "BACK"
+	ActionWait     ActionType = "WAIT"
+	ActionNoop     ActionType = "NOOP"
+
+	// ActionDone and ActionFail end a goal session; Reason says why.
+	ActionDone ActionType = "DONE"
+	ActionFail ActionType = "FAIL"
+)
+
+// Scroll directions.
+const (
+	ScrollUp    = "up"
+	ScrollDown  = "down"
+	ScrollLeft  = "left"
+	ScrollRight = "right"
+)
+
+const defaultWaitMs = 1000
+
+// MaxWaitMs bounds a WAIT envelope.
+const MaxWaitMs = 10000
+
+// Action is one envelope for the host: CLICK and TYPE target a node by ID,
+// SCROLL optionally targets a scrollable container.
+type Action struct {
+	Action     ActionType `json:"action"`
+	NodeID     string     `json:"node_id,omitempty"`
+	Text       string     `json:"text,omitempty"`
+	Direction  string     `json:"direction,omitempty"`
+	DurationMs int        `json:"duration_ms,omitempty"`
+	Reason     string     `json:"reason,omitempty"`
+}
+
+// Noop returns a NOOP envelope carrying reason.
+func Noop(reason string) Action {
+	return Action{Action: ActionNoop, Reason: reason}
+}
+
+// JSON encodes the envelope; it cannot fail for a valid Action.
+func (a Action) JSON() string {
+	raw, _ := json.Marshal(a)
+	return string(raw)
+}
+
+// Check validates what can be known without a screen. CLICK may name its
+// target by text alone, the form untyped screen payloads are answered with.
+func (a Action) Check() error {
+	switch a.Action {
+	case ActionClick:
+		if a.NodeID == "" && a.Text == "" {
+			return fmt.Errorf("CLICK requires node_id")
+		}
+	case ActionTypeText:
+		if a.NodeID == "" {
+			return fmt.Errorf("TYPE requires node_id")
+		}
+		if a.Text == "" {
+			return fmt.Errorf("TYPE requires text")
+		}
+	case ActionScroll:
+		switch a.Direction {
+		case ScrollUp, ScrollDown, ScrollLeft, ScrollRight:
+		default:
+			return fmt.Errorf("SCROLL: direction must be up, down, left or right")
+		}
+	case ActionWait:
+		if a.DurationMs < 0 || a.DurationMs > MaxWaitMs {
+			return fmt.Errorf("WAIT: duration_ms must be at most %d", MaxWaitMs)
+		}
+	case ActionBack, ActionNoop, ActionDone, ActionFail:
+	default:
+		return fmt.Errorf("unknown action %q", a.Action)
+	}
+	return nil
+}
+
+// Normalize fills defaults and validates a against s. A CLICK on a label
+// inside a clickable row is retargeted to the row.
+func (a *Action) Normalize(s *Screen) error {
+	a.Action = ActionType(strings.ToUpper(strings.TrimSpace(string(a.Action))))
+	a.Direction = strings.ToLower(strings.TrimSpace(a.Direction))
+	if a.Action == ActionWait && a.DurationMs <= 0 {
+		a.DurationMs = defaultWaitMs
+	}
+	if a.Action == ActionClick && a.NodeID == "" {
+		return fmt.Errorf("CLICK requires node_id")
+	}
+	if err := a.Check(); err != nil {
+		return err
+	}
+
+	switch a.Action {
+	case ActionClick:
+		if s.Find(a.NodeID) == nil {
+			return fmt.Errorf("CLICK: no node %q on screen", a.NodeID)
+		}
+		target := s.ClickTarget(a.NodeID)
+		if target == nil {
+			return fmt.Errorf("CLICK: node %q is not clickable", a.NodeID)
+		}
+		a.NodeID = target.ID
+	case ActionTypeText:
+		n := s.Find(a.NodeID)
+		if n == nil {
+			return fmt.Errorf("TYPE: no node %q on screen", a.NodeID)
+		}
+		if !n.Editable || n.Disabled {
+			return fmt.Errorf("TYPE: node %q is not editable", a.NodeID)
+		}
+	case ActionScroll:
+		if a.NodeID != "" {
+			if n := s.Find(a.NodeID); n == nil || !n.Scrollable {
+				return fmt.Errorf("SCROLL: node %q is not scrollable", a.NodeID)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+package screen
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxSummaryLines caps Diff.Summary so a full-screen change does not flood
+// the planner prompt.
+const maxSummaryLines = 20
+
+// NodeRef names a node in a diff.
+type NodeRef struct {
+	ID         string `json:"id"`
+	Role       string `json:"role,omitempty"`
+	Label      string `json:"label,omitempty"`
+	ResourceID string `json:"resource_id,omitempty"`
+}
+
+// NodeChange lists the fields that differ on a node present in both screens.
+type NodeChange struct {
+	NodeRef
+	Fields []string `json:"fields"`
+}
+
+// layoutOnly reports whether only the node's position changed.
+func (c NodeChange) layoutOnly() bool {
+	return len(c.Fields) == 1 && c.Fields[0] == "bounds"
+}
+
+// Diff describes how one screen turned into the next. Nodes are matched by
+// ID, so hosts should keep IDs stable for a node across reports.
+type Diff struct {
+	Previous   string       `json:"previous,omitempty"`
+	Current    string       `json:"current"`
+	AppChanged bool         `json:"app_changed,omitempty"`
+	Added      []NodeRef    `json:"added,omitempty"`
+	Removed    []NodeRef    `json:"removed,omitempty"`
+	Changed    []NodeChange `json:"changed,omitempty"`
+}
+
+// Compare diffs prev against cur. A nil prev yields an initial diff with
+// only the current fingerprint set.
+func Compare(prev, cur *Screen) Diff {
+	d := Diff{Current: cur.Fingerprint()}
+	if prev == nil {
+		return d
+	}
+	d.Previous = prev.Fingerprint()
+	d.AppChanged = prev.App != cur.App || prev.Activity != cur.Activity
+
+	cur.Walk(func(n *Node, _ int) bool {
+		old := prev.Find(n.ID)
+		if old == nil {
+			d.Added = append(d.Added, refOf(n))
+		} else if fields := changedFields(old, n); len(fields) > 0 {
+			d.Changed = append(d.Changed, NodeChange{NodeRef: refOf(n), Fields: fields})
+		}
+		return true
+	})
+	prev.Walk(func(n *Node, _ int) bool {
+		if cur.Find(n.ID) == nil {
+			d.Removed = append(d.Removed, refOf(n))
+		}
+		return true
+	})
+	return d
+}
+
+// Initial reports whether there was no previous screen.
+func (d Diff) Initial() bool {
+	return d.Previous == ""
+}
+
+// Empty reports whether the screens are identical, layout included.
+func (d Diff) Empty() bool {
+	return !d.Initial() && !d.AppChanged && len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// Meaningful reports whether content changed: a new screen, nodes added or
+// removed, or a label or state change. Pure movement, as in animations and
+// scrolling, is not meaningful.
+func (d Diff) Meaningful() bool {
+	if d.Initial() || d.AppChanged || len(d.Added) > 0 || len(d.Removed) > 0 {
+		return true
+	}
+	for _, c := range d.Changed {
+		if !c.layoutOnly() {
+			return true
+		}
+	}
+	return false
+}
+
+// Summary renders the meaningful part of the diff for a language model,
+// one change per line. It is empty for initial and layout-only diffs.
+func (d Diff) Summary() string {
+	if d.Initial() {
+		return ""
+	}
+	var lines []string
+	if d.AppChanged {
+		lines = append(lines, "now on a different app or activity")
+	}
+	for _, n := range d.Added {
+		if n.Label != "" {
+			lines = append(lines, "+ "+n.String())
+		}
+	}
+	for _, n := range d.Removed {
+		if n.Label != "" {
+			lines = append(lines, "- "+n.String())
+		}
+	}
+	for _, c := range d.Changed {
+		if !c.layoutOnly() {
+			lines = append(lines, fmt.Sprintf("~ %s: %s", c.NodeRef, strings.Join(c.Fields, ", ")))
+		}
+	}
+	if len(lines) > maxSummaryLines {
+		more := len(lines) - maxSummaryLines
+		lines = append(lines[:maxSummaryLines], fmt.Sprintf("... and %d more", more))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (r NodeRef) String() string {
+	s := fmt.Sprintf("[%s] %s", r.ID, orDefault(r.Role, "node"))
+	if r.Label != "" {
+		s += fmt.Sprintf(" %q", r.Label)
+	}
+	return s
+}
+
+func refOf(n *Node) NodeRef {
+	return NodeRef{ID: n.ID, Role: n.Role, Label: n.Label(), ResourceID: n.ResourceID}
+}
+
+func changedFields(a, b *Node) []string {
+	var fields []string
+	for _, f := range []struct {
+		name    string
+		changed bool
+	}{
+		{"role", a.Role != b.Role},
+		{"text", a.Text != b.Text},
+		{"description", a.Description != b.Description},
+		{"resource_id", a.ResourceID != b.ResourceID},
+		{"clickable", a.Clickable != b.Clickable},
+		{"editable", a.Editable != b.Editable},
+		{"scrollable", a.Scrollable != b.Scrollable},
+		{"checkable", a.Checkable != b.Checkable},
+		{"checked", a.Checked != b.Checked},
+		{"focused", a.Focused != b.Focused},
+		{"disabled", a.Disabled != b.Disabled},
+		{"bounds", a.Bounds != b.Bounds},
+	} {
+		if f.changed {
+			fields = append(fields, f.name)
+		}
+	}
+	return fields
+}
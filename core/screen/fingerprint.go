@@ -0,0 +1,21 @@
+package screen
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+// Fingerprint identifies what the screen shows: the app, the activity and
+// each node's role, resource ID, label and state, in document order. Bounds
+// are left out so scrolling and animation do not register as a new screen.
+func (s *Screen) Fingerprint() string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00", s.App, s.Activity)
+	s.Walk(func(n *Node, depth int) bool {
+		fmt.Fprintf(h, "%d\x00%s\x00%s\x00%s\x00%t%t%t\n",
+			depth, n.Role, n.ResourceID, n.Label(), n.Checked, n.Disabled, n.Focused)
+		return true
+	})
+	return hex.EncodeToString(h.Sum(nil)[:8])
+}
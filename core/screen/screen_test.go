@@ -0,0 +1,199 @@
+package screen
+
+import (
+	"strings"
+	"testing"
+)
+
+const settingsJSON = `{
+  "app": "com.android.settings",
+  "root": {"id": "root", "role": "group", "children": [
+    {"id": "list", "role": "list", "scrollable": true, "children": [
+      {"id": "row-display", "role": "group", "clickable": true, "children": [
+        {"id": "label-display", "role": "text", "text": "Display"}
+      ]},
+      {"id": "row-sound", "role": "group", "clickable": true, "disabled": true, "children": [
+        {"id": "label-sound", "role": "text", "text": "Sound"}
+      ]}
+    ]},
+    {"id": "search", "role": "edit", "description": "Search settings", "editable": true},
+    {"id": "spacer", "role": "group"}
+  ]}
+}`
+
+func mustParse(t *testing.T, raw string) *Screen {
+	t.Helper()
+	s, err := Parse([]byte(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestParse_RejectsMalformedTrees(t *testing.T) {
+	for name, raw := range map[string]string{
+		"no root":      `{"app":"x"}`,
+		"missing id":   `{"root":{"id":"a","children":[{"text":"Play"}]}}`,
+		"duplicate id": `{"root":{"id":"a","children":[{"id":"b"},{"id":"b"}]}}`,
+		"null child":   `{"root":{"id":"a","children":[null]}}`,
+	} {
+		if _, err := Parse([]byte(raw)); err == nil {
+			t.Errorf("%s: Parse succeeded", name)
+		}
+	}
+}
+
+func TestScreen_ClickTargetClimbsToClickableAncestor(t *testing.T) {
+	s := mustParse(t, settingsJSON)
+	if got := s.ClickTarget("label-display"); got == nil || got.ID != "row-display" {
+		t.Fatalf("ClickTarget(label-display) = %+v", got)
+	}
+	if got := s.ClickTarget("label-sound"); got != nil {
+		t.Fatalf("disabled row is a click target: %+v", got)
+	}
+}
+
+func TestAction_Normalize(t *testing.T) {
+	s := mustParse(t, settingsJSON)
+	cases := []struct {
+		name    string
+		action  Action
+		want    Action
+		wantErr string
+	}{
+		{name: "click retargets", action: Action{Action: "click", NodeID: "label-display"},
+			want: Action{Action: ActionClick, NodeID: "row-display"}},
+		{name: "click unknown node", action: Action{Action: ActionClick, NodeID: "nope"}, wantErr: "no node"},
+		{name: "click disabled", action: Action{Action: ActionClick, NodeID: "label-sound"}, wantErr: "not clickable"},
+		{name: "type", action: Action{Action: ActionTypeText, NodeID: "search", Text: "dark"},
+			want: Action{Action: ActionTypeText, NodeID: "search", Text: "dark"}},
+		{name: "type into label", action: Action{Action: ActionTypeText, NodeID: "label-display", Text: "x"}, wantErr: "not editable"},
+		{name: "type without text", action: Action{Action: ActionTypeText, NodeID: "search"}, wantErr: "requires text"},
+		{name: "scroll", action: Action{Action: ActionScroll, Direction: "Down", NodeID: "list"},
+			want: Action{Action: ActionScroll, Direction: ScrollDown, NodeID: "list"}},
+		{name: "scroll bad direction", action: Action{Action: ActionScroll, Direction: "sideways"}, wantErr: "direction"},
+		{name: "scroll static node", action: Action{Action: ActionScroll, Direction: ScrollUp, NodeID: "search"}, wantErr: "not scrollable"},
+		{name: "wait default", action: Action{Action: ActionWait}, want: Action{Action: ActionWait, DurationMs: defaultWaitMs}},
+		{name: "wait too long", action: Action{Action: ActionWait, DurationMs: 60000}, wantErr: "at most"},
+		{name: "back", action: Action{Action: ActionBack}, want: Action{Action: ActionBack}},
+		{name: "unknown", action: Action{Action: "SWIPE"}, wantErr: "unknown action"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := tc.action
+			err := got.Normalize(s)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tc.want {
+				t.Fatalf("got %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestScreen_OutlineFoldsInertNodes(t *testing.T) {
+	out := mustParse(t, settingsJSON).Outline()
+	for _, want := range []string{
+		"app: com.android.settings",
+		"[list] list {scrollable}",
+		`[row-display] group {clickable}`,
+		`[label-display] text "Display"`,
+		`[search] edit "Search settings" {editable}`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("outline missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "spacer") {
+		t.Errorf("outline kept an inert node:\n%s", out)
+	}
+}
+
+func TestScreen_FingerprintIgnoresLayout(t *testing.T) {
+	base := mustParse(t, settingsJSON)
+	moved := mustParse(t, settingsJSON)
+	moved.Find("row-display").Bounds = Bounds{Top: 400, Bottom: 480, Right: 1080}
+	if base.Fingerprint() != moved.Fingerprint() {
+		t.Fatal("moving a node changed the fingerprint")
+	}
+	relabeled := mustParse(t, settingsJSON)
+	relabeled.Find("label-display").Text = "Display & brightness"
+	if base.Fingerprint() == relabeled.Fingerprint() {
+		t.Fatal("changing a label kept the fingerprint")
+	}
+}
+
+func TestCompare(t *testing.T) {
+	before := mustParse(t, settingsJSON)
+	after := mustParse(t, settingsJSON)
+	after.Find("label-display").Text = "Display & brightness"
+	after.Find("row-sound").Bounds.Top = 300
+	after.Find("list").Children = after.Find("list").Children[:1]
+	after.Root.Children = append(after.Root.Children, &Node{ID: "toast", Role: RoleText, Text: "Saved"})
+	if err := after.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	d := Compare(before, after)
+	if len(d.Added) != 1 || d.Added[0].ID != "toast" {
+		t.Errorf("added = %+v", d.Added)
+	}
+	if len(d.Removed) != 2 || d.Removed[0].ID != "row-sound" || d.Removed[1].ID != "label-sound" {
+		t.Errorf("removed = %+v", d.Removed)
+	}
+	if len(d.Changed) != 1 || d.Changed[0].ID != "label-display" || d.Changed[0].Fields[0] != "text" {
+		t.Errorf("changed = %+v", d.Changed)
+	}
+	if !d.Meaningful() || d.Previous == d.Current {
+		t.Errorf("diff = %+v", d)
+	}
+	summary := d.Summary()
+	for _, want := range []string{`+ [toast] text "Saved"`, `- [label-sound] text "Sound"`, `~ [label-display] text "Display & brightness": text`} {
+		if !strings.Contains(summary, want) {
+			t.Errorf("summary missing %q:\n%s", want, summary)
+		}
+	}
+}
+
+func TestCompare_LayoutOnlyIsNotMeaningful(t *testing.T) {
+	before := mustParse(t, settingsJSON)
+	after := mustParse(t, settingsJSON)
+	after.Find("row-display").Bounds.Top = 120
+	d := Compare(before, after)
+	if d.Empty() || d.Meaningful() || d.Summary() != "" {
+		t.Fatalf("layout diff = %+v, summary %q", d, d.Summary())
+	}
+	if !Compare(before, before).Empty() {
+		t.Fatal("identical screens differ")
+	}
+	if initial := Compare(nil, after); !initial.Initial() || !initial.Meaningful() {
+		t.Fatalf("initial diff = %+v", initial)
+	}
+}
+
+func TestScreen_SelectorSurvivesNewIDs(t *testing.T) {
+	first := mustParse(t, settingsJSON)
+	sel := first.SelectorFor("row-display")
+	if sel == nil || sel.Label != "Display" {
+		t.Fatalf("selector = %+v", sel)
+	}
+
+	later := mustParse(t, strings.ReplaceAll(settingsJSON, `"id": "`, `"id": "v2-`))
+	n := later.Resolve(*sel)
+	if n == nil || n.ID != "v2-label-display" {
+		t.Fatalf("Resolve = %+v", n)
+	}
+	if target := later.ClickTarget(n.ID); target == nil || target.ID != "v2-row-display" {
+		t.Fatalf("click target = %+v", target)
+	}
+	if later.Resolve(Selector{Role: RoleGroup}) != nil {
+		t.Fatal("ambiguous selector resolved")
+	}
+}
@@ -0,0 +1,71 @@
+package screen
+
+import "strings"
+
+// Selector finds a node again on a later report of the same screen, where
+// host-assigned IDs may differ. Empty fields match anything.
+type Selector struct {
+	ResourceID string `json:"resource_id,omitempty"`
+	Role       string `json:"role,omitempty"`
+	Label      string `json:"label,omitempty"`
+}
+
+// SelectorFor describes the node with id, or returns nil if it is absent.
+// A node with neither a label nor a resource ID, typically a clickable row,
+// is described by its first labelled descendant instead; clicking that
+// reaches the row again through ClickTarget.
+func (s *Screen) SelectorFor(id string) *Selector {
+	n := s.Find(id)
+	if n == nil {
+		return nil
+	}
+	if n.ResourceID == "" && n.Label() == "" {
+		if d := firstLabelled(n); d != nil {
+			n = d
+		}
+	}
+	return &Selector{ResourceID: n.ResourceID, Role: n.Role, Label: n.Label()}
+}
+
+func firstLabelled(n *Node) *Node {
+	for _, c := range n.Children {
+		if c == nil {
+			continue
+		}
+		if c.Label() != "" || c.ResourceID != "" {
+			return c
+		}
+		if d := firstLabelled(c); d != nil {
+			return d
+		}
+	}
+	return nil
+}
+
+// Resolve returns the single node the selector matches, or nil when none
+// or several do.
+func (s *Screen) Resolve(sel Selector) *Node {
+	var found *Node
+	ambiguous := false
+	s.Walk(func(n *Node, _ int) bool {
+		if !sel.matches(n) {
+			return true
+		}
+		if found != nil {
+			ambiguous = true
+			return false
+		}
+		found = n
+		return true
+	})
+	if ambiguous {
+		return nil
+	}
+	return found
+}
+
+func (sel Selector) matches(n *Node) bool {
+	return (sel.ResourceID == "" || n.ResourceID == sel.ResourceID) &&
+		(sel.Role == "" || n.Role == sel.Role) &&
+		(sel.Label == "" || strings.EqualFold(n.Label(), sel.Label))
+}
@@ -0,0 +1,216 @@
+// Package screen models the accessibility tree a host UI layer reports and the
+// action envelopes the agent sends back to drive it.
+package screen
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Common node roles. Hosts map platform classes onto these; unknown classes
+// may be passed through verbatim.
+const (
+	RoleButton = "button"
+	RoleText   = "text"
+	RoleEdit   = "edit"
+	RoleImage  = "image"
+	RoleList   = "list"
+	RoleSwitch = "switch"
+	RoleCheck  = "checkbox"
+	RoleTab    = "tab"
+	RoleGroup  = "group"
+)
+
+// Bounds is a node's on-screen rectangle in pixels.
+type Bounds struct {
+	Left   int `json:"left"`
+	Top    int `json:"top"`
+	Right  int `json:"right"`
+	Bottom int `json:"bottom"`
+}
+
+// Empty reports whether the rectangle has no visible area.
+func (b Bounds) Empty() bool {
+	return b.Right <= b.Left || b.Bottom <= b.Top
+}
+
+// Node is one element of the accessibility tree. IDs are assigned by the
+// host and must be unique within a screen.
+type Node struct {
+	ID          string  `json:"id"`
+	Role        string  `json:"role,omitempty"`
+	Text        string  `json:"text,omitempty"`
+	Description string  `json:"description,omitempty"`
+	ResourceID  string  `json:"resource_id,omitempty"`
+	Bounds      Bounds  `json:"bounds"`
+	Clickable   bool    `json:"clickable,omitempty"`
+	Editable    bool    `json:"editable,omitempty"`
+	Scrollable  bool    `json:"scrollable,omitempty"`
+	Checkable   bool    `json:"checkable,omitempty"`
+	Checked     bool    `json:"checked,omitempty"`
+	Focused     bool    `json:"focused,omitempty"`
+	Disabled    bool    `json:"disabled,omitempty"`
+	Children    []*Node `json:"children,omitempty"`
+}
+
+// Label is the text a user would read for the node.
+func (n *Node) Label() string {
+	if t := strings.TrimSpace(n.Text); t != "" {
+		return t
+	}
+	return strings.TrimSpace(n.Description)
+}
+
+// Screen is one accessibility snapshot of the foreground app.
+type Screen struct {
+	App      string `json:"app,omitempty"`
+	Activity string `json:"activity,omitempty"`
+	Width    int    `json:"width,omitempty"`
+	Height   int    `json:"height,omitempty"`
+	Root     *Node  `json:"root"`
+
+	index  map[string]*Node
+	parent map[string]*Node
+}
+
+// Parse decodes and validates a screen: a root is required and node IDs
+// must be present and unique.
+func Parse(raw []byte) (*Screen, error) {
+	var s Screen
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return nil, fmt.Errorf("decode screen: %w", err)
+	}
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// Validate checks the tree and builds the lookup index.
+func (s *Screen) Validate() error {
+	if s.Root == nil {
+		return fmt.Errorf("screen has no root node")
+	}
+	s.index = map[string]*Node{}
+	s.parent = map[string]*Node{}
+	var walk func(n, parent *Node) error
+	walk = func(n, parent *Node) error {
+		if n == nil {
+			return fmt.Errorf("screen contains a null node")
+		}
+		if strings.TrimSpace(n.ID) == "" {
+			return fmt.Errorf("node %q has no id", n.Label())
+		}
+		if _, dup := s.index[n.ID]; dup {
+			return fmt.Errorf("duplicate node id %q", n.ID)
+		}
+		s.index[n.ID] = n
+		if parent != nil {
+			s.parent[n.ID] = parent
+		}
+		for _, c := range n.Children {
+			if err := walk(c, n); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return walk(s.Root, nil)
+}
+
+// Find returns the node with id, or nil.
+func (s *Screen) Find(id string) *Node {
+	if s.index == nil && s.Validate() != nil {
+		return nil
+	}
+	return s.index[id]
+}
+
+// Parent returns the parent of the node with id, or nil for the root.
+func (s *Screen) Parent(id string) *Node {
+	if s.parent == nil && s.Validate() != nil {
+		return nil
+	}
+	return s.parent[id]
+}
+
+// Walk visits nodes depth-first in document order until fn returns false.
+func (s *Screen) Walk(fn func(n *Node, depth int) bool) {
+	var walk func(n *Node, depth int) bool
+	walk = func(n *Node, depth int) bool {
+		if n == nil {
+			return true
+		}
+		if !fn(n, depth) {
+			return false
+		}
+		for _, c := range n.Children {
+			if !walk(c, depth+1) {
+				return false
+			}
+		}
+		return true
+	}
+	walk(s.Root, 0)
+}
+
+// ClickTarget returns the node itself when clickable, else its nearest
+// clickable ancestor: hosts often report the label inside a clickable row.
+func (s *Screen) ClickTarget(id string) *Node {
+	for n := s.Find(id); n != nil; n = s.Parent(n.ID) {
+		if n.Clickable && !n.Disabled {
+			return n
+		}
+	}
+	return nil
+}
+
+// Outline renders the tree compactly, one node per line, for a language
+// model. Nodes without a label or any interaction flag are folded away.
+func (s *Screen) Outline() string {
+	var b strings.Builder
+	if s.App != "" {
+		fmt.Fprintf(&b, "app: %s", s.App)
+		if s.Activity != "" {
+			fmt.Fprintf(&b, " (%s)", s.Activity)
+		}
+		b.WriteString("\n")
+	}
+	s.Walk(func(n *Node, depth int) bool {
+		var flags []string
+		for _, f := range []struct {
+			on   bool
+			name string
+		}{
+			{n.Clickable, "clickable"}, {n.Editable, "editable"}, {n.Scrollable, "scrollable"},
+			{n.Checkable && n.Checked, "checked"}, {n.Checkable && !n.Checked, "unchecked"},
+			{n.Focused, "focused"}, {n.Disabled, "disabled"},
+		} {
+			if f.on {
+				flags = append(flags, f.name)
+			}
+		}
+		label := n.Label()
+		if label == "" && len(flags) == 0 && depth > 0 {
+			return true
+		}
+		fmt.Fprintf(&b, "%s[%s] %s", strings.Repeat("  ", depth), n.ID, orDefault(n.Role, "node"))
+		if label != "" {
+			fmt.Fprintf(&b, " %q", label)
+		}
+		if len(flags) > 0 {
+			fmt.Fprintf(&b, " {%s}", strings.Join(flags, ","))
+		}
+		b.WriteString("\n")
+		return true
+	})
+	return b.String()
+}
+
+func orDefault(s, def string) string {
+	if s == "" {
+		return def
+	}
+	return s
+}
@@ -3,7 +3,37 @@ package skills
 import "context"
 
 // Browser defines deterministic browser actions implemented by platform plugins.
+//
+//upcraft:generate
 type Browser interface {
+	// Open a URL in the user's browser.
+	//
+	//upcraft:param url Absolute http(s) URL
+	//upcraft:user Opened: {url}
 	Visit(ctx context.Context, url string) error
+	// Open a web search for the query in the user's browser.
+	//
+	//upcraft:user Searching for: {query}
 	Search(ctx context.Context, query string) error
+	// Fetch a page without opening it and read its text and links.
+	//
+	//upcraft:param url Absolute http(s) URL
+	//upcraft:user Read: {url}
+	Fetch(ctx context.Context, url string) (*Page, error)
+}
+
+// Page is the readable view of a headlessly fetched web page.
+type Page struct {
+	URL       string `json:"url"`
+	Status    int    `json:"status"`
+	Title     string `json:"title,omitempty"`
+	Text      string `json:"text"`
+	Links     []Link `json:"links,omitempty"`
+	Truncated bool   `json:"truncated,omitempty"`
+}
+
+// Link is one outbound hyperlink found on a fetched page.
+type Link struct {
+	Text string `json:"text,omitempty"`
+	URL  string `json:"url"`
 }
@@ -0,0 +1,335 @@
+package skills
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Calendar manages timed events on the user's calendar.
+//
+//upcraft:generate
+type Calendar interface {
+	// Create a calendar event and return it with its assigned id.
+	//
+	//upcraft:param event Event to create; leave id empty
+	//upcraft:user Added to your calendar
+	CreateEvent(ctx context.Context, event Event) (*Event, error)
+	// List event occurrences overlapping a time range, expanding recurring events.
+	//
+	//upcraft:param from Range start (RFC 3339)
+	//upcraft:param to Range end (RFC 3339)
+	ListEvents(ctx context.Context, from time.Time, to time.Time) ([]Event, error)
+	// Replace an existing event, matched by id.
+	//
+	//upcraft:param event Full event including its id
+	//upcraft:user Calendar event updated
+	UpdateEvent(ctx context.Context, event Event) (*Event, error)
+	// Delete an event and all of its occurrences.
+	//
+	//upcraft:param id Event id
+	//upcraft:user Calendar event deleted
+	DeleteEvent(ctx context.Context, id string) error
+}
+
+// Reminders manages to-dos that alert the user at a due time.
+//
+//upcraft:generate
+type Reminders interface {
+	// Create a reminder and return it with its assigned id.
+	//
+	//upcraft:param reminder Reminder to create; leave id empty
+	//upcraft:user Reminder set
+	CreateReminder(ctx context.Context, reminder Reminder) (*Reminder, error)
+	// List reminders ordered by due time.
+	//
+	//upcraft:param include_done Also return completed reminders
+	//upcraft:optional include_done
+	ListReminders(ctx context.Context, includeDone bool) ([]Reminder, error)
+	// Replace an existing reminder, matched by id. Set done to complete it.
+	//
+	//upcraft:param reminder Full reminder including its id
+	//upcraft:user Reminder updated
+	UpdateReminder(ctx context.Context, reminder Reminder) (*Reminder, error)
+	// Delete a reminder.
+	//
+	//upcraft:param id Reminder id
+	//upcraft:user Reminder deleted
+	DeleteReminder(ctx context.Context, id string) error
+}
+
+// Recurrence frequencies.
+const (
+	FrequencyDaily   = "daily"
+	FrequencyWeekly  = "weekly"
+	FrequencyMonthly = "monthly"
+	FrequencyYearly  = "yearly"
+)
+
+// Event is one calendar entry. Times are instants; TimeZone sets the zone
+// they are shown and expanded in.
+type Event struct {
+	ID    string    `json:"id,omitempty"`
+	Title string    `json:"title"`
+	Start time.Time `json:"start"`
+	// End defaults to one hour after Start, or one day for all-day events.
+	End    time.Time `json:"end,omitempty"`
+	AllDay bool      `json:"all_day,omitempty"`
+	// IANA zone such as Asia/Kolkata; empty means UTC.
+	TimeZone   string      `json:"time_zone,omitempty"`
+	Location   string      `json:"location,omitempty"`
+	Notes      string      `json:"notes,omitempty"`
+	Recurrence *Recurrence `json:"recurrence,omitempty"`
+	// Minutes before Start at which to alert the user.
+	AlertMinutes []int `json:"alert_minutes,omitempty"`
+}
+
+// Reminder is a to-do that alerts at Due.
+type Reminder struct {
+	ID    string    `json:"id,omitempty"`
+	Title string    `json:"title"`
+	Due   time.Time `json:"due"`
+	// IANA zone such as Asia/Kolkata; empty means UTC.
+	TimeZone   string      `json:"time_zone,omitempty"`
+	Notes      string      `json:"notes,omitempty"`
+	Recurrence *Recurrence `json:"recurrence,omitempty"`
+	Done       bool        `json:"done,omitempty"`
+}
+
+// Recurrence is the subset of iCalendar RRULE the skill supports.
+type Recurrence struct {
+	// One of daily, weekly, monthly, yearly.
+	Frequency string `json:"frequency"`
+	// Repeat every Interval periods; defaults to 1.
+	Interval int `json:"interval,omitempty"`
+	// Stop after Count occurrences; zero means no limit.
+	Count int `json:"count,omitempty"`
+	// Stop after this time.
+	Until *time.Time `json:"until,omitempty"`
+	// Weekdays for weekly rules as two-letter codes: MO, TU, WE, TH, FR, SA, SU.
+	ByDay []string `json:"by_day,omitempty"`
+}
+
+var weekdayCodes = map[string]time.Weekday{
+	"SU": time.Sunday, "MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday,
+	"TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday,
+}
+
+// Zone resolves TimeZone, defaulting to UTC.
+func (e Event) Zone() (*time.Location, error) {
+	return loadZone(e.TimeZone)
+}
+
+// Zone resolves TimeZone, defaulting to UTC.
+func (r Reminder) Zone() (*time.Location, error) {
+	return loadZone(r.TimeZone)
+}
+
+// Normalize validates e, converts its times into its zone and fills End.
+func (e *Event) Normalize() error {
+	e.Title = strings.TrimSpace(e.Title)
+	if e.Title == "" {
+		return fmt.Errorf("title is required")
+	}
+	if e.Start.IsZero() {
+		return fmt.Errorf("start is required")
+	}
+	loc, err := e.Zone()
+	if err != nil {
+		return err
+	}
+	e.Start = e.Start.In(loc)
+	if e.AllDay {
+		e.Start = time.Date(e.Start.Year(), e.Start.Month(), e.Start.Day(), 0, 0, 0, 0, loc)
+	}
+	switch {
+	case e.End.IsZero() && e.AllDay:
+		e.End = e.Start.AddDate(0, 0, 1)
+	case e.End.IsZero():
+		e.End = e.Start.Add(time.Hour)
+	default:
+		e.End = e.End.In(loc)
+	}
+	if !e.End.After(e.Start) {
+		return fmt.Errorf("end must be after start")
+	}
+	for _, m := range e.AlertMinutes {
+		if m < 0 {
+			return fmt.Errorf("alert_minutes must not be negative")
+		}
+	}
+	return e.Recurrence.validate()
+}
+
+// Normalize validates r and converts Due into its zone.
+func (r *Reminder) Normalize() error {
+	r.Title = strings.TrimSpace(r.Title)
+	if r.Title == "" {
+		return fmt.Errorf("title is required")
+	}
+	if r.Due.IsZero() {
+		return fmt.Errorf("due is required")
+	}
+	loc, err := r.Zone()
+	if err != nil {
+		return err
+	}
+	r.Due = r.Due.In(loc)
+	return r.Recurrence.validate()
+}
+
+// Occurrences returns the instances of e that overlap [from, to), at most
+// limit of them. Non-recurring events yield at most themselves.
+func (e Event) Occurrences(from, to time.Time, limit int) []Event {
+	duration := e.End.Sub(e.Start)
+	var out []Event
+	e.Recurrence.each(e.Start, func(start time.Time) bool {
+		if !start.Before(to) || len(out) >= limit {
+			return false
+		}
+		if start.Add(duration).After(from) {
+			occurrence := e
+			occurrence.Start, occurrence.End = start, start.Add(duration)
+			out = append(out, occurrence)
+		}
+		return true
+	})
+	return out
+}
+
+// NextDue returns the first due time of r at or after t, or false when the
+// reminder does not recur past t.
+func (r Reminder) NextDue(t time.Time) (time.Time, bool) {
+	var next time.Time
+	r.Recurrence.each(r.Due, func(due time.Time) bool {
+		if !due.Before(t) {
+			next = due
+			return false
+		}
+		return true
+	})
+	return next, !next.IsZero()
+}
+
+func (rec *Recurrence) validate() error {
+	if rec == nil {
+		return nil
+	}
+	rec.Frequency = strings.ToLower(strings.TrimSpace(rec.Frequency))
+	switch rec.Frequency {
+	case FrequencyDaily, FrequencyWeekly, FrequencyMonthly, FrequencyYearly:
+	default:
+		return fmt.Errorf("recurrence frequency must be daily, weekly, monthly or yearly")
+	}
+	if rec.Interval < 0 || rec.Count < 0 {
+		return fmt.Errorf("recurrence interval and count must not be negative")
+	}
+	for i, day := range rec.ByDay {
+		day = strings.ToUpper(strings.TrimSpace(day))
+		if _, ok := weekdayCodes[day]; !ok {
+			return fmt.Errorf("recurrence by_day: unknown weekday %q", rec.ByDay[i])
+		}
+		rec.ByDay[i] = day
+	}
+	if len(rec.ByDay) > 0 && rec.Frequency != FrequencyWeekly {
+		return fmt.Errorf("recurrence by_day is only supported for weekly rules")
+	}
+	return nil
+}
+
+// maxRecurrenceSteps bounds expansion of open-ended rules.
+const maxRecurrenceSteps = 10000
+
+// each calls fn with successive occurrence starts until fn returns false or
+// the rule ends. Dates advance in the start's zone so wall-clock times stay
+// fixed across DST changes.
+func (rec *Recurrence) each(start time.Time, fn func(time.Time) bool) {
+	if rec == nil {
+		fn(start)
+		return
+	}
+	interval := rec.Interval
+	if interval == 0 {
+		interval = 1
+	}
+	emitted := 0
+	emit := func(t time.Time) bool {
+		if t.Before(start) {
+			return true
+		}
+		if rec.Until != nil && t.After(*rec.Until) {
+			return false
+		}
+		if rec.Count > 0 && emitted >= rec.Count {
+			return false
+		}
+		emitted++
+		return fn(t)
+	}
+
+	for step := 0; step < maxRecurrenceSteps; step++ {
+		n := step * interval
+		switch rec.Frequency {
+		case FrequencyDaily:
+			if !emit(start.AddDate(0, 0, n)) {
+				return
+			}
+		case FrequencyWeekly:
+			weekStart := start.AddDate(0, 0, 7*n)
+			if len(rec.ByDay) == 0 {
+				if !emit(weekStart) {
+					return
+				}
+				continue
+			}
+			// Walk the seven days from the week's Monday in order.
+			monday := weekStart.AddDate(0, 0, -((int(weekStart.Weekday()) + 6) % 7))
+			for d := 0; d < 7; d++ {
+				day := monday.AddDate(0, 0, d)
+				if rec.hasDay(day.Weekday()) && !emit(day) {
+					return
+				}
+			}
+		case FrequencyMonthly:
+			t := start.AddDate(0, n, 0)
+			if t.Day() != start.Day() {
+				continue // e.g. the 31st in a shorter month
+			}
+			if !emit(t) {
+				return
+			}
+		case FrequencyYearly:
+			t := start.AddDate(n, 0, 0)
+			if t.Day() != start.Day() {
+				continue // Feb 29 outside leap years
+			}
+			if !emit(t) {
+				return
+			}
+		default:
+			return
+		}
+	}
+}
+
+func (rec *Recurrence) hasDay(day time.Weekday) bool {
+	for _, code := range rec.ByDay {
+		if weekdayCodes[code] == day {
+			return true
+		}
+	}
+	return false
+}
+
+func loadZone(name string) (*time.Location, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown time zone %q", name)
+	}
+	return loc, nil
+}
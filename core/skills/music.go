@@ -3,9 +3,153 @@ package skills
 import "context"
 
 // MusicPlayer defines deterministic controls for music playback providers.
+//
+//upcraft:generate
 type MusicPlayer interface {
+	// Play music by user query string.
+	//
+	//upcraft:param query Track, artist or free-form search text
+	//upcraft:user Playing: {query}
 	Play(ctx context.Context, query string) error
+	// Pause current playback.
+	//
+	//upcraft:user Playback paused
 	Pause(ctx context.Context) error
+	// Resume current playback.
+	//
+	//upcraft:user Playback resumed
 	Resume(ctx context.Context) error
+	// Skip to next track.
+	//
+	//upcraft:user Skipped to next track
 	Next(ctx context.Context) error
 }
+
+// The interfaces below are optional capabilities of a MusicPlayer. Each one
+// binds its actions under the MusicPlayer skill only when the active
+// implementation satisfies it, so the planner never sees unsupported controls.
+
+// MusicNavigator moves within the current track list.
+//
+//upcraft:generate skill=MusicPlayer
+type MusicNavigator interface {
+	// Go back to the previous track.
+	//
+	//upcraft:user Back to the previous track
+	Previous(ctx context.Context) error
+	// Jump to a position in the current track.
+	//
+	//upcraft:param position_ms Position from the start of the track in milliseconds
+	//upcraft:user Jumped to {position_ms} ms
+	Seek(ctx context.Context, positionMs int) error
+}
+
+// MusicVolume sets the output volume.
+//
+//upcraft:generate skill=MusicPlayer
+type MusicVolume interface {
+	// Set the playback volume.
+	//
+	//upcraft:param percent Volume from 0 to 100
+	//upcraft:user Volume set to {percent}%
+	SetVolume(ctx context.Context, percent int) error
+}
+
+// Repeat modes for MusicModes.SetRepeat.
+const (
+	RepeatOff   = "off"
+	RepeatTrack = "track"
+	RepeatAll   = "context"
+)
+
+// MusicModes toggles shuffle and repeat.
+//
+//upcraft:generate skill=MusicPlayer
+type MusicModes interface {
+	// Turn shuffle on or off.
+	//
+	//upcraft:param enabled True to shuffle
+	SetShuffle(ctx context.Context, enabled bool) error
+	// Set the repeat mode: off, the current track, or the whole album or playlist.
+	//
+	//upcraft:param mode Repeat mode
+	//upcraft:enum mode off,track,context
+	//upcraft:user Repeat set to {mode}
+	SetRepeat(ctx context.Context, mode string) error
+}
+
+// MusicQueue adds tracks after the current one.
+//
+//upcraft:generate skill=MusicPlayer
+type MusicQueue interface {
+	// Queue the best matching track to play next.
+	//
+	//upcraft:param query Track or free-form search text
+	//upcraft:user Queued: {query}
+	AddToQueue(ctx context.Context, query string) error
+}
+
+// MusicLibrary plays whole collections by name.
+//
+//upcraft:generate skill=MusicPlayer
+type MusicLibrary interface {
+	// Play the best matching album.
+	//
+	//upcraft:param name Album name, optionally with the artist
+	//upcraft:user Playing album: {name}
+	PlayAlbum(ctx context.Context, name string) error
+	// Play an artist's top tracks.
+	//
+	//upcraft:param name Artist name
+	//upcraft:user Playing {name}
+	PlayArtist(ctx context.Context, name string) error
+	// Play the best matching playlist.
+	//
+	//upcraft:param name Playlist name
+	//upcraft:user Playing playlist: {name}
+	PlayPlaylist(ctx context.Context, name string) error
+}
+
+// PlaybackDevice is an output the player can play on.
+type PlaybackDevice struct {
+	ID            string `json:"id"`
+	Name          string `json:"name"`
+	Type          string `json:"type,omitempty"`
+	Active        bool   `json:"active"`
+	VolumePercent *int   `json:"volume_percent,omitempty"`
+}
+
+// MusicDevices lists outputs and moves playback between them.
+//
+//upcraft:generate skill=MusicPlayer
+type MusicDevices interface {
+	// List the devices playback can run on.
+	ListDevices(ctx context.Context) ([]PlaybackDevice, error)
+	// Move playback to another device, matched by id or name.
+	//
+	//upcraft:param device Device id or name
+	//upcraft:user Playing on {device}
+	TransferPlayback(ctx context.Context, device string) error
+}
+
+// NowPlaying describes the current playback state.
+type NowPlaying struct {
+	Playing    bool     `json:"playing"`
+	Track      string   `json:"track,omitempty"`
+	Artists    []string `json:"artists,omitempty"`
+	Album      string   `json:"album,omitempty"`
+	URI        string   `json:"uri,omitempty"`
+	PositionMs int      `json:"position_ms"`
+	DurationMs int      `json:"duration_ms,omitempty"`
+	Device     string   `json:"device,omitempty"`
+	Shuffle    bool     `json:"shuffle"`
+	Repeat     string   `json:"repeat,omitempty"`
+}
+
+// MusicStatus reports what is playing.
+//
+//upcraft:generate skill=MusicPlayer
+type MusicStatus interface {
+	// Describe the track that is playing now, if any.
+	NowPlaying(ctx context.Context) (*NowPlaying, error)
+}
@@ -0,0 +1,104 @@
+package skilltest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/skills"
+)
+
+// BrowserFactory returns a browser whose Visit and Search side effects are
+// stubbed out. Fetch must be able to reach loopback URLs.
+type BrowserFactory func(t *testing.T) skills.Browser
+
+const conformancePage = `<html><head><title>Conformance Page</title></head>
+<body><p>Hello conformance</p><a href="/next">Next page</a></body></html>`
+
+// TestBrowser runs the standard skills.Browser suite:
+//   - Visit rejects empty, malformed and non-http(s) URLs.
+//   - Search rejects empty and blank queries.
+//   - Visit and Search accept valid input.
+//   - Fetch returns status, title, text and absolute links, and fails on HTTP errors.
+//   - Every method returns an error wrapping context.Canceled for a canceled context.
+func TestBrowser(t *testing.T, newBrowser BrowserFactory) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, conformancePage)
+	}))
+	t.Cleanup(srv.Close)
+
+	t.Run("VisitRejectsInvalidURLs", func(t *testing.T) {
+		b := newBrowser(t)
+		for _, target := range []string{"", "   ", "not a url", "ftp://example.com/file", "javascript:alert(1)", "https://"} {
+			if err := b.Visit(context.Background(), target); err == nil {
+				t.Errorf("Visit(%q): expected error", target)
+			}
+		}
+	})
+
+	t.Run("SearchRejectsEmptyQuery", func(t *testing.T) {
+		b := newBrowser(t)
+		for _, query := range []string{"", "   "} {
+			if err := b.Search(context.Background(), query); err == nil {
+				t.Errorf("Search(%q): expected error", query)
+			}
+		}
+	})
+
+	t.Run("VisitAndSearchAcceptValidInput", func(t *testing.T) {
+		b := newBrowser(t)
+		if err := b.Visit(context.Background(), "https://example.com/docs"); err != nil {
+			t.Fatalf("Visit: %v", err)
+		}
+		if err := b.Search(context.Background(), "golang context"); err != nil {
+			t.Fatalf("Search: %v", err)
+		}
+	})
+
+	t.Run("FetchReadsPage", func(t *testing.T) {
+		page, err := newBrowser(t).Fetch(context.Background(), srv.URL)
+		if err != nil {
+			t.Fatalf("Fetch: %v", err)
+		}
+		if page.Status != http.StatusOK {
+			t.Errorf("Status = %d, want 200", page.Status)
+		}
+		if page.Title != "Conformance Page" {
+			t.Errorf("Title = %q", page.Title)
+		}
+		if !strings.Contains(page.Text, "Hello conformance") {
+			t.Errorf("Text = %q, missing body text", page.Text)
+		}
+		want := srv.URL + "/next"
+		found := false
+		for _, link := range page.Links {
+			found = found || link.URL == want
+		}
+		if !found {
+			t.Errorf("Links = %+v, want %s", page.Links, want)
+		}
+	})
+
+	t.Run("FetchFailsOnHTTPError", func(t *testing.T) {
+		if _, err := newBrowser(t).Fetch(context.Background(), srv.URL+"/missing"); err == nil {
+			t.Fatal("Fetch of a 404 page: expected error")
+		}
+	})
+
+	t.Run("HonorsCanceledContext", func(t *testing.T) {
+		b := newBrowser(t)
+		ctx := canceledContext()
+		requireCanceled(t, "Visit", b.Visit(ctx, "https://example.com"))
+		requireCanceled(t, "Search", b.Search(ctx, "golang"))
+		_, err := b.Fetch(ctx, srv.URL)
+		requireCanceled(t, "Fetch", err)
+	})
+}
@@ -0,0 +1,233 @@
+package skilltest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/skills"
+)
+
+// MusicPlayerFactory returns a fresh player with no playback in progress.
+type MusicPlayerFactory func(t *testing.T) skills.MusicPlayer
+
+// TestMusicPlayer runs the standard skills.MusicPlayer suite. Players backed
+// by a catalog must resolve the query "Hymn for the Weekend".
+//
+//   - Play rejects empty and blank queries.
+//   - Play followed by Pause, Resume and Next succeeds.
+//   - Pause while idle gives the same outcome on every call.
+//   - Every method returns an error wrapping context.Canceled for a canceled context.
+//   - Concurrent calls are safe.
+func TestMusicPlayer(t *testing.T, newPlayer MusicPlayerFactory) {
+	t.Run("PlayRejectsEmptyQuery", func(t *testing.T) {
+		p := newPlayer(t)
+		for _, query := range []string{"", "   "} {
+			if err := p.Play(context.Background(), query); err == nil {
+				t.Errorf("Play(%q): expected error", query)
+			}
+		}
+	})
+
+	t.Run("PlayThenControls", func(t *testing.T) {
+		p := newPlayer(t)
+		ctx := context.Background()
+		if err := p.Play(ctx, "Hymn for the Weekend"); err != nil {
+			t.Fatalf("Play: %v", err)
+		}
+		if err := p.Pause(ctx); err != nil {
+			t.Fatalf("Pause: %v", err)
+		}
+		if err := p.Resume(ctx); err != nil {
+			t.Fatalf("Resume: %v", err)
+		}
+		if err := p.Next(ctx); err != nil {
+			t.Fatalf("Next: %v", err)
+		}
+	})
+
+	t.Run("PauseWhenIdleIsConsistent", func(t *testing.T) {
+		p := newPlayer(t)
+		first := p.Pause(context.Background())
+		second := p.Pause(context.Background())
+		if (first == nil) != (second == nil) {
+			t.Fatalf("Pause while idle is not idempotent: first=%v second=%v", first, second)
+		}
+	})
+
+	t.Run("HonorsCanceledContext", func(t *testing.T) {
+		p := newPlayer(t)
+		ctx := canceledContext()
+		requireCanceled(t, "Play", p.Play(ctx, "Hymn for the Weekend"))
+		requireCanceled(t, "Pause", p.Pause(ctx))
+		requireCanceled(t, "Resume", p.Resume(ctx))
+		requireCanceled(t, "Next", p.Next(ctx))
+	})
+
+	t.Run("ConcurrentCalls", func(t *testing.T) {
+		p := newPlayer(t)
+		ctx := context.Background()
+		if err := p.Play(ctx, "Hymn for the Weekend"); err != nil {
+			t.Fatalf("Play: %v", err)
+		}
+
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				// Outcomes may interleave; only races and panics matter here.
+				switch i % 4 {
+				case 0:
+					_ = p.Play(ctx, fmt.Sprintf("track %d", i))
+				case 1:
+					_ = p.Pause(ctx)
+				case 2:
+					_ = p.Resume(ctx)
+				case 3:
+					_ = p.Next(ctx)
+				}
+			}(i)
+		}
+		wg.Wait()
+	})
+}
+
+// TestMusicCapabilities runs checks for every optional music capability the
+// player implements and skips the rest. The player must start idle and
+// resolve the tracks "Hymn for the Weekend" and "Yellow", the album
+// "Parachutes", the artist "Coldplay" and the playlist "Focus".
+func TestMusicCapabilities(t *testing.T, newPlayer MusicPlayerFactory) {
+	started := func(t *testing.T) skills.MusicPlayer {
+		p := newPlayer(t)
+		if err := p.Play(context.Background(), "Hymn for the Weekend"); err != nil {
+			t.Fatalf("Play: %v", err)
+		}
+		return p
+	}
+
+	t.Run("Navigator", func(t *testing.T) {
+		nav, ok := started(t).(skills.MusicNavigator)
+		if !ok {
+			t.Skip("MusicNavigator not implemented")
+		}
+		ctx := context.Background()
+		if err := nav.Seek(ctx, 30000); err != nil {
+			t.Fatalf("Seek: %v", err)
+		}
+		if err := nav.Seek(ctx, -1); err == nil {
+			t.Error("Seek(-1): expected error")
+		}
+		if err := nav.Previous(ctx); err != nil {
+			t.Fatalf("Previous: %v", err)
+		}
+		requireCanceled(t, "Previous", nav.Previous(canceledContext()))
+	})
+
+	t.Run("Volume", func(t *testing.T) {
+		vol, ok := started(t).(skills.MusicVolume)
+		if !ok {
+			t.Skip("MusicVolume not implemented")
+		}
+		if err := vol.SetVolume(context.Background(), 35); err != nil {
+			t.Fatalf("SetVolume: %v", err)
+		}
+		for _, bad := range []int{-1, 101} {
+			if err := vol.SetVolume(context.Background(), bad); err == nil {
+				t.Errorf("SetVolume(%d): expected error", bad)
+			}
+		}
+	})
+
+	t.Run("Modes", func(t *testing.T) {
+		modes, ok := started(t).(skills.MusicModes)
+		if !ok {
+			t.Skip("MusicModes not implemented")
+		}
+		ctx := context.Background()
+		if err := modes.SetShuffle(ctx, true); err != nil {
+			t.Fatalf("SetShuffle: %v", err)
+		}
+		for _, mode := range []string{skills.RepeatTrack, skills.RepeatAll, skills.RepeatOff} {
+			if err := modes.SetRepeat(ctx, mode); err != nil {
+				t.Fatalf("SetRepeat(%q): %v", mode, err)
+			}
+		}
+		if err := modes.SetRepeat(ctx, "sometimes"); err == nil {
+			t.Error("SetRepeat with unknown mode: expected error")
+		}
+	})
+
+	t.Run("Queue", func(t *testing.T) {
+		q, ok := started(t).(skills.MusicQueue)
+		if !ok {
+			t.Skip("MusicQueue not implemented")
+		}
+		if err := q.AddToQueue(context.Background(), "Yellow"); err != nil {
+			t.Fatalf("AddToQueue: %v", err)
+		}
+		if err := q.AddToQueue(context.Background(), " "); err == nil {
+			t.Error("AddToQueue with blank query: expected error")
+		}
+	})
+
+	t.Run("Library", func(t *testing.T) {
+		lib, ok := newPlayer(t).(skills.MusicLibrary)
+		if !ok {
+			t.Skip("MusicLibrary not implemented")
+		}
+		ctx := context.Background()
+		if err := lib.PlayAlbum(ctx, "Parachutes"); err != nil {
+			t.Fatalf("PlayAlbum: %v", err)
+		}
+		if err := lib.PlayArtist(ctx, "Coldplay"); err != nil {
+			t.Fatalf("PlayArtist: %v", err)
+		}
+		if err := lib.PlayPlaylist(ctx, "Focus"); err != nil {
+			t.Fatalf("PlayPlaylist: %v", err)
+		}
+		if err := lib.PlayAlbum(ctx, ""); err == nil {
+			t.Error("PlayAlbum with empty name: expected error")
+		}
+	})
+
+	t.Run("Devices", func(t *testing.T) {
+		dev, ok := started(t).(skills.MusicDevices)
+		if !ok {
+			t.Skip("MusicDevices not implemented")
+		}
+		ctx := context.Background()
+		devices, err := dev.ListDevices(ctx)
+		if err != nil || len(devices) == 0 {
+			t.Fatalf("ListDevices = %v, %v", devices, err)
+		}
+		target := devices[len(devices)-1]
+		if err := dev.TransferPlayback(ctx, target.Name); err != nil {
+			t.Fatalf("TransferPlayback(%q): %v", target.Name, err)
+		}
+		if err := dev.TransferPlayback(ctx, "no such device"); err == nil {
+			t.Error("TransferPlayback to unknown device: expected error")
+		}
+	})
+
+	t.Run("Status", func(t *testing.T) {
+		p := newPlayer(t)
+		status, ok := p.(skills.MusicStatus)
+		if !ok {
+			t.Skip("MusicStatus not implemented")
+		}
+		ctx := context.Background()
+		idle, err := status.NowPlaying(ctx)
+		if err != nil || idle == nil || idle.Playing {
+			t.Fatalf("NowPlaying while idle = %+v, %v", idle, err)
+		}
+		if err := p.Play(ctx, "Hymn for the Weekend"); err != nil {
+			t.Fatalf("Play: %v", err)
+		}
+		now, err := status.NowPlaying(ctx)
+		if err != nil || !now.Playing || now.Track == "" {
+			t.Fatalf("NowPlaying after Play = %+v, %v", now, err)
+		}
+	})
+}
@@ -0,0 +1,30 @@
+// Package skilltest holds behavioral conformance suites for skill
+// implementations. A plugin package calls the suite for each interface it
+// implements from its own tests, passing a constructor that returns a fresh,
+// offline-ready instance (fake transport, recorded HTTP, stubbed side effects).
+package skilltest
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// canceledContext returns a context that is already canceled.
+func canceledContext() context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	return ctx
+}
+
+// requireCanceled fails unless err reports the context cancellation.
+func requireCanceled(t *testing.T, call string, err error) {
+	t.Helper()
+	if err == nil {
+		t.Errorf("%s with canceled context: got nil error", call)
+		return
+	}
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("%s with canceled context: error %q does not wrap context.Canceled", call, err)
+	}
+}
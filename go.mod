@@ -16,6 +16,7 @@ require (
 	github.com/openai/openai-go/v3 v3.21.0
 	github.com/slack-go/slack v0.17.3
 	github.com/tencent-connect/botgo v0.2.1
+	github.com/tetratelabs/wazero v1.10.1
 	golang.org/x/oauth2 v0.35.0
 )
 
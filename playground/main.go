@@ -1,29 +1,39 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
-
-	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/plugins/spotify"
+	"sort"
 )
 
+// harnesses maps a name to a runner that exercises one plugin in isolation.
+// Scaffolded skills add themselves from their own file.
+var harnesses = map[string]func(ctx context.Context, args []string) error{}
+
 // Playground harness for testing one plugin in isolation.
+//
+//	go run ./playground <harness> [args...]
 func main() {
-	if os.Getenv("SPOTIFY_ACCESS_TOKEN") == "" {
-		fmt.Println("Please set SPOTIFY_ACCESS_TOKEN env var")
-		return
+	name := "spotify"
+	var args []string
+	if len(os.Args) > 1 {
+		name, args = os.Args[1], os.Args[2:]
 	}
 
-	plugin, err := spotify.NewMusicPluginFromEnv()
-	if err != nil {
-		fmt.Printf("failed to initialize plugin: %v\n", err)
-		return
+	run, ok := harnesses[name]
+	if !ok {
+		names := make([]string, 0, len(harnesses))
+		for n := range harnesses {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		fmt.Printf("unknown harness %q (available: %v)\n", name, names)
+		os.Exit(1)
 	}
 
-	if err := plugin.Play("Hymn for the Weekend"); err != nil {
+	if err := run(context.Background(), args); err != nil {
 		fmt.Printf("Failed: %v\n", err)
-		return
+		os.Exit(1)
 	}
-
-	fmt.Println("Success: Music play request sent.")
 }
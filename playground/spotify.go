@@ -0,0 +1,33 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/plugins/spotify"
+)
+
+func init() {
+	harnesses["spotify"] = runSpotify
+}
+
+// runSpotify plays through the stored `upcraft-cli spotify login`, falling
+// back to SPOTIFY_ACCESS_TOKEN.
+func runSpotify(ctx context.Context, args []string) error {
+	client, err := spotify.NewPlayerClientFromEnv()
+	if err != nil {
+		return err
+	}
+
+	query := "Hymn for the Weekend"
+	if len(args) > 0 {
+		query = strings.Join(args, " ")
+	}
+	if err := client.Play(ctx, query); err != nil {
+		return err
+	}
+
+	fmt.Println("Success: Music play request sent.")
+	return nil
+}
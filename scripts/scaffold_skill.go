@@ -1,71 +1,248 @@
+// Command scaffold_skill creates a complete skill slice: an annotated skill
+// interface, a plugin backed by a swappable data source, a backend manifest,
+// table-driven tests with a fake, a playground harness, and the generated
+// Register<Skill> binding.
+//
+//	go run ./scripts/scaffold_skill.go [-no-generate] <skill_name>
 package main
 
 import (
+	"bytes"
+	"encoding/json"
+	"flag"
 	"fmt"
+	"go/build"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io/fs"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"regexp"
 	"strings"
+	"text/template"
 )
 
+const modulePath = "github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent"
+
+var namePattern = regexp.MustCompile(`^[a-z][a-z0-9]*(_[a-z0-9]+)*$`)
+
 func main() {
-	if len(os.Args) < 2 {
-		fmt.Println("Usage: go run ./scripts/scaffold_skill.go <skill_name>")
+	root := flag.String("root", ".", "repository root")
+	noGenerate := flag.Bool("no-generate", false, "skip go generate for the registry binding")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: go run ./scripts/scaffold_skill.go [-no-generate] <skill_name>")
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
 		os.Exit(1)
 	}
 
-	name := normalizeName(os.Args[1])
-	if name == "" {
-		fmt.Println("Error: invalid skill name")
+	s, err := newScaffold(*root, flag.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	written, err := s.write()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
-	title := titleCase(name)
 
-	interfacePath := filepath.Join("core", "skills", name+".go")
-	pluginDir := filepath.Join("core", "plugins", name)
-	pluginPath := filepath.Join(pluginDir, "plugin.go")
+	fmt.Printf("Created skill: %s\n", s.Name)
+	for _, path := range written {
+		fmt.Printf("  %s\n", path)
+	}
 
-	if exists(interfacePath) || exists(pluginPath) {
-		fmt.Printf("Error: skill '%s' already exists\n", name)
+	if *noGenerate {
+		fmt.Println("Skipped binding generation; run `make generate` before `make test`.")
+	} else if err := s.generate(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: generate registry binding: %v\n", err)
+		fmt.Fprintln(os.Stderr, "Fix the interface, then run `make generate`.")
 		os.Exit(1)
+	} else {
+		fmt.Printf("  core/engine/register_%s_gen.go\n", s.Name)
 	}
 
-	if err := os.MkdirAll(pluginDir, 0o755); err != nil {
-		panic(err)
-	}
+	fmt.Println("Next:")
+	fmt.Printf("1. Replace sampleSource in %s with a real backend.\n", filepath.Join("core", "plugins", s.Package, "plugin.go"))
+	fmt.Printf("2. Try it: go run ./playground %s <query>\n", s.Name)
+	fmt.Println("3. Run checks: make test")
+}
 
-	interfaceCode := fmt.Sprintf(`package skills
+// scaffold holds the names derived from one skill name.
+type scaffold struct {
+	Root    string
+	Name    string // snake_case, used for file names and the harness name
+	Package string // Go package name under core/plugins
+	Title   string // exported skill interface name
+	Module  string
+}
 
-// %s defines the capability contract for the %s skill.
-type %s interface {
-	// Execute performs the primary skill action.
-	Execute(param string) error
+func newScaffold(root, raw string) (*scaffold, error) {
+	name := normalizeName(raw)
+	if !namePattern.MatchString(name) {
+		return nil, fmt.Errorf("invalid skill name %q: use lowercase letters, digits and underscores, starting with a letter", raw)
+	}
+	s := &scaffold{
+		Root:    root,
+		Name:    name,
+		Package: strings.ReplaceAll(name, "_", ""),
+		Title:   titleCase(name),
+		Module:  modulePath,
+	}
+	if err := s.validate(); err != nil {
+		return nil, err
+	}
+	return s, nil
 }
-`, title, name, title)
 
-	pluginCode := fmt.Sprintf(`package %s
+// validate refuses names that would not compile or would shadow something
+// contributors already import.
+func (s *scaffold) validate() error {
+	for _, n := range []string{s.Name, s.Package} {
+		if token.IsKeyword(n) {
+			return fmt.Errorf("skill name %q is a Go keyword", n)
+		}
+		if types.Universe.Lookup(n) != nil {
+			return fmt.Errorf("skill name %q is a predeclared Go identifier", n)
+		}
+	}
+	if stdlibPackageNames()[s.Package] {
+		return fmt.Errorf("skill name %q collides with a standard library package", s.Package)
+	}
+	if repo := repoPackageNames(s.Root); repo[s.Package] {
+		return fmt.Errorf("skill name %q collides with an existing package in this repo", s.Package)
+	}
 
-import "fmt"
+	for _, path := range s.paths() {
+		if exists(filepath.Join(s.Root, path)) {
+			return fmt.Errorf("skill %q already exists: %s", s.Name, path)
+		}
+	}
+	if exists(filepath.Join(s.Root, "core", "plugins", s.Package)) {
+		return fmt.Errorf("skill %q already exists: core/plugins/%s", s.Name, s.Package)
+	}
 
-// Plugin is the default implementation for %s.
-type Plugin struct{}
+	declared, err := skillsIdentifiers(filepath.Join(s.Root, "core", "skills"))
+	if err != nil {
+		return err
+	}
+	for _, ident := range []string{s.Title, s.Title + "Item"} {
+		if declared[ident] {
+			return fmt.Errorf("skills.%s is already declared", ident)
+		}
+	}
+	return nil
+}
 
-func New() *Plugin {
-	return &Plugin{}
+func (s *scaffold) paths() []string {
+	pluginDir := filepath.Join("core", "plugins", s.Package)
+	return []string{
+		filepath.Join("core", "skills", s.Name+".go"),
+		filepath.Join(pluginDir, "plugin.go"),
+		filepath.Join(pluginDir, "plugin_test.go"),
+		filepath.Join(pluginDir, "skill.json"),
+		filepath.Join("playground", s.Name+".go"),
+	}
 }
 
-func (p *Plugin) Execute(param string) error {
-	fmt.Printf("[%s Plugin] Executing: %%s\n", param)
-	return nil
+// write renders every file of the slice and returns their repo-relative paths.
+func (s *scaffold) write() ([]string, error) {
+	manifest, err := s.manifest()
+	if err != nil {
+		return nil, err
+	}
+	contents := []string{
+		s.render(interfaceTemplate),
+		s.render(pluginTemplate),
+		s.render(pluginTestTemplate),
+		manifest,
+		s.render(playgroundTemplate),
+	}
+
+	paths := s.paths()
+	for i, rel := range paths {
+		data := []byte(contents[i])
+		if strings.HasSuffix(rel, ".go") {
+			formatted, err := format.Source(data)
+			if err != nil {
+				return nil, fmt.Errorf("format %s: %w", rel, err)
+			}
+			data = formatted
+		}
+		full := filepath.Join(s.Root, rel)
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(full, data, 0o644); err != nil {
+			return nil, err
+		}
+	}
+	return paths, nil
+}
+
+// generate runs skillgen so the new interface gets its Register<Skill> binding.
+func (s *scaffold) generate() error {
+	cmd := exec.Command("go", "generate", "./core/engine")
+	cmd.Dir = s.Root
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
 }
-`, name, name, title)
 
-	writeFile(interfacePath, interfaceCode)
-	writeFile(pluginPath, pluginCode)
+func (s *scaffold) render(tmpl *template.Template) string {
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, s); err != nil {
+		panic(err)
+	}
+	return b.String()
+}
 
-	fmt.Printf("Created skill: %s\n", name)
-	fmt.Printf("1. Interface: %s\n", interfacePath)
-	fmt.Printf("2. Plugin:    %s\n", pluginPath)
-	fmt.Println("Next: register it in core/engine/registry.go")
+// manifest is the document POSTed to the backend's /admin/ingest endpoint.
+// Its schemas mirror what skillgen derives from the interface.
+func (s *scaffold) manifest() (string, error) {
+	actions := []map[string]interface{}{
+		{
+			"name":        "Search",
+			"description": "Search " + s.Name + " entries matching a free-form query",
+			"input_schema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"query": map[string]interface{}{"type": "string", "description": "Free-form search text"},
+					"limit": map[string]interface{}{"type": "integer", "description": "Maximum number of results"},
+				},
+				"required": []string{"query"},
+			},
+		},
+		{
+			"name":        "Get",
+			"description": "Get a single " + s.Name + " entry by id",
+			"input_schema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"id": map[string]interface{}{"type": "string", "description": "Identifier returned by Search"},
+				},
+				"required": []string{"id"},
+			},
+		},
+	}
+	schema, err := json.Marshal(map[string]interface{}{"actions": actions})
+	if err != nil {
+		return "", err
+	}
+	doc, err := json.MarshalIndent(map[string]string{
+		"name":        s.Title,
+		"description": "TODO: describe when the planner should use " + s.Title,
+		"json_schema": string(schema),
+	}, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(doc) + "\n", nil
 }
 
 func normalizeName(in string) string {
@@ -90,8 +267,353 @@ func exists(path string) bool {
 	return err == nil
 }
 
-func writeFile(path, content string) {
-	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
-		panic(err)
+// stdlibPackageNames returns the last path element of every GOROOT package.
+func stdlibPackageNames() map[string]bool {
+	names := map[string]bool{}
+	src := filepath.Join(build.Default.GOROOT, "src")
+	_ = filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return nil
+		}
+		switch d.Name() {
+		case "testdata", "vendor", "internal", "cmd":
+			return filepath.SkipDir
+		}
+		if path != src {
+			names[d.Name()] = true
+		}
+		return nil
+	})
+	return names
+}
+
+// repoPackageNames returns the package names declared by Go files under core.
+func repoPackageNames(root string) map[string]bool {
+	names := map[string]bool{}
+	fset := token.NewFileSet()
+	_ = filepath.WalkDir(filepath.Join(root, "core"), func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
+			return nil
+		}
+		if file, err := parser.ParseFile(fset, path, nil, parser.PackageClauseOnly); err == nil {
+			names[file.Name.Name] = true
+		}
+		return nil
+	})
+	return names
+}
+
+// skillsIdentifiers lists the top-level names declared in core/skills.
+func skillsIdentifiers(dir string) (map[string]bool, error) {
+	declared := map[string]bool{}
+	pkgs, err := parser.ParseDir(token.NewFileSet(), dir, nil, 0)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return declared, nil
+		}
+		return nil, fmt.Errorf("parse %s: %w", dir, err)
+	}
+	for _, pkg := range pkgs {
+		for _, file := range pkg.Files {
+			for name := range file.Scope.Objects {
+				declared[name] = true
+			}
+		}
 	}
+	return declared, nil
+}
+
+var interfaceTemplate = template.Must(template.New("interface").Parse(`package skills
+
+import "context"
+
+// {{.Title}} defines the capability contract for the {{.Name}} skill.
+//
+//upcraft:generate
+type {{.Title}} interface {
+	// Search {{.Name}} entries matching a free-form query.
+	//
+	//upcraft:param query Free-form search text
+	//upcraft:param limit Maximum number of results
+	//upcraft:optional limit
+	Search(ctx context.Context, query string, limit int) ([]{{.Title}}Item, error)
+	// Get a single {{.Name}} entry by id.
+	//
+	//upcraft:param id Identifier returned by Search
+	Get(ctx context.Context, id string) (*{{.Title}}Item, error)
+}
+
+// {{.Title}}Item is one {{.Name}} result handed back to the model.
+type {{.Title}}Item struct {
+	ID      string ` + "`json:\"id\"`" + `
+	Title   string ` + "`json:\"title\"`" + `
+	Summary string ` + "`json:\"summary,omitempty\"`" + `
+}
+`))
+
+var pluginTemplate = template.Must(template.New("plugin").Parse(`package {{.Package}}
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"{{.Module}}/core/skills"
+)
+
+const defaultLimit = 5
+
+// Source fetches {{.Name}} data. Tests swap in a fake.
+type Source interface {
+	Search(ctx context.Context, query string, limit int) ([]skills.{{.Title}}Item, error)
+	Get(ctx context.Context, id string) (*skills.{{.Title}}Item, error)
+}
+
+// Plugin implements skills.{{.Title}} on top of a Source.
+type Plugin struct {
+	source Source
+}
+
+var _ skills.{{.Title}} = (*Plugin)(nil)
+
+// New returns a plugin backed by canned sample data.
+func New() *Plugin {
+	return NewWithSource(sampleSource{})
+}
+
+// NewWithSource returns a plugin backed by source.
+func NewWithSource(source Source) *Plugin {
+	return &Plugin{source: source}
+}
+
+func (p *Plugin) Search(ctx context.Context, query string, limit int) ([]skills.{{.Title}}Item, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, fmt.Errorf("query is required")
+	}
+	if limit <= 0 {
+		limit = defaultLimit
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	items, err := p.source.Search(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("{{.Name}} search: %w", err)
+	}
+	if len(items) > limit {
+		items = items[:limit]
+	}
+	return items, nil
+}
+
+func (p *Plugin) Get(ctx context.Context, id string) (*skills.{{.Title}}Item, error) {
+	id = strings.TrimSpace(id)
+	if id == "" {
+		return nil, fmt.Errorf("id is required")
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	item, err := p.source.Get(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("{{.Name}} get %s: %w", id, err)
+	}
+	if item == nil {
+		return nil, fmt.Errorf("{{.Name}} %s not found", id)
+	}
+	return item, nil
+}
+
+// Health reports whether a data source is wired in.
+func (p *Plugin) Health(ctx context.Context) error {
+	if p.source == nil {
+		return fmt.Errorf("{{.Name}} source is not configured")
+	}
+	return nil
+}
+
+// sampleSource serves canned data so the skill works end to end before a
+// real backend is wired in.
+type sampleSource struct{}
+
+func (sampleSource) Search(_ context.Context, query string, _ int) ([]skills.{{.Title}}Item, error) {
+	return []skills.{{.Title}}Item{{"{{"}}ID: "sample-1", Title: "Sample {{.Name}} result", Summary: "Placeholder result for " + query{{"}}"}}, nil
+}
+
+func (sampleSource) Get(_ context.Context, id string) (*skills.{{.Title}}Item, error) {
+	if id != "sample-1" {
+		return nil, nil
+	}
+	return &skills.{{.Title}}Item{ID: id, Title: "Sample {{.Name}} result"}, nil
+}
+`))
+
+var pluginTestTemplate = template.Must(template.New("plugin_test").Parse(`package {{.Package}}
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"{{.Module}}/core/engine"
+	"{{.Module}}/core/skills"
+)
+
+type fakeSource struct {
+	items    []skills.{{.Title}}Item
+	err      error
+	gotQuery string
+	gotLimit int
+}
+
+func (f *fakeSource) Search(_ context.Context, query string, limit int) ([]skills.{{.Title}}Item, error) {
+	f.gotQuery, f.gotLimit = query, limit
+	return f.items, f.err
+}
+
+func (f *fakeSource) Get(_ context.Context, id string) (*skills.{{.Title}}Item, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	for i := range f.items {
+		if f.items[i].ID == id {
+			return &f.items[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func sampleItems(n int) []skills.{{.Title}}Item {
+	items := make([]skills.{{.Title}}Item, n)
+	for i := range items {
+		items[i] = skills.{{.Title}}Item{ID: string(rune('a' + i)), Title: "item"}
+	}
+	return items
+}
+
+func TestPlugin_Search(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		limit     int
+		source    *fakeSource
+		wantLimit int
+		wantCount int
+		wantErr   bool
+	}{
+		{name: "passes query and limit", query: " test ", limit: 2, source: &fakeSource{items: sampleItems(2)}, wantLimit: 2, wantCount: 2},
+		{name: "defaults limit", query: "test", source: &fakeSource{items: sampleItems(1)}, wantLimit: defaultLimit, wantCount: 1},
+		{name: "trims oversized results", query: "test", limit: 1, source: &fakeSource{items: sampleItems(3)}, wantLimit: 1, wantCount: 1},
+		{name: "rejects empty query", query: "  ", source: &fakeSource{}, wantErr: true},
+		{name: "wraps source error", query: "test", source: &fakeSource{err: errors.New("boom")}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items, err := NewWithSource(tt.source).Search(context.Background(), tt.query, tt.limit)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Search: %v", err)
+			}
+			if len(items) != tt.wantCount {
+				t.Fatalf("got %d items, want %d", len(items), tt.wantCount)
+			}
+			if tt.source.gotQuery != "test" || tt.source.gotLimit != tt.wantLimit {
+				t.Fatalf("source got query=%q limit=%d", tt.source.gotQuery, tt.source.gotLimit)
+			}
+		})
+	}
+}
+
+func TestPlugin_Get(t *testing.T) {
+	tests := []struct {
+		name    string
+		id      string
+		source  *fakeSource
+		wantErr bool
+	}{
+		{name: "found", id: "a", source: &fakeSource{items: sampleItems(1)}},
+		{name: "not found", id: "zzz", source: &fakeSource{items: sampleItems(1)}, wantErr: true},
+		{name: "empty id", id: "", source: &fakeSource{}, wantErr: true},
+		{name: "source error", id: "a", source: &fakeSource{err: errors.New("boom")}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			item, err := NewWithSource(tt.source).Get(context.Background(), tt.id)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Get: %v", err)
+			}
+			if item.ID != tt.id {
+				t.Fatalf("got item %q, want %q", item.ID, tt.id)
+			}
+		})
+	}
+}
+
+func TestRegister{{.Title}}_ExposesActions(t *testing.T) {
+	registry := engine.NewRegistry()
+	if err := engine.Register{{.Title}}(registry, NewWithSource(&fakeSource{items: sampleItems(1)})); err != nil {
+		t.Fatalf("Register{{.Title}}: %v", err)
+	}
+
+	result := registry.Execute(context.Background(), "{{.Title}}", "Search", map[string]interface{}{"query": "test"})
+	if result.IsError {
+		t.Fatalf("Search failed: %s", result.ModelText())
+	}
+	result = registry.Execute(context.Background(), "{{.Title}}", "Get", map[string]interface{}{})
+	if !result.IsError {
+		t.Fatal("expected missing id to fail")
+	}
+}
+`))
+
+var playgroundTemplate = template.Must(template.New("playground").Parse(`package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"{{.Module}}/core/engine"
+	"{{.Module}}/core/plugins/{{.Package}}"
+)
+
+func init() {
+	harnesses["{{.Name}}"] = run{{.Title}}
+}
+
+// run{{.Title}} drives the {{.Name}} skill through its registry binding.
+func run{{.Title}}(ctx context.Context, args []string) error {
+	query := "sample"
+	if len(args) > 0 {
+		query = strings.Join(args, " ")
+	}
+
+	registry := engine.NewRegistry()
+	if err := engine.Register{{.Title}}(registry, {{.Package}}.New()); err != nil {
+		return err
+	}
+	result := registry.Execute(ctx, "{{.Title}}", "Search", map[string]interface{}{"query": query})
+	if result.IsError {
+		return errors.New(result.ModelText())
+	}
+	fmt.Println(result.ModelText())
+	return nil
 }
+`))
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/json"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func scaffoldRoot(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	for _, dir := range []string{"core/skills", "core/engine", "core/plugins", "playground"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	files := map[string]string{
+		"core/skills/music.go":    "package skills\n\ntype MusicPlayer interface{}\n",
+		"core/engine/registry.go": "package engine\n",
+	}
+	for path, content := range files {
+		if err := os.WriteFile(filepath.Join(root, path), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func TestNewScaffold_ValidatesNames(t *testing.T) {
+	root := scaffoldRoot(t)
+	tests := []struct {
+		name    string
+		wantErr string
+	}{
+		{name: "weather"},
+		{name: "Stock-Ticker"},
+		{name: "9lives", wantErr: "invalid skill name"},
+		{name: "bad__name", wantErr: "invalid skill name"},
+		{name: "func", wantErr: "Go keyword"},
+		{name: "string", wantErr: "predeclared"},
+		{name: "json", wantErr: "standard library"},
+		{name: "engine", wantErr: "existing package"},
+		{name: "music", wantErr: "already exists"},
+		{name: "music_player", wantErr: "already declared"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newScaffold(root, tt.name)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("got error %v, want it to mention %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestScaffold_WritesParseableSlice(t *testing.T) {
+	root := scaffoldRoot(t)
+	s, err := newScaffold(root, "stock_ticker")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Package != "stockticker" || s.Title != "StockTicker" {
+		t.Fatalf("unexpected names: %+v", s)
+	}
+
+	written, err := s.write()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(written) != 5 {
+		t.Fatalf("wrote %d files, want 5", len(written))
+	}
+
+	fset := token.NewFileSet()
+	for _, rel := range written {
+		full := filepath.Join(root, rel)
+		if strings.HasSuffix(rel, ".json") {
+			raw, err := os.ReadFile(full)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var doc struct {
+				Name       string `json:"name"`
+				JSONSchema string `json:"json_schema"`
+			}
+			if err := json.Unmarshal(raw, &doc); err != nil {
+				t.Fatalf("%s: %v", rel, err)
+			}
+			if doc.Name != "StockTicker" || !json.Valid([]byte(doc.JSONSchema)) {
+				t.Fatalf("unexpected manifest: %s", raw)
+			}
+			continue
+		}
+		if _, err := parser.ParseFile(fset, full, nil, parser.ParseComments); err != nil {
+			t.Fatalf("%s does not parse: %v", rel, err)
+		}
+	}
+
+	iface, err := os.ReadFile(filepath.Join(root, "core", "skills", "stock_ticker.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"//upcraft:generate", "type StockTicker interface", "ctx context.Context"} {
+		if !strings.Contains(string(iface), want) {
+			t.Fatalf("interface missing %q:\n%s", want, iface)
+		}
+	}
+
+	if _, err := newScaffold(root, "stock_ticker"); err == nil {
+		t.Fatal("expected second scaffold of the same skill to be refused")
+	}
+}
@@ -0,0 +1,790 @@
+// Command skillgen emits engine.Registry bindings for annotated skill interfaces.
+//
+// An interface in core/skills opts in with a directive in its doc comment:
+//
+//	//upcraft:generate
+//	//upcraft:generate skill=Browser
+//
+// Several interfaces may share one skill= name to split optional capabilities;
+// a plugin then exposes only the actions of the interfaces it implements.
+//
+// Methods must take context.Context first and return error or (T, error).
+// The method doc comment becomes the action description; further directives
+// refine the binding:
+//
+//	//upcraft:param <name> <description>   describe one parameter
+//	//upcraft:optional <name>              parameter may be omitted
+//	//upcraft:enum <name> a,b,c            restrict a parameter to fixed values
+//	//upcraft:user <message>               ForUser text, {name} expands a parameter
+//	//upcraft:skip                         do not expose the method
+//
+// Run it through go generate in core/engine.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const (
+	generatedHeader = "// Code generated by skillgen. DO NOT EDIT."
+	directivePrefix = "upcraft:"
+)
+
+func main() {
+	skillsDir := flag.String("skills", "../skills", "directory containing skill interfaces")
+	outDir := flag.String("out", ".", "directory to write generated bindings into")
+	pkgName := flag.String("pkg", "engine", "package name of the generated files")
+	skillsImport := flag.String("skills-import", "github.com/UpCraft-Solutions-Pvt-Ltd/upcraft-agent/core/skills", "import path of the skills package")
+	flag.Parse()
+
+	if err := generate(*skillsDir, *outDir, *pkgName, *skillsImport); err != nil {
+		fail(err)
+	}
+}
+
+// generate writes the bindings of every annotated interface in skillsDir
+// into outDir. All files are rendered before any is touched, so a failure
+// leaves the previous bindings in place.
+func generate(skillsDir, outDir, pkgName, skillsImport string) error {
+	pkg, err := loadSkills(skillsDir)
+	if err != nil {
+		return err
+	}
+
+	ifaces, err := pkg.annotatedInterfaces()
+	if err != nil {
+		return err
+	}
+
+	outputs := make([][]byte, len(ifaces))
+	for i, iface := range ifaces {
+		src, err := render(pkg, pkgName, skillsImport, iface)
+		if err != nil {
+			return fmt.Errorf("%s: %w", iface.Name, err)
+		}
+		outputs[i] = src
+	}
+
+	if err := removeStaleOutputs(outDir); err != nil {
+		return err
+	}
+
+	for i, iface := range ifaces {
+		path := filepath.Join(outDir, "register_"+snakeCase(iface.Name)+"_gen.go")
+		if err := os.WriteFile(path, outputs[i], 0o644); err != nil {
+			return err
+		}
+		fmt.Printf("skillgen: wrote %s (%d actions)\n", path, len(iface.Methods))
+	}
+	return nil
+}
+
+func fail(err error) {
+	fmt.Fprintf(os.Stderr, "skillgen: %v\n", err)
+	os.Exit(1)
+}
+
+// skillsPackage is the parsed core/skills package.
+type skillsPackage struct {
+	fset    *token.FileSet
+	files   []*ast.File
+	structs map[string]*ast.StructType
+	named   map[string]ast.Expr
+}
+
+type skillInterface struct {
+	Name    string
+	Skill   string
+	Methods []skillMethod
+}
+
+type skillMethod struct {
+	Name        string
+	Description string
+	Params      []skillParam
+	Returns     ast.Expr
+	UserMessage string
+}
+
+type skillParam struct {
+	GoName      string
+	Key         string
+	Type        ast.Expr
+	Description string
+	Optional    bool
+	Enum        []string
+}
+
+func loadSkills(dir string) (*skillsPackage, error) {
+	fset := token.NewFileSet()
+	matches, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(matches)
+
+	pkg := &skillsPackage{fset: fset, structs: map[string]*ast.StructType{}, named: map[string]ast.Expr{}}
+	for _, path := range matches {
+		if strings.HasSuffix(path, "_test.go") {
+			continue
+		}
+		file, err := parser.ParseFile(fset, path, nil, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+		pkg.files = append(pkg.files, file)
+		for _, decl := range file.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				ts := spec.(*ast.TypeSpec)
+				if st, ok := ts.Type.(*ast.StructType); ok {
+					pkg.structs[ts.Name.Name] = st
+				} else {
+					pkg.named[ts.Name.Name] = ts.Type
+				}
+			}
+		}
+	}
+	return pkg, nil
+}
+
+func (p *skillsPackage) annotatedInterfaces() ([]skillInterface, error) {
+	var out []skillInterface
+	for _, file := range p.files {
+		for _, decl := range file.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				ts := spec.(*ast.TypeSpec)
+				it, ok := ts.Type.(*ast.InterfaceType)
+				if !ok {
+					continue
+				}
+				doc := ts.Doc
+				if doc == nil && len(gen.Specs) == 1 {
+					doc = gen.Doc
+				}
+				args, ok := findDirective(doc, "generate")
+				if !ok {
+					continue
+				}
+				iface, err := p.parseInterface(ts.Name.Name, args, it)
+				if err != nil {
+					return nil, fmt.Errorf("%s: %w", p.fset.Position(ts.Pos()), err)
+				}
+				out = append(out, iface)
+			}
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out, nil
+}
+
+func (p *skillsPackage) parseInterface(name, args string, it *ast.InterfaceType) (skillInterface, error) {
+	iface := skillInterface{Name: name, Skill: name}
+	for _, field := range strings.Fields(args) {
+		if v, ok := strings.CutPrefix(field, "skill="); ok && v != "" {
+			iface.Skill = v
+		}
+	}
+
+	for _, m := range it.Methods.List {
+		fn, ok := m.Type.(*ast.FuncType)
+		if !ok || len(m.Names) == 0 {
+			// Embedded interfaces are not bound.
+			continue
+		}
+		if _, skip := findDirective(m.Doc, "skip"); skip {
+			continue
+		}
+		method, err := p.parseMethod(m.Names[0].Name, m.Doc, fn)
+		if err != nil {
+			return iface, fmt.Errorf("%s.%s: %w", name, m.Names[0].Name, err)
+		}
+		iface.Methods = append(iface.Methods, method)
+	}
+	if len(iface.Methods) == 0 {
+		return iface, fmt.Errorf("no bindable methods")
+	}
+	return iface, nil
+}
+
+func (p *skillsPackage) parseMethod(name string, doc *ast.CommentGroup, fn *ast.FuncType) (skillMethod, error) {
+	method := skillMethod{Name: name, Description: description(doc)}
+	if method.Description == "" {
+		return method, fmt.Errorf("missing doc comment")
+	}
+
+	params := flattenFields(fn.Params)
+	if len(params) == 0 || exprString(params[0].Type) != "context.Context" {
+		return method, fmt.Errorf("first parameter must be context.Context")
+	}
+	for _, f := range params[1:] {
+		if f.Name == "" || f.Name == "_" {
+			return method, fmt.Errorf("parameters must be named")
+		}
+		method.Params = append(method.Params, skillParam{GoName: f.Name, Key: snakeCase(f.Name), Type: f.Type})
+	}
+
+	results := flattenFields(fn.Results)
+	switch {
+	case len(results) == 1 && exprString(results[0].Type) == "error":
+	case len(results) == 2 && exprString(results[1].Type) == "error":
+		method.Returns = results[0].Type
+	default:
+		return method, fmt.Errorf("must return error or (T, error)")
+	}
+
+	for _, d := range directives(doc) {
+		verb, rest, _ := strings.Cut(d, " ")
+		rest = strings.TrimSpace(rest)
+		switch verb {
+		case "user":
+			method.UserMessage = rest
+		case "param", "optional", "enum":
+			target, value, _ := strings.Cut(rest, " ")
+			param := method.param(target)
+			if param == nil {
+				return method, fmt.Errorf("%s directive names unknown parameter %q", verb, target)
+			}
+			switch verb {
+			case "param":
+				param.Description = strings.TrimSpace(value)
+			case "optional":
+				param.Optional = true
+			case "enum":
+				for _, v := range strings.Split(value, ",") {
+					if v = strings.TrimSpace(v); v != "" {
+						param.Enum = append(param.Enum, v)
+					}
+				}
+			}
+		}
+	}
+
+	for _, param := range method.Params {
+		if _, err := p.schemaFor(param.Type, map[string]bool{}); err != nil {
+			return method, fmt.Errorf("parameter %s: %w", param.GoName, err)
+		}
+	}
+	return method, nil
+}
+
+func (m *skillMethod) param(name string) *skillParam {
+	for i := range m.Params {
+		if m.Params[i].GoName == name || m.Params[i].Key == name {
+			return &m.Params[i]
+		}
+	}
+	return nil
+}
+
+type namedField struct {
+	Name string
+	Type ast.Expr
+}
+
+func flattenFields(list *ast.FieldList) []namedField {
+	if list == nil {
+		return nil
+	}
+	var out []namedField
+	for _, f := range list.List {
+		if len(f.Names) == 0 {
+			out = append(out, namedField{Type: f.Type})
+			continue
+		}
+		for _, n := range f.Names {
+			out = append(out, namedField{Name: n.Name, Type: f.Type})
+		}
+	}
+	return out
+}
+
+func directives(doc *ast.CommentGroup) []string {
+	if doc == nil {
+		return nil
+	}
+	var out []string
+	for _, c := range doc.List {
+		text := strings.TrimPrefix(c.Text, "//")
+		if d, ok := strings.CutPrefix(text, directivePrefix); ok {
+			out = append(out, strings.TrimSpace(d))
+		}
+	}
+	return out
+}
+
+func findDirective(doc *ast.CommentGroup, verb string) (string, bool) {
+	for _, d := range directives(doc) {
+		v, rest, _ := strings.Cut(d, " ")
+		if v == verb {
+			return strings.TrimSpace(rest), true
+		}
+	}
+	return "", false
+}
+
+// description returns the doc text without directives, collapsed to one line.
+func description(doc *ast.CommentGroup) string {
+	if doc == nil {
+		return ""
+	}
+	text := strings.Join(strings.Fields(doc.Text()), " ")
+	return strings.TrimSuffix(text, ".")
+}
+
+// schema is a JSON Schema fragment rendered as a Go map literal.
+type schema struct {
+	Type        string
+	Format      string
+	Description string
+	Enum        []string
+	Items       *schema
+	Additional  *schema
+	Properties  []property
+	Required    []string
+}
+
+type property struct {
+	Name   string
+	Schema *schema
+}
+
+func (p *skillsPackage) schemaFor(expr ast.Expr, seen map[string]bool) (*schema, error) {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		switch t.Name {
+		case "string":
+			return &schema{Type: "string"}, nil
+		case "bool":
+			return &schema{Type: "boolean"}, nil
+		case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64":
+			return &schema{Type: "integer"}, nil
+		case "float32", "float64":
+			return &schema{Type: "number"}, nil
+		}
+		if st, ok := p.structs[t.Name]; ok {
+			if seen[t.Name] {
+				return nil, fmt.Errorf("recursive type %s", t.Name)
+			}
+			seen[t.Name] = true
+			defer delete(seen, t.Name)
+			return p.structSchema(st, seen)
+		}
+		if underlying, ok := p.named[t.Name]; ok {
+			return p.schemaFor(underlying, seen)
+		}
+	case *ast.StarExpr:
+		return p.schemaFor(t.X, seen)
+	case *ast.ArrayType:
+		items, err := p.schemaFor(t.Elt, seen)
+		if err != nil {
+			return nil, err
+		}
+		return &schema{Type: "array", Items: items}, nil
+	case *ast.MapType:
+		if exprString(t.Key) != "string" {
+			return nil, fmt.Errorf("map keys must be strings")
+		}
+		values, err := p.schemaFor(t.Value, seen)
+		if err != nil {
+			return nil, err
+		}
+		return &schema{Type: "object", Additional: values}, nil
+	case *ast.SelectorExpr:
+		if exprString(t) == "time.Time" {
+			return &schema{Type: "string", Format: "date-time"}, nil
+		}
+	case *ast.InterfaceType:
+		return &schema{}, nil
+	}
+	return nil, fmt.Errorf("unsupported type %s", exprString(expr))
+}
+
+func (p *skillsPackage) structSchema(st *ast.StructType, seen map[string]bool) (*schema, error) {
+	out := &schema{Type: "object"}
+	for _, f := range st.Fields.List {
+		if len(f.Names) == 0 {
+			return nil, fmt.Errorf("embedded fields are not supported")
+		}
+		for _, n := range f.Names {
+			if !n.IsExported() {
+				continue
+			}
+			key, omitEmpty, skip := jsonTag(f.Tag, n.Name)
+			if skip {
+				continue
+			}
+			fs, err := p.schemaFor(f.Type, seen)
+			if err != nil {
+				return nil, fmt.Errorf("field %s: %w", n.Name, err)
+			}
+			if f.Doc != nil {
+				fs.Description = description(f.Doc)
+			} else if f.Comment != nil {
+				fs.Description = description(f.Comment)
+			}
+			out.Properties = append(out.Properties, property{Name: key, Schema: fs})
+			if _, isPtr := f.Type.(*ast.StarExpr); !omitEmpty && !isPtr {
+				out.Required = append(out.Required, key)
+			}
+		}
+	}
+	return out, nil
+}
+
+func jsonTag(tag *ast.BasicLit, fieldName string) (string, bool, bool) {
+	if tag == nil {
+		return fieldName, false, false
+	}
+	raw, err := strconv.Unquote(tag.Value)
+	if err != nil {
+		return fieldName, false, false
+	}
+	value, ok := lookupTag(raw, "json")
+	if !ok {
+		return fieldName, false, false
+	}
+	if value == "-" {
+		return "", false, true
+	}
+	name, opts, _ := strings.Cut(value, ",")
+	if name == "" {
+		name = fieldName
+	}
+	return name, strings.Contains(","+opts+",", ",omitempty,"), false
+}
+
+func lookupTag(tag, key string) (string, bool) {
+	for _, part := range strings.Fields(tag) {
+		k, v, ok := strings.Cut(part, ":")
+		if ok && k == key {
+			unquoted, err := strconv.Unquote(v)
+			return unquoted, err == nil
+		}
+	}
+	return "", false
+}
+
+func (s *schema) render(b *bytes.Buffer) {
+	var entries []string
+	if s.Type != "" {
+		entries = append(entries, fmt.Sprintf("%q: %q", "type", s.Type))
+	}
+	if s.Format != "" {
+		entries = append(entries, fmt.Sprintf("%q: %q", "format", s.Format))
+	}
+	if s.Description != "" {
+		entries = append(entries, fmt.Sprintf("%q: %q", "description", s.Description))
+	}
+	if len(s.Enum) > 0 {
+		entries = append(entries, fmt.Sprintf("%q: %s", "enum", stringSlice(s.Enum)))
+	}
+
+	nested := s.Items != nil || s.Additional != nil || s.Type == "object"
+	if !nested {
+		b.WriteString("map[string]interface{}{" + strings.Join(entries, ", ") + "}")
+		return
+	}
+
+	b.WriteString("map[string]interface{}{\n")
+	for _, e := range entries {
+		b.WriteString(e + ",\n")
+	}
+	if s.Items != nil {
+		b.WriteString(`"items": `)
+		s.Items.render(b)
+		b.WriteString(",\n")
+	}
+	if s.Additional != nil {
+		b.WriteString(`"additionalProperties": `)
+		s.Additional.render(b)
+		b.WriteString(",\n")
+	} else if s.Type == "object" {
+		b.WriteString(`"properties": map[string]interface{}{`)
+		if len(s.Properties) > 0 {
+			b.WriteString("\n")
+		}
+		for _, prop := range s.Properties {
+			fmt.Fprintf(b, "%q: ", prop.Name)
+			prop.Schema.render(b)
+			b.WriteString(",\n")
+		}
+		b.WriteString("},\n")
+		if len(s.Required) > 0 {
+			fmt.Fprintf(b, "%q: %s,\n", "required", stringSlice(s.Required))
+		}
+	}
+	b.WriteString("}")
+}
+
+func stringSlice(values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = strconv.Quote(v)
+	}
+	return "[]string{" + strings.Join(quoted, ", ") + "}"
+}
+
+func render(pkg *skillsPackage, pkgName, skillsImport string, iface skillInterface) ([]byte, error) {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "%s\n\npackage %s\n\n", generatedHeader, pkgName)
+	b.WriteString("import (\n\"context\"\n\"fmt\"\n")
+	if usesTime(iface) {
+		b.WriteString("\"time\"\n")
+	}
+	b.WriteString("\n")
+	fmt.Fprintf(&b, "%q\n)\n\n", skillsImport)
+
+	implName := "impl"
+	fmt.Fprintf(&b, "func Register%s(registry ActionRegistrar, %s skills.%s) error {\n", iface.Name, implName, iface.Name)
+	b.WriteString("if registry == nil {\nreturn fmt.Errorf(\"registry is required\")\n}\n")
+	fmt.Fprintf(&b, "if %s == nil {\nreturn fmt.Errorf(%q)\n}\n\n", implName, humanName(iface.Name)+" implementation is required")
+	b.WriteString("entries := []RegisteredAction{\n")
+	for _, m := range iface.Methods {
+		if err := renderAction(&b, pkg, iface, m, implName); err != nil {
+			return nil, fmt.Errorf("%s: %w", m.Name, err)
+		}
+	}
+	b.WriteString("}\n\n")
+	b.WriteString("for _, e := range entries {\nif err := registry.Register(e); err != nil {\nreturn err\n}\n}\nreturn nil\n}\n")
+
+	// Let PluginManager bind any started plugin that satisfies the interface.
+	b.WriteString("\nfunc init() {\n")
+	fmt.Fprintf(&b, "registerSkillBinder(%q, func(registry ActionRegistrar, plugin interface{}) (bool, error) {\n", iface.Name)
+	fmt.Fprintf(&b, "%s, ok := plugin.(skills.%s)\nif !ok {\nreturn false, nil\n}\n", implName, iface.Name)
+	fmt.Fprintf(&b, "return true, Register%s(registry, %s)\n})\n}\n", iface.Name, implName)
+
+	formatted, err := format.Source(b.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("format generated code: %w\n%s", err, b.String())
+	}
+	return formatted, nil
+}
+
+func renderAction(b *bytes.Buffer, pkg *skillsPackage, iface skillInterface, m skillMethod, implName string) error {
+	label := iface.Skill + "." + m.Name
+
+	input := &schema{Type: "object"}
+	for _, p := range m.Params {
+		ps, err := pkg.schemaFor(p.Type, map[string]bool{})
+		if err != nil {
+			return err
+		}
+		if p.Description != "" {
+			ps.Description = p.Description
+		}
+		ps.Enum = p.Enum
+		input.Properties = append(input.Properties, property{Name: p.Key, Schema: ps})
+		if !p.Optional {
+			input.Required = append(input.Required, p.Key)
+		}
+	}
+
+	b.WriteString("{\n")
+	fmt.Fprintf(b, "Skill: %q,\nAction: %q,\nDescription: %q,\nInputSchema: ", iface.Skill, m.Name, m.Description)
+	input.render(b)
+	b.WriteString(",\nHandler: func(ctx context.Context, input map[string]interface{}) *ActionResult {\n")
+
+	callArgs := []string{"ctx"}
+	for _, p := range m.Params {
+		arg, err := renderDecode(b, p)
+		if err != nil {
+			return err
+		}
+		callArgs = append(callArgs, arg)
+	}
+	call := fmt.Sprintf("%s.%s(%s)", implName, m.Name, strings.Join(callArgs, ", "))
+
+	userMsg, err := renderUserMessage(m)
+	if err != nil {
+		return err
+	}
+
+	if m.Returns == nil {
+		fmt.Fprintf(b, "if err := %s; err != nil {\nreturn ErrorResult(%q, err)\n}\n", call, label+" failed")
+		fmt.Fprintf(b, "return SuccessResult(%q, %s)\n", label+" executed", userMsg)
+	} else {
+		fmt.Fprintf(b, "out, err := %s\nif err != nil {\nreturn ErrorResult(%q, err)\n}\n", call, label+" failed")
+		if userMsg == `""` {
+			fmt.Fprintf(b, "return modelJSON(%q, out)\n", label)
+		} else {
+			fmt.Fprintf(b, "result := modelJSON(%q, out)\n", label)
+			fmt.Fprintf(b, "if !result.IsError {\nresult.ForUser = %s\n}\nreturn result\n", userMsg)
+		}
+	}
+	b.WriteString("},\n},\n")
+	return nil
+}
+
+// renderDecode writes the argument decoding for p and returns the call expression.
+func renderDecode(b *bytes.Buffer, p skillParam) (string, error) {
+	required := strconv.FormatBool(!p.Optional)
+	if ident, ok := p.Type.(*ast.Ident); ok && isBuiltin(ident.Name) {
+		var helper, native string
+		switch {
+		case ident.Name == "string":
+			helper, native = "stringArg", "string"
+		case ident.Name == "bool":
+			helper, native = "boolArg", "bool"
+		case strings.HasPrefix(ident.Name, "float"):
+			helper, native = "floatArg", "float64"
+		default:
+			helper, native = "intArg", "int64"
+		}
+		fmt.Fprintf(b, "%s, bad := %s(input, %q, %s)\nif bad != nil {\nreturn bad\n}\n", p.GoName, helper, p.Key, required)
+		if native != ident.Name {
+			return fmt.Sprintf("%s(%s)", ident.Name, p.GoName), nil
+		}
+		return p.GoName, nil
+	}
+
+	fmt.Fprintf(b, "var %s %s\nif bad := decodeArg(input, %q, %s, &%s); bad != nil {\nreturn bad\n}\n", p.GoName, qualify(p.Type), p.Key, required, p.GoName)
+	return p.GoName, nil
+}
+
+func renderUserMessage(m skillMethod) (string, error) {
+	tmpl := m.UserMessage
+	if tmpl == "" {
+		return `""`, nil
+	}
+	var parts []string
+	for tmpl != "" {
+		start := strings.Index(tmpl, "{")
+		if start < 0 {
+			parts = append(parts, strconv.Quote(tmpl))
+			break
+		}
+		end := strings.Index(tmpl[start:], "}")
+		if end < 0 {
+			return "", fmt.Errorf("unterminated placeholder in user message")
+		}
+		if start > 0 {
+			parts = append(parts, strconv.Quote(tmpl[:start]))
+		}
+		name := tmpl[start+1 : start+end]
+		p := m.param(name)
+		if p == nil {
+			return "", fmt.Errorf("user message references unknown parameter %q", name)
+		}
+		if ident, ok := p.Type.(*ast.Ident); ok && ident.Name == "string" {
+			parts = append(parts, p.GoName)
+		} else {
+			parts = append(parts, "fmt.Sprint("+p.GoName+")")
+		}
+		tmpl = tmpl[start+end+1:]
+	}
+	return strings.Join(parts, " + "), nil
+}
+
+// usesTime reports whether a decoded parameter names time.Time directly.
+func usesTime(iface skillInterface) bool {
+	for _, m := range iface.Methods {
+		for _, p := range m.Params {
+			found := false
+			ast.Inspect(p.Type, func(n ast.Node) bool {
+				if sel, ok := n.(*ast.SelectorExpr); ok && exprString(sel) == "time.Time" {
+					found = true
+				}
+				return !found
+			})
+			if found {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func isBuiltin(name string) bool {
+	switch name {
+	case "string", "bool", "int", "int8", "int16", "int32", "int64",
+		"uint", "uint8", "uint16", "uint32", "uint64", "float32", "float64":
+		return true
+	}
+	return false
+}
+
+// qualify renders a type expression from the skills package for use in engine.
+func qualify(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		if isBuiltin(t.Name) || t.Name == "error" || t.Name == "any" {
+			return t.Name
+		}
+		return "skills." + t.Name
+	case *ast.StarExpr:
+		return "*" + qualify(t.X)
+	case *ast.ArrayType:
+		return "[]" + qualify(t.Elt)
+	case *ast.MapType:
+		return "map[" + qualify(t.Key) + "]" + qualify(t.Value)
+	}
+	return exprString(expr)
+}
+
+func exprString(expr ast.Expr) string {
+	var b bytes.Buffer
+	if err := format.Node(&b, token.NewFileSet(), expr); err != nil {
+		return fmt.Sprintf("%T", expr)
+	}
+	return b.String()
+}
+
+func removeStaleOutputs(dir string) error {
+	matches, err := filepath.Glob(filepath.Join(dir, "register_*_gen.go"))
+	if err != nil {
+		return err
+	}
+	for _, path := range matches {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if bytes.HasPrefix(content, []byte(generatedHeader)) {
+			if err := os.Remove(path); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func snakeCase(in string) string {
+	var b strings.Builder
+	runes := []rune(in)
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && (unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(runes[i-1]))) {
+				b.WriteByte('_')
+			}
+			b.WriteRune(unicode.ToLower(r))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// humanName turns "MusicPlayer" into "music player" for error messages.
+func humanName(in string) string {
+	return strings.ReplaceAll(snakeCase(in), "_", " ")
+}
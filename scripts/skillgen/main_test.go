@@ -0,0 +1,164 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const sampleSkills = `package skills
+
+import (
+	"context"
+	"time"
+)
+
+// Alarm schedules alarms.
+//
+//upcraft:generate skill=Alarms
+type Alarm interface {
+	// Set an alarm.
+	//
+	//upcraft:param label What the alarm is for
+	//upcraft:optional snoozeMinutes
+	//upcraft:enum tone soft,loud
+	//upcraft:user Alarm set: {label} (+{snoozeMinutes}m)
+	Set(ctx context.Context, label string, at time.Time, snoozeMinutes int, tone string, days []string) error
+	// List alarms.
+	List(ctx context.Context, filter AlarmFilter) ([]AlarmInfo, error)
+	// Internal helper.
+	//
+	//upcraft:skip
+	Reset(ctx context.Context) error
+}
+
+// AlarmFilter narrows List.
+type AlarmFilter struct {
+	// Only enabled alarms.
+	Enabled bool   ` + "`json:\"enabled\"`" + `
+	Label   string ` + "`json:\"label,omitempty\"`" + `
+	Hidden  string ` + "`json:\"-\"`" + `
+}
+
+// AlarmInfo is one alarm.
+type AlarmInfo struct {
+	Label string ` + "`json:\"label\"`" + `
+}
+
+// Unannotated is ignored.
+type Unannotated interface {
+	Do(ctx context.Context) error
+}
+`
+
+func TestRender_SampleInterface(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "alarm.go"), []byte(sampleSkills), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	pkg, err := loadSkills(dir)
+	if err != nil {
+		t.Fatalf("loadSkills: %v", err)
+	}
+	ifaces, err := pkg.annotatedInterfaces()
+	if err != nil {
+		t.Fatalf("annotatedInterfaces: %v", err)
+	}
+	if len(ifaces) != 1 || ifaces[0].Skill != "Alarms" || len(ifaces[0].Methods) != 2 {
+		t.Fatalf("unexpected interfaces: %+v", ifaces)
+	}
+
+	src, err := render(pkg, "engine", "example.com/skills", ifaces[0])
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	out := string(src)
+
+	for _, want := range []string{
+		"func RegisterAlarm(registry ActionRegistrar, impl skills.Alarm) error",
+		`Skill:       "Alarms"`,
+		`"description": "What the alarm is for"`,
+		`map[string]interface{}{"type": "string", "format": "date-time"}`,
+		`"enum": []string{"soft", "loud"}`,
+		`"required": []string{"label", "at", "tone", "days"}`,
+		`snoozeMinutes, bad := intArg(input, "snooze_minutes", false)`,
+		`impl.Set(ctx, label, at, int(snoozeMinutes), tone, days)`,
+		`var at time.Time`,
+		`var filter skills.AlarmFilter`,
+		`"Alarm set: "+label+" (+"+fmt.Sprint(snoozeMinutes)+"m)"`,
+		`return modelJSON("Alarms.List", out)`,
+		`"required": []string{"enabled"}`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("generated code missing %q\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "Reset") || strings.Contains(out, `"Hidden"`) {
+		t.Errorf("skipped method or field leaked into output\n%s", out)
+	}
+}
+
+func TestGenerate_RenderErrorKeepsExistingBindings(t *testing.T) {
+	skillsDir, outDir := t.TempDir(), t.TempDir()
+	bad := strings.Replace(sampleSkills, "{label}", "{lable}", 1)
+	if err := os.WriteFile(filepath.Join(skillsDir, "alarm.go"), []byte(bad), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	existing := filepath.Join(outDir, "register_alarm_gen.go")
+	old := generatedHeader + "\n\npackage engine\n"
+	if err := os.WriteFile(existing, []byte(old), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	err := generate(skillsDir, outDir, "engine", "example.com/skills")
+	if err == nil || !strings.Contains(err.Error(), `unknown parameter "lable"`) {
+		t.Fatalf("err = %v", err)
+	}
+	if got, err := os.ReadFile(existing); err != nil || string(got) != old {
+		t.Fatalf("existing bindings = %q, %v", got, err)
+	}
+}
+
+func TestParseMethod_Rejections(t *testing.T) {
+	cases := map[string]string{
+		"no context": `// Do it.
+	Do(name string) error`,
+		"no error": `// Do it.
+	Do(ctx context.Context) string`,
+		"no doc": `Do(ctx context.Context) error`,
+		"bad type": `// Do it.
+	Do(ctx context.Context, ch chan int) error`,
+	}
+	for name, method := range cases {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			src := "package skills\n\nimport \"context\"\n\n//upcraft:generate\ntype X interface {\n\t" + method + "\n}\n"
+			if err := os.WriteFile(filepath.Join(dir, "x.go"), []byte(src), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			pkg, err := loadSkills(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := pkg.annotatedInterfaces(); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestSnakeCase(t *testing.T) {
+	for in, want := range map[string]string{
+		"MusicPlayer":   "music_player",
+		"snoozeMinutes": "snooze_minutes",
+		"URL":           "url",
+		"deviceID":      "device_id",
+		"HTTPServer":    "http_server",
+	} {
+		if got := snakeCase(in); got != want {
+			t.Errorf("snakeCase(%q) = %q, want %q", in, got, want)
+		}
+	}
+}